@@ -9,30 +9,49 @@ import (
 	"syscall"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"payflow/internal/api"
 	"payflow/internal/api/middleware"
 	"payflow/internal/database"
+	"payflow/pkg/circuitbreaker"
 	"payflow/pkg/factory"
-	"payflow/pkg/metrics"
 	"payflow/pkg/tracing"
 )
 
+// Used when the corresponding SERVER_*_TIMEOUT is unset or zero, so the
+// server never runs without timeouts.
+const (
+	defaultReadTimeout       = 15 * time.Second
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultWriteTimeout      = 30 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+)
+
 func main() {
-	appFactory, err := factory.NewFactory()
+	// Deferred first so it runs last: a failing command still shuts the
+	// factory down before the process exits.
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	rootCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	appFactory, err := factory.NewFactory(rootCtx)
 	if err != nil {
 		fmt.Printf("Factory oluşturulamadı: %v\n", err)
-		os.Exit(1)
+		exitCode = 1
+		return
 	}
 
 	log := appFactory.GetLogger()
 	cfg := appFactory.GetConfig()
 	db := appFactory.GetDB()
 
-	defer db.Close()
-
 	log.Info("Uygulama başlatılıyor", map[string]interface{}{"env": cfg.AppEnv})
 
 	shutdownTracing, err := tracing.InitTracer(
@@ -41,12 +60,35 @@ func main() {
 		"jaeger:4317",
 	)
 	if err != nil {
-		log.Error("Tracing başlatılamadı", map[string]interface{}{"error": err.Error()})
+		log.ErrorWithErr("Tracing başlatılamadı", err, nil)
 	} else {
 		defer shutdownTracing()
 	}
 
-	migrationService := database.NewMigrationService(db, log)
+	// Deferred after the tracer so it runs first and spans recorded while
+	// shutting down are still exported.
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		// Shutdown logs its own errors; the log file may be closed by now.
+		appFactory.Shutdown(shutdownCtx)
+	}()
+
+	migrationService := database.NewMigrationService(db, appFactory.GetConnectionManager().Dialect(), log, cfg.Currency.Default, cfg.Database.MigrationAcceptChecksumChanges)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate-rollback" {
+		name, err := rollbackMigration(migrationService, os.Args[2:])
+		if err != nil {
+			log.ErrorWithErr("Migration geri alınamadı", err, map[string]interface{}{"name": name})
+			exitCode = 1
+			return
+		}
+
+		log.Info("Migration geri alındı", map[string]interface{}{"name": name})
+		return
+	}
+
 	if err := migrationService.RunMigrations(); err != nil {
 		log.Fatal("Migrationlar uygulanamadı", map[string]interface{}{"error": err.Error()})
 	}
@@ -55,65 +97,39 @@ func main() {
 	transactionService := appFactory.GetTransactionService()
 	balanceService := appFactory.GetBalanceService()
 	auditLogService := appFactory.GetAuditLogService()
+	recurringTransferService := appFactory.GetRecurringTransferService()
 	warmUpManager := appFactory.GetWarmUpManager()
 
-	ctx := context.Background()
 	log.Info("Cache warm-up başlatılıyor...", map[string]interface{}{})
-	if err := warmUpManager.WarmUpFrequentlyAccessedData(ctx); err != nil {
-		log.Error("Cache warm-up başarısız", map[string]interface{}{"error": err.Error()})
+	if err := warmUpManager.WarmUpFrequentlyAccessedData(rootCtx); err != nil {
+		log.ErrorWithErr("Cache warm-up başarısız", err, nil)
 	} else {
 		log.Info("Cache warm-up tamamlandı", map[string]interface{}{})
 	}
 
-	go func() {
-		warmUpCtx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+	go warmUpManager.ScheduledWarmUp(rootCtx, 30*time.Minute)
 
-		warmUpManager.ScheduledWarmUp(warmUpCtx, 30*time.Minute)
-	}()
+	recurringTransferService.Start()
+	auditLogService.Start()
 
-	defer func() {
-		log.Info("TransactionService kapatılıyor...", map[string]interface{}{})
-		transactionService.Shutdown()
-	}()
+	go reloadConfigOnHangup(rootCtx, appFactory)
 
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				stats, err := transactionService.GetWorkerPoolStats()
-				if err != nil {
-					log.Error("Worker pool istatistikleri alınamadı", map[string]interface{}{"error": err.Error()})
-					continue
-				}
-
-				metrics.UpdateWorkerPoolStats(
-					stats.QueueLength,
-					3,
-				)
-
-				log.Info("Worker Pool İstatistikleri", map[string]interface{}{
-					"submitted":        stats.Submitted,
-					"completed":        stats.Completed,
-					"failed":           stats.Failed,
-					"rejected":         stats.Rejected,
-					"avg_process_time": stats.AvgProcessTime.String(),
-					"queue_length":     stats.QueueLength,
-					"queue_capacity":   stats.QueueCapacity,
-				})
-			}
-		}
-	}()
+	authenticator := middleware.NewAuthenticator(appFactory.GetTokenManager(), userService, log)
 
-	userHandler := api.NewUserHandler(userService, log)
-	transactionHandler := api.NewTransactionHandler(transactionService, userService, log)
-	balanceHandler := api.NewBalanceHandler(balanceService, log)
-	auditLogHandler := api.NewAuditLogHandler(auditLogService, log)
+	userHandler := api.NewUserHandler(userService, appFactory.GetTokenManager(), authenticator, log)
+	transactionHandler := api.NewTransactionHandler(transactionService, authenticator, log)
+	balanceHandler := api.NewBalanceHandler(balanceService, authenticator, log)
+	auditLogHandler := api.NewAuditLogHandler(auditLogService, authenticator, log)
+	recurringTransferHandler := api.NewRecurringTransferHandler(recurringTransferService, authenticator, log)
 	cacheHandler := api.NewCacheHandler(appFactory.GetCache(), warmUpManager, log)
 	healthHandler := api.NewHealthHandler(appFactory, log)
+	circuitBreakerHandler := api.NewCircuitBreakerHandler(circuitbreaker.DefaultRegistry, authenticator, log)
+	loadBalancerHandler := api.NewLoadBalancerHandler(appFactory.GetLoadBalancer(), authenticator, log)
+	loggingHandler := api.NewLoggingHandler(appFactory.GetLogSampler(), authenticator, log)
+	docsHandler, err := api.NewDocsHandler()
+	if err != nil {
+		log.Fatal("API dokümantasyonu yüklenemedi", map[string]interface{}{"error": err.Error()})
+	}
 
 	mux := http.NewServeMux()
 
@@ -121,7 +137,12 @@ func main() {
 	transactionHandler.RegisterRoutes(mux)
 	balanceHandler.RegisterRoutes(mux)
 	auditLogHandler.RegisterRoutes(mux)
+	recurringTransferHandler.RegisterRoutes(mux)
 	cacheHandler.RegisterRoutes(mux)
+	circuitBreakerHandler.RegisterRoutes(mux)
+	loadBalancerHandler.RegisterRoutes(mux)
+	loggingHandler.RegisterRoutes(mux)
+	docsHandler.RegisterRoutes(mux)
 
 	log.Info("Tüm route'lar register edildi", map[string]interface{}{
 		"user_routes":        "✓",
@@ -129,51 +150,68 @@ func main() {
 		"balance_routes":     "✓",
 		"audit_routes":       "✓",
 		"cache_routes":       "✓",
+		"circuit_breakers":   "✓",
 	})
 
-	mux.HandleFunc("/debug/routes", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			w.Header().Set("Content-Type", "text/plain")
-			w.Write([]byte("Registered routes:\n"))
-			w.Write([]byte("GET /\n"))
-			w.Write([]byte("GET /metrics\n"))
-			w.Write([]byte("GET /debug/routes\n"))
-			w.Write([]byte("Balance routes:\n"))
-			w.Write([]byte("POST /api/balances/initialize\n"))
-			w.Write([]byte("GET /api/balances/history\n"))
-			w.Write([]byte("POST /api/balances/replay\n"))
-			w.Write([]byte("POST /api/balances/rebuild\n"))
-			w.Write([]byte("GET /api/balances\n"))
-			w.Write([]byte("Cache routes:\n"))
-			w.Write([]byte("GET /api/cache/stats\n"))
-			w.Write([]byte("POST /api/cache/warmup\n"))
-			w.Write([]byte("POST /api/cache/invalidate\n"))
-			w.Write([]byte("GET /api/cache/keys\n"))
-			w.Write([]byte("GET /api/cache/health\n"))
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
+	mux.HandleFunc("GET /debug/routes", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("Registered routes:\n"))
+		w.Write([]byte("GET /\n"))
+		w.Write([]byte("GET /metrics\n"))
+		w.Write([]byte("GET /debug/routes\n"))
+		w.Write([]byte("GET /openapi.json\n"))
+		w.Write([]byte("GET /openapi.yaml\n"))
+		w.Write([]byte("GET /docs\n"))
+		w.Write([]byte("Balance routes:\n"))
+		w.Write([]byte("POST /api/balances/{user_id}/initialize\n"))
+		w.Write([]byte("POST /api/balances/bulk\n"))
+		w.Write([]byte("GET /api/balances/{user_id}/history\n"))
+		w.Write([]byte("GET /api/balances/{user_id}/at\n"))
+		w.Write([]byte("POST /api/balances/{user_id}/replay\n"))
+		w.Write([]byte("POST /api/balances/{user_id}/rebuild\n"))
+		w.Write([]byte("GET /api/balances/{user_id}\n"))
+		w.Write([]byte("Cache routes:\n"))
+		w.Write([]byte("GET /api/cache/stats\n"))
+		w.Write([]byte("POST /api/cache/warmup\n"))
+		w.Write([]byte("POST /api/cache/invalidate\n"))
+		w.Write([]byte("GET /api/cache/keys\n"))
+		w.Write([]byte("GET /api/cache/health\n"))
+		w.Write([]byte("Circuit breaker routes:\n"))
+		w.Write([]byte("GET /api/circuit-breakers\n"))
+		w.Write([]byte("POST /api/circuit-breakers/reset\n"))
+		w.Write([]byte("POST /api/circuit-breakers/trip\n"))
+		w.Write([]byte("POST /api/circuit-breakers/force-open\n"))
+		w.Write([]byte("POST /api/circuit-breakers/force-close\n"))
+		w.Write([]byte("Load balancer routes:\n"))
+		w.Write([]byte("POST /api/load-balancer/backends\n"))
+		w.Write([]byte("DELETE /api/load-balancer/backends\n"))
+		w.Write([]byte("POST /api/load-balancer/maintenance\n"))
+		w.Write([]byte("Logging routes:\n"))
+		w.Write([]byte("GET /api/logging/sampling\n"))
+		w.Write([]byte("POST /api/logging/sampling\n"))
 	})
 
-	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			promhttp.Handler().ServeHTTP(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+	mux.Handle("GET /metrics", promhttp.Handler())
 
-	mux.HandleFunc("/health", healthHandler.HealthCheck)
-	mux.HandleFunc("/health/live", healthHandler.LivenessCheck)
-	mux.HandleFunc("/health/ready", healthHandler.ReadinessCheck)
+	mux.HandleFunc("GET /health", healthHandler.HealthCheck)
+	mux.HandleFunc("GET /health/live", healthHandler.LivenessCheck)
+	mux.HandleFunc("GET /health/ready", healthHandler.ReadinessCheck)
 
 	var handler http.Handler = mux
+	handler = middleware.RateLimit(appFactory.GetRedisClient(), appFactory.GetConfigStore(), authenticator, log)(handler)
+	handler = middleware.CORS(cfg.CORS)(handler)
 	handler = middleware.TracingMiddleware(handler)
-	handler = middleware.MetricsMiddleware(handler)
+	handler = middleware.MetricsMiddleware(mux)(handler)
+	handler = middleware.RequestID(handler)
+	handler = middleware.Recover(log)(handler)
 
 	server := &http.Server{
-		Addr:    ":" + cfg.Server.Port,
-		Handler: handler,
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           handler,
+		ReadTimeout:       serverTimeout(cfg.Server.ReadTimeout, defaultReadTimeout),
+		ReadHeaderTimeout: defaultReadHeaderTimeout,
+		WriteTimeout:      serverTimeout(cfg.Server.WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:       serverTimeout(cfg.Server.IdleTimeout, defaultIdleTimeout),
 	}
 
 	go func() {
@@ -184,9 +222,8 @@ func main() {
 		}
 	}()
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	<-rootCtx.Done()
+	stop()
 
 	log.Info("Sunucu kapatılıyor...", map[string]interface{}{})
 
@@ -194,8 +231,47 @@ func main() {
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatal("Sunucu kapatılırken hata oluştu", map[string]interface{}{"error": err.Error()})
+		log.ErrorWithErr("Sunucu kapatılırken hata oluştu", err, nil)
 	}
 
 	log.Info("Sunucu başarıyla kapatıldı", map[string]interface{}{})
 }
+
+// serverTimeout converts a timeout configured in seconds, falling back to
+// fallback when it is not set.
+func serverTimeout(seconds int, fallback time.Duration) time.Duration {
+	if seconds <= 0 {
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// reloadConfigOnHangup reloads the hot-reloadable settings on every SIGHUP
+// until ctx is done.
+func reloadConfigOnHangup(ctx context.Context, appFactory factory.Factory) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-hangup:
+			if err := appFactory.ReloadConfig(); err != nil {
+				appFactory.GetLogger().ErrorWithErr("Yapılandırma yeniden yüklenemedi, mevcut ayarlar korunuyor", err, nil)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// rollbackMigration handles "migrate-rollback [name]": without a name the most
+// recently applied migration is rolled back. It returns the name of the
+// migration it rolled back or tried to.
+func rollbackMigration(migrationService *database.MigrationService, args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], migrationService.RollbackMigration(args[0])
+	}
+
+	return migrationService.RollbackLast()
+}
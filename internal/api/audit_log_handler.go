@@ -1,22 +1,29 @@
 package api
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"payflow/internal/api/middleware"
 	"payflow/internal/domain"
+	"payflow/pkg/auth"
 	"payflow/pkg/logger"
 )
 
 type AuditLogHandler struct {
 	service domain.AuditLogService
+	auth    *middleware.Authenticator
 	logger  logger.Logger
 }
 
-func NewAuditLogHandler(service domain.AuditLogService, logger logger.Logger) *AuditLogHandler {
+func NewAuditLogHandler(service domain.AuditLogService, auth *middleware.Authenticator, logger logger.Logger) *AuditLogHandler {
 	return &AuditLogHandler{
 		service: service,
+		auth:    auth,
 		logger:  logger,
 	}
 }
@@ -47,15 +54,15 @@ func (h *AuditLogHandler) GetAllLogs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	logs, err := h.service.GetAllLogs(page, pageSize)
+	logs, totalCount, err := h.service.GetAllLogs(page, pageSize)
 	if err != nil {
-		h.logger.Error("Denetim günlükleri alınamadı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Denetim günlükleri alınamadı", err, nil)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(logs)
+	json.NewEncoder(w).Encode(NewPaginatedResponse(logs, page, pageSize, totalCount))
 }
 
 func (h *AuditLogHandler) GetEntityLogs(w http.ResponseWriter, r *http.Request) {
@@ -76,16 +83,16 @@ func (h *AuditLogHandler) GetEntityLogs(w http.ResponseWriter, r *http.Request)
 
 	entityType := domain.EntityType(entityTypeStr)
 	switch entityType {
-	case domain.EntityTypeUser, domain.EntityTypeTransaction, domain.EntityTypeBalance:
+	case domain.EntityTypeUser, domain.EntityTypeTransaction, domain.EntityTypeBalance, domain.EntityTypeAuditLog:
 	default:
 		h.logger.Error("Geçersiz entity_type", map[string]interface{}{"entity_type": entityTypeStr})
-		http.Error(w, "Geçersiz entity_type. Geçerli değerler: user, transaction, balance", http.StatusBadRequest)
+		http.Error(w, "Geçersiz entity_type. Geçerli değerler: user, transaction, balance, audit_log", http.StatusBadRequest)
 		return
 	}
 
 	entityID, err := strconv.ParseInt(entityIDStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz entity_id formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz entity_id formatı", err, nil)
 		http.Error(w, "Geçersiz entity_id formatı", http.StatusBadRequest)
 		return
 	}
@@ -116,7 +123,7 @@ func (h *AuditLogHandler) LogAction(w http.ResponseWriter, r *http.Request) {
 	var req LogActionRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("İstek gövdesi decode edilemedi", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
 		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
 		return
 	}
@@ -130,10 +137,10 @@ func (h *AuditLogHandler) LogAction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	switch req.Action {
-	case domain.ActionTypeCreate, domain.ActionTypeUpdate, domain.ActionTypeDelete:
+	case domain.ActionTypeCreate, domain.ActionTypeUpdate, domain.ActionTypeDelete, domain.ActionTypeRollback, domain.ActionTypeInitialize:
 	default:
 		h.logger.Error("Geçersiz action", map[string]interface{}{"action": req.Action})
-		http.Error(w, "Geçersiz action. Geçerli değerler: create, update, delete", http.StatusBadRequest)
+		http.Error(w, "Geçersiz action. Geçerli değerler: create, update, delete, rollback, initialize", http.StatusBadRequest)
 		return
 	}
 
@@ -143,7 +150,7 @@ func (h *AuditLogHandler) LogAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.LogAction(req.EntityType, req.EntityID, req.Action, req.Details)
+	err := h.service.LogAction(req.EntityType, req.EntityID, req.Action, req.Details, auth.ActorIDFromContext(r.Context()))
 	if err != nil {
 		h.logger.Error("Denetim günlüğü eklenemedi", map[string]interface{}{
 			"entity_type": req.EntityType,
@@ -158,23 +165,150 @@ func (h *AuditLogHandler) LogAction(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
-func (h *AuditLogHandler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/audit-logs", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			h.GetAllLogs(w, r)
-		case http.MethodPost:
-			h.LogAction(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+const exportFlushEvery = 500
+
+var auditLogCSVHeader = []string{"id", "entity_type", "entity_id", "action", "actor_id", "details", "created_at"}
+
+// ExportLogs streams logs in a date range as CSV or NDJSON. Rows are written
+// as they are read from the database and flushed periodically, so exports of
+// any size use constant memory.
+func (h *AuditLogHandler) ExportLogs(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		h.logger.Error("Geçersiz dışa aktarma formatı", map[string]interface{}{"format": format})
+		http.Error(w, "Geçersiz format. Geçerli değerler: csv, ndjson", http.StatusBadRequest)
+		return
+	}
+
+	var start, end time.Time
+	var err error
+
+	if startDateStr := r.URL.Query().Get("start_date"); startDateStr != "" {
+		start, _, err = parseDateParam(startDateStr)
+		if err != nil {
+			h.logger.Error("Geçersiz start_date formatı", map[string]interface{}{"start_date": startDateStr})
+			http.Error(w, "Geçersiz start_date formatı. YYYY-MM-DD veya RFC3339 olmalı", http.StatusBadRequest)
+			return
 		}
-	})
+	}
+
+	if endDateStr := r.URL.Query().Get("end_date"); endDateStr != "" {
+		var dateOnly bool
+		end, dateOnly, err = parseDateParam(endDateStr)
+		if err != nil {
+			h.logger.Error("Geçersiz end_date formatı", map[string]interface{}{"end_date": endDateStr})
+			http.Error(w, "Geçersiz end_date formatı. YYYY-MM-DD veya RFC3339 olmalı", http.StatusBadRequest)
+			return
+		}
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		h.logger.Error("Geçersiz tarih aralığı", map[string]interface{}{"start_date": start, "end_date": end})
+		http.Error(w, "Başlangıç tarihi bitiş tarihinden önce olmalı", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming desteklenmiyor", http.StatusInternalServerError)
+		return
+	}
 
-	mux.HandleFunc("/api/entity-logs", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			h.GetEntityLogs(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	// Large exports outlive the server write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Yazma zaman aşımı kaldırılamadı", map[string]interface{}{"error": err.Error()})
+	}
+
+	filename := fmt.Sprintf("audit-logs-%s.%s", time.Now().Format("20060102-150405"), format)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var writeRow func(*domain.AuditLog) error
+	var flush func() error
+
+	if format == "csv" {
+		csvWriter := csv.NewWriter(w)
+		if err := csvWriter.Write(auditLogCSVHeader); err != nil {
+			return
+		}
+
+		writeRow = func(log *domain.AuditLog) error {
+			return csvWriter.Write([]string{
+				strconv.FormatInt(log.ID, 10),
+				string(log.EntityType),
+				strconv.FormatInt(log.EntityID, 10),
+				string(log.Action),
+				strconv.FormatInt(log.ActorID, 10),
+				log.Details,
+				log.CreatedAt.Format(time.RFC3339),
+			})
+		}
+		flush = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	} else {
+		encoder := json.NewEncoder(w)
+		writeRow = func(log *domain.AuditLog) error {
+			return encoder.Encode(log)
+		}
+		flush = func() error { return nil }
+	}
+
+	count := 0
+	err = h.service.ExportLogs(start, end, func(log *domain.AuditLog) error {
+		if err := writeRow(log); err != nil {
+			return err
 		}
+
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			flusher.Flush()
+		}
+		return nil
 	})
+	if flushErr := flush(); err == nil {
+		err = flushErr
+	}
+	flusher.Flush()
+
+	// Headers are already sent, so a failure can only truncate the file.
+	if err != nil {
+		h.logger.ErrorWithErr("Denetim kayıtları dışa aktarımı yarıda kaldı", err, map[string]interface{}{"rows": count})
+		return
+	}
+
+	h.logger.Info("Denetim kayıtları dışa aktarıldı", map[string]interface{}{
+		"format":     format,
+		"rows":       count,
+		"actor_id":   auth.ActorIDFromContext(r.Context()),
+		"start_date": start,
+		"end_date":   end,
+	})
+}
+
+func (h *AuditLogHandler) RegisterRoutes(mux *http.ServeMux) {
+	readLogs := h.auth.RequirePermission(domain.PermissionAuditLogsRead)
+	writeLogs := h.auth.RequirePermission(domain.PermissionAuditLogsWrite)
+
+	mux.Handle("GET /api/audit-logs", readLogs(http.HandlerFunc(h.GetAllLogs)))
+	mux.Handle("POST /api/audit-logs", writeLogs(http.HandlerFunc(h.LogAction)))
+	mux.Handle("GET /api/audit-logs/export", h.auth.RequirePermission(domain.PermissionAuditLogsExport)(http.HandlerFunc(h.ExportLogs)))
+	mux.Handle("GET /api/entity-logs", readLogs(http.HandlerFunc(h.GetEntityLogs)))
 }
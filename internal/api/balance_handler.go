@@ -2,28 +2,33 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"payflow/internal/api/middleware"
 	"payflow/internal/domain"
 	"payflow/pkg/logger"
 )
 
 type BalanceHandler struct {
 	service domain.BalanceService
+	auth    *middleware.Authenticator
 	logger  logger.Logger
 }
 
-func NewBalanceHandler(service domain.BalanceService, logger logger.Logger) *BalanceHandler {
+func NewBalanceHandler(service domain.BalanceService, auth *middleware.Authenticator, logger logger.Logger) *BalanceHandler {
 	return &BalanceHandler{
 		service: service,
+		auth:    auth,
 		logger:  logger,
 	}
 }
 
 func (h *BalanceHandler) GetUserBalance(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
+	userIDStr := pathOrQuery(r, "user_id", "user_id")
 	if userIDStr == "" {
 		h.logger.Error("user_id parametresi eksik", map[string]interface{}{})
 		http.Error(w, "user_id parametresi eksik", http.StatusBadRequest)
@@ -32,15 +37,17 @@ func (h *BalanceHandler) GetUserBalance(w http.ResponseWriter, r *http.Request)
 
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz user_id formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz user_id formatı", err, nil)
 		http.Error(w, "Geçersiz user_id formatı", http.StatusBadRequest)
 		return
 	}
 
-	balance, err := h.service.GetBalance(userID)
+	currency := r.URL.Query().Get("currency")
+
+	balance, err := h.service.GetBalance(r.Context(), userID, currency)
 	if err != nil {
-		h.logger.Error("Bakiye bilgisi alınamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logger.ErrorWithErr("Bakiye bilgisi alınamadı", err, map[string]interface{}{"user_id": userID, "currency": currency})
+		http.Error(w, err.Error(), currencyErrorStatus(err))
 		return
 	}
 
@@ -48,8 +55,52 @@ func (h *BalanceHandler) GetUserBalance(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(balance)
 }
 
+// maxBulkBalanceUsers caps how many balances one bulk request may read.
+const maxBulkBalanceUsers = 100
+
+func (h *BalanceHandler) GetUserBalances(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+
+	var userIDs []int64
+	if err := json.NewDecoder(r.Body).Decode(&userIDs); err != nil {
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
+		http.Error(w, "Geçersiz istek gövdesi, user_id dizisi bekleniyor", http.StatusBadRequest)
+		return
+	}
+
+	unique := make([]int64, 0, len(userIDs))
+	seen := make(map[int64]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if !seen[userID] {
+			seen[userID] = true
+			unique = append(unique, userID)
+		}
+	}
+
+	if len(unique) == 0 {
+		http.Error(w, "En az bir user_id gerekli", http.StatusBadRequest)
+		return
+	}
+	if len(unique) > maxBulkBalanceUsers {
+		http.Error(w, fmt.Sprintf("En fazla %d user_id istenebilir", maxBulkBalanceUsers), http.StatusBadRequest)
+		return
+	}
+
+	currency := r.URL.Query().Get("currency")
+
+	balances, err := h.service.GetBalances(r.Context(), unique, currency)
+	if err != nil {
+		h.logger.ErrorWithErr("Bakiye bilgileri alınamadı", err, map[string]interface{}{"users": len(unique), "currency": currency})
+		http.Error(w, err.Error(), currencyErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balances)
+}
+
 func (h *BalanceHandler) InitializeUserBalance(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
+	userIDStr := pathOrQuery(r, "user_id", "user_id")
 	if userIDStr == "" {
 		h.logger.Error("user_id parametresi eksik", map[string]interface{}{})
 		http.Error(w, "user_id parametresi eksik", http.StatusBadRequest)
@@ -58,21 +109,23 @@ func (h *BalanceHandler) InitializeUserBalance(w http.ResponseWriter, r *http.Re
 
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz user_id formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz user_id formatı", err, nil)
 		http.Error(w, "Geçersiz user_id formatı", http.StatusBadRequest)
 		return
 	}
 
-	err = h.service.InitializeBalance(userID)
+	currency := r.URL.Query().Get("currency")
+
+	err = h.service.InitializeBalance(r.Context(), userID, currency)
 	if err != nil {
-		h.logger.Error("Bakiye başlatılamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.logger.ErrorWithErr("Bakiye başlatılamadı", err, map[string]interface{}{"user_id": userID, "currency": currency})
+		http.Error(w, err.Error(), currencyErrorStatus(err))
 		return
 	}
 
-	balance, err := h.service.GetBalance(userID)
+	balance, err := h.service.GetBalance(r.Context(), userID, currency)
 	if err != nil {
-		h.logger.Error("Bakiye bilgisi alınamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		h.logger.ErrorWithErr("Bakiye bilgisi alınamadı", err, map[string]interface{}{"user_id": userID})
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -83,7 +136,7 @@ func (h *BalanceHandler) InitializeUserBalance(w http.ResponseWriter, r *http.Re
 }
 
 func (h *BalanceHandler) GetBalanceHistory(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
+	userIDStr := pathOrQuery(r, "user_id", "user_id")
 	if userIDStr == "" {
 		h.logger.Error("user_id parametresi eksik", map[string]interface{}{})
 		http.Error(w, "user_id parametresi eksik", http.StatusBadRequest)
@@ -92,7 +145,7 @@ func (h *BalanceHandler) GetBalanceHistory(w http.ResponseWriter, r *http.Reques
 
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz user_id formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz user_id formatı", err, nil)
 		http.Error(w, "Geçersiz user_id formatı", http.StatusBadRequest)
 		return
 	}
@@ -113,27 +166,36 @@ func (h *BalanceHandler) GetBalanceHistory(w http.ResponseWriter, r *http.Reques
 
 	startDate, err := time.Parse(time.RFC3339, startDateStr)
 	if err != nil {
-		h.logger.Error("Geçersiz start_date formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz start_date formatı", err, nil)
 		http.Error(w, "Geçersiz start_date formatı. RFC3339 formatında olmalı (örn: 2023-01-01T00:00:00Z)", http.StatusBadRequest)
 		return
 	}
 
 	endDate, err := time.Parse(time.RFC3339, endDateStr)
 	if err != nil {
-		h.logger.Error("Geçersiz end_date formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz end_date formatı", err, nil)
 		http.Error(w, "Geçersiz end_date formatı. RFC3339 formatında olmalı (örn: 2023-01-01T00:00:00Z)", http.StatusBadRequest)
 		return
 	}
 
-	history, err := h.service.GetBalanceHistory(userID, startDate, endDate)
+	if !startDate.Before(endDate) {
+		h.logger.Error("Geçersiz tarih aralığı", map[string]interface{}{"start_date": startDate, "end_date": endDate})
+		http.Error(w, "Başlangıç tarihi bitiş tarihinden önce olmalı", http.StatusBadRequest)
+		return
+	}
+
+	currency := r.URL.Query().Get("currency")
+
+	history, err := h.service.GetBalanceHistory(r.Context(), userID, currency, startDate, endDate)
 	if err != nil {
 		h.logger.Error("Bakiye geçmişi alınamadı", map[string]interface{}{
 			"user_id":    userID,
+			"currency":   currency,
 			"start_date": startDate,
 			"end_date":   endDate,
 			"error":      err.Error(),
 		})
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), currencyErrorStatus(err))
 		return
 	}
 
@@ -141,8 +203,50 @@ func (h *BalanceHandler) GetBalanceHistory(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(history)
 }
 
+func (h *BalanceHandler) GetBalanceAt(w http.ResponseWriter, r *http.Request) {
+	userIDStr := pathOrQuery(r, "user_id", "user_id")
+	if userIDStr == "" {
+		h.logger.Error("user_id parametresi eksik", map[string]interface{}{})
+		http.Error(w, "user_id parametresi eksik", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		h.logger.ErrorWithErr("Geçersiz user_id formatı", err, nil)
+		http.Error(w, "Geçersiz user_id formatı", http.StatusBadRequest)
+		return
+	}
+
+	timestampStr := r.URL.Query().Get("timestamp")
+	if timestampStr == "" {
+		h.logger.Error("timestamp parametresi eksik", map[string]interface{}{})
+		http.Error(w, "timestamp parametresi eksik", http.StatusBadRequest)
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, timestampStr)
+	if err != nil {
+		h.logger.ErrorWithErr("Geçersiz timestamp formatı", err, nil)
+		http.Error(w, "Geçersiz timestamp formatı. RFC3339 formatında olmalı (örn: 2023-01-01T00:00:00Z)", http.StatusBadRequest)
+		return
+	}
+
+	currency := r.URL.Query().Get("currency")
+
+	snapshot, err := h.service.GetBalanceAt(r.Context(), userID, currency, at)
+	if err != nil {
+		h.logger.ErrorWithErr("Geçmiş bakiye alınamadı", err, map[string]interface{}{"user_id": userID, "currency": currency, "timestamp": at})
+		http.Error(w, err.Error(), currencyErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
 func (h *BalanceHandler) ReplayBalanceEvents(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
+	userIDStr := pathOrQuery(r, "user_id", "user_id")
 	if userIDStr == "" {
 		h.logger.Error("user_id parametresi eksik", map[string]interface{}{})
 		http.Error(w, "user_id parametresi eksik", http.StatusBadRequest)
@@ -151,14 +255,21 @@ func (h *BalanceHandler) ReplayBalanceEvents(w http.ResponseWriter, r *http.Requ
 
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz user_id formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz user_id formatı", err, nil)
 		http.Error(w, "Geçersiz user_id formatı", http.StatusBadRequest)
 		return
 	}
 
-	err = h.service.ReplayBalanceEvents(userID)
+	fromVersion, err := parseFromVersion(r)
 	if err != nil {
-		h.logger.Error("Bakiye eventleri tekrar oynatılamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz from_version formatı", err, nil)
+		http.Error(w, "Geçersiz from_version formatı", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.ReplayBalanceEvents(userID, fromVersion)
+	if err != nil {
+		h.logger.ErrorWithErr("Bakiye eventleri tekrar oynatılamadı", err, map[string]interface{}{"user_id": userID, "from_version": fromVersion})
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -169,7 +280,7 @@ func (h *BalanceHandler) ReplayBalanceEvents(w http.ResponseWriter, r *http.Requ
 }
 
 func (h *BalanceHandler) RebuildBalanceState(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
+	userIDStr := pathOrQuery(r, "user_id", "user_id")
 	if userIDStr == "" {
 		h.logger.Error("user_id parametresi eksik", map[string]interface{}{})
 		http.Error(w, "user_id parametresi eksik", http.StatusBadRequest)
@@ -178,14 +289,14 @@ func (h *BalanceHandler) RebuildBalanceState(w http.ResponseWriter, r *http.Requ
 
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz user_id formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz user_id formatı", err, nil)
 		http.Error(w, "Geçersiz user_id formatı", http.StatusBadRequest)
 		return
 	}
 
 	err = h.service.RebuildBalanceState(userID)
 	if err != nil {
-		h.logger.Error("Bakiye durumu yeniden oluşturulamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		h.logger.ErrorWithErr("Bakiye durumu yeniden oluşturulamadı", err, map[string]interface{}{"user_id": userID})
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -195,55 +306,49 @@ func (h *BalanceHandler) RebuildBalanceState(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Balance state rebuilt successfully"})
 }
 
-func (h *BalanceHandler) RegisterRoutes(mux *http.ServeMux) {
-	h.logger.Info("Balance routes register ediliyor...", map[string]interface{}{})
-
-	mux.HandleFunc("/api/balances/initialize", func(w http.ResponseWriter, r *http.Request) {
-		h.logger.Info("Initialize route çağrıldı", map[string]interface{}{"method": r.Method, "path": r.URL.Path})
-		if r.Method == http.MethodPost {
-			h.InitializeUserBalance(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	mux.HandleFunc("/api/balances/history", func(w http.ResponseWriter, r *http.Request) {
-		h.logger.Info("History route çağrıldı", map[string]interface{}{"method": r.Method, "path": r.URL.Path})
-		if r.Method == http.MethodGet {
-			h.GetBalanceHistory(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	mux.HandleFunc("/api/balances/replay", func(w http.ResponseWriter, r *http.Request) {
-		h.logger.Info("Replay route çağrıldı", map[string]interface{}{"method": r.Method, "path": r.URL.Path})
-		if r.Method == http.MethodPost {
-			h.ReplayBalanceEvents(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	mux.HandleFunc("/api/balances/rebuild", func(w http.ResponseWriter, r *http.Request) {
-		h.logger.Info("Rebuild route çağrıldı", map[string]interface{}{"method": r.Method, "path": r.URL.Path})
-		if r.Method == http.MethodPost {
-			h.RebuildBalanceState(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	mux.HandleFunc("/api/balances", func(w http.ResponseWriter, r *http.Request) {
-		h.logger.Info("Ana balance route çağrıldı", map[string]interface{}{"method": r.Method, "path": r.URL.Path})
-		if r.Method == http.MethodGet {
-			h.GetUserBalance(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+// parseFromVersion reads the optional from_version query parameter used by
+// the replay endpoints; when absent the whole event stream is replayed.
+func parseFromVersion(r *http.Request) (int, error) {
+	fromVersionStr := r.URL.Query().Get("from_version")
+	if fromVersionStr == "" {
+		return 0, nil
+	}
 
-	h.logger.Info("Balance routes başarıyla register edildi", map[string]interface{}{
-		"routes": []string{"/api/balances/initialize", "/api/balances/history", "/api/balances/replay", "/api/balances/rebuild", "/api/balances"},
-	})
+	fromVersion, err := strconv.Atoi(fromVersionStr)
+	if err != nil {
+		return 0, err
+	}
+
+	if fromVersion < 1 {
+		return 0, fmt.Errorf("from_version pozitif olmalı: %d", fromVersion)
+	}
+
+	return fromVersion, nil
+}
+
+func currencyErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrInvalidCurrency) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *BalanceHandler) RegisterRoutes(mux *http.ServeMux) {
+	manageSystem := h.auth.RequirePermission(domain.PermissionSystemManage)
+
+	mux.HandleFunc("GET /api/balances/{user_id}", h.GetUserBalance)
+	mux.Handle("POST /api/balances/{user_id}/initialize", manageSystem(http.HandlerFunc(h.InitializeUserBalance)))
+	mux.HandleFunc("POST /api/balances/bulk", h.GetUserBalances)
+	mux.HandleFunc("GET /api/balances/{user_id}/history", h.GetBalanceHistory)
+	mux.HandleFunc("GET /api/balances/{user_id}/at", h.GetBalanceAt)
+	mux.Handle("POST /api/balances/{user_id}/replay", manageSystem(http.HandlerFunc(h.ReplayBalanceEvents)))
+	mux.Handle("POST /api/balances/{user_id}/rebuild", manageSystem(http.HandlerFunc(h.RebuildBalanceState)))
+
+	// Query-parameter forms kept for one release.
+	mux.HandleFunc("GET /api/balances", deprecatedRoute(h.logger, "GET /api/balances/{user_id}", h.GetUserBalance))
+	mux.Handle("POST /api/balances/initialize", manageSystem(deprecatedRoute(h.logger, "POST /api/balances/{user_id}/initialize", h.InitializeUserBalance)))
+	mux.HandleFunc("GET /api/balances/history", deprecatedRoute(h.logger, "GET /api/balances/{user_id}/history", h.GetBalanceHistory))
+	mux.HandleFunc("GET /api/balances/at", deprecatedRoute(h.logger, "GET /api/balances/{user_id}/at", h.GetBalanceAt))
+	mux.Handle("POST /api/balances/replay", manageSystem(deprecatedRoute(h.logger, "POST /api/balances/{user_id}/replay", h.ReplayBalanceEvents)))
+	mux.Handle("POST /api/balances/rebuild", manageSystem(deprecatedRoute(h.logger, "POST /api/balances/{user_id}/rebuild", h.RebuildBalanceState)))
 }
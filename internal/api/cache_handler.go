@@ -22,10 +22,15 @@ type CacheStatsResponse struct {
 	CacheType  string                 `json:"cache_type"`
 	Uptime     time.Duration          `json:"uptime"`
 	TotalKeys  int                    `json:"total_keys"`
+	Truncated  bool                   `json:"truncated"`
 	CacheStats map[string]interface{} `json:"cache_stats"`
 	Timestamp  time.Time              `json:"timestamp"`
 }
 
+// cacheStatsKeyLimit caps how many keys a stats call scans; counts are a
+// sample beyond it and the response is marked truncated.
+const cacheStatsKeyLimit = 10000
+
 type WarmUpRequest struct {
 	UserID *int64 `json:"user_id,omitempty"`
 	Type   string `json:"type"` // "user", "top_users", "frequent_data"
@@ -47,25 +52,27 @@ func NewCacheHandler(cache cache.Cache, warmUpManager *cache.WarmUpManager, logg
 }
 
 func (h *CacheHandler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/cache/stats", h.handleCacheStats)
-	mux.HandleFunc("/api/cache/warmup", h.handleWarmUp)
-	mux.HandleFunc("/api/cache/invalidate", h.handleInvalidate)
-	mux.HandleFunc("/api/cache/keys", h.handleKeys)
-	mux.HandleFunc("/api/cache/health", h.handleHealth)
+	mux.HandleFunc("GET /api/cache/stats", h.handleCacheStats)
+	mux.HandleFunc("POST /api/cache/warmup", h.handleWarmUp)
+	mux.HandleFunc("POST /api/cache/invalidate", h.handleInvalidate)
+	mux.HandleFunc("GET /api/cache/keys", h.handleKeys)
+	mux.HandleFunc("GET /api/cache/health", h.handleHealth)
 }
 
 func (h *CacheHandler) handleCacheStats(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+	limit := cacheStatsKeyLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
 	}
 
 	ctx := context.Background()
 
-	// Get all keys matching payflow prefix
-	keys, err := h.cache.GetKeys(ctx, "*")
+	// Get keys matching payflow prefix, up to limit
+	keys, err := h.cache.GetKeys(ctx, "*", limit)
 	if err != nil {
-		h.logger.Error("Cache keys alınamadı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Cache keys alınamadı", err, nil)
 		http.Error(w, "Cache stats could not be retrieved", http.StatusInternalServerError)
 		return
 	}
@@ -73,6 +80,7 @@ func (h *CacheHandler) handleCacheStats(w http.ResponseWriter, r *http.Request)
 	stats := CacheStatsResponse{
 		CacheType: "Redis",
 		TotalKeys: len(keys),
+		Truncated: len(keys) >= limit,
 		CacheStats: map[string]interface{}{
 			"user_keys":        countKeysByPrefix(keys, "user:"),
 			"balance_keys":     countKeysByPrefix(keys, "balance:"),
@@ -88,11 +96,6 @@ func (h *CacheHandler) handleCacheStats(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *CacheHandler) handleWarmUp(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req WarmUpRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -152,11 +155,6 @@ func (h *CacheHandler) handleWarmUp(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CacheHandler) handleInvalidate(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req CacheInvalidateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -169,7 +167,7 @@ func (h *CacheHandler) handleInvalidate(w http.ResponseWriter, r *http.Request)
 
 	if req.Pattern != nil {
 		// Delete by pattern
-		keys, getErr := h.cache.GetKeys(ctx, *req.Pattern)
+		keys, getErr := h.cache.GetKeys(ctx, *req.Pattern, 0)
 		if getErr != nil {
 			http.Error(w, fmt.Sprintf("Error getting keys: %v", getErr), http.StatusInternalServerError)
 			return
@@ -195,7 +193,7 @@ func (h *CacheHandler) handleInvalidate(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err != nil {
-		h.logger.Error("Cache invalidation hatası", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Cache invalidation hatası", err, nil)
 		http.Error(w, fmt.Sprintf("Cache invalidation failed: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -211,11 +209,6 @@ func (h *CacheHandler) handleInvalidate(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *CacheHandler) handleKeys(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	pattern := r.URL.Query().Get("pattern")
 	if pattern == "" {
 		pattern = "*"
@@ -230,7 +223,7 @@ func (h *CacheHandler) handleKeys(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
-	keys, err := h.cache.GetKeys(ctx, pattern)
+	keys, err := h.cache.GetKeys(ctx, pattern, limit)
 	if err != nil {
 		h.logger.Error("Cache keys alınamadı", map[string]interface{}{
 			"pattern": pattern,
@@ -240,11 +233,6 @@ func (h *CacheHandler) handleKeys(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Apply limit
-	if len(keys) > limit {
-		keys = keys[:limit]
-	}
-
 	response := map[string]interface{}{
 		"keys":      keys,
 		"count":     len(keys),
@@ -258,11 +246,6 @@ func (h *CacheHandler) handleKeys(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CacheHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	ctx := context.Background()
 	err := h.cache.Ping(ctx)
 
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"payflow/internal/api/middleware"
+	"payflow/internal/domain"
+	"payflow/pkg/auth"
+	"payflow/pkg/circuitbreaker"
+	"payflow/pkg/logger"
+)
+
+type CircuitBreakerHandler struct {
+	registry *circuitbreaker.Registry
+	auth     *middleware.Authenticator
+	logger   logger.Logger
+}
+
+type CircuitBreakerStatus struct {
+	Name   string                `json:"name"`
+	State  string                `json:"state"`
+	Forced bool                  `json:"forced"`
+	Counts circuitbreaker.Counts `json:"counts"`
+}
+
+func NewCircuitBreakerHandler(registry *circuitbreaker.Registry, auth *middleware.Authenticator, logger logger.Logger) *CircuitBreakerHandler {
+	return &CircuitBreakerHandler{
+		registry: registry,
+		auth:     auth,
+		logger:   logger,
+	}
+}
+
+func (h *CircuitBreakerHandler) GetCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	breakers := h.registry.All()
+
+	statuses := make([]CircuitBreakerStatus, 0, len(breakers))
+	for _, cb := range breakers {
+		statuses = append(statuses, h.status(cb))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+func (h *CircuitBreakerHandler) ResetCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	h.control(w, r, "reset", h.registry.Reset)
+}
+
+func (h *CircuitBreakerHandler) TripCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	h.control(w, r, "trip", h.registry.Trip)
+}
+
+func (h *CircuitBreakerHandler) ForceOpenCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	h.control(w, r, "force_open", h.registry.ForceOpen)
+}
+
+func (h *CircuitBreakerHandler) ForceCloseCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	h.control(w, r, "force_close", h.registry.ForceClose)
+}
+
+func (h *CircuitBreakerHandler) control(w http.ResponseWriter, r *http.Request, action string, apply func(name string) error) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "name parametresi eksik", http.StatusBadRequest)
+		return
+	}
+
+	if err := apply(name); err != nil {
+		if errors.Is(err, circuitbreaker.ErrNotFound) {
+			http.Error(w, "Devre kesici bulunamadı", http.StatusNotFound)
+			return
+		}
+		h.logger.ErrorWithErr("Devre kesici güncellenemedi", err, map[string]interface{}{"name": name, "action": action})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Warn("Devre kesici manuel olarak değiştirildi", map[string]interface{}{
+		"name":     name,
+		"action":   action,
+		"actor_id": auth.ActorIDFromContext(r.Context()),
+	})
+
+	cb, _ := h.registry.Get(name)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.status(cb))
+}
+
+func (h *CircuitBreakerHandler) status(cb *circuitbreaker.CircuitBreaker) CircuitBreakerStatus {
+	return CircuitBreakerStatus{
+		Name:   cb.Name(),
+		State:  cb.State().String(),
+		Forced: cb.Forced(),
+		Counts: cb.Counts(),
+	}
+}
+
+func (h *CircuitBreakerHandler) RegisterRoutes(mux *http.ServeMux) {
+	manage := h.auth.RequirePermission(domain.PermissionSystemManage)
+
+	mux.Handle("GET /api/circuit-breakers", manage(http.HandlerFunc(h.GetCircuitBreakers)))
+	mux.Handle("POST /api/circuit-breakers/reset", manage(http.HandlerFunc(h.ResetCircuitBreaker)))
+	mux.Handle("POST /api/circuit-breakers/trip", manage(http.HandlerFunc(h.TripCircuitBreaker)))
+	mux.Handle("POST /api/circuit-breakers/force-open", manage(http.HandlerFunc(h.ForceOpenCircuitBreaker)))
+	mux.Handle("POST /api/circuit-breakers/force-close", manage(http.HandlerFunc(h.ForceCloseCircuitBreaker)))
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPISpec is maintained by hand next to the handlers; update it together
+// with any route or payload change.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at /openapi.json.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="tr">
+<head>
+  <meta charset="utf-8">
+  <title>PayFlow API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+type DocsHandler struct {
+	specJSON []byte
+}
+
+// NewDocsHandler converts the embedded spec to JSON once, so a broken spec
+// stops the server at startup instead of failing on the first request.
+func NewDocsHandler() (*DocsHandler, error) {
+	var spec map[string]interface{}
+	if err := yaml.Unmarshal(openAPISpec, &spec); err != nil {
+		return nil, fmt.Errorf("OpenAPI spec okunamadı: %w", err)
+	}
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAPI spec JSON'a çevrilemedi: %w", err)
+	}
+
+	return &DocsHandler{specJSON: specJSON}, nil
+}
+
+func (h *DocsHandler) GetSpecJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(h.specJSON)
+}
+
+func (h *DocsHandler) GetSpecYAML(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPISpec)
+}
+
+func (h *DocsHandler) GetDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+func (h *DocsHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /openapi.json", h.GetSpecJSON)
+	mux.HandleFunc("GET /openapi.yaml", h.GetSpecYAML)
+	mux.HandleFunc("GET /docs", h.GetDocs)
+}
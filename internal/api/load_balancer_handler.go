@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"payflow/internal/api/middleware"
+	"payflow/internal/domain"
+	"payflow/pkg/auth"
+	"payflow/pkg/loadbalancer"
+	"payflow/pkg/logger"
+)
+
+// maxDrainWait caps how long a maintenance request may block on ?wait.
+const maxDrainWait = 5 * time.Minute
+
+type LoadBalancerHandler struct {
+	loadBalancer *loadbalancer.LoadBalancer
+	auth         *middleware.Authenticator
+	logger       logger.Logger
+}
+
+type BackendRequest struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+type MaintenanceResponse struct {
+	Host        string `json:"host"`
+	Maintenance bool   `json:"maintenance"`
+	Drained     bool   `json:"drained"`
+}
+
+// NewLoadBalancerHandler accepts a nil load balancer; its endpoints then
+// answer 503.
+func NewLoadBalancerHandler(loadBalancer *loadbalancer.LoadBalancer, auth *middleware.Authenticator, logger logger.Logger) *LoadBalancerHandler {
+	return &LoadBalancerHandler{
+		loadBalancer: loadBalancer,
+		auth:         auth,
+		logger:       logger,
+	}
+}
+
+func (h *LoadBalancerHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	if h.loadBalancer == nil {
+		http.Error(w, "Load balancer etkin değil", http.StatusServiceUnavailable)
+		return
+	}
+
+	host := r.URL.Query().Get("host")
+	if host == "" {
+		http.Error(w, "host parametresi eksik", http.StatusBadRequest)
+		return
+	}
+
+	enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+	if err != nil {
+		http.Error(w, "Geçersiz enabled değeri, true veya false olmalı", http.StatusBadRequest)
+		return
+	}
+
+	var wait time.Duration
+	if waitStr := r.URL.Query().Get("wait"); waitStr != "" {
+		wait, err = time.ParseDuration(waitStr)
+		if err != nil || wait < 0 {
+			http.Error(w, "Geçersiz wait değeri", http.StatusBadRequest)
+			return
+		}
+		if wait > maxDrainWait {
+			wait = maxDrainWait
+		}
+	}
+
+	if err := h.loadBalancer.SetMaintenance(host, enabled); err != nil {
+		if errors.Is(err, loadbalancer.ErrBackendNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Warn("Backend bakım modu değiştirildi", map[string]interface{}{
+		"host":     host,
+		"enabled":  enabled,
+		"actor_id": auth.ActorIDFromContext(r.Context()),
+	})
+
+	response := MaintenanceResponse{Host: host, Maintenance: enabled}
+	if enabled && wait > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), wait)
+		defer cancel()
+
+		response.Drained = h.loadBalancer.WaitForDrain(ctx, host) == nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *LoadBalancerHandler) AddBackend(w http.ResponseWriter, r *http.Request) {
+	if h.loadBalancer == nil {
+		http.Error(w, "Load balancer etkin değil", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req BackendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
+		return
+	}
+
+	backendURL, err := url.Parse(req.URL)
+	if err != nil || backendURL.Host == "" || (backendURL.Scheme != "http" && backendURL.Scheme != "https") {
+		http.Error(w, "Geçersiz url, http(s)://host:port biçiminde olmalı", http.StatusBadRequest)
+		return
+	}
+
+	if req.Weight < 0 {
+		http.Error(w, "weight negatif olamaz", http.StatusBadRequest)
+		return
+	}
+	if req.Weight == 0 {
+		req.Weight = 1
+	}
+
+	if err := h.loadBalancer.AddBackend(req.URL, req.Weight); err != nil {
+		if errors.Is(err, loadbalancer.ErrBackendExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Warn("Backend eklendi", map[string]interface{}{
+		"url":      req.URL,
+		"weight":   req.Weight,
+		"actor_id": auth.ActorIDFromContext(r.Context()),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(req)
+}
+
+func (h *LoadBalancerHandler) RemoveBackend(w http.ResponseWriter, r *http.Request) {
+	if h.loadBalancer == nil {
+		http.Error(w, "Load balancer etkin değil", http.StatusServiceUnavailable)
+		return
+	}
+
+	backendURL := r.URL.Query().Get("url")
+	if backendURL == "" {
+		http.Error(w, "url parametresi eksik", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.loadBalancer.RemoveBackend(backendURL); err != nil {
+		if errors.Is(err, loadbalancer.ErrBackendNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Warn("Backend kaldırıldı", map[string]interface{}{
+		"url":      backendURL,
+		"actor_id": auth.ActorIDFromContext(r.Context()),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *LoadBalancerHandler) RegisterRoutes(mux *http.ServeMux) {
+	manage := h.auth.RequirePermission(domain.PermissionSystemManage)
+
+	mux.Handle("POST /api/load-balancer/backends", manage(http.HandlerFunc(h.AddBackend)))
+	mux.Handle("DELETE /api/load-balancer/backends", manage(http.HandlerFunc(h.RemoveBackend)))
+	mux.Handle("POST /api/load-balancer/maintenance", manage(http.HandlerFunc(h.SetMaintenance)))
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"payflow/internal/api/middleware"
+	"payflow/internal/domain"
+	"payflow/pkg/auth"
+	"payflow/pkg/logger"
+)
+
+// maxFullLogging caps how long sampling may be switched off in one request.
+const maxFullLogging = time.Hour
+
+type LoggingHandler struct {
+	sampler *logger.Sampler
+	auth    *middleware.Authenticator
+	logger  logger.Logger
+}
+
+type SamplingStatus struct {
+	Rate             uint32     `json:"rate"`
+	FullLoggingUntil *time.Time `json:"full_logging_until,omitempty"`
+}
+
+func NewLoggingHandler(sampler *logger.Sampler, auth *middleware.Authenticator, logger logger.Logger) *LoggingHandler {
+	return &LoggingHandler{
+		sampler: sampler,
+		auth:    auth,
+		logger:  logger,
+	}
+}
+
+func (h *LoggingHandler) GetSampling(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.status())
+}
+
+// UpdateSampling accepts ?rate=N to change the sample rate and
+// ?full_logging=10m to log everything for a while (0 ends it early).
+func (h *LoggingHandler) UpdateSampling(w http.ResponseWriter, r *http.Request) {
+	rateStr := r.URL.Query().Get("rate")
+	fullStr := r.URL.Query().Get("full_logging")
+	if rateStr == "" && fullStr == "" {
+		http.Error(w, "rate veya full_logging parametresi gerekli", http.StatusBadRequest)
+		return
+	}
+
+	var rate uint64
+	if rateStr != "" {
+		var err error
+		rate, err = strconv.ParseUint(rateStr, 10, 32)
+		if err != nil || rate < 1 {
+			http.Error(w, "Geçersiz rate değeri", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var full time.Duration
+	if fullStr != "" {
+		var err error
+		full, err = time.ParseDuration(fullStr)
+		if err != nil || full < 0 {
+			http.Error(w, "Geçersiz full_logging değeri", http.StatusBadRequest)
+			return
+		}
+		if full > maxFullLogging {
+			full = maxFullLogging
+		}
+	}
+
+	if rateStr != "" {
+		h.sampler.SetRate(uint32(rate))
+	}
+	if fullStr != "" {
+		h.sampler.EnableFullLogging(full)
+	}
+
+	h.logger.Warn("Log örnekleme ayarları değiştirildi", map[string]interface{}{
+		"rate":         h.sampler.Rate(),
+		"full_logging": full.String(),
+		"actor_id":     auth.ActorIDFromContext(r.Context()),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.status())
+}
+
+func (h *LoggingHandler) status() SamplingStatus {
+	status := SamplingStatus{Rate: h.sampler.Rate()}
+	if until := h.sampler.FullLoggingUntil(); !until.IsZero() {
+		status.FullLoggingUntil = &until
+	}
+	return status
+}
+
+func (h *LoggingHandler) RegisterRoutes(mux *http.ServeMux) {
+	manage := h.auth.RequirePermission(domain.PermissionSystemManage)
+
+	mux.Handle("GET /api/logging/sampling", manage(http.HandlerFunc(h.GetSampling)))
+	mux.Handle("POST /api/logging/sampling", manage(http.HandlerFunc(h.UpdateSampling)))
+}
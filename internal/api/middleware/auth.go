@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"payflow/internal/domain"
+	"payflow/pkg/auth"
+	"payflow/pkg/logger"
+)
+
+const ApiKeyHeader = "X-API-Key"
+
+// Authenticator resolves the caller from a bearer JWT or, as a fallback, an
+// X-API-Key header and injects the user into the request context.
+type Authenticator struct {
+	tokens      *auth.TokenManager
+	userService domain.UserService
+	logger      logger.Logger
+}
+
+func NewAuthenticator(tokens *auth.TokenManager, userService domain.UserService, logger logger.Logger) *Authenticator {
+	return &Authenticator{
+		tokens:      tokens,
+		userService: userService,
+		logger:      logger,
+	}
+}
+
+// RequireAuth rejects unauthenticated requests with 401 and, when roles are
+// given, callers without one of them with 403. Admins pass every role check.
+func (a *Authenticator) RequireAuth(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return a.Authenticate(RequireRoles(roles...)(next))
+	}
+}
+
+// RequirePermission authenticates the caller and rejects it with 403 unless
+// its role grants permission.
+func (a *Authenticator) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return a.Authenticate(RequirePermission(a.userService, permission)(next))
+	}
+}
+
+// Identify returns the caller if the request carries credentials that verify
+// and belong to an active user, and nil otherwise. It never rejects.
+func (a *Authenticator) Identify(r *http.Request) *domain.User {
+	if r.Header.Get("Authorization") == "" && r.Header.Get(ApiKeyHeader) == "" {
+		return nil
+	}
+
+	user, _, _ := a.authenticate(r)
+	return user
+}
+
+// Identified stores the caller in the request context when Identify finds
+// one, so open routes can still attribute their audit records.
+func (a *Authenticator) Identified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := a.Identify(r); user != nil {
+			r = r.WithContext(auth.WithUser(r.Context(), user))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ActsFor reports whether the caller stored in the request context is the
+// user userID or holds permission. It must run behind an authenticating
+// middleware.
+func (a *Authenticator) ActsFor(r *http.Request, userID int64, permission string) (bool, error) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return false, nil
+	}
+
+	if user.ID == userID {
+		return true, nil
+	}
+
+	return a.userService.HasPermission(user.ID, permission)
+}
+
+// Authenticate resolves the caller and stores it in the request context.
+func (a *Authenticator) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, status, message := a.authenticate(r)
+		if user == nil {
+			http.Error(w, message, status)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+	})
+}
+
+// RequireRoles must run after an authenticating middleware; it checks the
+// user stored in the request context.
+func RequireRoles(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := auth.UserFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Yetkilendirme gerekli", http.StatusUnauthorized)
+				return
+			}
+
+			if !hasRole(user, roles) {
+				http.Error(w, "Bu işlem için yetkiniz yok", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission must run after an authenticating middleware; it checks
+// the permission against the role map held by the user service.
+func RequirePermission(userService domain.UserService, permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := auth.UserFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Yetkilendirme gerekli", http.StatusUnauthorized)
+				return
+			}
+
+			allowed, err := userService.HasPermission(user.ID, permission)
+			if err != nil {
+				http.Error(w, "Yetki kontrolü yapılamadı", http.StatusInternalServerError)
+				return
+			}
+
+			if !allowed {
+				http.Error(w, "Bu işlem için yetkiniz yok", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (*domain.User, int, string) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		tokenString, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return nil, http.StatusUnauthorized, "Geçersiz Authorization başlığı"
+		}
+
+		claims, err := a.tokens.Parse(strings.TrimSpace(tokenString))
+		if err != nil {
+			a.logger.ErrorWithErr("Token doğrulanamadı", err, nil)
+			return nil, http.StatusUnauthorized, "Geçersiz veya süresi dolmuş token"
+		}
+
+		// The user is reloaded so deleted users and role changes take effect
+		// before the token expires.
+		user, err := a.userService.GetUserByID(claims.UserID)
+		if err != nil {
+			a.logger.ErrorWithErr("Token kullanıcısı bulunamadı", err, map[string]interface{}{"user_id": claims.UserID})
+			return nil, http.StatusUnauthorized, "Geçersiz veya süresi dolmuş token"
+		}
+
+		if revokedByPasswordChange(user, claims) {
+			a.logger.Warn("Şifre değişikliğinden önce verilmiş token reddedildi", map[string]interface{}{"user_id": user.ID})
+			return nil, http.StatusUnauthorized, "Geçersiz veya süresi dolmuş token"
+		}
+
+		return activeUser(user)
+	}
+
+	apiKey := r.Header.Get(ApiKeyHeader)
+	if apiKey == "" {
+		a.logger.Error("Kimlik bilgisi eksik", map[string]interface{}{"path": r.URL.Path})
+		return nil, http.StatusUnauthorized, "Yetkilendirme gerekli"
+	}
+
+	user, err := a.userService.GetUserByApiKey(apiKey)
+	if err != nil {
+		a.logger.ErrorWithErr("API anahtarı geçersiz", err, nil)
+		return nil, http.StatusUnauthorized, "Geçersiz API anahtarı"
+	}
+
+	return activeUser(user)
+}
+
+// revokedByPasswordChange compares at second precision, the resolution of the
+// JWT iat claim.
+func revokedByPasswordChange(user *domain.User, claims *auth.Claims) bool {
+	if user.PasswordChangedAt == nil {
+		return false
+	}
+
+	if claims.IssuedAt == nil {
+		return true
+	}
+
+	return claims.IssuedAt.Time.Before(user.PasswordChangedAt.Truncate(time.Second))
+}
+
+func activeUser(user *domain.User) (*domain.User, int, string) {
+	if !user.IsActive {
+		return nil, http.StatusForbidden, domain.ErrUserInactive.Error()
+	}
+
+	return user, 0, ""
+}
+
+func hasRole(user *domain.User, roles []string) bool {
+	if len(roles) == 0 || user.Role == domain.UserRoleAdmin {
+		return true
+	}
+
+	for _, role := range roles {
+		if user.Role == role {
+			return true
+		}
+	}
+
+	return false
+}
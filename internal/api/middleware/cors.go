@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payflow/internal/config"
+)
+
+// corsRequiredHeaders are sent by API clients on almost every request, so
+// they are allowed even when CORS_ALLOWED_HEADERS leaves them out.
+var corsRequiredHeaders = []string{ApiKeyHeader, RequestIDHeader}
+
+// corsExposedHeaders are response headers browsers may read cross-origin.
+var corsExposedHeaders = []string{
+	RequestIDHeader,
+	"X-Trace-ID",
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"Retry-After",
+}
+
+// CORS adds cross-origin headers for allowlisted origins and answers
+// preflight requests with 204. The request's Origin is echoed back, never
+// "*", when credentials are allowed. Without configured origins it is a
+// no-op.
+func CORS(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(cfg.AllowedOrigins) == 0 {
+			return next
+		}
+
+		allowAny := false
+		origins := make(map[string]bool, len(cfg.AllowedOrigins))
+		for _, origin := range cfg.AllowedOrigins {
+			if origin == "*" {
+				allowAny = true
+				continue
+			}
+			origins[strings.ToLower(origin)] = true
+		}
+
+		headers := append([]string(nil), cfg.AllowedHeaders...)
+		for _, required := range corsRequiredHeaders {
+			if !containsFold(headers, required) {
+				headers = append(headers, required)
+			}
+		}
+
+		allowMethods := strings.Join(cfg.AllowedMethods, ", ")
+		allowHeaders := strings.Join(headers, ", ")
+		exposeHeaders := strings.Join(corsExposedHeaders, ", ")
+		maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			allowed := origins[strings.ToLower(origin)] || allowAny
+			if !allowed {
+				// Without CORS headers the browser blocks the response.
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowAny && !origins[strings.ToLower(origin)] && !cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
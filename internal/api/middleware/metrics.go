@@ -2,42 +2,95 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"payflow/pkg/metrics"
 )
 
-func MetricsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		startTime := time.Now()
-
-		rw := &responseWriter{
-			ResponseWriter: w,
-			statusCode:     http.StatusOK,
-		}
-
-		next.ServeHTTP(rw, r)
-
-		duration := time.Since(startTime)
-		metrics.RecordHttpRequest(
-			r.Method,
-			r.URL.Path,
-			http.StatusText(rw.statusCode),
-			duration,
-		)
-	})
+const unmatchedRouteLabel = "unmatched"
+
+// MetricsMiddleware records request count and duration per method, route
+// pattern and status code. The endpoint label is the pattern the mux would
+// match, not the raw path, so ids and query strings do not create new series.
+func MetricsMiddleware(mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			startTime := time.Now()
+			endpoint := routeLabel(mux, r)
+
+			rw := &responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+
+			defer func() {
+				statusCode := rw.statusCode
+
+				// A panic is counted as the 500 Recover will answer with.
+				recovered := recover()
+				if recovered != nil {
+					statusCode = http.StatusInternalServerError
+				}
+
+				metrics.RecordHttpRequest(
+					r.Method,
+					endpoint,
+					strconv.Itoa(statusCode),
+					time.Since(startTime),
+				)
+
+				if recovered != nil {
+					panic(recovered)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// routeLabel returns the path part of the registered pattern matching r,
+// e.g. "/api/users" for "GET /api/users".
+func routeLabel(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	if pattern == "" {
+		return unmatchedRouteLabel
+	}
+
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		pattern = pattern[i+1:]
+	}
+
+	return pattern
 }
 
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	wroteHeader bool
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
+	if !rw.wroteHeader {
+		rw.statusCode = code
+		rw.wroteHeader = true
+	}
 	rw.ResponseWriter.WriteHeader(code)
 }
 
 func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
 	return rw.ResponseWriter.Write(b)
 }
+
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"payflow/pkg/metrics"
+)
+
+func durationSamples(t *testing.T, method, endpoint string) uint64 {
+	t.Helper()
+
+	var m dto.Metric
+	if err := metrics.HttpRequestDuration.WithLabelValues(method, endpoint).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMetricsMiddlewareRecordsRequest(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "kullanıcı bulunamadı", http.StatusNotFound)
+	})
+	handler := MetricsMiddleware(mux)(mux)
+
+	requests := metrics.HttpRequestsTotal.WithLabelValues("GET", "/api/users/{id}", "404")
+	requestsBefore := testutil.ToFloat64(requests)
+	samplesBefore := durationSamples(t, "GET", "/api/users/{id}")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users/42?fields=all", nil))
+
+	if got := testutil.ToFloat64(requests) - requestsBefore; got != 1 {
+		t.Fatalf("istek sayacı rota deseni ve durum koduyla 1 artmalı, artış: %v", got)
+	}
+	if got := durationSamples(t, "GET", "/api/users/{id}") - samplesBefore; got != 1 {
+		t.Fatalf("istek süresi bir kez gözlemlenmeli, gözlem: %d", got)
+	}
+	if got := testutil.ToFloat64(metrics.HttpRequestsTotal.WithLabelValues("GET", "/api/users/42", "404")); got != 0 {
+		t.Fatalf("ham yol etiket olarak kullanılmamalı, alınan: %v", got)
+	}
+}
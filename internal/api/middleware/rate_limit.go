@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"payflow/internal/config"
+	"payflow/pkg/logger"
+)
+
+// tokenBucketScript refills the bucket from the elapsed time (Redis server
+// clock, so all instances agree), takes one token if available and returns
+// {allowed, remaining, retry_after_ms}.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1]) or burst
+local ts = tonumber(data[2]) or now
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(burst, tokens + elapsed * rate / 1000)
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry_after = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+
+return {allowed, math.floor(tokens), retry_after}
+`)
+
+var rateLimitExemptPrefixes = []string{"/health", "/metrics"}
+
+// RateLimit applies a token bucket per client shared through Redis. Clients
+// whose credentials verify are identified by user, everyone else by client IP,
+// so random API keys cannot be used to get a fresh bucket. If Redis is
+// unavailable requests are let through. The limits are read from the current
+// configuration snapshot on every request, so a reload applies without a
+// restart.
+func RateLimit(client redis.UniversalClient, configStore *config.Store, authenticator *Authenticator, logger logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := configStore.Get().RateLimit
+			if !cfg.Enabled || cfg.Rate <= 0 || cfg.Burst <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			for _, prefix := range rateLimitExemptPrefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			key := "ratelimit:" + rateLimitIdentity(r, authenticator, cfg.TrustedProxies)
+
+			result, err := tokenBucketScript.Run(r.Context(), client, []string{key}, cfg.Rate, cfg.Burst).Int64Slice()
+			if err != nil || len(result) != 3 {
+				errMsg := "beklenmeyen yanıt"
+				if err != nil {
+					errMsg = err.Error()
+				}
+				logger.Error("Rate limit kontrol edilemedi", map[string]interface{}{"error": errMsg})
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(cfg.Burst))
+			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(result[1], 10))
+
+			if result[0] != 1 {
+				retryAfter := int64(math.Ceil(float64(result[2]) / 1000))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+
+				logger.Warn("Rate limit aşıldı", map[string]interface{}{"path": r.URL.Path, "client": key})
+				w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+				http.Error(w, "Çok fazla istek", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rateLimitIdentity(r *http.Request, authenticator *Authenticator, trustedProxies []string) string {
+	if user := authenticator.Identify(r); user != nil {
+		return "user:" + strconv.FormatInt(user.ID, 10)
+	}
+
+	return "ip:" + clientIP(r, trustedProxies)
+}
+
+// clientIP returns the peer address, or, when the peer is a trusted proxy,
+// the right-most X-Forwarded-For entry that is not itself a trusted proxy.
+// Entries further left are set by the client and cannot be trusted.
+func clientIP(r *http.Request, trustedProxies []string) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !isTrustedProxy(host, trustedProxies) {
+		return host
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+		if !isTrustedProxy(hop, trustedProxies) {
+			return hop
+		}
+		host = hop
+	}
+
+	return host
+}
+
+func isTrustedProxy(address string, trustedProxies []string) bool {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, proxy := range trustedProxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			if prefix.Contains(addr) {
+				return true
+			}
+			continue
+		}
+
+		if proxyAddr, err := netip.ParseAddr(proxy); err == nil && proxyAddr.Unmap() == addr {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "192.168.1.1"}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"doğrudan istemci", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"güvenilmeyen eş XFF'i yok sayılır", "203.0.113.7:5000", "1.2.3.4", "203.0.113.7"},
+		{"güvenilen proxy", "10.1.2.3:5000", "198.51.100.9", "198.51.100.9"},
+		{"sahte sol girdiler atlanır", "10.1.2.3:5000", "1.2.3.4, 198.51.100.9", "198.51.100.9"},
+		{"proxy zinciri", "192.168.1.1:5000", "198.51.100.9, 10.0.0.5", "198.51.100.9"},
+		{"XFF olmadan proxy", "10.1.2.3:5000", "", "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			if got := clientIP(r, trusted); got != tt.want {
+				t.Fatalf("beklenen %s, alınan %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"payflow/pkg/logger"
+)
+
+// Recover turns a panic in a handler into a 500 JSON response instead of
+// crashing the server. It belongs outermost in the chain; the tracing
+// middleware marks the span as errored before the panic reaches it. The
+// request id is read back from the response header set by RequestID.
+func Recover(logger logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				// ErrAbortHandler is how a handler deliberately aborts a
+				// response; net/http handles it without logging a stack.
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				logger.ErrorContext(r.Context(), "Handler panic yakalandı", map[string]interface{}{
+					"panic":      fmt.Sprint(recovered),
+					"stack":      string(debug.Stack()),
+					"method":     r.Method,
+					"path":       r.URL.Path,
+					"request_id": w.Header().Get(RequestIDHeader),
+				})
+
+				// Once the handler has started the response the status can
+				// no longer be changed; the client sees a truncated body.
+				if rw.wroteHeader {
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error":      "Beklenmeyen bir hata oluştu",
+					"request_id": w.Header().Get(RequestIDHeader),
+				})
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
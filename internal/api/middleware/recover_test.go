@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payflow/pkg/logger"
+)
+
+func TestRecoverAnswersPanicWith500AndKeepsServing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("beklenmeyen durum")
+	})
+	mux.HandleFunc("GET /ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Same order as the server: Metrics re-panics, Recover answers.
+	var handler http.Handler = mux
+	handler = MetricsMiddleware(mux)(handler)
+	handler = RequestID(handler)
+	handler = Recover(logger.New(logger.ErrorLevel, logger.FormatJSON, io.Discard))(handler)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/panic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("beklenen 500, alınan: %d", resp.StatusCode)
+	}
+
+	var body map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("yanıt JSON olmalı: %v", err)
+	}
+	if body["error"] == "" || body["request_id"] != resp.Header.Get(RequestIDHeader) {
+		t.Fatalf("yanıt hata mesajı ve istek kimliği içermeli, alınan: %v", body)
+	}
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(server.URL + "/ok")
+		if err != nil {
+			t.Fatalf("panik sonrası sunucu istek kabul etmeli: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("beklenen 200, alınan: %d", resp.StatusCode)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -12,7 +13,16 @@ import (
 func TracingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracing.StartSpan(r.Context(), "http_request")
-		defer span.End()
+		defer func() {
+			// A panic is re-raised for Recover after the span is marked.
+			if recovered := recover(); recovered != nil {
+				span.SetAttributes(attribute.Int("http.status_code", http.StatusInternalServerError))
+				span.SetStatus(codes.Error, fmt.Sprint(recovered))
+				span.End()
+				panic(recovered)
+			}
+			span.End()
+		}()
 
 		span.SetAttributes(
 			attribute.String("http.method", r.Method),
@@ -0,0 +1,30 @@
+package api
+
+// PaginatedResponse is the envelope every list endpoint responds with, so
+// clients can render "page X of Y" the same way everywhere.
+type PaginatedResponse[T any] struct {
+	Data       []T   `json:"data"`
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalCount int64 `json:"total_count"`
+	TotalPages int   `json:"total_pages"`
+}
+
+func NewPaginatedResponse[T any](data []T, page, pageSize int, totalCount int64) PaginatedResponse[T] {
+	if data == nil {
+		data = []T{}
+	}
+
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = int((totalCount + int64(pageSize) - 1) / int64(pageSize))
+	}
+
+	return PaginatedResponse[T]{
+		Data:       data,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalCount: totalCount,
+		TotalPages: totalPages,
+	}
+}
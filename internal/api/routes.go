@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+
+	"payflow/pkg/logger"
+)
+
+// pathOrQuery returns the path value name, falling back to the query
+// parameter legacyParam for requests that arrive on a deprecated route.
+func pathOrQuery(r *http.Request, name, legacyParam string) string {
+	if value := r.PathValue(name); value != "" {
+		return value
+	}
+	return r.URL.Query().Get(legacyParam)
+}
+
+// deprecatedRoute serves a legacy query-parameter route for one more release.
+// Each call is logged with the path-parameter route replacing it, and the
+// response carries a Deprecation header so clients can find it too.
+func deprecatedRoute(logger logger.Logger, replacement string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.WarnContext(r.Context(), "Kullanımdan kaldırılmış route çağrıldı", map[string]interface{}{
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"replacement": replacement,
+		})
+
+		w.Header().Set("Deprecation", "true")
+		next(w, r)
+	}
+}
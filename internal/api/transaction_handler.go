@@ -2,30 +2,37 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"payflow/internal/api/middleware"
 	"payflow/internal/domain"
+	"payflow/pkg/auth"
+	"payflow/pkg/fx"
 	"payflow/pkg/logger"
 )
 
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 type TransactionHandler struct {
-	service     domain.TransactionService
-	userService domain.UserService
-	logger      logger.Logger
+	service domain.TransactionService
+	auth    *middleware.Authenticator
+	logger  logger.Logger
 }
 
-func NewTransactionHandler(service domain.TransactionService, userService domain.UserService, logger logger.Logger) *TransactionHandler {
+func NewTransactionHandler(service domain.TransactionService, auth *middleware.Authenticator, logger logger.Logger) *TransactionHandler {
 	return &TransactionHandler{
-		service:     service,
-		userService: userService,
-		logger:      logger,
+		service: service,
+		auth:    auth,
+		logger:  logger,
 	}
 }
 
 func (h *TransactionHandler) GetTransactionByID(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Query().Get("id")
+	idStr := pathOrQuery(r, "id", "id")
 	if idStr == "" {
 		h.logger.Error("ID parametresi eksik", map[string]interface{}{})
 		http.Error(w, "ID parametresi eksik", http.StatusBadRequest)
@@ -34,14 +41,14 @@ func (h *TransactionHandler) GetTransactionByID(w http.ResponseWriter, r *http.R
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz ID formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz ID formatı", err, nil)
 		http.Error(w, "Geçersiz ID formatı", http.StatusBadRequest)
 		return
 	}
 
-	transaction, err := h.service.GetTransactionByID(id)
+	transaction, err := h.service.GetTransactionByID(r.Context(), id)
 	if err != nil {
-		h.logger.Error("İşlem bulunamadı", map[string]interface{}{"id": id, "error": err.Error()})
+		h.logger.ErrorWithErr("İşlem bulunamadı", err, map[string]interface{}{"id": id})
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
@@ -50,8 +57,105 @@ func (h *TransactionHandler) GetTransactionByID(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(transaction)
 }
 
+const streamHeartbeatInterval = 15 * time.Second
+
+func (h *TransactionHandler) StreamTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	idStr := pathOrQuery(r, "id", "id")
+	if idStr == "" {
+		h.logger.Error("ID parametresi eksik", map[string]interface{}{})
+		http.Error(w, "ID parametresi eksik", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.logger.ErrorWithErr("Geçersiz ID formatı", err, nil)
+		http.Error(w, "Geçersiz ID formatı", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming desteklenmiyor", http.StatusInternalServerError)
+		return
+	}
+
+	// The stream stays open until the transaction settles, which may outlive
+	// the server write timeout; heartbeats detect dead clients instead.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Yazma zaman aşımı kaldırılamadı", map[string]interface{}{"error": err.Error()})
+	}
+
+	// Subscribe before reading the current state so no transition is missed.
+	updates, unsubscribe := h.service.SubscribeStatus(id)
+	defer unsubscribe()
+
+	transaction, err := h.service.GetTransactionByID(r.Context(), id)
+	if err != nil {
+		h.logger.ErrorWithErr("İşlem bulunamadı", err, map[string]interface{}{"id": id})
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeStatusEvent(w, transaction); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	if transaction.Status.IsTerminal() {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			if err := writeStatusEvent(w, update); err != nil {
+				return
+			}
+			flusher.Flush()
+
+			if update.Status.IsTerminal() {
+				return
+			}
+		}
+	}
+}
+
+func writeStatusEvent(w http.ResponseWriter, transaction *domain.Transaction) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"id":     transaction.ID,
+		"status": transaction.Status,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+	return err
+}
+
 func (h *TransactionHandler) GetUserTransactions(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
+	userIDStr := pathOrQuery(r, "id", "user_id")
 	if userIDStr == "" {
 		h.logger.Error("user_id parametresi eksik", map[string]interface{}{})
 		http.Error(w, "user_id parametresi eksik", http.StatusBadRequest)
@@ -60,32 +164,151 @@ func (h *TransactionHandler) GetUserTransactions(w http.ResponseWriter, r *http.
 
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz user_id formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz user_id formatı", err, nil)
 		http.Error(w, "Geçersiz user_id formatı", http.StatusBadRequest)
 		return
 	}
 
-	transactions, err := h.service.GetUserTransactions(userID)
+	pageStr := r.URL.Query().Get("page")
+	pageSizeStr := r.URL.Query().Get("page_size")
+
+	page := 1
+	pageSize := 50
+
+	if pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			h.logger.Error("Geçersiz sayfa numarası", map[string]interface{}{"page": pageStr})
+			http.Error(w, "Geçersiz sayfa numarası", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if pageSizeStr != "" {
+		pageSize, err = strconv.Atoi(pageSizeStr)
+		if err != nil || pageSize < 1 || pageSize > 100 {
+			h.logger.Error("Geçersiz sayfa boyutu", map[string]interface{}{"page_size": pageSizeStr})
+			http.Error(w, "Geçersiz sayfa boyutu. 1-100 arası bir değer olmalı", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var filter domain.TransactionFilter
+
+	if startDateStr := r.URL.Query().Get("start_date"); startDateStr != "" {
+		filter.StartDate, _, err = parseDateParam(startDateStr)
+		if err != nil {
+			h.logger.Error("Geçersiz start_date formatı", map[string]interface{}{"start_date": startDateStr})
+			http.Error(w, "Geçersiz start_date formatı. YYYY-MM-DD veya RFC3339 olmalı", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if endDateStr := r.URL.Query().Get("end_date"); endDateStr != "" {
+		var dateOnly bool
+		filter.EndDate, dateOnly, err = parseDateParam(endDateStr)
+		if err != nil {
+			h.logger.Error("Geçersiz end_date formatı", map[string]interface{}{"end_date": endDateStr})
+			http.Error(w, "Geçersiz end_date formatı. YYYY-MM-DD veya RFC3339 olmalı", http.StatusBadRequest)
+			return
+		}
+		if dateOnly {
+			filter.EndDate = filter.EndDate.AddDate(0, 0, 1)
+		}
+	}
+
+	if !filter.StartDate.IsZero() && !filter.EndDate.IsZero() && !filter.StartDate.Before(filter.EndDate) {
+		h.logger.Error("Geçersiz tarih aralığı", map[string]interface{}{"start_date": filter.StartDate, "end_date": filter.EndDate})
+		http.Error(w, "Başlangıç tarihi bitiş tarihinden önce olmalı", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.GetUserTransactionsPaginated(r.Context(), userID, filter, page, pageSize)
 	if err != nil {
-		h.logger.Error("Kullanıcı işlemleri alınamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		h.logger.ErrorWithErr("Kullanıcı işlemleri alınamadı", err, map[string]interface{}{"user_id": userID})
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(transactions)
+	json.NewEncoder(w).Encode(NewPaginatedResponse(result.Transactions, result.Page, result.PageSize, result.TotalCount))
+}
+
+func parseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+func (h *TransactionHandler) GetTransactionsByStatus(w http.ResponseWriter, r *http.Request) {
+	status := domain.TransactionStatus(r.URL.Query().Get("status"))
+	if !status.IsValid() {
+		h.logger.Error("Geçersiz işlem durumu", map[string]interface{}{"status": status})
+		http.Error(w, "Geçersiz işlem durumu", http.StatusBadRequest)
+		return
+	}
+
+	pageStr := r.URL.Query().Get("page")
+	pageSizeStr := r.URL.Query().Get("page_size")
+	olderThanStr := r.URL.Query().Get("older_than")
+
+	page := 1
+	pageSize := 50
+	var olderThan time.Duration
+	var err error
+
+	if pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			h.logger.Error("Geçersiz sayfa numarası", map[string]interface{}{"page": pageStr})
+			http.Error(w, "Geçersiz sayfa numarası", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if pageSizeStr != "" {
+		pageSize, err = strconv.Atoi(pageSizeStr)
+		if err != nil || pageSize < 1 || pageSize > 100 {
+			h.logger.Error("Geçersiz sayfa boyutu", map[string]interface{}{"page_size": pageSizeStr})
+			http.Error(w, "Geçersiz sayfa boyutu. 1-100 arası bir değer olmalı", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if olderThanStr != "" {
+		olderThan, err = time.ParseDuration(olderThanStr)
+		if err != nil || olderThan < 0 {
+			h.logger.Error("Geçersiz older_than değeri", map[string]interface{}{"older_than": olderThanStr})
+			http.Error(w, "Geçersiz older_than değeri. Örnek: 15m, 2h", http.StatusBadRequest)
+			return
+		}
+	}
+
+	transactions, totalCount, err := h.service.GetTransactionsByStatus(r.Context(), status, olderThan, page, pageSize)
+	if err != nil {
+		h.logger.ErrorWithErr("Duruma göre işlemler alınamadı", err, map[string]interface{}{"status": status})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(NewPaginatedResponse(transactions, page, pageSize, totalCount))
 }
 
 type DepositRequest struct {
-	UserID int64   `json:"user_id"`
-	Amount float64 `json:"amount"`
+	UserID   int64        `json:"user_id"`
+	Amount   domain.Money `json:"amount"`
+	Currency string       `json:"currency"`
 }
 
 func (h *TransactionHandler) DepositFunds(w http.ResponseWriter, r *http.Request) {
 	var req DepositRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("İstek gövdesi decode edilemedi", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
 		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
 		return
 	}
@@ -102,9 +325,25 @@ func (h *TransactionHandler) DepositFunds(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	transaction, err := h.service.DepositFunds(req.UserID, req.Amount)
+	transaction, err := h.service.DepositFunds(r.Context(), req.UserID, req.Amount, req.Currency, r.Header.Get(IdempotencyKeyHeader))
 	if err != nil {
-		h.logger.Error("Para yatırma işlemi başarısız", map[string]interface{}{"user_id": req.UserID, "amount": req.Amount, "error": err.Error()})
+		h.logger.ErrorWithErr("Para yatırma işlemi başarısız", err, map[string]interface{}{"user_id": req.UserID, "amount": req.Amount, "currency": req.Currency})
+		if errors.Is(err, domain.ErrInvalidCurrency) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, domain.ErrTransactionLimit) || errors.Is(err, domain.ErrIdempotencyKeyMismatch) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, domain.ErrUserInactive) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -115,15 +354,16 @@ func (h *TransactionHandler) DepositFunds(w http.ResponseWriter, r *http.Request
 }
 
 type WithdrawRequest struct {
-	UserID int64   `json:"user_id"`
-	Amount float64 `json:"amount"`
+	UserID   int64        `json:"user_id"`
+	Amount   domain.Money `json:"amount"`
+	Currency string       `json:"currency"`
 }
 
 func (h *TransactionHandler) WithdrawFunds(w http.ResponseWriter, r *http.Request) {
 	var req WithdrawRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("İstek gövdesi decode edilemedi", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
 		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
 		return
 	}
@@ -140,9 +380,25 @@ func (h *TransactionHandler) WithdrawFunds(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	transaction, err := h.service.WithdrawFunds(req.UserID, req.Amount)
+	transaction, err := h.service.WithdrawFunds(r.Context(), req.UserID, req.Amount, req.Currency, r.Header.Get(IdempotencyKeyHeader))
 	if err != nil {
-		h.logger.Error("Para çekme işlemi başarısız", map[string]interface{}{"user_id": req.UserID, "amount": req.Amount, "error": err.Error()})
+		h.logger.ErrorWithErr("Para çekme işlemi başarısız", err, map[string]interface{}{"user_id": req.UserID, "amount": req.Amount, "currency": req.Currency})
+		if errors.Is(err, domain.ErrInvalidCurrency) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, domain.ErrTransactionLimit) || errors.Is(err, domain.ErrIdempotencyKeyMismatch) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, domain.ErrUserInactive) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -153,16 +409,18 @@ func (h *TransactionHandler) WithdrawFunds(w http.ResponseWriter, r *http.Reques
 }
 
 type TransferRequest struct {
-	FromUserID int64   `json:"from_user_id"`
-	ToUserID   int64   `json:"to_user_id"`
-	Amount     float64 `json:"amount"`
+	FromUserID int64        `json:"from_user_id"`
+	ToUserID   int64        `json:"to_user_id"`
+	Amount     domain.Money `json:"amount"`
+	Currency   string       `json:"currency"`
+	ToCurrency string       `json:"to_currency"`
 }
 
 func (h *TransactionHandler) TransferFunds(w http.ResponseWriter, r *http.Request) {
 	var req TransferRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("İstek gövdesi decode edilemedi", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
 		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
 		return
 	}
@@ -185,14 +443,32 @@ func (h *TransactionHandler) TransferFunds(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	transaction, err := h.service.TransferFunds(req.FromUserID, req.ToUserID, req.Amount)
+	transaction, err := h.service.TransferFunds(r.Context(), req.FromUserID, req.ToUserID, req.Amount, req.Currency, req.ToCurrency, r.Header.Get(IdempotencyKeyHeader))
 	if err != nil {
 		h.logger.Error("Transfer işlemi başarısız", map[string]interface{}{
 			"from_user_id": req.FromUserID,
 			"to_user_id":   req.ToUserID,
 			"amount":       req.Amount,
+			"currency":     req.Currency,
+			"to_currency":  req.ToCurrency,
 			"error":        err.Error(),
 		})
+		if errors.Is(err, domain.ErrInvalidCurrency) || errors.Is(err, fx.ErrRateNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, domain.ErrTransactionLimit) || errors.Is(err, domain.ErrIdempotencyKeyMismatch) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, domain.ErrUserInactive) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -202,38 +478,164 @@ func (h *TransactionHandler) TransferFunds(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(transaction)
 }
 
-func (h *TransactionHandler) GetWorkerPoolStats(w http.ResponseWriter, r *http.Request) {
+// authorizeSender rejects the request with 403 unless the caller is the
+// sender of the scheduled transfer or may manage other users' transfers.
+func (h *TransactionHandler) authorizeSender(w http.ResponseWriter, r *http.Request, fromUserID int64) bool {
+	allowed, err := h.auth.ActsFor(r, fromUserID, domain.PermissionTransactionsManage)
+	if err != nil {
+		h.logger.ErrorWithErr("Yetki kontrolü yapılamadı", err, map[string]interface{}{"from_user_id": fromUserID})
+		http.Error(w, "Yetki kontrolü yapılamadı", http.StatusInternalServerError)
+		return false
+	}
+
+	if !allowed {
+		h.logger.Warn("Başka kullanıcının zamanlanmış transferine erişim reddedildi", map[string]interface{}{
+			"from_user_id": fromUserID,
+			"actor_id":     auth.ActorIDFromContext(r.Context()),
+		})
+		http.Error(w, "Bu işlem için yetkiniz yok", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+type ScheduleTransferRequest struct {
+	FromUserID  int64        `json:"from_user_id"`
+	ToUserID    int64        `json:"to_user_id"`
+	Amount      domain.Money `json:"amount"`
+	ScheduledAt time.Time    `json:"scheduled_at"`
+}
+
+func (h *TransactionHandler) ScheduleTransfer(w http.ResponseWriter, r *http.Request) {
+	var req ScheduleTransferRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
+		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
+		return
+	}
+
+	if req.FromUserID <= 0 || req.ToUserID <= 0 {
+		h.logger.Error("Geçersiz kullanıcı ID'si", map[string]interface{}{"from_user_id": req.FromUserID, "to_user_id": req.ToUserID})
+		http.Error(w, "Geçersiz kullanıcı ID'si", http.StatusBadRequest)
+		return
+	}
+
+	if req.FromUserID == req.ToUserID {
+		h.logger.Error("Aynı hesaba transfer yapılamaz", map[string]interface{}{"user_id": req.FromUserID})
+		http.Error(w, "Aynı hesaba transfer yapılamaz", http.StatusBadRequest)
+		return
+	}
+
+	if req.Amount <= 0 {
+		h.logger.Error("Geçersiz miktar", map[string]interface{}{"amount": req.Amount})
+		http.Error(w, "Geçersiz miktar. Pozitif bir değer girilmeli", http.StatusBadRequest)
+		return
+	}
+
+	if req.ScheduledAt.IsZero() {
+		h.logger.Error("Zamanlama tarihi eksik", map[string]interface{}{})
+		http.Error(w, "scheduled_at gerekli (RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeSender(w, r, req.FromUserID) {
+		return
+	}
+
+	transaction, err := h.service.ScheduleTransfer(r.Context(), req.FromUserID, req.ToUserID, req.Amount, req.ScheduledAt)
+	if err != nil {
+		h.logger.Error("Transfer zamanlanamadı", map[string]interface{}{
+			"from_user_id": req.FromUserID,
+			"to_user_id":   req.ToUserID,
+			"amount":       req.Amount,
+			"scheduled_at": req.ScheduledAt,
+			"error":        err.Error(),
+		})
+		if errors.Is(err, domain.ErrInvalidScheduleTime) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, domain.ErrTransactionLimit) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, domain.ErrUserInactive) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
 
-	apiKey := r.Header.Get("X-API-Key")
-	if apiKey == "" {
-		h.logger.Error("API anahtarı eksik", map[string]interface{}{})
-		http.Error(w, "Yetkilendirme gerekli", http.StatusUnauthorized)
+func (h *TransactionHandler) CancelScheduledTransfer(w http.ResponseWriter, r *http.Request) {
+	transactionIDStr := pathOrQuery(r, "id", "id")
+	if transactionIDStr == "" {
+		h.logger.Error("İşlem ID'si eksik", map[string]interface{}{})
+		http.Error(w, "İşlem ID'si gerekli", http.StatusBadRequest)
 		return
 	}
 
-	user, err := h.userService.GetUserByApiKey(apiKey)
+	transactionID, err := strconv.ParseInt(transactionIDStr, 10, 64)
 	if err != nil {
-		h.logger.Error("API anahtarı geçersiz", map[string]interface{}{"error": err.Error()})
-		http.Error(w, "Geçersiz API anahtarı", http.StatusUnauthorized)
+		h.logger.ErrorWithErr("Geçersiz işlem ID'si", err, nil)
+		http.Error(w, "Geçersiz işlem ID'si", http.StatusBadRequest)
 		return
 	}
 
-	isAdmin, err := h.userService.HasAdminRole(user.ID)
+	transaction, err := h.service.GetTransactionByID(r.Context(), transactionID)
 	if err != nil {
-		h.logger.Error("Yetki kontrolü yapılamadı", map[string]interface{}{"error": err.Error()})
-		http.Error(w, "Yetki kontrolü yapılamadı", http.StatusInternalServerError)
+		h.logger.ErrorWithErr("İşlem bulunamadı", err, map[string]interface{}{"transaction_id": transactionID})
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	if !isAdmin {
-		h.logger.Warn("Yetkisiz erişim", map[string]interface{}{"user_id": user.ID})
-		http.Error(w, "Bu işlemi yapmak için admin yetkisi gerekiyor", http.StatusForbidden)
+	// Only transfers can be scheduled; anything else is left to the
+	// permission check and then rejected by the service as not scheduled.
+	var fromUserID int64
+	if transaction.FromUserID != nil {
+		fromUserID = *transaction.FromUserID
+	}
+	if !h.authorizeSender(w, r, fromUserID) {
 		return
 	}
 
+	if err := h.service.CancelScheduledTransfer(r.Context(), transactionID); err != nil {
+		h.logger.Error("Zamanlanmış transfer iptal edilemedi", map[string]interface{}{
+			"transaction_id": transactionID,
+			"error":          err.Error(),
+		})
+		if errors.Is(err, domain.ErrTransactionNotScheduled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":         "success",
+		"message":        "Zamanlanmış transfer iptal edildi",
+		"transaction_id": transactionID,
+	})
+}
+
+func (h *TransactionHandler) GetWorkerPoolStats(w http.ResponseWriter, r *http.Request) {
 	stats, err := h.service.GetWorkerPoolStats()
 	if err != nil {
-		h.logger.Error("Worker pool istatistikleri alınamadı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Worker pool istatistikleri alınamadı", err, nil)
 		http.Error(w, "İstatistikler alınamadı: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -242,36 +644,37 @@ func (h *TransactionHandler) GetWorkerPoolStats(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(stats)
 }
 
-func (h *TransactionHandler) RollbackTransaction(w http.ResponseWriter, r *http.Request) {
+type ResizeWorkerPoolRequest struct {
+	NumWorkers int `json:"num_workers"`
+}
 
-	apiKey := r.Header.Get("X-API-Key")
-	if apiKey == "" {
-		h.logger.Error("API anahtarı eksik", map[string]interface{}{})
-		http.Error(w, "Yetkilendirme gerekli", http.StatusUnauthorized)
+func (h *TransactionHandler) ResizeWorkerPool(w http.ResponseWriter, r *http.Request) {
+	var req ResizeWorkerPoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
+		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
 		return
 	}
 
-	user, err := h.userService.GetUserByApiKey(apiKey)
-	if err != nil {
-		h.logger.Error("API anahtarı geçersiz", map[string]interface{}{"error": err.Error()})
-		http.Error(w, "Geçersiz API anahtarı", http.StatusUnauthorized)
+	if err := h.service.ResizeWorkerPool(req.NumWorkers); err != nil {
+		h.logger.ErrorWithErr("Worker pool yeniden boyutlandırılamadı", err, map[string]interface{}{"num_workers": req.NumWorkers})
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	isAdmin, err := h.userService.HasAdminRole(user.ID)
+	stats, err := h.service.GetWorkerPoolStats()
 	if err != nil {
-		h.logger.Error("Yetki kontrolü yapılamadı", map[string]interface{}{"error": err.Error()})
-		http.Error(w, "Yetki kontrolü yapılamadı", http.StatusInternalServerError)
+		h.logger.ErrorWithErr("Worker pool istatistikleri alınamadı", err, nil)
+		http.Error(w, "İstatistikler alınamadı: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if !isAdmin {
-		h.logger.Warn("Yetkisiz erişim", map[string]interface{}{"user_id": user.ID})
-		http.Error(w, "Bu işlemi yapmak için admin yetkisi gerekiyor", http.StatusForbidden)
-		return
-	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
 
-	transactionIDStr := r.URL.Query().Get("id")
+func (h *TransactionHandler) RollbackTransaction(w http.ResponseWriter, r *http.Request) {
+	transactionIDStr := pathOrQuery(r, "id", "id")
 	if transactionIDStr == "" {
 		h.logger.Error("İşlem ID'si eksik", map[string]interface{}{})
 		http.Error(w, "İşlem ID'si gerekli", http.StatusBadRequest)
@@ -280,12 +683,12 @@ func (h *TransactionHandler) RollbackTransaction(w http.ResponseWriter, r *http.
 
 	transactionID, err := strconv.ParseInt(transactionIDStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz işlem ID'si", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz işlem ID'si", err, nil)
 		http.Error(w, "Geçersiz işlem ID'si", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.service.RollbackTransaction(transactionID); err != nil {
+	if err := h.service.RollbackTransaction(r.Context(), transactionID, auth.ActorIDFromContext(r.Context())); err != nil {
 		h.logger.Error("İşlem geri alınamadı", map[string]interface{}{
 			"transaction_id": transactionID,
 			"error":          err.Error(),
@@ -305,10 +708,12 @@ func (h *TransactionHandler) RollbackTransaction(w http.ResponseWriter, r *http.
 
 type BatchTransactionRequest struct {
 	Transactions []struct {
-		SenderID    int64   `json:"sender_id"`
-		ReceiverID  int64   `json:"receiver_id"`
-		Amount      float64 `json:"amount"`
-		Description string  `json:"description"`
+		Type        domain.TransactionType `json:"type"`
+		SenderID    int64                  `json:"sender_id"`
+		ReceiverID  int64                  `json:"receiver_id"`
+		Amount      domain.Money           `json:"amount"`
+		Currency    string                 `json:"currency"`
+		Description string                 `json:"description"`
 	} `json:"transactions"`
 }
 
@@ -322,7 +727,7 @@ func (h *TransactionHandler) ProcessBatchTransactions(w http.ResponseWriter, r *
 	var req BatchTransactionRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("İstek gövdesi decode edilemedi", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
 		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
 		return
 	}
@@ -335,9 +740,47 @@ func (h *TransactionHandler) ProcessBatchTransactions(w http.ResponseWriter, r *
 
 	transactions := make([]*domain.Transaction, 0, len(req.Transactions))
 	for _, t := range req.Transactions {
-		if t.SenderID <= 0 || t.ReceiverID <= 0 {
-			h.logger.Error("Geçersiz kullanıcı ID'si", map[string]interface{}{"sender_id": t.SenderID, "receiver_id": t.ReceiverID})
-			http.Error(w, "Geçersiz kullanıcı ID'si", http.StatusBadRequest)
+		txType := t.Type
+		if txType == "" {
+			txType = domain.TransactionTypeTransfer
+		}
+
+		transaction := &domain.Transaction{
+			Amount:    t.Amount,
+			Type:      txType,
+			Status:    domain.TransactionStatusPending,
+			CreatedAt: time.Now(),
+		}
+
+		senderID := t.SenderID
+		receiverID := t.ReceiverID
+
+		switch txType {
+		case domain.TransactionTypeDeposit:
+			if receiverID <= 0 {
+				h.logger.Error("Para yatırma için geçersiz alıcı ID'si", map[string]interface{}{"receiver_id": receiverID})
+				http.Error(w, "Para yatırma işlemi için geçerli bir receiver_id gerekli", http.StatusBadRequest)
+				return
+			}
+			transaction.ToUserID = &receiverID
+		case domain.TransactionTypeWithdraw:
+			if senderID <= 0 {
+				h.logger.Error("Para çekme için geçersiz gönderen ID'si", map[string]interface{}{"sender_id": senderID})
+				http.Error(w, "Para çekme işlemi için geçerli bir sender_id gerekli", http.StatusBadRequest)
+				return
+			}
+			transaction.FromUserID = &senderID
+		case domain.TransactionTypeTransfer:
+			if senderID <= 0 || receiverID <= 0 {
+				h.logger.Error("Geçersiz kullanıcı ID'si", map[string]interface{}{"sender_id": senderID, "receiver_id": receiverID})
+				http.Error(w, "Transfer işlemi için geçerli sender_id ve receiver_id gerekli", http.StatusBadRequest)
+				return
+			}
+			transaction.FromUserID = &senderID
+			transaction.ToUserID = &receiverID
+		default:
+			h.logger.Error("Geçersiz işlem tipi", map[string]interface{}{"type": txType})
+			http.Error(w, "Geçersiz işlem tipi. deposit, withdraw veya transfer olmalı", http.StatusBadRequest)
 			return
 		}
 
@@ -347,25 +790,25 @@ func (h *TransactionHandler) ProcessBatchTransactions(w http.ResponseWriter, r *
 			return
 		}
 
-		senderID := t.SenderID
-		receiverID := t.ReceiverID
-		transaction := &domain.Transaction{
-			FromUserID: &senderID,
-			ToUserID:   &receiverID,
-			Amount:     t.Amount,
-			Type:       domain.TransactionTypeTransfer,
-			Status:     domain.TransactionStatusPending,
-			CreatedAt:  time.Now(),
+		if t.Currency != "" {
+			currency, err := domain.NormalizeCurrency(t.Currency, "")
+			if err != nil {
+				h.logger.Error("Geçersiz para birimi", map[string]interface{}{"currency": t.Currency})
+				http.Error(w, "Geçersiz para birimi. ISO 4217 formatında olmalı (örn: TRY)", http.StatusBadRequest)
+				return
+			}
+			transaction.Currency = currency
 		}
+
 		transactions = append(transactions, transaction)
 	}
 
 	h.logger.Info("Toplu işlem başlatılıyor", map[string]interface{}{"count": len(transactions)})
-	processed, failed, err := h.service.ProcessBatchTransactions(transactions)
+	processed, failed, err := h.service.ProcessBatchTransactions(r.Context(), transactions)
 
 	var response BatchTransactionResponse
 	if err != nil {
-		h.logger.Error("Toplu işlem başarısız", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Toplu işlem başarısız", err, nil)
 		response = BatchTransactionResponse{
 			Processed: processed,
 			Failed:    failed,
@@ -389,68 +832,66 @@ func (h *TransactionHandler) ProcessBatchTransactions(w http.ResponseWriter, r *
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *TransactionHandler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/transactions", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			h.GetTransactionByID(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	mux.HandleFunc("/api/user-transactions", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			h.GetUserTransactions(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	mux.HandleFunc("/api/transactions/deposit", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			h.DepositFunds(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+func (h *TransactionHandler) ReplayTransactionEvents(w http.ResponseWriter, r *http.Request) {
+	idStr := pathOrQuery(r, "id", "transaction_id")
+	if idStr == "" {
+		h.logger.Error("transaction_id parametresi eksik", map[string]interface{}{})
+		http.Error(w, "transaction_id parametresi eksik", http.StatusBadRequest)
+		return
+	}
 
-	mux.HandleFunc("/api/transactions/withdraw", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			h.WithdrawFunds(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+	transactionID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.logger.ErrorWithErr("Geçersiz transaction_id formatı", err, nil)
+		http.Error(w, "Geçersiz transaction_id formatı", http.StatusBadRequest)
+		return
+	}
 
-	mux.HandleFunc("/api/transactions/transfer", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			h.TransferFunds(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+	fromVersion, err := parseFromVersion(r)
+	if err != nil {
+		h.logger.ErrorWithErr("Geçersiz from_version formatı", err, nil)
+		http.Error(w, "Geçersiz from_version formatı", http.StatusBadRequest)
+		return
+	}
 
-	mux.HandleFunc("/api/transactions/batch", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			h.ProcessBatchTransactions(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+	if err := h.service.ReplayTransactionEvents(transactionID, fromVersion); err != nil {
+		h.logger.ErrorWithErr("İşlem eventleri tekrar oynatılamadı", err, map[string]interface{}{"transaction_id": transactionID, "from_version": fromVersion})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	mux.HandleFunc("/api/transactions/stats", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			h.GetWorkerPoolStats(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Transaction events replayed successfully"})
+}
 
-	mux.HandleFunc("/api/transactions/rollback", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			h.RollbackTransaction(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+func (h *TransactionHandler) RegisterRoutes(mux *http.ServeMux) {
+	readTransactions := h.auth.RequirePermission(domain.PermissionTransactionsRead)
+	manageSystem := h.auth.RequirePermission(domain.PermissionSystemManage)
+	authenticated := h.auth.RequireAuth()
+
+	mux.HandleFunc("GET /api/transactions/{id}", h.GetTransactionByID)
+	mux.HandleFunc("GET /api/transactions/{id}/stream", h.StreamTransactionStatus)
+	mux.HandleFunc("GET /api/users/{id}/transactions", h.GetUserTransactions)
+	mux.Handle("GET /api/transactions/by-status", readTransactions(http.HandlerFunc(h.GetTransactionsByStatus)))
+
+	mux.Handle("POST /api/transactions/deposit", h.auth.Identified(http.HandlerFunc(h.DepositFunds)))
+	mux.Handle("POST /api/transactions/withdraw", h.auth.Identified(http.HandlerFunc(h.WithdrawFunds)))
+	mux.Handle("POST /api/transactions/transfer", h.auth.Identified(http.HandlerFunc(h.TransferFunds)))
+	mux.Handle("POST /api/transactions/schedule", authenticated(http.HandlerFunc(h.ScheduleTransfer)))
+	mux.Handle("DELETE /api/transactions/schedule/{id}", authenticated(http.HandlerFunc(h.CancelScheduledTransfer)))
+	mux.Handle("POST /api/transactions/batch", h.auth.Identified(http.HandlerFunc(h.ProcessBatchTransactions)))
+	mux.Handle("POST /api/transactions/{id}/replay", manageSystem(http.HandlerFunc(h.ReplayTransactionEvents)))
+
+	mux.Handle("GET /api/transactions/stats", readTransactions(http.HandlerFunc(h.GetWorkerPoolStats)))
+	mux.Handle("POST /api/transactions/workers/resize", manageSystem(http.HandlerFunc(h.ResizeWorkerPool)))
+	mux.Handle("POST /api/transactions/{id}/rollback", h.auth.RequirePermission(domain.PermissionTransactionsRollback)(http.HandlerFunc(h.RollbackTransaction)))
+
+	// Query-parameter forms kept for one release.
+	mux.HandleFunc("GET /api/transactions", deprecatedRoute(h.logger, "GET /api/transactions/{id}", h.GetTransactionByID))
+	mux.HandleFunc("GET /api/transactions/stream", deprecatedRoute(h.logger, "GET /api/transactions/{id}/stream", h.StreamTransactionStatus))
+	mux.HandleFunc("GET /api/user-transactions", deprecatedRoute(h.logger, "GET /api/users/{id}/transactions", h.GetUserTransactions))
+	mux.Handle("DELETE /api/transactions/schedule", authenticated(deprecatedRoute(h.logger, "DELETE /api/transactions/schedule/{id}", h.CancelScheduledTransfer)))
+	mux.Handle("POST /api/transactions/replay", manageSystem(deprecatedRoute(h.logger, "POST /api/transactions/{id}/replay", h.ReplayTransactionEvents)))
+	mux.Handle("POST /api/transactions/rollback", h.auth.RequirePermission(domain.PermissionTransactionsRollback)(deprecatedRoute(h.logger, "POST /api/transactions/{id}/rollback", h.RollbackTransaction)))
 }
@@ -1,24 +1,30 @@
 package api
 
 import (
-	"crypto/sha256"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
+	"payflow/internal/api/middleware"
 	"payflow/internal/domain"
+	"payflow/pkg/auth"
 	"payflow/pkg/logger"
 )
 
 type UserHandler struct {
 	service domain.UserService
+	tokens  *auth.TokenManager
+	auth    *middleware.Authenticator
 	logger  logger.Logger
 }
 
-func NewUserHandler(service domain.UserService, logger logger.Logger) *UserHandler {
+func NewUserHandler(service domain.UserService, tokens *auth.TokenManager, auth *middleware.Authenticator, logger logger.Logger) *UserHandler {
 	return &UserHandler{
 		service: service,
+		tokens:  tokens,
+		auth:    auth,
 		logger:  logger,
 	}
 }
@@ -33,7 +39,7 @@ type CreateUserRequest struct {
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req CreateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("İstek gövdesi decode edilemedi", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
 		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
 		return
 	}
@@ -44,17 +50,28 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	passwordHash := fmt.Sprintf("%x", sha256.Sum256([]byte(req.Password)))
+	passwordHash, err := h.service.HashPassword(req.Password)
+	if err != nil {
+		h.logger.ErrorWithErr("Şifre hashlenemedi", err, nil)
+		http.Error(w, "Kullanıcı oluşturulamadı", http.StatusInternalServerError)
+		return
+	}
+
+	// Registration is open, so only an admin may pick the new user's role.
+	role := domain.UserRoleUser
+	if caller, ok := auth.UserFromContext(r.Context()); ok && caller.Role == domain.UserRoleAdmin && req.Role != "" {
+		role = req.Role
+	}
 
 	user := &domain.User{
 		Username:     req.Username,
 		Email:        req.Email,
 		PasswordHash: passwordHash,
-		Role:         req.Role,
+		Role:         role,
 	}
 
-	if err := h.service.CreateUser(user); err != nil {
-		h.logger.Error("Kullanıcı oluşturulamadı", map[string]interface{}{"error": err.Error()})
+	if err := h.service.CreateUser(user, auth.ActorIDFromContext(r.Context())); err != nil {
+		h.logger.ErrorWithErr("Kullanıcı oluşturulamadı", err, nil)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -65,7 +82,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Query().Get("id")
+	idStr := pathOrQuery(r, "id", "id")
 	if idStr == "" {
 		h.logger.Error("ID parametresi eksik", map[string]interface{}{})
 		http.Error(w, "ID parametresi eksik", http.StatusBadRequest)
@@ -74,14 +91,19 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz ID formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz ID formatı", err, nil)
 		http.Error(w, "Geçersiz ID formatı", http.StatusBadRequest)
 		return
 	}
 
-	user, err := h.service.GetUserByID(id)
+	var user *domain.User
+	if r.URL.Query().Get("include_deleted") == "true" {
+		user, err = h.service.GetUserByIDIncludingDeleted(id)
+	} else {
+		user, err = h.service.GetUserByID(id)
+	}
 	if err != nil {
-		h.logger.Error("Kullanıcı bulunamadı", map[string]interface{}{"id": id, "error": err.Error()})
+		h.logger.ErrorWithErr("Kullanıcı bulunamadı", err, map[string]interface{}{"id": id})
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
@@ -90,11 +112,50 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
+// ListUsers returns a page of users. API keys are stored hashed and are never
+// part of the response.
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	pageStr := r.URL.Query().Get("page")
+	pageSizeStr := r.URL.Query().Get("page_size")
+
+	page := 1
+	pageSize := 50
+
+	var err error
+	if pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			h.logger.Error("Geçersiz sayfa numarası", map[string]interface{}{"page": pageStr})
+			http.Error(w, "Geçersiz sayfa numarası", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if pageSizeStr != "" {
+		pageSize, err = strconv.Atoi(pageSizeStr)
+		if err != nil || pageSize < 1 || pageSize > 100 {
+			h.logger.Error("Geçersiz sayfa boyutu", map[string]interface{}{"page_size": pageSizeStr})
+			http.Error(w, "Geçersiz sayfa boyutu. 1-100 arası bir değer olmalı", http.StatusBadRequest)
+			return
+		}
+	}
+
+	users, totalCount, err := h.service.ListUsers(page, pageSize, r.URL.Query().Get("search"))
+	if err != nil {
+		h.logger.ErrorWithErr("Kullanıcılar listelenemedi", err, nil)
+		http.Error(w, "Kullanıcılar listelenemedi", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(NewPaginatedResponse(users, page, pageSize, totalCount))
+}
+
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	var user domain.User
 
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		h.logger.Error("İstek gövdesi decode edilemedi", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
 		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
 		return
 	}
@@ -105,8 +166,24 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.UpdateUser(&user); err != nil {
-		h.logger.Error("Kullanıcı güncelleme hatası", map[string]interface{}{"id": user.ID, "error": err.Error()})
+	// users.manage may be granted to non-admin roles; only admins change roles.
+	if caller, _ := auth.UserFromContext(r.Context()); caller == nil || caller.Role != domain.UserRoleAdmin {
+		existing, err := h.service.GetUserByID(user.ID)
+		if err != nil {
+			h.logger.ErrorWithErr("Kullanıcı bulunamadı", err, map[string]interface{}{"id": user.ID})
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if user.Role != "" && user.Role != existing.Role {
+			h.logger.Warn("Rol değişikliği reddedildi", map[string]interface{}{"id": user.ID, "role": user.Role})
+			http.Error(w, "Rol yalnızca yöneticiler tarafından değiştirilebilir", http.StatusForbidden)
+			return
+		}
+		user.Role = existing.Role
+	}
+
+	if err := h.service.UpdateUser(&user, auth.ActorIDFromContext(r.Context())); err != nil {
+		h.logger.ErrorWithErr("Kullanıcı güncelleme hatası", err, map[string]interface{}{"id": user.ID})
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -116,7 +193,7 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Query().Get("id")
+	idStr := pathOrQuery(r, "id", "id")
 	if idStr == "" {
 		h.logger.Error("ID parametresi eksik", map[string]interface{}{})
 		http.Error(w, "ID parametresi eksik", http.StatusBadRequest)
@@ -125,13 +202,13 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		h.logger.Error("Geçersiz ID formatı", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("Geçersiz ID formatı", err, nil)
 		http.Error(w, "Geçersiz ID formatı", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.service.DeleteUser(id); err != nil {
-		h.logger.Error("Kullanıcı silme hatası", map[string]interface{}{"id": id, "error": err.Error()})
+	if err := h.service.DeleteUser(id, auth.ActorIDFromContext(r.Context())); err != nil {
+		h.logger.ErrorWithErr("Kullanıcı silme hatası", err, map[string]interface{}{"id": id})
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -139,37 +216,66 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *UserHandler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/users", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			h.GetUserByID(w, r)
-		case http.MethodPost:
-			h.CreateUser(w, r)
-		case http.MethodPut:
-			h.UpdateUser(w, r)
-		case http.MethodDelete:
-			h.DeleteUser(w, r)
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	idStr := pathOrQuery(r, "id", "id")
+	if idStr == "" {
+		h.logger.Error("ID parametresi eksik", map[string]interface{}{})
+		http.Error(w, "ID parametresi eksik", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.logger.ErrorWithErr("Geçersiz ID formatı", err, nil)
+		http.Error(w, "Geçersiz ID formatı", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RestoreUser(id, auth.ActorIDFromContext(r.Context())); err != nil {
+		h.logger.ErrorWithErr("Kullanıcı geri yükleme hatası", err, map[string]interface{}{"id": id})
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, domain.ErrUserNotDeleted):
+			http.Error(w, err.Error(), http.StatusConflict)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
-	})
+		return
+	}
 
-	mux.HandleFunc("/api/login", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			h.Login(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Kullanıcı geri yüklendi",
+		"user_id": id,
 	})
+}
 
-	mux.HandleFunc("/api/users/api-key", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			h.GenerateApiKey(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+func (h *UserHandler) RegisterRoutes(mux *http.ServeMux) {
+	manageUsers := h.auth.RequirePermission(domain.PermissionUsersManage)
+
+	mux.HandleFunc("GET /api/users/{id}", h.GetUserByID)
+	mux.Handle("POST /api/users", h.auth.Identified(http.HandlerFunc(h.CreateUser)))
+	mux.Handle("PUT /api/users", manageUsers(http.HandlerFunc(h.UpdateUser)))
+	mux.Handle("DELETE /api/users/{id}", manageUsers(http.HandlerFunc(h.DeleteUser)))
+
+	mux.Handle("GET /api/users/list", h.auth.RequirePermission(domain.PermissionUsersRead)(http.HandlerFunc(h.ListUsers)))
+	mux.Handle("POST /api/users/{id}/restore", manageUsers(http.HandlerFunc(h.RestoreUser)))
+	mux.Handle("POST /api/users/{id}/activation-token", manageUsers(http.HandlerFunc(h.GenerateActivationToken)))
+	mux.Handle("POST /api/users/api-key", h.auth.Authenticate(http.HandlerFunc(h.GenerateApiKey)))
+
+	mux.HandleFunc("POST /api/login", h.Login)
+	mux.HandleFunc("POST /api/users/activate", h.ActivateUser)
+	mux.HandleFunc("POST /api/users/password-reset/request", h.RequestPasswordReset)
+	mux.HandleFunc("POST /api/users/password-reset/confirm", h.ResetPassword)
+
+	// Query-parameter forms kept for one release.
+	mux.HandleFunc("GET /api/users", deprecatedRoute(h.logger, "GET /api/users/{id}", h.GetUserByID))
+	mux.Handle("DELETE /api/users", manageUsers(deprecatedRoute(h.logger, "DELETE /api/users/{id}", h.DeleteUser)))
+	mux.Handle("POST /api/users/restore", manageUsers(deprecatedRoute(h.logger, "POST /api/users/{id}/restore", h.RestoreUser)))
+	mux.Handle("POST /api/users/activation-token", manageUsers(deprecatedRoute(h.logger, "POST /api/users/{id}/activation-token", h.GenerateActivationToken)))
 }
 
 type LoginRequest struct {
@@ -178,16 +284,18 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
-	ApiKey   string `json:"api_key"`
+	UserID         int64     `json:"user_id"`
+	Username       string    `json:"username"`
+	Role           string    `json:"role"`
+	Token          string    `json:"token"`
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+	ApiKey         string    `json:"api_key"`
 }
 
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("İstek gövdesi decode edilemedi", map[string]interface{}{"error": err.Error()})
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
 		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
 		return
 	}
@@ -200,23 +308,36 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	apiKey, err := h.service.Login(req.Username, req.Password)
 	if err != nil {
-		h.logger.Error("Giriş başarısız", map[string]interface{}{"username": req.Username, "error": err.Error()})
+		h.logger.ErrorWithErr("Giriş başarısız", err, map[string]interface{}{"username": req.Username})
+		if errors.Is(err, domain.ErrUserInactive) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		http.Error(w, "Geçersiz kullanıcı adı veya şifre", http.StatusUnauthorized)
 		return
 	}
 
 	user, err := h.service.GetUserByUsername(req.Username)
 	if err != nil {
-		h.logger.Error("Kullanıcı bilgileri alınamadı", map[string]interface{}{"username": req.Username, "error": err.Error()})
+		h.logger.ErrorWithErr("Kullanıcı bilgileri alınamadı", err, map[string]interface{}{"username": req.Username})
+		http.Error(w, "Sunucu hatası", http.StatusInternalServerError)
+		return
+	}
+
+	token, expiresAt, err := h.tokens.Issue(user)
+	if err != nil {
+		h.logger.ErrorWithErr("Token oluşturulamadı", err, map[string]interface{}{"user_id": user.ID})
 		http.Error(w, "Sunucu hatası", http.StatusInternalServerError)
 		return
 	}
 
 	response := LoginResponse{
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role,
-		ApiKey:   apiKey,
+		UserID:         user.ID,
+		Username:       user.Username,
+		Role:           user.Role,
+		Token:          token,
+		TokenExpiresAt: expiresAt,
+		ApiKey:         apiKey,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -225,23 +346,15 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) GenerateApiKey(w http.ResponseWriter, r *http.Request) {
-	authHeader := r.Header.Get("X-API-Key")
-	if authHeader == "" {
-		h.logger.Error("API anahtarı eksik", map[string]interface{}{})
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
 		http.Error(w, "Yetkilendirme gerekli", http.StatusUnauthorized)
 		return
 	}
 
-	user, err := h.service.GetUserByApiKey(authHeader)
-	if err != nil {
-		h.logger.Error("API anahtarı geçersiz", map[string]interface{}{"error": err.Error()})
-		http.Error(w, "Geçersiz API anahtarı", http.StatusUnauthorized)
-		return
-	}
-
 	apiKey, err := h.service.GenerateApiKey(user.ID)
 	if err != nil {
-		h.logger.Error("API anahtarı oluşturulamadı", map[string]interface{}{"user_id": user.ID, "error": err.Error()})
+		h.logger.ErrorWithErr("API anahtarı oluşturulamadı", err, map[string]interface{}{"user_id": user.ID})
 		http.Error(w, "API anahtarı oluşturulamadı", http.StatusInternalServerError)
 		return
 	}
@@ -253,3 +366,139 @@ func (h *UserHandler) GenerateApiKey(w http.ResponseWriter, r *http.Request) {
 		"message": "API anahtarı başarıyla yenilendi",
 	})
 }
+
+type ActivateUserRequest struct {
+	Token string `json:"token"`
+}
+
+func (h *UserHandler) ActivateUser(w http.ResponseWriter, r *http.Request) {
+	var req ActivateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
+		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
+		return
+	}
+
+	if req.Token == "" {
+		h.logger.Error("Aktivasyon kodu eksik", map[string]interface{}{})
+		http.Error(w, "Aktivasyon kodu gereklidir", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.ActivateUser(req.Token)
+	if err != nil {
+		h.logger.ErrorWithErr("Kullanıcı aktifleştirilemedi", err, nil)
+		if errors.Is(err, domain.ErrInvalidActivationToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Kullanıcı aktifleştirilemedi", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+// GenerateActivationToken reissues an activation token for an inactive user.
+// The token is also returned so support can deliver it out of band.
+func (h *UserHandler) GenerateActivationToken(w http.ResponseWriter, r *http.Request) {
+	idStr := pathOrQuery(r, "id", "user_id")
+	if idStr == "" {
+		h.logger.Error("Kullanıcı ID'si eksik", map[string]interface{}{})
+		http.Error(w, "Kullanıcı ID'si gerekli", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.logger.ErrorWithErr("Geçersiz kullanıcı ID'si", err, nil)
+		http.Error(w, "Geçersiz kullanıcı ID'si", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.service.GenerateActivationToken(userID)
+	if err != nil {
+		h.logger.ErrorWithErr("Aktivasyon kodu oluşturulamadı", err, map[string]interface{}{"user_id": userID})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id":          userID,
+		"activation_token": token,
+	})
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
+		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
+		return
+	}
+
+	if req.Email == "" {
+		h.logger.Error("E-posta adresi eksik", map[string]interface{}{})
+		http.Error(w, "E-posta adresi gereklidir", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(req.Email); err != nil {
+		h.logger.ErrorWithErr("Şifre sıfırlama isteği oluşturulamadı", err, nil)
+		http.Error(w, "Şifre sıfırlama isteği oluşturulamadı", http.StatusInternalServerError)
+		return
+	}
+
+	// The response is the same whether or not the email is registered.
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "E-posta adresi kayıtlıysa şifre sıfırlama kodu gönderildi",
+	})
+}
+
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req PasswordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.ErrorWithErr("İstek gövdesi decode edilemedi", err, nil)
+		http.Error(w, "Geçersiz istek gövdesi", http.StatusBadRequest)
+		return
+	}
+
+	if req.Token == "" || req.NewPassword == "" {
+		h.logger.Error("Eksik parametreler", map[string]interface{}{})
+		http.Error(w, "Sıfırlama kodu ve yeni şifre gereklidir", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.ResetPassword(req.Token, req.NewPassword)
+	if err != nil {
+		h.logger.ErrorWithErr("Şifre sıfırlanamadı", err, nil)
+		if errors.Is(err, domain.ErrInvalidResetToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Şifre sıfırlanamadı", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id": user.ID,
+		"message": "Şifre sıfırlandı; tekrar giriş yapmanız gerekiyor",
+	})
+}
@@ -6,12 +6,31 @@ import (
 	"time"
 )
 
+var processTimeBuckets = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
 type Stats struct {
 	Submitted      int64
 	Completed      int64
 	Failed         int64
 	Rejected       int64
 	AvgProcessTime time.Duration
+	P50ProcessTime time.Duration
+	P95ProcessTime time.Duration
+	P99ProcessTime time.Duration
 }
 
 type StatsCollector struct {
@@ -21,11 +40,15 @@ type StatsCollector struct {
 	rejected       int64
 	totalProcTime  int64
 	processedCount int64
+	bucketCounts   []int64
+	maxProcTime    time.Duration
 	mutex          sync.RWMutex
 }
 
 func NewStatsCollector() *StatsCollector {
-	return &StatsCollector{}
+	return &StatsCollector{
+		bucketCounts: make([]int64, len(processTimeBuckets)+1),
+	}
 }
 
 func (sc *StatsCollector) IncrementSubmitted() {
@@ -50,6 +73,43 @@ func (sc *StatsCollector) RecordProcessingTime(d time.Duration) {
 
 	sc.totalProcTime += d.Nanoseconds()
 	sc.processedCount++
+
+	i := 0
+	for i < len(processTimeBuckets) && d > processTimeBuckets[i] {
+		i++
+	}
+	sc.bucketCounts[i]++
+
+	if d > sc.maxProcTime {
+		sc.maxProcTime = d
+	}
+}
+
+// percentile returns the upper bound of the bucket containing the given
+// quantile. Samples above the largest bucket are reported as the maximum
+// observed processing time. Callers must hold the read lock.
+func (sc *StatsCollector) percentile(q float64) time.Duration {
+	if sc.processedCount == 0 {
+		return 0
+	}
+
+	rank := int64(q * float64(sc.processedCount))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var cumulative int64
+	for i, count := range sc.bucketCounts {
+		cumulative += count
+		if cumulative >= rank {
+			if i < len(processTimeBuckets) {
+				return processTimeBuckets[i]
+			}
+			return sc.maxProcTime
+		}
+	}
+
+	return sc.maxProcTime
 }
 
 func (sc *StatsCollector) GetStats() Stats {
@@ -65,6 +125,9 @@ func (sc *StatsCollector) GetStats() Stats {
 
 	if sc.processedCount > 0 {
 		stats.AvgProcessTime = time.Duration(sc.totalProcTime / sc.processedCount)
+		stats.P50ProcessTime = sc.percentile(0.50)
+		stats.P95ProcessTime = sc.percentile(0.95)
+		stats.P99ProcessTime = sc.percentile(0.99)
 	}
 
 	return stats
@@ -80,4 +143,8 @@ func (sc *StatsCollector) Reset() {
 	defer sc.mutex.Unlock()
 	sc.totalProcTime = 0
 	sc.processedCount = 0
+	sc.maxProcTime = 0
+	for i := range sc.bucketCounts {
+		sc.bucketCounts[i] = 0
+	}
 }
@@ -3,17 +3,29 @@ package concurrent
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"payflow/internal/domain"
 	"payflow/pkg/logger"
+	"payflow/pkg/metrics"
 )
 
-type TransactionProcessor = func(transaction *domain.Transaction) error
+// TransactionProcessor receives the context the transaction was submitted
+// with, detached from its cancellation, so logs keep the originating request id.
+type TransactionProcessor = func(ctx context.Context, transaction *domain.Transaction) error
+
+type job struct {
+	ctx         context.Context
+	transaction *domain.Transaction
+}
 
 type WorkerPool struct {
 	numWorkers     int
-	jobQueue       chan *domain.Transaction
+	busyWorkers    int32
+	nextWorkerID   int
+	jobQueue       chan job
+	retire         chan struct{}
 	processor      TransactionProcessor
 	wg             sync.WaitGroup
 	ctx            context.Context
@@ -29,7 +41,8 @@ func NewWorkerPool(numWorkers int, queueSize int, processor TransactionProcessor
 
 	return &WorkerPool{
 		numWorkers:     numWorkers,
-		jobQueue:       make(chan *domain.Transaction, queueSize),
+		jobQueue:       make(chan job, queueSize),
+		retire:         make(chan struct{}),
 		processor:      processor,
 		ctx:            ctx,
 		cancel:         cancel,
@@ -53,17 +66,60 @@ func (wp *WorkerPool) Start() {
 	})
 
 	for i := 0; i < wp.numWorkers; i++ {
-		wp.wg.Add(1)
-		workerID := i
-		go func() {
-			defer wp.wg.Done()
-			wp.worker(workerID)
-		}()
+		wp.spawnWorker()
 	}
 
 	wp.started = true
 }
 
+func (wp *WorkerPool) spawnWorker() {
+	wp.wg.Add(1)
+	workerID := wp.nextWorkerID
+	wp.nextWorkerID++
+	go func() {
+		defer wp.wg.Done()
+		wp.worker(workerID)
+	}()
+}
+
+// Resize changes the number of workers without touching the job queue. Extra
+// workers are started immediately; surplus workers retire once they finish
+// the transaction they are currently processing.
+func (wp *WorkerPool) Resize(newWorkerCount int) {
+	wp.mutex.Lock()
+	defer wp.mutex.Unlock()
+
+	current := wp.numWorkers
+	wp.numWorkers = newWorkerCount
+
+	if !wp.started || newWorkerCount == current {
+		return
+	}
+
+	wp.logger.Info("İşçi havuzu yeniden boyutlandırılıyor", map[string]interface{}{
+		"from": current,
+		"to":   newWorkerCount,
+	})
+
+	if newWorkerCount > current {
+		for i := current; i < newWorkerCount; i++ {
+			wp.spawnWorker()
+		}
+		return
+	}
+
+	retireCount := current - newWorkerCount
+	go func() {
+		for i := 0; i < retireCount; i++ {
+			select {
+			case wp.retire <- struct{}{}:
+			case <-wp.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
 func (wp *WorkerPool) Stop() {
 	wp.mutex.Lock()
 	if !wp.started {
@@ -71,27 +127,66 @@ func (wp *WorkerPool) Stop() {
 		return
 	}
 	wp.started = false
+	wp.cancel()
+	close(wp.jobQueue)
 	wp.mutex.Unlock()
 
 	wp.logger.Info("İşçi havuzu durduruluyor", map[string]interface{}{})
-	wp.cancel()
-	close(wp.jobQueue)
 	wp.wg.Wait()
 }
 
-func (wp *WorkerPool) Submit(transaction *domain.Transaction) bool {
+// Drain stops accepting new submissions and lets the workers finish everything
+// already queued. If the queue is not empty by the time timeout elapses, the
+// workers are cancelled and Drain returns false.
+func (wp *WorkerPool) Drain(timeout time.Duration) bool {
 	wp.mutex.Lock()
 	if !wp.started {
 		wp.mutex.Unlock()
-		return false
+		return true
 	}
+	wp.started = false
+	close(wp.jobQueue)
 	wp.mutex.Unlock()
 
+	wp.logger.Info("İşçi havuzu boşaltılıyor", map[string]interface{}{
+		"queue_length": len(wp.jobQueue),
+		"timeout":      timeout.String(),
+	})
+
+	done := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		wp.cancel()
+		wp.logger.Info("İşçi havuzu boşaltıldı", map[string]interface{}{})
+		return true
+	case <-time.After(timeout):
+		wp.logger.Warn("İşçi havuzu zaman aşımı içinde boşaltılamadı, durduruluyor", map[string]interface{}{
+			"remaining": len(wp.jobQueue),
+		})
+		wp.cancel()
+		<-done
+		return false
+	}
+}
+
+func (wp *WorkerPool) Submit(ctx context.Context, transaction *domain.Transaction) bool {
+	wp.mutex.Lock()
+	defer wp.mutex.Unlock()
+
+	if !wp.started {
+		return false
+	}
+
 	// Non-blocking send
 	select {
-	case wp.jobQueue <- transaction:
+	case wp.jobQueue <- job{ctx: context.WithoutCancel(ctx), transaction: transaction}:
 		wp.statsCollector.IncrementSubmitted()
-		wp.logger.Info("İşlem kuyruğa eklendi", map[string]interface{}{
+		wp.logger.InfoContext(ctx, "İşlem kuyruğa eklendi", map[string]interface{}{
 			"transaction_id": transaction.ID,
 			"type":           transaction.Type,
 			"amount":         transaction.Amount,
@@ -99,7 +194,7 @@ func (wp *WorkerPool) Submit(transaction *domain.Transaction) bool {
 		return true
 	default:
 		wp.statsCollector.IncrementRejected()
-		wp.logger.Warn("İşlem kuyruğu dolu, işlem reddedildi", map[string]interface{}{
+		wp.logger.WarnContext(ctx, "İşlem kuyruğu dolu, işlem reddedildi", map[string]interface{}{
 			"transaction_id": transaction.ID,
 		})
 		return false
@@ -114,27 +209,36 @@ func (wp *WorkerPool) worker(id int) {
 		case <-wp.ctx.Done():
 			wp.logger.Info("İşçi durduruldu", map[string]interface{}{"worker_id": id})
 			return
-		case transaction, ok := <-wp.jobQueue:
+		case <-wp.retire:
+			wp.logger.Info("İşçi havuzdan çıkarıldı", map[string]interface{}{"worker_id": id})
+			return
+		case queued, ok := <-wp.jobQueue:
 			if !ok {
 				wp.logger.Info("İş kuyruğu kapatıldı, işçi durduruluyor", map[string]interface{}{"worker_id": id})
 				return
 			}
 
+			transaction := queued.transaction
+			jobLogger := wp.logger.WithContext(queued.ctx)
+
 			startTime := time.Now()
-			wp.logger.Info("İşlem işleniyor", map[string]interface{}{
+			jobLogger.Info("İşlem işleniyor", map[string]interface{}{
 				"worker_id":      id,
 				"transaction_id": transaction.ID,
 				"type":           transaction.Type,
 				"amount":         transaction.Amount,
 			})
 
-			err := wp.processor(transaction)
+			atomic.AddInt32(&wp.busyWorkers, 1)
+			err := wp.processor(queued.ctx, transaction)
+			atomic.AddInt32(&wp.busyWorkers, -1)
 
 			processingTime := time.Since(startTime)
+			metrics.RecordTransactionProcessingTime(string(transaction.Type), processingTime)
 
 			if err != nil {
 				wp.statsCollector.IncrementFailed()
-				wp.logger.Error("İşlem başarısız oldu", map[string]interface{}{
+				jobLogger.Error("İşlem başarısız oldu", map[string]interface{}{
 					"worker_id":       id,
 					"transaction_id":  transaction.ID,
 					"error":           err.Error(),
@@ -143,7 +247,7 @@ func (wp *WorkerPool) worker(id int) {
 			} else {
 				wp.statsCollector.IncrementCompleted()
 				wp.statsCollector.RecordProcessingTime(processingTime)
-				wp.logger.Info("İşlem başarıyla tamamlandı", map[string]interface{}{
+				jobLogger.Info("İşlem başarıyla tamamlandı", map[string]interface{}{
 					"worker_id":       id,
 					"transaction_id":  transaction.ID,
 					"processing_time": processingTime.String(),
@@ -157,6 +261,17 @@ func (wp *WorkerPool) GetStats() Stats {
 	return wp.statsCollector.GetStats()
 }
 
+func (wp *WorkerPool) NumWorkers() int {
+	wp.mutex.Lock()
+	defer wp.mutex.Unlock()
+
+	return wp.numWorkers
+}
+
+func (wp *WorkerPool) BusyWorkers() int {
+	return int(atomic.LoadInt32(&wp.busyWorkers))
+}
+
 func (wp *WorkerPool) QueueLength() int {
 	return len(wp.jobQueue)
 }
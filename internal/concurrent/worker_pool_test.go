@@ -0,0 +1,52 @@
+package concurrent
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"payflow/internal/domain"
+	"payflow/pkg/logger"
+)
+
+func TestDrainProcessesEveryQueuedTransaction(t *testing.T) {
+	var mu sync.Mutex
+	processed := make(map[int64]int)
+
+	processor := func(ctx context.Context, tx *domain.Transaction) error {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		processed[tx.ID]++
+		mu.Unlock()
+		return nil
+	}
+
+	const queued = 100
+	pool := NewWorkerPool(2, queued, processor, logger.New(logger.ErrorLevel, logger.FormatJSON, io.Discard))
+	pool.Start()
+
+	for i := 1; i <= queued; i++ {
+		if !pool.Submit(context.Background(), &domain.Transaction{ID: int64(i)}) {
+			t.Fatalf("işlem %d kuyruğa eklenemedi", i)
+		}
+	}
+
+	if !pool.Drain(5 * time.Second) {
+		t.Fatal("kuyruk zaman aşımı içinde boşaltılamadı")
+	}
+
+	if len(processed) != queued {
+		t.Fatalf("beklenen %d işlem, işlenen: %d", queued, len(processed))
+	}
+	for id, count := range processed {
+		if count != 1 {
+			t.Fatalf("işlem %d %d kez işlendi", id, count)
+		}
+	}
+
+	if pool.Submit(context.Background(), &domain.Transaction{ID: queued + 1}) {
+		t.Fatal("boşaltma sonrası yeni işlem kabul edilmemeli")
+	}
+}
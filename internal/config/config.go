@@ -1,20 +1,41 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	AppEnv   string `mapstructure:"APP_ENV"`
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	LogLevel string `mapstructure:"LOG_LEVEL"`
+	AppEnv      string `mapstructure:"APP_ENV"`
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Redis       RedisConfig
+	Cache       CacheConfig
+	Transaction TransactionConfig
+	Currency    CurrencyConfig
+	EventStore  EventStoreConfig
+	Security    SecurityConfig
+	Auth        AuthConfig
+	RateLimit   RateLimitConfig
+	AuditLog    AuditLogConfig
+	// CircuitBreaker configures the database circuit breaker.
+	CircuitBreaker CircuitBreakerConfig
+	CORS           CORSConfig
+	LogLevel       string `mapstructure:"LOG_LEVEL"`
+	// LogFormat is json or console; empty keeps the APP_ENV based default.
+	LogFormat string `mapstructure:"LOG_FORMAT"`
+	LogOutput string `mapstructure:"LOG_OUTPUT"`
+	LogFile   string `mapstructure:"LOG_FILE"`
+	// LogSampleRate keeps 1 in N worker pool and cache debug/info entries.
+	LogSampleRate int `mapstructure:"LOG_SAMPLE_RATE"`
 }
 
 type ServerConfig struct {
@@ -28,6 +49,7 @@ type ServerConfig struct {
 }
 
 type DatabaseConfig struct {
+	Driver   string `mapstructure:"DB_DRIVER"`
 	Host     string `mapstructure:"DB_HOST"`
 	Port     string `mapstructure:"DB_PORT"`
 	User     string `mapstructure:"DB_USER"`
@@ -40,16 +62,23 @@ type DatabaseConfig struct {
 	MaxOpenConns    int `mapstructure:"DB_MAX_OPEN_CONNS"`
 	MaxIdleConns    int `mapstructure:"DB_MAX_IDLE_CONNS"`
 	ConnMaxLifetime int `mapstructure:"DB_CONN_MAX_LIFETIME"`
+
+	ReadYourWritesWindow time.Duration `mapstructure:"DB_READ_YOUR_WRITES_WINDOW"`
+	QueryTimeout         time.Duration `mapstructure:"DB_QUERY_TIMEOUT"`
+
+	MigrationAcceptChecksumChanges bool `mapstructure:"DB_MIGRATION_ACCEPT_CHECKSUM_CHANGES"`
 }
 
+// ReplicaConfig is loaded from numbered variables (DB_READ_HOST_1,
+// DB_READ_HOST_2, ...); see parseReadReplicas.
 type ReplicaConfig struct {
-	Host     string `mapstructure:"DB_READ_HOST_1"`
-	Port     string `mapstructure:"DB_READ_PORT_1"`
-	User     string `mapstructure:"DB_READ_USER_1"`
-	Password string `mapstructure:"DB_READ_PASSWORD_1"`
-	Name     string `mapstructure:"DB_READ_NAME_1"`
-	SSLMode  string `mapstructure:"DB_READ_SSL_MODE_1"`
-	Weight   int    `mapstructure:"DB_READ_WEIGHT_1"`
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+	Weight   int
 }
 
 type RedisConfig struct {
@@ -65,11 +94,99 @@ type RedisConfig struct {
 	MinIdleConns int `mapstructure:"REDIS_MIN_IDLE_CONNS"`
 }
 
+// CacheConfig controls the in-process L1 cache in front of Redis and the
+// jitter applied to Redis expirations.
+type CacheConfig struct {
+	L1Enabled bool          `mapstructure:"CACHE_L1_ENABLED"`
+	L1Size    int           `mapstructure:"CACHE_L1_SIZE"`
+	L1TTL     time.Duration `mapstructure:"CACHE_L1_TTL"`
+	TTLJitter float64       `mapstructure:"CACHE_TTL_JITTER"`
+}
+
+type TransactionConfig struct {
+	RollbackWindow time.Duration `mapstructure:"TRANSACTION_ROLLBACK_WINDOW"`
+	PendingTimeout time.Duration `mapstructure:"TRANSACTION_PENDING_TIMEOUT"`
+	ReaperInterval time.Duration `mapstructure:"TRANSACTION_REAPER_INTERVAL"`
+	DrainTimeout   time.Duration `mapstructure:"TRANSACTION_DRAIN_TIMEOUT"`
+
+	SchedulerInterval time.Duration `mapstructure:"TRANSACTION_SCHEDULER_INTERVAL"`
+
+	Limits TransactionLimits
+}
+
+type TransactionLimits struct {
+	MinAmount float64 `mapstructure:"TRANSACTION_MIN_AMOUNT"`
+	MaxAmount float64 `mapstructure:"TRANSACTION_MAX_AMOUNT"`
+	DailyMax  float64 `mapstructure:"TRANSACTION_DAILY_MAX"`
+}
+
+type CurrencyConfig struct {
+	Default string             `mapstructure:"CURRENCY_DEFAULT"`
+	Rates   map[string]float64 `mapstructure:"FX_RATES"`
+}
+
+type EventStoreConfig struct {
+	SnapshotInterval int `mapstructure:"EVENT_SNAPSHOT_INTERVAL"`
+}
+
+type SecurityConfig struct {
+	PasswordHashCost      int           `mapstructure:"PASSWORD_HASH_COST"`
+	RequireActivation     bool          `mapstructure:"USER_REQUIRE_ACTIVATION"`
+	ActivationTokenTTL    time.Duration `mapstructure:"USER_ACTIVATION_TOKEN_TTL"`
+	PasswordResetTokenTTL time.Duration `mapstructure:"PASSWORD_RESET_TOKEN_TTL"`
+}
+
+type AuthConfig struct {
+	JWTSecret       string              `mapstructure:"JWT_SECRET"`
+	TokenTTL        time.Duration       `mapstructure:"JWT_TOKEN_TTL"`
+	RolePermissions map[string][]string `mapstructure:"ROLE_PERMISSIONS"`
+}
+
+// RateLimitConfig: X-Forwarded-For is only honoured on requests whose peer
+// address is one of TrustedProxies (IPs or CIDRs).
+type RateLimitConfig struct {
+	Enabled        bool     `mapstructure:"RATE_LIMIT_ENABLED"`
+	Rate           float64  `mapstructure:"RATE_LIMIT_RATE"`
+	Burst          int      `mapstructure:"RATE_LIMIT_BURST"`
+	TrustedProxies []string `mapstructure:"RATE_LIMIT_TRUSTED_PROXIES"`
+}
+
+type CircuitBreakerConfig struct {
+	// FailureThreshold: the breaker opens once consecutive failures exceed it.
+	FailureThreshold int           `mapstructure:"CB_FAILURE_THRESHOLD"`
+	MaxRequests      int           `mapstructure:"CB_MAX_REQUESTS"`
+	Timeout          time.Duration `mapstructure:"CB_TIMEOUT"`
+}
+
+// CORSConfig controls cross-origin access; with no allowed origins no CORS
+// headers are sent. "*" allows any origin but cannot be combined with
+// credentials.
+type CORSConfig struct {
+	AllowedOrigins   []string      `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `mapstructure:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `mapstructure:"CORS_ALLOWED_HEADERS"`
+	AllowCredentials bool          `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `mapstructure:"CORS_MAX_AGE"`
+}
+
+type AuditLogConfig struct {
+	Retention         time.Duration `mapstructure:"AUDIT_LOG_RETENTION"`
+	ArchiveEnabled    bool          `mapstructure:"AUDIT_LOG_ARCHIVE_ENABLED"`
+	RetentionInterval time.Duration `mapstructure:"AUDIT_LOG_RETENTION_INTERVAL"`
+	RetentionBatch    int           `mapstructure:"AUDIT_LOG_RETENTION_BATCH"`
+}
+
 type LoadBalancerConfig struct {
 	Enabled             bool   `mapstructure:"LB_ENABLED"`
 	Algorithm           string `mapstructure:"LB_ALGORITHM"`
 	HealthCheckPath     string `mapstructure:"LB_HEALTH_CHECK_PATH"`
 	HealthCheckInterval int    `mapstructure:"LB_HEALTH_CHECK_INTERVAL"`
+	// StickyFallback picks the backend for new or re-pinned sessions when
+	// Algorithm is sticky_session.
+	StickyFallback string        `mapstructure:"LB_STICKY_FALLBACK"`
+	StickyTTL      time.Duration `mapstructure:"LB_STICKY_TTL"`
+	// MaxRetries applies to GET, HEAD and OPTIONS only.
+	MaxRetries int `mapstructure:"LB_MAX_RETRIES"`
 }
 
 func Load() (*Config, error) {
@@ -78,24 +195,96 @@ func Load() (*Config, error) {
 		fmt.Println("UYARI: .env dosyası bulunamadı, çevresel değişkenler kullanılacak")
 	}
 
+	return build()
+}
+
+// Reload reads the configuration again. Unlike Load, values in the .env file
+// override the process environment, since the environment of a running
+// process cannot change and the file is the only source of new values.
+func Reload() (*Config, error) {
+	if err := godotenv.Overload(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf(".env dosyası okunamadı: %w", err)
+	}
+
+	return build()
+}
+
+func build() (*Config, error) {
 	viper.AutomaticEnv()
 
 	viper.SetDefault("APP_ENV", "development")
 	viper.SetDefault("SERVER_PORT", "8081")
 	viper.SetDefault("SERVER_TIMEOUT", "30s")
 	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_FORMAT", "")
+	viper.SetDefault("LOG_OUTPUT", "stdout")
+	viper.SetDefault("LOG_SAMPLE_RATE", 1)
+	viper.SetDefault("DB_DRIVER", "postgres")
+	viper.SetDefault("DB_READ_YOUR_WRITES_WINDOW", "5s")
+	viper.SetDefault("DB_QUERY_TIMEOUT", "5s")
+	viper.SetDefault("DB_MIGRATION_ACCEPT_CHECKSUM_CHANGES", false)
+	viper.SetDefault("DB_MAX_OPEN_CONNS", 25)
+	viper.SetDefault("DB_MAX_IDLE_CONNS", 10)
+	viper.SetDefault("DB_CONN_MAX_LIFETIME", 300)
+	viper.SetDefault("LB_STICKY_FALLBACK", "round_robin")
+	viper.SetDefault("LB_STICKY_TTL", "30m")
+	viper.SetDefault("LB_MAX_RETRIES", 2)
+	viper.SetDefault("CACHE_L1_ENABLED", true)
+	viper.SetDefault("CACHE_L1_SIZE", 10000)
+	viper.SetDefault("CACHE_L1_TTL", "5s")
+	viper.SetDefault("CACHE_TTL_JITTER", 0.1)
+	viper.SetDefault("TRANSACTION_ROLLBACK_WINDOW", "24h")
+	viper.SetDefault("TRANSACTION_PENDING_TIMEOUT", "5m")
+	viper.SetDefault("TRANSACTION_REAPER_INTERVAL", "1m")
+	viper.SetDefault("TRANSACTION_DRAIN_TIMEOUT", "20s")
+	viper.SetDefault("TRANSACTION_SCHEDULER_INTERVAL", "30s")
+	viper.SetDefault("CURRENCY_DEFAULT", "TRY")
+	viper.SetDefault("EVENT_SNAPSHOT_INTERVAL", 50)
+	viper.SetDefault("PASSWORD_HASH_COST", 12)
+	viper.SetDefault("USER_REQUIRE_ACTIVATION", true)
+	viper.SetDefault("USER_ACTIVATION_TOKEN_TTL", "24h")
+	viper.SetDefault("PASSWORD_RESET_TOKEN_TTL", "1h")
+	viper.SetDefault("JWT_TOKEN_TTL", "1h")
+	viper.SetDefault("ROLE_PERMISSIONS", "support=transactions.read|users.read,auditor=audit_logs.read")
+	viper.SetDefault("RATE_LIMIT_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_RATE", 10)
+	viper.SetDefault("RATE_LIMIT_BURST", 20)
+	viper.SetDefault("AUDIT_LOG_RETENTION", "0")
+	viper.SetDefault("AUDIT_LOG_ARCHIVE_ENABLED", true)
+	viper.SetDefault("AUDIT_LOG_RETENTION_INTERVAL", "1h")
+	viper.SetDefault("AUDIT_LOG_RETENTION_BATCH", 1000)
+	viper.SetDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
+	viper.SetDefault("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-API-Key,X-Request-ID,Idempotency-Key")
+	viper.SetDefault("CORS_ALLOW_CREDENTIALS", false)
+	viper.SetDefault("CORS_MAX_AGE", "10m")
+	viper.SetDefault("CB_FAILURE_THRESHOLD", 3)
+	viper.SetDefault("CB_MAX_REQUESTS", 3)
+	viper.SetDefault("CB_TIMEOUT", "30s")
 
 	var cfg Config
 
 	cfg.AppEnv = viper.GetString("APP_ENV")
 	cfg.Server.Port = viper.GetString("SERVER_PORT")
 
+	cfg.Database.Driver = strings.ToLower(viper.GetString("DB_DRIVER"))
 	cfg.Database.Host = viper.GetString("DB_HOST")
 	cfg.Database.Port = viper.GetString("DB_PORT")
 	cfg.Database.User = viper.GetString("DB_USER")
 	cfg.Database.Password = viper.GetString("DB_PASSWORD")
 	cfg.Database.Name = viper.GetString("DB_NAME")
 	cfg.Database.SSLMode = viper.GetString("DB_SSL_MODE")
+	cfg.Database.ReadYourWritesWindow = viper.GetDuration("DB_READ_YOUR_WRITES_WINDOW")
+	cfg.Database.QueryTimeout = viper.GetDuration("DB_QUERY_TIMEOUT")
+	cfg.Database.MigrationAcceptChecksumChanges = viper.GetBool("DB_MIGRATION_ACCEPT_CHECKSUM_CHANGES")
+	cfg.Database.MaxOpenConns = viper.GetInt("DB_MAX_OPEN_CONNS")
+	cfg.Database.MaxIdleConns = viper.GetInt("DB_MAX_IDLE_CONNS")
+	cfg.Database.ConnMaxLifetime = viper.GetInt("DB_CONN_MAX_LIFETIME")
+
+	replicas, err := parseReadReplicas(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Database.ReadReplicas = replicas
 
 	cfg.Redis.Host = viper.GetString("REDIS_HOST")
 	cfg.Redis.Port = viper.GetString("REDIS_PORT")
@@ -103,10 +292,15 @@ func Load() (*Config, error) {
 	cfg.Redis.DB = viper.GetInt("REDIS_DB")
 
 	cfg.Redis.Cluster = viper.GetBool("REDIS_CLUSTER")
-	cfg.Redis.Nodes = viper.GetStringSlice("REDIS_CLUSTER_NODES")
+	cfg.Redis.Nodes = parseList(viper.GetString("REDIS_CLUSTER_NODES"))
 	cfg.Redis.PoolSize = viper.GetInt("REDIS_POOL_SIZE")
 	cfg.Redis.MinIdleConns = viper.GetInt("REDIS_MIN_IDLE_CONNS")
 
+	cfg.Cache.L1Enabled = viper.GetBool("CACHE_L1_ENABLED")
+	cfg.Cache.L1Size = viper.GetInt("CACHE_L1_SIZE")
+	cfg.Cache.L1TTL = viper.GetDuration("CACHE_L1_TTL")
+	cfg.Cache.TTLJitter = viper.GetFloat64("CACHE_TTL_JITTER")
+
 	cfg.Server.Host = viper.GetString("SERVER_HOST")
 	cfg.Server.ReadTimeout = viper.GetInt("SERVER_READ_TIMEOUT")
 	cfg.Server.WriteTimeout = viper.GetInt("SERVER_WRITE_TIMEOUT")
@@ -116,12 +310,183 @@ func Load() (*Config, error) {
 	cfg.Server.LoadBalancer.Algorithm = viper.GetString("LB_ALGORITHM")
 	cfg.Server.LoadBalancer.HealthCheckPath = viper.GetString("LB_HEALTH_CHECK_PATH")
 	cfg.Server.LoadBalancer.HealthCheckInterval = viper.GetInt("LB_HEALTH_CHECK_INTERVAL")
+	cfg.Server.LoadBalancer.StickyFallback = viper.GetString("LB_STICKY_FALLBACK")
+	cfg.Server.LoadBalancer.StickyTTL = viper.GetDuration("LB_STICKY_TTL")
+	cfg.Server.LoadBalancer.MaxRetries = viper.GetInt("LB_MAX_RETRIES")
+
+	cfg.Transaction.RollbackWindow = viper.GetDuration("TRANSACTION_ROLLBACK_WINDOW")
+	cfg.Transaction.PendingTimeout = viper.GetDuration("TRANSACTION_PENDING_TIMEOUT")
+	cfg.Transaction.ReaperInterval = viper.GetDuration("TRANSACTION_REAPER_INTERVAL")
+	cfg.Transaction.DrainTimeout = viper.GetDuration("TRANSACTION_DRAIN_TIMEOUT")
+	cfg.Transaction.SchedulerInterval = viper.GetDuration("TRANSACTION_SCHEDULER_INTERVAL")
+	cfg.Transaction.Limits.MinAmount = viper.GetFloat64("TRANSACTION_MIN_AMOUNT")
+	cfg.Transaction.Limits.MaxAmount = viper.GetFloat64("TRANSACTION_MAX_AMOUNT")
+	cfg.Transaction.Limits.DailyMax = viper.GetFloat64("TRANSACTION_DAILY_MAX")
+
+	cfg.Currency.Default = strings.ToUpper(viper.GetString("CURRENCY_DEFAULT"))
+
+	rates, err := parseRates(viper.GetString("FX_RATES"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Currency.Rates = rates
+
+	cfg.EventStore.SnapshotInterval = viper.GetInt("EVENT_SNAPSHOT_INTERVAL")
+
+	cfg.Security.PasswordHashCost = viper.GetInt("PASSWORD_HASH_COST")
+	cfg.Security.RequireActivation = viper.GetBool("USER_REQUIRE_ACTIVATION")
+	cfg.Security.ActivationTokenTTL = viper.GetDuration("USER_ACTIVATION_TOKEN_TTL")
+	cfg.Security.PasswordResetTokenTTL = viper.GetDuration("PASSWORD_RESET_TOKEN_TTL")
+
+	cfg.Auth.JWTSecret = viper.GetString("JWT_SECRET")
+	cfg.Auth.TokenTTL = viper.GetDuration("JWT_TOKEN_TTL")
+
+	rolePermissions, err := parseRolePermissions(viper.GetString("ROLE_PERMISSIONS"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Auth.RolePermissions = rolePermissions
+
+	cfg.RateLimit.Enabled = viper.GetBool("RATE_LIMIT_ENABLED")
+	cfg.RateLimit.Rate = viper.GetFloat64("RATE_LIMIT_RATE")
+	cfg.RateLimit.Burst = viper.GetInt("RATE_LIMIT_BURST")
+	cfg.RateLimit.TrustedProxies = parseList(viper.GetString("RATE_LIMIT_TRUSTED_PROXIES"))
 
-	cfg.LogLevel = viper.GetString("LOG_LEVEL")
+	cfg.AuditLog.Retention = viper.GetDuration("AUDIT_LOG_RETENTION")
+	cfg.AuditLog.ArchiveEnabled = viper.GetBool("AUDIT_LOG_ARCHIVE_ENABLED")
+	cfg.AuditLog.RetentionInterval = viper.GetDuration("AUDIT_LOG_RETENTION_INTERVAL")
+	cfg.AuditLog.RetentionBatch = viper.GetInt("AUDIT_LOG_RETENTION_BATCH")
+
+	cfg.CircuitBreaker.FailureThreshold = viper.GetInt("CB_FAILURE_THRESHOLD")
+	cfg.CircuitBreaker.MaxRequests = viper.GetInt("CB_MAX_REQUESTS")
+	cfg.CircuitBreaker.Timeout = viper.GetDuration("CB_TIMEOUT")
+
+	cfg.CORS.AllowedOrigins = parseList(viper.GetString("CORS_ALLOWED_ORIGINS"))
+	cfg.CORS.AllowedMethods = parseList(strings.ToUpper(viper.GetString("CORS_ALLOWED_METHODS")))
+	cfg.CORS.AllowedHeaders = parseList(viper.GetString("CORS_ALLOWED_HEADERS"))
+	cfg.CORS.AllowCredentials = viper.GetBool("CORS_ALLOW_CREDENTIALS")
+	cfg.CORS.MaxAge = viper.GetDuration("CORS_MAX_AGE")
+
+	cfg.LogLevel = strings.ToLower(viper.GetString("LOG_LEVEL"))
+	cfg.LogFormat = strings.ToLower(viper.GetString("LOG_FORMAT"))
+	cfg.LogOutput = strings.ToLower(viper.GetString("LOG_OUTPUT"))
+	cfg.LogFile = viper.GetString("LOG_FILE")
+	cfg.LogSampleRate = viper.GetInt("LOG_SAMPLE_RATE")
 
 	return &cfg, nil
 }
 
+// parseReadReplicas reads DB_READ_HOST_1, DB_READ_HOST_2, ... until the first
+// missing index. Port, user, password, database name and SSL mode default to
+// the master's values and weight defaults to 1.
+func parseReadReplicas(master DatabaseConfig) ([]ReplicaConfig, error) {
+	var replicas []ReplicaConfig
+	for i := 1; ; i++ {
+		host := viper.GetString(fmt.Sprintf("DB_READ_HOST_%d", i))
+		if host == "" {
+			return replicas, nil
+		}
+
+		replica := ReplicaConfig{
+			Host:     host,
+			Port:     replicaSetting("DB_READ_PORT", i, master.Port),
+			User:     replicaSetting("DB_READ_USER", i, master.User),
+			Password: replicaSetting("DB_READ_PASSWORD", i, master.Password),
+			Name:     replicaSetting("DB_READ_NAME", i, master.Name),
+			SSLMode:  replicaSetting("DB_READ_SSL_MODE", i, master.SSLMode),
+			Weight:   1,
+		}
+
+		if weight := viper.GetString(fmt.Sprintf("DB_READ_WEIGHT_%d", i)); weight != "" {
+			w, err := strconv.Atoi(weight)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("geçersiz DB_READ_WEIGHT_%d: %s", i, weight)
+			}
+			replica.Weight = w
+		}
+
+		replicas = append(replicas, replica)
+	}
+}
+
+func replicaSetting(prefix string, index int, fallback string) string {
+	if value := viper.GetString(fmt.Sprintf("%s_%d", prefix, index)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// parseList splits a comma-separated value, dropping empty entries.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// parseRates reads FX_RATES entries of the form "USD/TRY=32.5,EUR/TRY=35.1".
+func parseRates(value string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	if strings.TrimSpace(value) == "" {
+		return rates, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		pair, rateStr, ok := strings.Cut(entry, "=")
+		if !ok || !strings.Contains(pair, "/") {
+			return nil, fmt.Errorf("geçersiz FX_RATES girdisi: %s", entry)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("geçersiz döviz kuru: %s", entry)
+		}
+
+		rates[strings.ToUpper(strings.TrimSpace(pair))] = rate
+	}
+
+	return rates, nil
+}
+
+// parseRolePermissions reads ROLE_PERMISSIONS entries of the form
+// "support=transactions.read|audit_logs.read,auditor=audit_logs.read".
+func parseRolePermissions(value string) (map[string][]string, error) {
+	roles := make(map[string][]string)
+	if strings.TrimSpace(value) == "" {
+		return roles, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		role, permissionsStr, ok := strings.Cut(entry, "=")
+		role = strings.ToLower(strings.TrimSpace(role))
+		if !ok || role == "" {
+			return nil, fmt.Errorf("geçersiz ROLE_PERMISSIONS girdisi: %s", entry)
+		}
+
+		for _, permission := range strings.Split(permissionsStr, "|") {
+			if permission = strings.TrimSpace(permission); permission != "" {
+				roles[role] = append(roles[role], permission)
+			}
+		}
+	}
+
+	return roles, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -146,10 +511,3 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
-
-func getEnvAsSlice(key, separator string) []string {
-	if value := os.Getenv(key); value != "" {
-		return []string{}
-	}
-	return []string{}
-}
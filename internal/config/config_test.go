@@ -0,0 +1,49 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestParseReadReplicasLoadsNumberedReplicas(t *testing.T) {
+	viper.AutomaticEnv()
+
+	t.Setenv("DB_READ_HOST_1", "replica-1")
+	t.Setenv("DB_READ_HOST_2", "replica-2")
+	t.Setenv("DB_READ_PORT_2", "5433")
+	t.Setenv("DB_READ_WEIGHT_2", "3")
+	t.Setenv("DB_READ_HOST_3", "replica-3")
+	t.Setenv("DB_READ_USER_3", "reader")
+	t.Setenv("DB_READ_PASSWORD_3", "secret")
+	t.Setenv("DB_READ_WEIGHT_3", "0")
+	// Numbering stops at the first gap.
+	t.Setenv("DB_READ_HOST_5", "replica-5")
+
+	master := DatabaseConfig{Port: "5432", User: "payflow", Password: "pw", Name: "payflow", SSLMode: "disable"}
+	replicas, err := parseReadReplicas(master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ReplicaConfig{
+		{Host: "replica-1", Port: "5432", User: "payflow", Password: "pw", Name: "payflow", SSLMode: "disable", Weight: 1},
+		{Host: "replica-2", Port: "5433", User: "payflow", Password: "pw", Name: "payflow", SSLMode: "disable", Weight: 3},
+		{Host: "replica-3", Port: "5432", User: "reader", Password: "secret", Name: "payflow", SSLMode: "disable", Weight: 0},
+	}
+	if !reflect.DeepEqual(replicas, want) {
+		t.Fatalf("beklenen %+v, alınan %+v", want, replicas)
+	}
+}
+
+func TestParseReadReplicasRejectsInvalidWeight(t *testing.T) {
+	viper.AutomaticEnv()
+
+	t.Setenv("DB_READ_HOST_1", "replica-1")
+	t.Setenv("DB_READ_WEIGHT_1", "-1")
+
+	if _, err := parseReadReplicas(DatabaseConfig{}); err == nil {
+		t.Fatal("negatif ağırlık reddedilmeli")
+	}
+}
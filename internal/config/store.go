@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// Store holds the current configuration snapshot. A snapshot is never
+// modified after it is stored, so readers see either the old or the new
+// configuration, never a mix of both.
+type Store struct {
+	current atomic.Pointer[Config]
+}
+
+func NewStore(cfg *Config) *Store {
+	s := &Store{}
+	s.current.Store(cfg)
+	return s
+}
+
+func (s *Store) Get() *Config {
+	return s.current.Load()
+}
+
+// Apply stores a snapshot taking the hot-reloadable settings from next and
+// everything else from the current snapshot. It returns the new snapshot and
+// the names of settings that differ in next but only take effect after a
+// restart.
+func (s *Store) Apply(next *Config) (*Config, []string) {
+	current := s.Get()
+
+	merged := *current
+	merged.LogLevel = next.LogLevel
+	merged.RateLimit = next.RateLimit
+	merged.CircuitBreaker = next.CircuitBreaker
+	merged.Cache.L1TTL = next.Cache.L1TTL
+	merged.Cache.TTLJitter = next.Cache.TTLJitter
+
+	s.current.Store(&merged)
+
+	return &merged, changedSettings(reflect.ValueOf(merged), reflect.ValueOf(*next), "")
+}
+
+// changedSettings lists the fields that differ between a and b, descending
+// into nested config structs. Fields are named by their mapstructure tag when
+// they have one.
+func changedSettings(a, b reflect.Value, prefix string) []string {
+	var changed []string
+
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+
+		name := field.Tag.Get("mapstructure")
+		if name == "" {
+			name = prefix + field.Name
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			changed = append(changed, changedSettings(a.Field(i), b.Field(i), fmt.Sprintf("%s%s.", prefix, field.Name))...)
+			continue
+		}
+
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+
+	return changed
+}
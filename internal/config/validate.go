@@ -0,0 +1,183 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+)
+
+var logLevels = map[string]bool{
+	"debug": true,
+	"info":  true,
+	"warn":  true,
+	"error": true,
+	"fatal": true,
+	"panic": true,
+}
+
+// loadBalancerAlgorithms mirrors the algorithms known to pkg/loadbalancer.
+var loadBalancerAlgorithms = map[string]bool{
+	"round_robin":          true,
+	"weighted_round_robin": true,
+	"least_connections":    true,
+	"least_response_time":  true,
+	"ip_hash":              true,
+	"sticky_session":       true,
+}
+
+// Validate checks required settings, numeric ranges and enum values. It
+// reports every problem at once rather than stopping at the first one.
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		add("geçersiz SERVER_PORT: %q", c.Server.Port)
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		add("SERVER_READ_TIMEOUT, SERVER_WRITE_TIMEOUT ve SERVER_IDLE_TIMEOUT negatif olamaz")
+	}
+
+	switch c.Database.Driver {
+	case "postgres":
+		for _, required := range []struct{ name, value string }{
+			{"DB_HOST", c.Database.Host},
+			{"DB_PORT", c.Database.Port},
+			{"DB_USER", c.Database.User},
+			{"DB_NAME", c.Database.Name},
+		} {
+			if required.value == "" {
+				add("%s zorunludur", required.name)
+			}
+		}
+	case "sqlite":
+	default:
+		add("geçersiz DB_DRIVER: %s", c.Database.Driver)
+	}
+	if c.Database.MaxOpenConns <= 0 {
+		add("DB_MAX_OPEN_CONNS sıfırdan büyük olmalıdır: %d", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns <= 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		add("DB_MAX_IDLE_CONNS 1 ile DB_MAX_OPEN_CONNS arasında olmalıdır: %d", c.Database.MaxIdleConns)
+	}
+	if c.Database.ConnMaxLifetime <= 0 {
+		add("DB_CONN_MAX_LIFETIME sıfırdan büyük olmalıdır: %d", c.Database.ConnMaxLifetime)
+	}
+	if c.Database.QueryTimeout < 0 || c.Database.ReadYourWritesWindow < 0 {
+		add("DB_QUERY_TIMEOUT ve DB_READ_YOUR_WRITES_WINDOW negatif olamaz")
+	}
+
+	if c.Redis.Cluster {
+		if len(c.Redis.Nodes) == 0 {
+			add("REDIS_CLUSTER etkin ancak REDIS_CLUSTER_NODES tanımlı değil")
+		}
+	} else if c.Redis.Host == "" || c.Redis.Port == "" {
+		add("REDIS_HOST ve REDIS_PORT zorunludur")
+	}
+	// A zero pool size keeps the go-redis default.
+	if c.Redis.PoolSize < 0 || c.Redis.MinIdleConns < 0 {
+		add("REDIS_POOL_SIZE ve REDIS_MIN_IDLE_CONNS negatif olamaz")
+	}
+
+	if c.Cache.L1Enabled && (c.Cache.L1Size <= 0 || c.Cache.L1TTL <= 0) {
+		add("CACHE_L1_ENABLED için CACHE_L1_SIZE ve CACHE_L1_TTL sıfırdan büyük olmalıdır")
+	}
+	if c.Cache.TTLJitter < 0 || c.Cache.TTLJitter >= 1 {
+		add("CACHE_TTL_JITTER 0 ile 1 arasında olmalıdır: %g", c.Cache.TTLJitter)
+	}
+
+	if c.Transaction.DrainTimeout <= 0 {
+		add("TRANSACTION_DRAIN_TIMEOUT sıfırdan büyük olmalıdır: %s", c.Transaction.DrainTimeout)
+	}
+	if c.Transaction.Limits.MinAmount < 0 || c.Transaction.Limits.MaxAmount < 0 || c.Transaction.Limits.DailyMax < 0 {
+		add("işlem limitleri negatif olamaz")
+	}
+	if c.Transaction.Limits.MaxAmount > 0 && c.Transaction.Limits.MinAmount > c.Transaction.Limits.MaxAmount {
+		add("TRANSACTION_MIN_AMOUNT, TRANSACTION_MAX_AMOUNT değerinden büyük olamaz")
+	}
+
+	if len(c.Currency.Default) != 3 {
+		add("geçersiz CURRENCY_DEFAULT: %s", c.Currency.Default)
+	}
+
+	if c.EventStore.SnapshotInterval < 0 {
+		add("EVENT_SNAPSHOT_INTERVAL negatif olamaz: %d", c.EventStore.SnapshotInterval)
+	}
+
+	// bcrypt accepts costs from 4 to 31.
+	if c.Security.PasswordHashCost < 4 || c.Security.PasswordHashCost > 31 {
+		add("PASSWORD_HASH_COST 4 ile 31 arasında olmalıdır: %d", c.Security.PasswordHashCost)
+	}
+
+	if c.Auth.TokenTTL <= 0 {
+		add("JWT_TOKEN_TTL sıfırdan büyük olmalıdır: %s", c.Auth.TokenTTL)
+	}
+
+	if c.RateLimit.Enabled && (c.RateLimit.Rate <= 0 || c.RateLimit.Burst <= 0) {
+		add("RATE_LIMIT_ENABLED için RATE_LIMIT_RATE ve RATE_LIMIT_BURST sıfırdan büyük olmalıdır")
+	}
+
+	for _, proxy := range c.RateLimit.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				add("RATE_LIMIT_TRUSTED_PROXIES geçersiz IP veya CIDR içeriyor: %s", proxy)
+			}
+		}
+	}
+
+	if c.CircuitBreaker.FailureThreshold <= 0 || c.CircuitBreaker.MaxRequests <= 0 || c.CircuitBreaker.Timeout <= 0 {
+		add("CB_FAILURE_THRESHOLD, CB_MAX_REQUESTS ve CB_TIMEOUT sıfırdan büyük olmalıdır")
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" && c.CORS.AllowCredentials {
+			add("CORS_ALLOWED_ORIGINS=* CORS_ALLOW_CREDENTIALS ile birlikte kullanılamaz")
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		add("CORS_MAX_AGE negatif olamaz: %s", c.CORS.MaxAge)
+	}
+
+	if c.AuditLog.Retention > 0 && (c.AuditLog.RetentionInterval <= 0 || c.AuditLog.RetentionBatch <= 0) {
+		add("AUDIT_LOG_RETENTION için AUDIT_LOG_RETENTION_INTERVAL ve AUDIT_LOG_RETENTION_BATCH sıfırdan büyük olmalıdır")
+	}
+
+	lb := c.Server.LoadBalancer
+	if lb.Algorithm != "" && !loadBalancerAlgorithms[lb.Algorithm] {
+		add("geçersiz LB_ALGORITHM: %s", lb.Algorithm)
+	}
+	if lb.Algorithm == "sticky_session" && (lb.StickyFallback == "sticky_session" || !loadBalancerAlgorithms[lb.StickyFallback]) {
+		add("geçersiz LB_STICKY_FALLBACK: %s", lb.StickyFallback)
+	}
+	if lb.MaxRetries < 0 || lb.HealthCheckInterval < 0 {
+		add("LB_MAX_RETRIES ve LB_HEALTH_CHECK_INTERVAL negatif olamaz")
+	}
+
+	if !logLevels[c.LogLevel] {
+		add("geçersiz LOG_LEVEL: %s", c.LogLevel)
+	}
+	if c.LogFormat != "" && c.LogFormat != "json" && c.LogFormat != "console" {
+		add("geçersiz LOG_FORMAT: %s", c.LogFormat)
+	}
+	switch c.LogOutput {
+	case "stdout", "stderr":
+	case "file":
+		if c.LogFile == "" {
+			add("LOG_OUTPUT=file için LOG_FILE zorunludur")
+		}
+	default:
+		add("geçersiz LOG_OUTPUT: %s", c.LogOutput)
+	}
+	if c.LogSampleRate < 1 {
+		add("geçersiz LOG_SAMPLE_RATE: %d", c.LogSampleRate)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("geçersiz yapılandırma:\n%w", errors.Join(errs...))
+}
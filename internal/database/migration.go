@@ -1,46 +1,93 @@
 package database
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	sqldialect "payflow/pkg/database"
 	"payflow/pkg/logger"
 )
 
 type Migration struct {
 	ID        int64
 	Name      string
+	Checksum  string
 	AppliedAt time.Time
 }
 
+// Executor is the part of *sql.Tx a migration uses. Checksums are computed by
+// running the migration against a recorder instead of the database, so a
+// migration must not branch on query results.
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+var (
+	ErrUnknownMigration      = errors.New("bilinmeyen migration")
+	ErrMigrationNotApplied   = errors.New("migration uygulanmamış")
+	ErrMigrationNotLast      = errors.New("yalnızca son uygulanan migration geri alınabilir")
+	ErrMigrationIrreversible = errors.New("migration geri alınamaz")
+	ErrNoAppliedMigrations   = errors.New("uygulanmış migration yok")
+	ErrMigrationChecksum     = errors.New("uygulanmış migration değiştirilmiş")
+)
+
+type migrationStep struct {
+	Name string
+	Up   func(Executor) error
+	Down func(Executor) error
+}
+
 type MigrationService struct {
-	db     *sql.DB
-	logger logger.Logger
+	db              *sql.DB
+	dialect         sqldialect.Dialect
+	logger          logger.Logger
+	defaultCurrency string
+	// acceptChecksumChanges records the new checksum of an edited migration
+	// instead of failing, for intentional edits.
+	acceptChecksumChanges bool
 }
 
-func NewMigrationService(db *sql.DB, logger logger.Logger) *MigrationService {
+func NewMigrationService(db *sql.DB, dialect sqldialect.Dialect, logger logger.Logger, defaultCurrency string, acceptChecksumChanges bool) *MigrationService {
 	return &MigrationService{
-		db:     db,
-		logger: logger,
+		db:                    db,
+		dialect:               dialect,
+		logger:                logger,
+		defaultCurrency:       defaultCurrency,
+		acceptChecksumChanges: acceptChecksumChanges,
 	}
 }
 
 func (m *MigrationService) InitMigrationTable() error {
 	query := `
     CREATE TABLE IF NOT EXISTS migrations (
-        id SERIAL PRIMARY KEY,
+        id ` + m.dialect.AutoIncrementPrimaryKey() + `,
         name TEXT NOT NULL UNIQUE,
+        checksum TEXT,
         applied_at TIMESTAMP NOT NULL
     )
     `
 
 	_, err := m.db.Exec(query)
 	if err != nil {
-		m.logger.Error("Migration tablosu oluşturulamadı", map[string]interface{}{"error": err.Error()})
+		m.logger.ErrorWithErr("Migration tablosu oluşturulamadı", err, nil)
 		return err
 	}
 
+	// Tables created before checksums were tracked lack the column; SQLite has
+	// no ADD COLUMN IF NOT EXISTS, so probe for it instead.
+	if _, err := m.db.Exec("SELECT checksum FROM migrations WHERE 1 = 0"); err != nil {
+		if _, err := m.db.Exec("ALTER TABLE migrations ADD COLUMN checksum TEXT"); err != nil {
+			m.logger.ErrorWithErr("Migration tablosuna checksum kolonu eklenemedi", err, nil)
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -49,31 +96,40 @@ func (m *MigrationService) IsMigrationApplied(name string) (bool, error) {
 	query := "SELECT COUNT(*) FROM migrations WHERE name = $1"
 	err := m.db.QueryRow(query, name).Scan(&count)
 	if err != nil {
-		m.logger.Error("Migration durumu kontrol edilemedi", map[string]interface{}{"name": name, "error": err.Error()})
+		m.logger.ErrorWithErr("Migration durumu kontrol edilemedi", err, map[string]interface{}{"name": name})
 		return false, err
 	}
 
 	return count > 0, nil
 }
 
-func (m *MigrationService) RecordMigration(name string) error {
-	query := "INSERT INTO migrations (name, applied_at) VALUES ($1, $2)"
-	_, err := m.db.Exec(query, name, time.Now())
+func (m *MigrationService) RecordMigration(tx *sql.Tx, name, checksum string) error {
+	query := "INSERT INTO migrations (name, checksum, applied_at) VALUES ($1, $2, $3)"
+	_, err := tx.Exec(query, name, checksum, time.Now())
 	if err != nil {
-		m.logger.Error("Migration kaydedilemedi", map[string]interface{}{"name": name, "error": err.Error()})
+		m.logger.ErrorWithErr("Migration kaydedilemedi", err, map[string]interface{}{"name": name})
 		return err
 	}
 
 	return nil
 }
 
-func (m *MigrationService) ApplyMigration(name string, migrationFunc func(*sql.DB) error) error {
+func (m *MigrationService) ApplyMigration(name string, migrationFunc func(Executor) error) error {
+	checksum, err := MigrationChecksum(migrationFunc)
+	if err != nil {
+		return err
+	}
+
 	applied, err := m.IsMigrationApplied(name)
 	if err != nil {
 		return err
 	}
 
 	if applied {
+		if err := m.verifyChecksum(name, checksum); err != nil {
+			return err
+		}
+
 		m.logger.Info("Migration zaten uygulanmış", map[string]interface{}{"name": name})
 		return nil
 	}
@@ -82,28 +138,28 @@ func (m *MigrationService) ApplyMigration(name string, migrationFunc func(*sql.D
 
 	tx, err := m.db.Begin()
 	if err != nil {
-		m.logger.Error("Transaction başlatılamadı", map[string]interface{}{"error": err.Error()})
+		m.logger.ErrorWithErr("Transaction başlatılamadı", err, nil)
 		return err
 	}
 
 	defer func() {
 		if err != nil {
 			tx.Rollback()
-			m.logger.Error("Migration geri alındı", map[string]interface{}{"name": name, "error": err.Error()})
+			m.logger.ErrorWithErr("Migration geri alındı", err, map[string]interface{}{"name": name})
 		}
 	}()
 
-	if err = migrationFunc(m.db); err != nil {
-		m.logger.Error("Migration uygulanamadı", map[string]interface{}{"name": name, "error": err.Error()})
+	if err = migrationFunc(tx); err != nil {
+		m.logger.ErrorWithErr("Migration uygulanamadı", err, map[string]interface{}{"name": name})
 		return err
 	}
 
-	if err = m.RecordMigration(name); err != nil {
+	if err = m.RecordMigration(tx, name, checksum); err != nil {
 		return err
 	}
 
 	if err = tx.Commit(); err != nil {
-		m.logger.Error("Transaction commit edilemedi", map[string]interface{}{"error": err.Error()})
+		m.logger.ErrorWithErr("Transaction commit edilemedi", err, nil)
 		return err
 	}
 
@@ -111,6 +167,45 @@ func (m *MigrationService) ApplyMigration(name string, migrationFunc func(*sql.D
 	return nil
 }
 
+// verifyChecksum compares an applied migration with its current definition.
+// Rows recorded before checksums were tracked adopt the current one.
+func (m *MigrationService) verifyChecksum(name, checksum string) error {
+	var stored sql.NullString
+	if err := m.db.QueryRow("SELECT checksum FROM migrations WHERE name = $1", name).Scan(&stored); err != nil {
+		m.logger.ErrorWithErr("Migration checksum okunamadı", err, map[string]interface{}{"name": name})
+		return err
+	}
+
+	if stored.Valid && stored.String == checksum {
+		return nil
+	}
+
+	if stored.Valid && !m.acceptChecksumChanges {
+		m.logger.Error("Uygulanmış migration değiştirilmiş", map[string]interface{}{
+			"name":     name,
+			"applied":  stored.String,
+			"current":  checksum,
+			"override": "DB_MIGRATION_ACCEPT_CHECKSUM_CHANGES=true",
+		})
+		return fmt.Errorf("%w: %s (uygulanan checksum %s, güncel %s)", ErrMigrationChecksum, name, stored.String, checksum)
+	}
+
+	if stored.Valid {
+		m.logger.Warn("Değiştirilmiş migration checksum'ı kabul edildi", map[string]interface{}{
+			"name":    name,
+			"applied": stored.String,
+			"current": checksum,
+		})
+	}
+
+	if _, err := m.db.Exec("UPDATE migrations SET checksum = $1 WHERE name = $2", checksum, name); err != nil {
+		m.logger.ErrorWithErr("Migration checksum kaydedilemedi", err, map[string]interface{}{"name": name})
+		return err
+	}
+
+	return nil
+}
+
 func (m *MigrationService) RunMigrations() error {
 	m.logger.Info("Migrationlar başlatılıyor", map[string]interface{}{})
 
@@ -118,31 +213,176 @@ func (m *MigrationService) RunMigrations() error {
 		return fmt.Errorf("migration tablosu oluşturulamadı: %w", err)
 	}
 
-	migrations := []struct {
-		Name string
-		Func func(*sql.DB) error
-	}{
-		{"create_users_table", CreateUsersTable},
-		{"create_transactions_table", CreateTransactionsTable},
-		{"create_balances_table", CreateBalancesTable},
-		{"create_audit_logs_table", CreateAuditLogsTable},
-		{"create_balance_history_table", CreateBalanceHistoryTable},
-		{"create_event_store_table", CreateEventStoreTable},
+	for _, migration := range m.migrations() {
+		if err := m.ApplyMigration(migration.Name, migration.Up); err != nil {
+			return fmt.Errorf("migration uygulanamadı %s: %w", migration.Name, err)
+		}
 	}
 
-	for _, migration := range migrations {
-		if err := m.ApplyMigration(migration.Name, migration.Func); err != nil {
-			return fmt.Errorf("migration uygulanamadı %s: %w", migration.Name, err)
+	return nil
+}
+
+// migrations lists the steps in the order they are applied. A nil Down marks
+// a step that cannot be undone without losing data.
+func (m *MigrationService) migrations() []migrationStep {
+	d := m.dialect
+
+	return []migrationStep{
+		{"create_users_table", CreateUsersTable(d), dropTable("users")},
+		{"create_transactions_table", CreateTransactionsTable(d), dropTable("transactions")},
+		{"create_balances_table", CreateBalancesTable, dropTable("balances")},
+		{"create_audit_logs_table", CreateAuditLogsTable(d), dropTable("audit_logs")},
+		{"create_balance_history_table", CreateBalanceHistoryTable(d), dropTable("balance_history")},
+		{"create_event_store_table", CreateEventStoreTable(d), dropTable("event_store")},
+		{"add_transactions_idempotency_key", AddTransactionsIdempotencyKey(d), DropTransactionsIdempotencyKey(d)},
+		{"add_balances_overdraft_limit", AddBalancesOverdraftLimit(d), DropBalancesOverdraftLimit(d)},
+		{"add_currency_columns", AddCurrencyColumns(d, m.defaultCurrency), DropCurrencyColumns(d)},
+		{"add_transactions_conversion", AddTransactionsConversion(d), DropTransactionsConversion(d)},
+		{"add_transactions_scheduled_at", AddTransactionsScheduledAt(d), DropTransactionsScheduledAt(d)},
+		{"create_recurring_transfers_table", CreateRecurringTransfersTable(d), dropTable("recurring_transfers")},
+		{"create_snapshots_table", CreateSnapshotsTable(d), dropTable("snapshots")},
+		{"add_event_store_version_unique", AddEventStoreVersionUnique(d), DropEventStoreVersionUnique},
+		{"add_event_store_event_type_index", AddEventStoreEventTypeIndex, DropEventStoreEventTypeIndex},
+		{"create_event_subscriber_cursors_table", CreateEventSubscriberCursorsTable, dropTable("event_subscriber_cursors")},
+		{"hash_user_api_keys", HashUserApiKeys(d), nil},
+		{"add_user_activation", AddUserActivation(d), DropUserActivation(d)},
+		{"create_password_reset_tokens_table", CreatePasswordResetTokensTable(d), DropPasswordResetTokensTable(d)},
+		{"add_users_deleted_at", AddUsersDeletedAt(d), DropUsersDeletedAt(d)},
+		{"add_audit_logs_actor_id", AddAuditLogsActorID(d), DropAuditLogsActorID(d)},
+		{"create_audit_logs_archive_table", CreateAuditLogsArchiveTable, DropAuditLogsArchiveTable},
+		{"scope_transactions_idempotency_key", ScopeTransactionsIdempotencyKey, UnscopeTransactionsIdempotencyKey},
+		{"add_balances_created_at", AddBalancesCreatedAt, DropBalancesCreatedAt},
+	}
+}
+
+// RollbackMigration undoes an applied migration and removes its record. Only
+// the most recently applied migration can be rolled back, since later ones
+// may depend on it.
+func (m *MigrationService) RollbackMigration(name string) error {
+	var step *migrationStep
+	for _, migration := range m.migrations() {
+		if migration.Name == name {
+			migration := migration
+			step = &migration
+			break
+		}
+	}
+	if step == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownMigration, name)
+	}
+
+	applied, err := m.IsMigrationApplied(name)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf("%w: %s", ErrMigrationNotApplied, name)
+	}
+
+	last, err := m.lastAppliedMigration()
+	if err != nil {
+		return err
+	}
+	if last != name {
+		return fmt.Errorf("%w: %s (son uygulanan: %s)", ErrMigrationNotLast, name, last)
+	}
+
+	if step.Down == nil {
+		return fmt.Errorf("%w: %s", ErrMigrationIrreversible, name)
+	}
+
+	m.logger.Info("Migration geri alınıyor", map[string]interface{}{"name": name})
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		m.logger.ErrorWithErr("Transaction başlatılamadı", err, nil)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			m.logger.ErrorWithErr("Migration geri alma işlemi iptal edildi", err, map[string]interface{}{"name": name})
 		}
+	}()
+
+	if err = step.Down(tx); err != nil {
+		m.logger.ErrorWithErr("Migration geri alınamadı", err, map[string]interface{}{"name": name})
+		return err
 	}
 
+	if _, err = tx.Exec("DELETE FROM migrations WHERE name = $1", name); err != nil {
+		m.logger.ErrorWithErr("Migration kaydı silinemedi", err, map[string]interface{}{"name": name})
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		m.logger.ErrorWithErr("Transaction commit edilemedi", err, nil)
+		return err
+	}
+
+	m.logger.Info("Migration başarıyla geri alındı", map[string]interface{}{"name": name})
 	return nil
 }
 
-func CreateUsersTable(db *sql.DB) error {
-	query := `
+// RollbackLast undoes the most recently applied migration and returns its name.
+func (m *MigrationService) RollbackLast() (string, error) {
+	name, err := m.lastAppliedMigration()
+	if err != nil {
+		return "", err
+	}
+
+	return name, m.RollbackMigration(name)
+}
+
+func (m *MigrationService) lastAppliedMigration() (string, error) {
+	var name string
+	err := m.db.QueryRow("SELECT name FROM migrations ORDER BY id DESC LIMIT 1").Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", ErrNoAppliedMigrations
+	}
+	if err != nil {
+		m.logger.ErrorWithErr("Son migration okunamadı", err, nil)
+		return "", err
+	}
+
+	return name, nil
+}
+
+// MigrationChecksum hashes the statements a migration runs. Arguments are left
+// out so that values such as the default currency can differ per environment,
+// and whitespace is collapsed so reindenting a query is not a change.
+func MigrationChecksum(migrationFunc func(Executor) error) (string, error) {
+	recorder := &checksumRecorder{}
+	if err := migrationFunc(recorder); err != nil {
+		return "", fmt.Errorf("migration checksum hesaplanamadı: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(recorder.statements, ";")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type checksumRecorder struct {
+	statements []string
+}
+
+func (r *checksumRecorder) Exec(query string, args ...interface{}) (sql.Result, error) {
+	r.statements = append(r.statements, strings.Join(strings.Fields(query), " "))
+	return driver.RowsAffected(0), nil
+}
+
+func dropTable(table string) func(Executor) error {
+	return func(tx Executor) error {
+		_, err := tx.Exec("DROP TABLE IF EXISTS " + table)
+		return err
+	}
+}
+
+func CreateUsersTable(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
     CREATE TABLE IF NOT EXISTS users (
-        id SERIAL PRIMARY KEY,
+        id ` + d.AutoIncrementPrimaryKey() + `,
         username TEXT NOT NULL UNIQUE,
         email TEXT NOT NULL UNIQUE,
         password_hash TEXT NOT NULL,
@@ -153,14 +393,16 @@ func CreateUsersTable(db *sql.DB) error {
     )
     `
 
-	_, err := db.Exec(query)
-	return err
+		_, err := tx.Exec(query)
+		return err
+	}
 }
 
-func CreateTransactionsTable(db *sql.DB) error {
-	query := `
+func CreateTransactionsTable(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
     CREATE TABLE IF NOT EXISTS transactions (
-        id SERIAL PRIMARY KEY,
+        id ` + d.AutoIncrementPrimaryKey() + `,
         from_user_id INTEGER,
         to_user_id INTEGER,
         amount NUMERIC(18,2) NOT NULL,
@@ -172,11 +414,12 @@ func CreateTransactionsTable(db *sql.DB) error {
     )
     `
 
-	_, err := db.Exec(query)
-	return err
+		_, err := tx.Exec(query)
+		return err
+	}
 }
 
-func CreateBalancesTable(db *sql.DB) error {
+func CreateBalancesTable(tx Executor) error {
 	query := `
     CREATE TABLE IF NOT EXISTS balances (
         user_id INTEGER PRIMARY KEY,
@@ -186,14 +429,15 @@ func CreateBalancesTable(db *sql.DB) error {
     )
     `
 
-	_, err := db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
 
-func CreateAuditLogsTable(db *sql.DB) error {
-	query := `
+func CreateAuditLogsTable(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
     CREATE TABLE IF NOT EXISTS audit_logs (
-        id SERIAL PRIMARY KEY,
+        id ` + d.AutoIncrementPrimaryKey() + `,
         entity_type TEXT NOT NULL,
         entity_id INTEGER NOT NULL,
         action TEXT NOT NULL,
@@ -202,14 +446,16 @@ func CreateAuditLogsTable(db *sql.DB) error {
     )
     `
 
-	_, err := db.Exec(query)
-	return err
+		_, err := tx.Exec(query)
+		return err
+	}
 }
 
-func CreateBalanceHistoryTable(db *sql.DB) error {
-	query := `
+func CreateBalanceHistoryTable(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
     CREATE TABLE IF NOT EXISTS balance_history (
-        id SERIAL PRIMARY KEY,
+        id ` + d.AutoIncrementPrimaryKey() + `,
         user_id INTEGER NOT NULL,
         amount NUMERIC(18,2) NOT NULL,
         previous_amount NUMERIC(18,2) NOT NULL,
@@ -224,21 +470,23 @@ func CreateBalanceHistoryTable(db *sql.DB) error {
     CREATE INDEX IF NOT EXISTS balance_history_created_at_idx ON balance_history (created_at);
     `
 
-	_, err := db.Exec(query)
-	return err
+		_, err := tx.Exec(query)
+		return err
+	}
 }
 
-func CreateEventStoreTable(db *sql.DB) error {
-	query := `
+func CreateEventStoreTable(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
     CREATE TABLE IF NOT EXISTS event_store (
-        id SERIAL PRIMARY KEY,
+        id ` + d.AutoIncrementPrimaryKey() + `,
         aggregate_id TEXT NOT NULL,
         aggregate_type TEXT NOT NULL,
         event_type TEXT NOT NULL,
-        event_data JSONB NOT NULL,
+        event_data ` + d.JSONType() + ` NOT NULL,
         version INTEGER NOT NULL,
         created_at TIMESTAMP NOT NULL,
-        metadata JSONB
+        metadata ` + d.JSONType() + `
     );
     
     CREATE INDEX IF NOT EXISTS event_store_aggregate_idx ON event_store (aggregate_type, aggregate_id);
@@ -246,6 +494,548 @@ func CreateEventStoreTable(db *sql.DB) error {
     CREATE INDEX IF NOT EXISTS event_store_created_at_idx ON event_store (created_at);
     `
 
-	_, err := db.Exec(query)
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func AddTransactionsIdempotencyKey(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.AddColumn("transactions", "idempotency_key TEXT") + `;
+
+    CREATE UNIQUE INDEX IF NOT EXISTS transactions_idempotency_key_idx ON transactions (idempotency_key);
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func DropTransactionsIdempotencyKey(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    DROP INDEX IF EXISTS transactions_idempotency_key_idx;
+    ` + d.DropColumn("transactions", "idempotency_key") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func AddBalancesOverdraftLimit(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.AddColumn("balances", "overdraft_limit NUMERIC(18,2) NOT NULL DEFAULT 0") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func DropBalancesOverdraftLimit(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.DropColumn("balances", "overdraft_limit") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func AddCurrencyColumns(d sqldialect.Dialect, defaultCurrency string) func(Executor) error {
+	if !d.SupportsAlterConstraint() {
+		return addCurrencyColumnsByRebuild(defaultCurrency)
+	}
+
+	return func(tx Executor) error {
+		statements := []struct {
+			query string
+			args  []interface{}
+		}{
+			{query: `ALTER TABLE balances ADD COLUMN IF NOT EXISTS currency CHAR(3)`},
+			{query: `UPDATE balances SET currency = $1 WHERE currency IS NULL`, args: []interface{}{defaultCurrency}},
+			{query: `ALTER TABLE balances ALTER COLUMN currency SET NOT NULL`},
+			{query: `ALTER TABLE balances DROP CONSTRAINT IF EXISTS balances_pkey`},
+			{query: `ALTER TABLE balances ADD PRIMARY KEY (user_id, currency)`},
+
+			{query: `ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency CHAR(3)`},
+			{query: `UPDATE transactions SET currency = $1 WHERE currency IS NULL`, args: []interface{}{defaultCurrency}},
+			{query: `ALTER TABLE transactions ALTER COLUMN currency SET NOT NULL`},
+
+			{query: `ALTER TABLE balance_history ADD COLUMN IF NOT EXISTS currency CHAR(3)`},
+			{query: `UPDATE balance_history SET currency = $1 WHERE currency IS NULL`, args: []interface{}{defaultCurrency}},
+			{query: `ALTER TABLE balance_history ALTER COLUMN currency SET NOT NULL`},
+			{query: `CREATE INDEX IF NOT EXISTS balance_history_user_currency_idx ON balance_history (user_id, currency, created_at)`},
+		}
+
+		for _, stmt := range statements {
+			if _, err := tx.Exec(stmt.query, stmt.args...); err != nil {
+				return fmt.Errorf("para birimi kolonları eklenemedi: %w", err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// addCurrencyColumnsByRebuild is AddCurrencyColumns for databases that cannot
+// alter constraints in place. The new columns are added NOT NULL with an empty
+// default and then filled, and balances is rebuilt to change its primary key.
+func addCurrencyColumnsByRebuild(defaultCurrency string) func(Executor) error {
+	return func(tx Executor) error {
+		for _, table := range []string{"balances", "transactions", "balance_history"} {
+			if _, err := tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN currency CHAR(3) NOT NULL DEFAULT ''`); err != nil {
+				return fmt.Errorf("para birimi kolonları eklenemedi: %w", err)
+			}
+			if _, err := tx.Exec(`UPDATE `+table+` SET currency = $1 WHERE currency = ''`, defaultCurrency); err != nil {
+				return fmt.Errorf("para birimi kolonları eklenemedi: %w", err)
+			}
+		}
+
+		err := rebuildTable(tx, "balances", `
+        user_id INTEGER NOT NULL,
+        amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+        last_updated_at TIMESTAMP NOT NULL,
+        overdraft_limit NUMERIC(18,2) NOT NULL DEFAULT 0,
+        currency CHAR(3) NOT NULL,
+        PRIMARY KEY (user_id, currency),
+        FOREIGN KEY (user_id) REFERENCES users (id)
+    `, "user_id, amount, last_updated_at, overdraft_limit, currency")
+		if err != nil {
+			return fmt.Errorf("para birimi kolonları eklenemedi: %w", err)
+		}
+
+		if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS balance_history_user_currency_idx ON balance_history (user_id, currency, created_at)`); err != nil {
+			return fmt.Errorf("para birimi kolonları eklenemedi: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// DropCurrencyColumns restores the single-balance-per-user key, so it fails
+// while any user still holds balances in more than one currency.
+func DropCurrencyColumns(d sqldialect.Dialect) func(Executor) error {
+	if !d.SupportsAlterConstraint() {
+		return dropCurrencyColumnsByRebuild
+	}
+
+	return func(tx Executor) error {
+		query := `
+    DROP INDEX IF EXISTS balance_history_user_currency_idx;
+    ALTER TABLE balance_history DROP COLUMN IF EXISTS currency;
+    ALTER TABLE transactions DROP COLUMN IF EXISTS currency;
+    ALTER TABLE balances DROP CONSTRAINT IF EXISTS balances_pkey;
+    ALTER TABLE balances DROP COLUMN IF EXISTS currency;
+    ALTER TABLE balances ADD PRIMARY KEY (user_id);
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func dropCurrencyColumnsByRebuild(tx Executor) error {
+	query := `
+    DROP INDEX IF EXISTS balance_history_user_currency_idx;
+    ALTER TABLE balance_history DROP COLUMN currency;
+    ALTER TABLE transactions DROP COLUMN currency;
+    `
+
+	if _, err := tx.Exec(query); err != nil {
+		return err
+	}
+
+	return rebuildTable(tx, "balances", `
+        user_id INTEGER PRIMARY KEY,
+        amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+        last_updated_at TIMESTAMP NOT NULL,
+        overdraft_limit NUMERIC(18,2) NOT NULL DEFAULT 0,
+        FOREIGN KEY (user_id) REFERENCES users (id)
+    `, "user_id, amount, last_updated_at, overdraft_limit")
+}
+
+// rebuildTable replaces table with one created from definition and copies
+// columns across. It is how SQLite changes constraints it cannot alter in
+// place; the table must not be referenced by foreign keys.
+func rebuildTable(tx Executor, table, definition, columns string) error {
+	query := `
+    CREATE TABLE ` + table + `_rebuilt (` + definition + `);
+    INSERT INTO ` + table + `_rebuilt (` + columns + `) SELECT ` + columns + ` FROM ` + table + `;
+    DROP TABLE ` + table + `;
+    ALTER TABLE ` + table + `_rebuilt RENAME TO ` + table + `;
+    `
+
+	_, err := tx.Exec(query)
+	return err
+}
+
+func AddTransactionsConversion(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.AddColumn("transactions", "to_currency CHAR(3)") + `;
+    ` + d.AddColumn("transactions", "converted_amount NUMERIC(18,2)") + `;
+    ` + d.AddColumn("transactions", "exchange_rate NUMERIC(18,8)") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func DropTransactionsConversion(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.DropColumn("transactions", "exchange_rate") + `;
+    ` + d.DropColumn("transactions", "converted_amount") + `;
+    ` + d.DropColumn("transactions", "to_currency") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func AddTransactionsScheduledAt(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.AddColumn("transactions", "scheduled_at TIMESTAMP") + `;
+    CREATE INDEX IF NOT EXISTS transactions_scheduled_at_idx ON transactions (scheduled_at) WHERE status = 'scheduled';
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func DropTransactionsScheduledAt(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    DROP INDEX IF EXISTS transactions_scheduled_at_idx;
+    ` + d.DropColumn("transactions", "scheduled_at") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func CreateRecurringTransfersTable(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    CREATE TABLE IF NOT EXISTS recurring_transfers (
+        id ` + d.AutoIncrementPrimaryKey() + `,
+        from_user_id INTEGER NOT NULL,
+        to_user_id INTEGER NOT NULL,
+        amount NUMERIC(18,2) NOT NULL,
+        currency CHAR(3) NOT NULL,
+        run_interval TEXT NOT NULL,
+        next_run_at TIMESTAMP NOT NULL,
+        end_date TIMESTAMP,
+        status TEXT NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        FOREIGN KEY (from_user_id) REFERENCES users (id),
+        FOREIGN KEY (to_user_id) REFERENCES users (id)
+    );
+    CREATE INDEX IF NOT EXISTS recurring_transfers_due_idx ON recurring_transfers (next_run_at) WHERE status = 'active';
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func CreateSnapshotsTable(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    CREATE TABLE IF NOT EXISTS snapshots (
+        id ` + d.AutoIncrementPrimaryKey() + `,
+        aggregate_id TEXT NOT NULL,
+        aggregate_type TEXT NOT NULL,
+        version INTEGER NOT NULL,
+        state ` + d.JSONType() + ` NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        UNIQUE (aggregate_type, aggregate_id, version)
+    )
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// AddEventStoreVersionUnique renumbers versions that concurrent writers may have
+// duplicated, keeping their original order, before enforcing uniqueness.
+func AddEventStoreVersionUnique(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    UPDATE ` + d.UpdateAlias("event_store", "e") + `
+    SET version = ordered.new_version
+    FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY aggregate_type, aggregate_id ORDER BY version, id) AS new_version
+        FROM event_store
+    ) ordered
+    WHERE e.id = ordered.id AND e.version <> ordered.new_version;
+
+    CREATE UNIQUE INDEX IF NOT EXISTS event_store_aggregate_version_uidx ON event_store (aggregate_type, aggregate_id, version);
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// DropEventStoreVersionUnique only removes the constraint; renumbered versions
+// are kept.
+func DropEventStoreVersionUnique(tx Executor) error {
+	query := `
+    DROP INDEX IF EXISTS event_store_aggregate_version_uidx;
+    `
+
+	_, err := tx.Exec(query)
+	return err
+}
+
+func AddEventStoreEventTypeIndex(tx Executor) error {
+	query := `
+    CREATE INDEX IF NOT EXISTS event_store_event_type_idx ON event_store (event_type, created_at);
+    DROP INDEX IF EXISTS event_store_version_idx;
+    `
+
+	_, err := tx.Exec(query)
+	return err
+}
+
+func DropEventStoreEventTypeIndex(tx Executor) error {
+	query := `
+    CREATE INDEX IF NOT EXISTS event_store_version_idx ON event_store (aggregate_type, aggregate_id, version);
+    DROP INDEX IF EXISTS event_store_event_type_idx;
+    `
+
+	_, err := tx.Exec(query)
+	return err
+}
+
+func CreateEventSubscriberCursorsTable(tx Executor) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS event_subscriber_cursors (
+        subscriber VARCHAR(255) PRIMARY KEY,
+        last_event_id BIGINT NOT NULL,
+        updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    )
+    `
+
+	_, err := tx.Exec(query)
+	return err
+}
+
+func HashUserApiKeys(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    UPDATE users SET api_key = NULL WHERE api_key = '';
+    UPDATE users SET api_key = ` + d.SHA256Hex("api_key") + ` WHERE api_key IS NOT NULL;
+    ALTER TABLE users RENAME COLUMN api_key TO api_key_hash;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// AddUserActivation keeps existing users active; new users may start inactive
+// until they redeem an activation token.
+func AddUserActivation(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.AddColumn("users", "is_active BOOLEAN NOT NULL DEFAULT TRUE") + `;
+    ` + d.AddColumn("users", "email_verified_at TIMESTAMP") + `;
+
+    CREATE TABLE IF NOT EXISTS user_activation_tokens (
+        id ` + d.AutoIncrementPrimaryKey() + `,
+        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        token_hash VARCHAR(64) NOT NULL UNIQUE,
+        expires_at TIMESTAMP NOT NULL,
+        used_at TIMESTAMP,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+    CREATE INDEX IF NOT EXISTS user_activation_tokens_user_id_idx ON user_activation_tokens (user_id);
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func DropUserActivation(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    DROP TABLE IF EXISTS user_activation_tokens;
+    ` + d.DropColumn("users", "email_verified_at") + `;
+    ` + d.DropColumn("users", "is_active") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func CreatePasswordResetTokensTable(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.AddColumn("users", "password_changed_at TIMESTAMP") + `;
+
+    CREATE TABLE IF NOT EXISTS password_reset_tokens (
+        id ` + d.AutoIncrementPrimaryKey() + `,
+        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        token_hash VARCHAR(64) NOT NULL UNIQUE,
+        expires_at TIMESTAMP NOT NULL,
+        used_at TIMESTAMP,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+    CREATE INDEX IF NOT EXISTS password_reset_tokens_user_id_idx ON password_reset_tokens (user_id);
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func DropPasswordResetTokensTable(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    DROP TABLE IF EXISTS password_reset_tokens;
+    ` + d.DropColumn("users", "password_changed_at") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func AddUsersDeletedAt(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.AddColumn("users", "deleted_at TIMESTAMP") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func DropUsersDeletedAt(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.DropColumn("users", "deleted_at") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// AddAuditLogsActorID attributes existing rows to the system actor (0).
+func AddAuditLogsActorID(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    ` + d.AddColumn("audit_logs", "actor_id BIGINT NOT NULL DEFAULT 0") + `;
+    CREATE INDEX IF NOT EXISTS audit_logs_actor_id_idx ON audit_logs (actor_id);
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+func DropAuditLogsActorID(d sqldialect.Dialect) func(Executor) error {
+	return func(tx Executor) error {
+		query := `
+    DROP INDEX IF EXISTS audit_logs_actor_id_idx;
+    ` + d.DropColumn("audit_logs", "actor_id") + `;
+    `
+
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// CreateAuditLogsArchiveTable keeps the original ids so archived rows can be
+// traced back to references in logs and exports.
+func CreateAuditLogsArchiveTable(tx Executor) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS audit_logs_archive (
+        id BIGINT PRIMARY KEY,
+        entity_type TEXT NOT NULL,
+        entity_id INTEGER NOT NULL,
+        action TEXT NOT NULL,
+        details TEXT,
+        actor_id BIGINT NOT NULL DEFAULT 0,
+        created_at TIMESTAMP NOT NULL,
+        archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+    CREATE INDEX IF NOT EXISTS audit_logs_archive_created_at_idx ON audit_logs_archive (created_at);
+    CREATE INDEX IF NOT EXISTS audit_logs_created_at_idx ON audit_logs (created_at);
+    `
+
+	_, err := tx.Exec(query)
+	return err
+}
+
+// DropAuditLogsArchiveTable discards archived rows along with the table.
+func DropAuditLogsArchiveTable(tx Executor) error {
+	query := `
+    DROP INDEX IF EXISTS audit_logs_created_at_idx;
+    DROP TABLE IF EXISTS audit_logs_archive;
+    `
+
+	_, err := tx.Exec(query)
+	return err
+}
+
+// ScopeTransactionsIdempotencyKey makes idempotency keys unique per owning
+// user (the sender, or the recipient for deposits) instead of globally, so one
+// user's key never resolves to another user's transaction.
+func ScopeTransactionsIdempotencyKey(tx Executor) error {
+	query := `
+    DROP INDEX IF EXISTS transactions_idempotency_key_idx;
+    CREATE UNIQUE INDEX IF NOT EXISTS transactions_owner_idempotency_key_idx ON transactions (COALESCE(from_user_id, to_user_id), idempotency_key);
+    `
+
+	_, err := tx.Exec(query)
+	return err
+}
+
+func UnscopeTransactionsIdempotencyKey(tx Executor) error {
+	query := `
+    DROP INDEX IF EXISTS transactions_owner_idempotency_key_idx;
+    CREATE UNIQUE INDEX IF NOT EXISTS transactions_idempotency_key_idx ON transactions (idempotency_key);
+    `
+
+	_, err := tx.Exec(query)
+	return err
+}
+
+// AddBalancesCreatedAt records when each balance was opened. Existing rows are
+// backfilled from their earliest history entry, or from last_updated_at when
+// they have none.
+func AddBalancesCreatedAt(tx Executor) error {
+	query := `
+    ALTER TABLE balances ADD COLUMN created_at TIMESTAMP;
+    UPDATE balances SET created_at = COALESCE(
+        (SELECT MIN(h.created_at) FROM balance_history h WHERE h.user_id = balances.user_id AND h.currency = balances.currency),
+        last_updated_at
+    );
+    `
+
+	_, err := tx.Exec(query)
+	return err
+}
+
+func DropBalancesCreatedAt(tx Executor) error {
+	_, err := tx.Exec(`ALTER TABLE balances DROP COLUMN created_at`)
 	return err
 }
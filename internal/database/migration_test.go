@@ -0,0 +1,125 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+
+	sqldialect "payflow/pkg/database"
+	"payflow/pkg/logger"
+	"payflow/pkg/password"
+)
+
+func newTestMigrationService(t *testing.T) *MigrationService {
+	t.Helper()
+
+	dialect, err := sqldialect.NewDialect(sqldialect.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(dialect.DriverName(), dialect.DSN("", "", "", "", "file:"+t.Name()+"?mode=memory&cache=shared", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m := NewMigrationService(db, dialect, logger.New(logger.ErrorLevel, logger.FormatJSON, io.Discard), "TRY", false)
+	if err := m.InitMigrationTable(); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFailedMigrationRollsBackEarlierStatements(t *testing.T) {
+	m := newTestMigrationService(t)
+
+	broken := func(tx Executor) error {
+		if _, err := tx.Exec("CREATE TABLE first_step (id INTEGER PRIMARY KEY)"); err != nil {
+			return err
+		}
+		_, err := tx.Exec("INSERT INTO missing_table (id) VALUES (1)")
+		return err
+	}
+
+	if err := m.ApplyMigration("broken", broken); err == nil {
+		t.Fatal("ikinci ifadesi başarısız olan migration hata döndürmeli")
+	}
+
+	var tables int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'first_step'").Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Fatal("ilk ifadenin oluşturduğu tablo geri alınmalı")
+	}
+
+	applied, err := m.IsMigrationApplied("broken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied {
+		t.Fatal("başarısız migration uygulanmış olarak kaydedilmemeli")
+	}
+}
+
+func TestMigrationsRunOnSQLite(t *testing.T) {
+	m := newTestMigrationService(t)
+
+	// Apply the history up to the currency migration, then give it a balance
+	// to carry over while balances is rebuilt.
+	for _, step := range m.migrations() {
+		if step.Name == "add_currency_columns" {
+			break
+		}
+		if err := m.ApplyMigration(step.Name, step.Up); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seed := `
+    INSERT INTO users (id, username, email, password_hash, api_key, created_at, updated_at)
+    VALUES (1, 'alice', 'alice@example.com', 'hash', 'plain-key', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+    INSERT INTO balances (user_id, amount, last_updated_at) VALUES (1, 12.50, CURRENT_TIMESTAMP);
+    `
+	if _, err := m.db.Exec(seed); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.RunMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	var currency string
+	var amount float64
+	if err := m.db.QueryRow("SELECT currency, amount FROM balances WHERE user_id = 1").Scan(&currency, &amount); err != nil {
+		t.Fatal(err)
+	}
+	if currency != "TRY" || amount != 12.50 {
+		t.Fatalf("bakiye korunmalı, beklenen TRY 12.50, alınan: %s %.2f", currency, amount)
+	}
+
+	var apiKeyHash string
+	if err := m.db.QueryRow("SELECT api_key_hash FROM users WHERE id = 1").Scan(&apiKeyHash); err != nil {
+		t.Fatal(err)
+	}
+	if want := password.HashApiKey("plain-key"); apiKeyHash != want {
+		t.Fatalf("API anahtarı hashlenmeli, beklenen %s, alınan: %s", want, apiKeyHash)
+	}
+
+	// Every reversible step after the API key hashing rolls back and applies
+	// again.
+	for {
+		name, err := m.RollbackLast()
+		if errors.Is(err, ErrMigrationIrreversible) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%s geri alınamadı: %v", name, err)
+		}
+	}
+	if err := m.RunMigrations(); err != nil {
+		t.Fatal(err)
+	}
+}
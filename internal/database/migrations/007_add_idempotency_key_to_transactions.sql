@@ -0,0 +1,8 @@
+-- +migrate Up
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_idempotency_key_idx ON transactions (idempotency_key);
+
+-- +migrate Down
+DROP INDEX IF EXISTS transactions_idempotency_key_idx;
+ALTER TABLE transactions DROP COLUMN IF EXISTS idempotency_key;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS overdraft_limit DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE balances DROP COLUMN IF EXISTS overdraft_limit;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- +migrate Up
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS currency CHAR(3);
+UPDATE balances SET currency = 'TRY' WHERE currency IS NULL;
+ALTER TABLE balances ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE balances DROP CONSTRAINT IF EXISTS balances_user_id_key;
+ALTER TABLE balances ADD CONSTRAINT balances_user_id_currency_key UNIQUE (user_id, currency);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency CHAR(3);
+UPDATE transactions SET currency = 'TRY' WHERE currency IS NULL;
+ALTER TABLE transactions ALTER COLUMN currency SET NOT NULL;
+
+ALTER TABLE balance_history ADD COLUMN IF NOT EXISTS currency CHAR(3);
+UPDATE balance_history SET currency = 'TRY' WHERE currency IS NULL;
+ALTER TABLE balance_history ALTER COLUMN currency SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_balance_history_user_currency ON balance_history(user_id, currency, created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_balance_history_user_currency;
+ALTER TABLE balance_history DROP COLUMN IF EXISTS currency;
+ALTER TABLE transactions DROP COLUMN IF EXISTS currency;
+ALTER TABLE balances DROP CONSTRAINT IF EXISTS balances_user_id_currency_key;
+ALTER TABLE balances DROP COLUMN IF EXISTS currency;
+ALTER TABLE balances ADD CONSTRAINT balances_user_id_key UNIQUE (user_id);
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS to_currency CHAR(3);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS converted_amount DECIMAL(15,2);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18,8);
+
+-- +migrate Down
+ALTER TABLE transactions DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE transactions DROP COLUMN IF EXISTS converted_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS to_currency;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS transactions_scheduled_at_idx ON transactions (scheduled_at) WHERE status = 'scheduled';
+
+-- +migrate Down
+DROP INDEX IF EXISTS transactions_scheduled_at_idx;
+ALTER TABLE transactions DROP COLUMN IF EXISTS scheduled_at;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS recurring_transfers (
+    id SERIAL PRIMARY KEY,
+    from_user_id INTEGER NOT NULL REFERENCES users(id),
+    to_user_id INTEGER NOT NULL REFERENCES users(id),
+    amount DECIMAL(15,2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    run_interval VARCHAR(20) NOT NULL,
+    next_run_at TIMESTAMP NOT NULL,
+    end_date TIMESTAMP,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS recurring_transfers_due_idx ON recurring_transfers (next_run_at) WHERE status = 'active';
+
+-- +migrate Down
+DROP TABLE IF EXISTS recurring_transfers;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +migrate Up
+UPDATE event_store e
+SET version = ordered.new_version
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY aggregate_type, aggregate_id ORDER BY version, id) AS new_version
+    FROM event_store
+) ordered
+WHERE e.id = ordered.id AND e.version <> ordered.new_version;
+
+CREATE UNIQUE INDEX IF NOT EXISTS event_store_aggregate_version_uidx ON event_store (aggregate_type, aggregate_id, version);
+
+-- +migrate Down
+DROP INDEX IF EXISTS event_store_aggregate_version_uidx;
\ No newline at end of file
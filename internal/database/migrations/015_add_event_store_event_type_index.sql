@@ -0,0 +1,8 @@
+-- +migrate Up
+CREATE INDEX IF NOT EXISTS event_store_event_type_idx ON event_store (event_type, created_at);
+-- event_store_aggregate_version_uidx covers (aggregate_type, aggregate_id, version)
+DROP INDEX IF EXISTS event_store_version_idx;
+
+-- +migrate Down
+CREATE INDEX IF NOT EXISTS event_store_version_idx ON event_store (aggregate_type, aggregate_id, version);
+DROP INDEX IF EXISTS event_store_event_type_idx;
\ No newline at end of file
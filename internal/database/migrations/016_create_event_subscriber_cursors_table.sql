@@ -0,0 +1,9 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS event_subscriber_cursors (
+    subscriber VARCHAR(255) PRIMARY KEY,
+    last_event_id BIGINT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS event_subscriber_cursors;
\ No newline at end of file
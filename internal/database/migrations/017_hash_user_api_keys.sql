@@ -0,0 +1,9 @@
+-- +migrate Up
+UPDATE users SET api_key = NULL WHERE api_key = '';
+UPDATE users SET api_key = encode(sha256(convert_to(api_key, 'UTF8')), 'hex') WHERE api_key IS NOT NULL;
+ALTER TABLE users RENAME COLUMN api_key TO api_key_hash;
+
+-- +migrate Down
+-- Hashed keys cannot be restored; users must generate new API keys.
+ALTER TABLE users RENAME COLUMN api_key_hash TO api_key;
+UPDATE users SET api_key = NULL;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- +migrate Down
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
\ No newline at end of file
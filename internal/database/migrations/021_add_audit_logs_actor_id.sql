@@ -0,0 +1,8 @@
+-- +migrate Up
+-- Existing rows are attributed to the system actor (0).
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS actor_id BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS audit_logs_actor_id_idx ON audit_logs (actor_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS audit_logs_actor_id_idx;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS actor_id;
\ No newline at end of file
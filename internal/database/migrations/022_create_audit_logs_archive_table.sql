@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS audit_logs_archive (
+    id BIGINT PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    details TEXT,
+    actor_id BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS audit_logs_archive_created_at_idx ON audit_logs_archive (created_at);
+CREATE INDEX IF NOT EXISTS audit_logs_created_at_idx ON audit_logs (created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS audit_logs_created_at_idx;
+DROP TABLE IF EXISTS audit_logs_archive;
\ No newline at end of file
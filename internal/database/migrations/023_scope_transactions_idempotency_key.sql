@@ -0,0 +1,8 @@
+-- +migrate Up
+-- Keys are unique per owning user: the sender, or the recipient for deposits.
+DROP INDEX IF EXISTS transactions_idempotency_key_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_owner_idempotency_key_idx ON transactions (COALESCE(from_user_id, to_user_id), idempotency_key);
+
+-- +migrate Down
+DROP INDEX IF EXISTS transactions_owner_idempotency_key_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_idempotency_key_idx ON transactions (idempotency_key);
@@ -0,0 +1,10 @@
+-- +migrate Up
+-- Balances opened before this migration take the time of their first history entry.
+ALTER TABLE balances ADD COLUMN created_at TIMESTAMP;
+UPDATE balances SET created_at = COALESCE(
+    (SELECT MIN(h.created_at) FROM balance_history h WHERE h.user_id = balances.user_id AND h.currency = balances.currency),
+    last_updated_at
+);
+
+-- +migrate Down
+ALTER TABLE balances DROP COLUMN created_at;
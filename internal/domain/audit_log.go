@@ -10,17 +10,28 @@ const (
 	EntityTypeTransaction EntityType = "transaction"
 	EntityTypeBalance     EntityType = "balance"
 
-	ActionTypeCreate ActionType = "create"
-	ActionTypeUpdate ActionType = "update"
-	ActionTypeDelete ActionType = "delete"
+	EntityTypeRecurringTransfer EntityType = "recurring_transfer"
+	EntityTypeAuditLog          EntityType = "audit_log"
+
+	ActionTypeCreate     ActionType = "create"
+	ActionTypeUpdate     ActionType = "update"
+	ActionTypeDelete     ActionType = "delete"
+	ActionTypeRollback   ActionType = "rollback"
+	ActionTypeInitialize ActionType = "initialize"
+	ActionTypeArchive    ActionType = "archive"
 )
 
+// SystemActorID attributes an audit log to the system: background jobs and
+// requests without an authenticated caller.
+const SystemActorID int64 = 0
+
 type AuditLog struct {
 	ID         int64      `json:"id"`
 	EntityType EntityType `json:"entity_type"`
 	EntityID   int64      `json:"entity_id"`
 	Action     ActionType `json:"action"`
 	Details    string     `json:"details,omitempty"`
+	ActorID    int64      `json:"actor_id"`
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
@@ -28,10 +39,17 @@ type AuditLogRepository interface {
 	Create(log *AuditLog) error
 	FindByEntityID(entityType EntityType, entityID int64) ([]*AuditLog, error)
 	FindAll(limit, offset int) ([]*AuditLog, error)
+	Count() (int64, error)
+	StreamByDateRange(start, end time.Time, fn func(*AuditLog) error) error
+	ArchiveOlderThan(cutoff time.Time, limit int) (int64, error)
+	DeleteOlderThan(cutoff time.Time, limit int) (int64, error)
 }
 
 type AuditLogService interface {
-	LogAction(entityType EntityType, entityID int64, action ActionType, details string) error
+	LogAction(entityType EntityType, entityID int64, action ActionType, details string, actorID int64) error
 	GetEntityLogs(entityType EntityType, entityID int64) ([]*AuditLog, error)
-	GetAllLogs(page, pageSize int) ([]*AuditLog, error)
+	GetAllLogs(page, pageSize int) ([]*AuditLog, int64, error)
+	ExportLogs(start, end time.Time, fn func(*AuditLog) error) error
+	Start()
+	Stop()
 }
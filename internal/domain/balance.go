@@ -1,37 +1,125 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func NormalizeCurrency(currency, fallback string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = strings.ToUpper(fallback)
+	}
+
+	if !currencyCodePattern.MatchString(currency) {
+		return "", ErrInvalidCurrency
+	}
+
+	return currency, nil
+}
 
 type Balance struct {
-	UserID        int64     `json:"user_id"`
-	Amount        float64   `json:"amount"`
-	LastUpdatedAt time.Time `json:"last_updated_at"`
+	UserID         int64     `json:"user_id"`
+	Currency       string    `json:"currency"`
+	Amount         Money     `json:"amount"`
+	OverdraftLimit Money     `json:"overdraft_limit"`
+	LastUpdatedAt  time.Time `json:"last_updated_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (b *Balance) CanWithdraw(amount Money) bool {
+	return b.Amount-amount >= -b.OverdraftLimit
+}
+
+type BalanceOperation string
+
+const (
+	BalanceOperationInitialize  BalanceOperation = "initialize"
+	BalanceOperationDeposit     BalanceOperation = "deposit"
+	BalanceOperationWithdraw    BalanceOperation = "withdraw"
+	BalanceOperationTransferIn  BalanceOperation = "transfer_in"
+	BalanceOperationTransferOut BalanceOperation = "transfer_out"
+)
+
+// BalanceEvent is the payload of a balance_updated event. It records the
+// change applied to the balance, not the resulting amount, so the balance can
+// be reconstructed by folding events from zero.
+type BalanceEvent struct {
+	UserID        int64            `json:"user_id"`
+	Currency      string           `json:"currency"`
+	Operation     BalanceOperation `json:"operation"`
+	Amount        Money            `json:"amount"`
+	TransactionID int64            `json:"transaction_id,omitempty"`
+	OccurredAt    time.Time        `json:"occurred_at"`
+}
+
+// Apply folds the event into balance. Events written before deltas were
+// recorded carry no operation and hold the resulting amount instead.
+func (e *BalanceEvent) Apply(balance *Balance) {
+	switch e.Operation {
+	case BalanceOperationDeposit, BalanceOperationTransferIn:
+		balance.Amount += e.Amount
+	case BalanceOperationWithdraw, BalanceOperationTransferOut:
+		balance.Amount -= e.Amount
+	case BalanceOperationInitialize:
+	default:
+		balance.Amount = e.Amount
+	}
+
+	if !e.OccurredAt.IsZero() {
+		balance.LastUpdatedAt = e.OccurredAt
+	}
 }
 
 type BalanceHistory struct {
 	ID             int64     `json:"id"`
 	UserID         int64     `json:"user_id"`
-	Amount         float64   `json:"amount"`
-	PreviousAmount float64   `json:"previous_amount"`
+	Currency       string    `json:"currency"`
+	Amount         Money     `json:"amount"`
+	PreviousAmount Money     `json:"previous_amount"`
 	TransactionID  int64     `json:"transaction_id"`
 	Operation      string    `json:"operation"`
 	CreatedAt      time.Time `json:"created_at"`
 }
 
+type BalanceSnapshot struct {
+	UserID        int64      `json:"user_id"`
+	Currency      string     `json:"currency"`
+	Amount        Money      `json:"amount"`
+	At            time.Time  `json:"at"`
+	AccountExists bool       `json:"account_exists"`
+	LastChangedAt *time.Time `json:"last_changed_at,omitempty"`
+}
+
 type BalanceRepository interface {
-	FindByUserID(userID int64) (*Balance, error)
-	Create(balance *Balance) error
-	Update(balance *Balance) (*Balance, error)
-	InitializeBalance(userID int64) error
-	GetBalanceHistory(userID int64, startTime, endTime time.Time) ([]*Balance, error)
+	FindByUserAndCurrency(ctx context.Context, userID int64, currency string) (*Balance, error)
+	Create(ctx context.Context, balance *Balance) error
+	Update(ctx context.Context, balance *Balance) (*Balance, error)
+	Deposit(ctx context.Context, userID int64, currency string, amount Money, transactionID int64) (*Balance, error)
+	Withdraw(ctx context.Context, userID int64, currency string, amount Money, transactionID int64) (*Balance, error)
+	Transfer(ctx context.Context, fromUserID, toUserID int64, fromCurrency, toCurrency string, amount, convertedAmount Money, transactionID int64) (*Balance, *Balance, error)
+	SetOverdraftLimit(ctx context.Context, userID int64, currency string, limit Money) error
+	InitializeBalance(ctx context.Context, userID int64, currency string) error
+	GetBalanceHistory(ctx context.Context, userID int64, currency string, startTime, endTime time.Time) ([]*BalanceHistory, error)
+	FindLatestHistoryAt(ctx context.Context, userID int64, currency string, at time.Time) (*BalanceHistory, error)
+	FindTopByBalance(ctx context.Context, currency string, limit int) ([]*Balance, error)
+	FindByUsersAndCurrency(ctx context.Context, userIDs []int64, currency string) ([]*Balance, error)
 }
 
 type BalanceService interface {
-	GetBalance(userID int64) (*Balance, error)
-	DepositAtomically(userID int64, amount float64) (*Balance, error)
-	WithdrawAtomically(userID int64, amount float64) (*Balance, error)
-	InitializeBalance(userID int64) error
-	GetBalanceHistory(userID int64, startTime, endTime time.Time) ([]*Balance, error)
-	ReplayBalanceEvents(userID int64) error
+	GetBalance(ctx context.Context, userID int64, currency string) (*Balance, error)
+	GetBalances(ctx context.Context, userIDs []int64, currency string) (map[int64]*Balance, error)
+	DepositAtomically(userID int64, currency string, amount Money, transactionID int64) (*Balance, error)
+	WithdrawAtomically(userID int64, currency string, amount Money, transactionID int64) (*Balance, error)
+	TransferAtomically(fromUserID, toUserID int64, fromCurrency, toCurrency string, amount, convertedAmount Money, transactionID int64) error
+	SetOverdraftLimit(ctx context.Context, userID int64, currency string, limit Money) error
+	InitializeBalance(ctx context.Context, userID int64, currency string) error
+	GetBalanceHistory(ctx context.Context, userID int64, currency string, startTime, endTime time.Time) ([]*BalanceHistory, error)
+	GetBalanceAt(ctx context.Context, userID int64, currency string, at time.Time) (*BalanceSnapshot, error)
+	ReplayBalanceEvents(userID int64, fromVersion int) error
 	RebuildBalanceState(userID int64) error
 }
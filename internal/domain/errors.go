@@ -1,13 +1,35 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	ErrConcurrentModification = errors.New("eşzamanlı değişiklik tespit edildi")
-	ErrInsufficientFunds      = errors.New("yetersiz bakiye")
-	ErrInvalidAmount          = errors.New("geçersiz miktar")
-	ErrInvalidTransaction     = errors.New("geçersiz işlem")
-	ErrUserNotFound           = errors.New("kullanıcı bulunamadı")
-	ErrTransactionNotFound    = errors.New("işlem bulunamadı")
-	ErrBalanceNotFound        = errors.New("bakiye bulunamadı")
+	ErrConcurrentModification  = errors.New("eşzamanlı değişiklik tespit edildi")
+	ErrInsufficientFunds       = errors.New("yetersiz bakiye")
+	ErrInvalidAmount           = errors.New("geçersiz miktar")
+	ErrInvalidTransaction      = errors.New("geçersiz işlem")
+	ErrUserNotFound            = errors.New("kullanıcı bulunamadı")
+	ErrUserInactive            = errors.New("kullanıcı hesabı aktif değil")
+	ErrUserNotDeleted          = errors.New("kullanıcı silinmemiş")
+	ErrInvalidActivationToken  = errors.New("geçersiz veya süresi dolmuş aktivasyon kodu")
+	ErrInvalidResetToken       = errors.New("geçersiz veya süresi dolmuş şifre sıfırlama kodu")
+	ErrTransactionNotFound     = errors.New("işlem bulunamadı")
+	ErrBalanceNotFound         = errors.New("bakiye bulunamadı")
+	ErrDuplicateIdempotencyKey = errors.New("idempotency anahtarı zaten kullanılmış")
+	ErrIdempotencyKeyMismatch  = errors.New("idempotency anahtarı farklı parametrelerle kullanılmış")
+	ErrDuplicateSubscriber     = errors.New("aynı isimle kayıtlı event abonesi var")
+	ErrInvalidCurrency         = errors.New("geçersiz para birimi")
+	ErrTransactionLimit        = errors.New("işlem limiti aşıldı")
+	ErrInvalidScheduleTime     = errors.New("zamanlanan tarih gelecekte olmalı")
+	ErrTransactionNotScheduled = errors.New("işlem zamanlanmış durumda değil")
+
+	ErrInvalidRecurringInterval   = errors.New("geçersiz tekrar aralığı")
+	ErrRecurringTransferNotFound  = errors.New("düzenli transfer bulunamadı")
+	ErrRecurringTransferNotActive = errors.New("düzenli transfer aktif değil")
 )
+
+func InsufficientFundsError(balance *Balance) error {
+	return fmt.Errorf("%w: mevcut bakiye %s %s, kredi limiti %s", ErrInsufficientFunds, balance.Amount, balance.Currency, balance.OverdraftLimit)
+}
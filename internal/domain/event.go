@@ -8,11 +8,16 @@ import (
 type EventType string
 
 const (
-	EventTypeTransactionCreated   EventType = "transaction_created"
-	EventTypeTransactionCompleted EventType = "transaction_completed"
-	EventTypeTransactionFailed    EventType = "transaction_failed"
-	EventTypeBalanceUpdated       EventType = "balance_updated"
-	EventTypeUserCreated          EventType = "user_created"
+	EventTypeTransactionCreated    EventType = "transaction_created"
+	EventTypeTransactionCompleted  EventType = "transaction_completed"
+	EventTypeTransactionFailed     EventType = "transaction_failed"
+	EventTypeTransactionCancelled  EventType = "transaction_cancelled"
+	EventTypeTransactionRolledBack EventType = "transaction_rolled_back"
+	EventTypeBalanceUpdated        EventType = "balance_updated"
+	EventTypeUserCreated           EventType = "user_created"
+	EventTypeUserUpdated           EventType = "user_updated"
+	EventTypeUserDeleted           EventType = "user_deleted"
+	EventTypeUserRestored          EventType = "user_restored"
 )
 
 type Event struct {
@@ -26,12 +31,28 @@ type Event struct {
 	Metadata      json.RawMessage `json:"metadata,omitempty"`
 }
 
+type Snapshot struct {
+	ID            int64           `json:"id"`
+	AggregateID   string          `json:"aggregate_id"`
+	AggregateType string          `json:"aggregate_type"`
+	Version       int             `json:"version"`
+	State         json.RawMessage `json:"state"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
 type EventStoreRepository interface {
 	Save(event *Event) error
 	GetEvents(aggregateType string, aggregateID string) ([]*Event, error)
 	GetEventsByType(eventType EventType) ([]*Event, error)
 	GetEventsByTimeRange(startTime, endTime time.Time) ([]*Event, error)
 	GetLastVersion(aggregateType string, aggregateID string) (int, error)
+	GetEventsAfterVersion(aggregateType string, aggregateID string, version int) ([]*Event, error)
+	GetEventsFromVersion(aggregateType string, aggregateID string, fromVersion int) ([]*Event, error)
+	SaveSnapshot(snapshot *Snapshot) error
+	GetLatestSnapshot(aggregateType string, aggregateID string) (*Snapshot, error)
+	GetEventsByTypeAfterID(eventType EventType, afterID int64, limit int) ([]*Event, error)
+	GetSubscriberCursor(subscriber string) (int64, error)
+	SaveSubscriberCursor(subscriber string, eventID int64) error
 }
 
 type EventStoreService interface {
@@ -41,4 +62,10 @@ type EventStoreService interface {
 	GetEventsByTimeRange(startTime, endTime time.Time) ([]*Event, error)
 	ReplayEvents(aggregateType string, aggregateID string, handler func(*Event) error) error
 	GetLastVersion(aggregateType string, aggregateID string) (int, error)
+	GetEventsAfterVersion(aggregateType string, aggregateID string, version int) ([]*Event, error)
+	GetEventsFromVersion(aggregateType string, aggregateID string, fromVersion int) ([]*Event, error)
+	SaveSnapshot(aggregateType string, aggregateID string, version int, state interface{}) error
+	GetLatestSnapshot(aggregateType string, aggregateID string) (*Snapshot, error)
+	ShouldSnapshot(version int) bool
+	Subscribe(subscriber string, eventType EventType, handler func(*Event) error) error
 }
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor currency units (e.g. kuruş, cent), so ledger
+// arithmetic stays exact. It is encoded as a decimal with two fraction digits.
+type Money int64
+
+const moneyScale = 100
+
+func NewMoneyFromFloat(value float64) Money {
+	return Money(math.Round(value * moneyScale))
+}
+
+func ParseMoney(value string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("%w: boş tutar", ErrInvalidAmount)
+	}
+
+	negative := false
+	switch value[0] {
+	case '-':
+		negative = true
+		value = value[1:]
+	case '+':
+		value = value[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("%w: en fazla iki ondalık basamak desteklenir: %q", ErrInvalidAmount, value)
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	var cents int64
+	if fraction != "" {
+		fraction += strings.Repeat("0", 2-len(fraction))
+		cents, err = strconv.ParseInt(fraction, 10, 64)
+		if err != nil || cents < 0 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+		}
+	}
+
+	if units > (math.MaxInt64-cents)/moneyScale {
+		return 0, fmt.Errorf("%w: tutar çok büyük: %q", ErrInvalidAmount, value)
+	}
+
+	amount := Money(units*moneyScale + cents)
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / moneyScale
+}
+
+func (m Money) String() string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, value/moneyScale, value%moneyScale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	value := string(data)
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+
+	if strings.ContainsAny(value, "eE") {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+		}
+		value = strconv.FormatFloat(parsed, 'f', -1, 64)
+	}
+
+	amount, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+
+	*m = amount
+	return nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		amount, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = amount
+		return nil
+	case string:
+		amount, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = amount
+		return nil
+	case int64:
+		*m = Money(v * moneyScale)
+		return nil
+	case float64:
+		*m = NewMoneyFromFloat(v)
+		return nil
+	default:
+		return fmt.Errorf("tutar okunamadı: desteklenmeyen tip %T", src)
+	}
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
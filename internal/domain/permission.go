@@ -0,0 +1,39 @@
+package domain
+
+const (
+	PermissionTransactionsRead     = "transactions.read"
+	PermissionTransactionsRollback = "transactions.rollback"
+	PermissionTransactionsManage   = "transactions.manage"
+	PermissionAuditLogsRead        = "audit_logs.read"
+	PermissionAuditLogsWrite       = "audit_logs.write"
+	PermissionAuditLogsExport      = "audit_logs.export"
+	PermissionUsersRead            = "users.read"
+	PermissionUsersManage          = "users.manage"
+	PermissionSystemManage         = "system.manage"
+)
+
+// RolePermissions maps a role to the permissions it grants. Admins are
+// superusers and pass every check regardless of the map.
+type RolePermissions map[string]map[string]struct{}
+
+func NewRolePermissions(roles map[string][]string) RolePermissions {
+	rp := make(RolePermissions, len(roles))
+	for role, permissions := range roles {
+		set := make(map[string]struct{}, len(permissions))
+		for _, permission := range permissions {
+			set[permission] = struct{}{}
+		}
+		rp[role] = set
+	}
+
+	return rp
+}
+
+func (rp RolePermissions) Allows(role, permission string) bool {
+	if role == UserRoleAdmin {
+		return true
+	}
+
+	_, ok := rp[role][permission]
+	return ok
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type RecurringInterval string
+type RecurringTransferStatus string
+
+const (
+	RecurringIntervalWeekly  RecurringInterval = "weekly"
+	RecurringIntervalMonthly RecurringInterval = "monthly"
+
+	RecurringTransferStatusActive    RecurringTransferStatus = "active"
+	RecurringTransferStatusCompleted RecurringTransferStatus = "completed"
+	RecurringTransferStatusCancelled RecurringTransferStatus = "cancelled"
+)
+
+func (i RecurringInterval) IsValid() bool {
+	switch i {
+	case RecurringIntervalWeekly, RecurringIntervalMonthly:
+		return true
+	}
+	return false
+}
+
+func (i RecurringInterval) Next(from time.Time) time.Time {
+	switch i {
+	case RecurringIntervalWeekly:
+		return from.AddDate(0, 0, 7)
+	case RecurringIntervalMonthly:
+		return from.AddDate(0, 1, 0)
+	}
+	return from
+}
+
+type RecurringTransfer struct {
+	ID         int64                   `json:"id"`
+	FromUserID int64                   `json:"from_user_id"`
+	ToUserID   int64                   `json:"to_user_id"`
+	Amount     Money                   `json:"amount"`
+	Currency   string                  `json:"currency"`
+	Interval   RecurringInterval       `json:"interval"`
+	NextRunAt  time.Time               `json:"next_run_at"`
+	EndDate    *time.Time              `json:"end_date,omitempty"`
+	Status     RecurringTransferStatus `json:"status"`
+	CreatedAt  time.Time               `json:"created_at"`
+}
+
+// OccurrenceKey is the idempotency key of the transaction materialized for the
+// occurrence at the given time, so an occurrence is created at most once.
+func (r *RecurringTransfer) OccurrenceKey(at time.Time) string {
+	return fmt.Sprintf("recurring:%d:%s", r.ID, at.Format("20060102"))
+}
+
+type RecurringTransferRepository interface {
+	Create(transfer *RecurringTransfer) error
+	FindByID(id int64) (*RecurringTransfer, error)
+	FindDue(dueBefore time.Time, limit int) ([]*RecurringTransfer, error)
+	Advance(id int64, currentRunAt, nextRunAt time.Time, status RecurringTransferStatus) (bool, error)
+	UpdateStatus(id int64, from, to RecurringTransferStatus) (bool, error)
+}
+
+type RecurringTransferService interface {
+	CreateRecurringTransfer(ctx context.Context, fromUserID, toUserID int64, amount Money, interval RecurringInterval, startAt time.Time, endDate *time.Time) (*RecurringTransfer, error)
+	GetRecurringTransfer(id int64) (*RecurringTransfer, error)
+	CancelRecurringTransfer(ctx context.Context, id int64) error
+	Start()
+	Stop()
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"time"
 )
 
@@ -13,50 +14,173 @@ const (
 	TransactionTypeTransfer TransactionType = "transfer"
 
 	TransactionStatusPending    TransactionStatus = "pending"
+	TransactionStatusProcessing TransactionStatus = "processing"
 	TransactionStatusCompleted  TransactionStatus = "completed"
 	TransactionStatusFailed     TransactionStatus = "failed"
 	TransactionStatusRolledBack TransactionStatus = "rolled_back"
+	TransactionStatusScheduled  TransactionStatus = "scheduled"
+	TransactionStatusCancelled  TransactionStatus = "cancelled"
 )
 
+func (s TransactionStatus) IsValid() bool {
+	switch s {
+	case TransactionStatusPending,
+		TransactionStatusProcessing,
+		TransactionStatusCompleted,
+		TransactionStatusFailed,
+		TransactionStatusRolledBack,
+		TransactionStatusScheduled,
+		TransactionStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (s TransactionStatus) IsTerminal() bool {
+	switch s {
+	case TransactionStatusCompleted,
+		TransactionStatusFailed,
+		TransactionStatusRolledBack,
+		TransactionStatusCancelled:
+		return true
+	}
+	return false
+}
+
 type TransactionStats struct {
 	Submitted      int64
 	Completed      int64
 	Failed         int64
 	Rejected       int64
 	AvgProcessTime time.Duration
+	P50ProcessTime time.Duration
+	P95ProcessTime time.Duration
+	P99ProcessTime time.Duration
 	QueueLength    int
 	QueueCapacity  int
+	NumWorkers     int
+	BusyWorkers    int
+	IdleWorkers    int
 }
 
 type Transaction struct {
 	ID         int64             `json:"id"`
 	FromUserID *int64            `json:"from_user_id,omitempty"`
 	ToUserID   *int64            `json:"to_user_id,omitempty"`
-	Amount     float64           `json:"amount"`
+	Amount     Money             `json:"amount"`
+	Currency   string            `json:"currency"`
 	Type       TransactionType   `json:"type"`
 	Status     TransactionStatus `json:"status"`
 	CreatedAt  time.Time         `json:"created_at"`
+
+	ToCurrency      string  `json:"to_currency,omitempty"`
+	ConvertedAmount Money   `json:"converted_amount,omitempty"`
+	ExchangeRate    float64 `json:"exchange_rate,omitempty"`
+
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// IdempotencyOwnerID is the user an idempotency key is scoped to: the sender,
+// or the recipient for deposits.
+func (t *Transaction) IdempotencyOwnerID() int64 {
+	if t.FromUserID != nil {
+		return *t.FromUserID
+	}
+	if t.ToUserID != nil {
+		return *t.ToUserID
+	}
+	return 0
+}
+
+// SameRequest reports whether other was created from the same parameters, so
+// a retried request can be told apart from a reused idempotency key.
+func (t *Transaction) SameRequest(other *Transaction) bool {
+	return t.Type == other.Type &&
+		sameUserID(t.FromUserID, other.FromUserID) &&
+		sameUserID(t.ToUserID, other.ToUserID) &&
+		t.Amount == other.Amount &&
+		t.Currency == other.Currency &&
+		t.TargetCurrency() == other.TargetCurrency()
+}
+
+func sameUserID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (t *Transaction) TargetCurrency() string {
+	if t.ToCurrency != "" {
+		return t.ToCurrency
+	}
+	return t.Currency
+}
+
+func (t *Transaction) TargetAmount() Money {
+	if t.ToCurrency != "" && t.ToCurrency != t.Currency {
+		return t.ConvertedAmount
+	}
+	return t.Amount
+}
+
+type TransactionFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+type TransactionPage struct {
+	Transactions []*Transaction `json:"transactions"`
+	TotalCount   int64          `json:"total_count"`
+	Page         int            `json:"page"`
+	PageSize     int            `json:"page_size"`
+}
+
+// TransactionSummary aggregates all transactions; ActiveUsers counts users
+// with a transaction since the time passed to Summarize.
+type TransactionSummary struct {
+	TotalTransactions int64            `json:"total_transactions"`
+	CompletedVolume   map[string]Money `json:"completed_volume"`
+	ActiveUsers       int64            `json:"active_users"`
 }
 
 type TransactionRepository interface {
-	FindByID(id int64) (*Transaction, error)
-	FindByUserID(userID int64) ([]*Transaction, error)
-	Create(transaction *Transaction) error
-	UpdateStatus(id int64, status TransactionStatus) error
+	FindByID(ctx context.Context, id int64) (*Transaction, error)
+	FindByUserID(ctx context.Context, userID int64) ([]*Transaction, error)
+	FindByUserIDPaginated(ctx context.Context, userID int64, filter TransactionFilter, limit, offset int) ([]*Transaction, int64, error)
+	FindByIdempotencyKey(ctx context.Context, userID int64, key string) (*Transaction, error)
+	FindByStatus(ctx context.Context, status TransactionStatus, createdBefore time.Time, limit, offset int) ([]*Transaction, error)
+	CountByStatus(ctx context.Context, status TransactionStatus, createdBefore time.Time) (int64, error)
+	Create(ctx context.Context, transaction *Transaction) error
+	UpdateStatus(ctx context.Context, id int64, status TransactionStatus) error
+	TransitionStatus(ctx context.Context, id int64, from, to TransactionStatus) (bool, error)
+	FindDueScheduled(ctx context.Context, dueBefore time.Time, limit int) ([]*Transaction, error)
+	SumUserTransactionsSince(ctx context.Context, userID int64, since time.Time) (map[string]Money, error)
+	FindRecent(ctx context.Context, limit int) ([]*Transaction, error)
+	Summarize(ctx context.Context, since time.Time) (*TransactionSummary, error)
 }
 
 type TransactionService interface {
-	GetTransactionByID(id int64) (*Transaction, error)
-	GetUserTransactions(userID int64) ([]*Transaction, error)
-	DepositFunds(userID int64, amount float64) (*Transaction, error)
-	WithdrawFunds(userID int64, amount float64) (*Transaction, error)
-	TransferFunds(fromUserID, toUserID int64, amount float64) (*Transaction, error)
+	GetTransactionByID(ctx context.Context, id int64) (*Transaction, error)
+	GetUserTransactions(ctx context.Context, userID int64) ([]*Transaction, error)
+	GetUserTransactionsPaginated(ctx context.Context, userID int64, filter TransactionFilter, page, pageSize int) (*TransactionPage, error)
+	GetTransactionsByStatus(ctx context.Context, status TransactionStatus, olderThan time.Duration, page, pageSize int) ([]*Transaction, int64, error)
+	DepositFunds(ctx context.Context, userID int64, amount Money, currency, idempotencyKey string) (*Transaction, error)
+	WithdrawFunds(ctx context.Context, userID int64, amount Money, currency, idempotencyKey string) (*Transaction, error)
+	TransferFunds(ctx context.Context, fromUserID, toUserID int64, amount Money, fromCurrency, toCurrency, idempotencyKey string) (*Transaction, error)
+	ScheduleTransfer(ctx context.Context, fromUserID, toUserID int64, amount Money, at time.Time) (*Transaction, error)
+	CancelScheduledTransfer(ctx context.Context, id int64) error
+	ValidateTransfer(ctx context.Context, fromUserID, toUserID int64, amount Money, currency string) error
+	SubscribeStatus(transactionID int64) (<-chan *Transaction, func())
 
 	GetWorkerPoolStats() (TransactionStats, error)
-	ProcessBatchTransactions(transactions []*Transaction) (processed int, failed int, err error)
+	ResizeWorkerPool(numWorkers int) error
+	ProcessBatchTransactions(ctx context.Context, transactions []*Transaction) (processed int, failed int, err error)
 	Shutdown()
-	RollbackTransaction(transactionID int64) error
-	IsTransactionEligibleForRollback(transactionID int64) (bool, error)
-	ReplayTransactionEvents(transactionID int64) error
+	RollbackTransaction(ctx context.Context, transactionID, actorID int64) error
+	IsTransactionEligibleForRollback(ctx context.Context, transactionID int64) (bool, error)
+	ReplayTransactionEvents(transactionID int64, fromVersion int) error
 	RebuildTransactionState(transactionID int64) error
 }
@@ -3,29 +3,86 @@ package domain
 import "time"
 
 const (
-	UserRoleAdmin = "admin"
-	UserRoleUser  = "user"
+	UserRoleAdmin   = "admin"
+	UserRoleUser    = "user"
+	UserRoleSupport = "support"
+	UserRoleAuditor = "auditor"
 )
 
+// PasswordChangedAt revokes JWTs issued before a password reset. It is
+// serialized because the auth middleware reads users from the cache.
 type User struct {
-	ID           int64     `json:"id"`
-	Username     string    `json:"username"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	Role         string    `json:"role"`
-	ApiKey       string    `json:"api_key,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                int64      `json:"id"`
+	Username          string     `json:"username"`
+	Email             string     `json:"email"`
+	PasswordHash      string     `json:"-"`
+	Role              string     `json:"role"`
+	ApiKeyHash        string     `json:"-"`
+	IsActive          bool       `json:"is_active"`
+	EmailVerifiedAt   *time.Time `json:"email_verified_at,omitempty"`
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type UserChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// UserEvent is the payload of user events. Credentials are never included;
+// Changes is only set on user_updated events.
+type UserEvent struct {
+	UserID   int64                 `json:"user_id"`
+	Username string                `json:"username"`
+	Email    string                `json:"email"`
+	Role     string                `json:"role"`
+	Changes  map[string]UserChange `json:"changes,omitempty"`
+}
+
+func DiffUsers(before, after *User) map[string]UserChange {
+	changes := make(map[string]UserChange)
+
+	if before.Username != after.Username {
+		changes["username"] = UserChange{Old: before.Username, New: after.Username}
+	}
+	if before.Email != after.Email {
+		changes["email"] = UserChange{Old: before.Email, New: after.Email}
+	}
+	if before.Role != after.Role {
+		changes["role"] = UserChange{Old: before.Role, New: after.Role}
+	}
+
+	return changes
 }
 
 type UserRepository interface {
 	FindByID(id int64) (*User, error)
 	FindByUsername(username string) (*User, error)
 	FindByEmail(email string) (*User, error)
-	FindByApiKey(apiKey string) (*User, error)
+	FindByApiKeyHash(apiKeyHash string) (*User, error)
+	FindAll(limit, offset int, search string) ([]*User, error)
+	Count(search string) (int64, error)
+	FindByIDIncludingDeleted(id int64) (*User, error)
 	Create(user *User) error
 	Update(user *User) error
 	Delete(id int64) error
+	Restore(id int64) error
+
+	CreateActivationToken(userID int64, tokenHash string, expiresAt time.Time) error
+	ConsumeActivationToken(tokenHash string, now time.Time) (int64, error)
+	Activate(userID int64, verifiedAt time.Time) error
+
+	CreatePasswordResetToken(userID int64, tokenHash string, expiresAt time.Time) error
+	ConsumePasswordResetToken(tokenHash string, now time.Time) (int64, error)
+	ResetPassword(userID int64, passwordHash string, changedAt time.Time) error
+}
+
+// UserNotifier delivers account tokens to users, e.g. by email.
+type UserNotifier interface {
+	SendActivationToken(user *User, token string, expiresAt time.Time) error
+	SendPasswordResetToken(user *User, token string, expiresAt time.Time) error
 }
 
 type UserService interface {
@@ -33,13 +90,22 @@ type UserService interface {
 	GetUserByUsername(username string) (*User, error)
 	GetUserByEmail(email string) (*User, error)
 	GetUserByApiKey(apiKey string) (*User, error)
-	CreateUser(user *User) error
-	UpdateUser(user *User) error
-	DeleteUser(id int64) error
+	ListUsers(page, pageSize int, search string) ([]*User, int64, error)
+	CreateUser(user *User, actorID int64) error
+	UpdateUser(user *User, actorID int64) error
+	DeleteUser(id, actorID int64) error
+	RestoreUser(id, actorID int64) error
+	GetUserByIDIncludingDeleted(id int64) (*User, error)
 	GenerateApiKey(userID int64) (string, error)
+	HashPassword(password string) (string, error)
+	GenerateActivationToken(userID int64) (string, error)
+	ActivateUser(token string) (*User, error)
+	RequestPasswordReset(email string) error
+	ResetPassword(token, newPassword string) (*User, error)
 
 	HasAdminRole(userID int64) (bool, error)
 	CheckPermission(userID int64, requiredRole string) (bool, error)
+	HasPermission(userID int64, permission string) (bool, error)
 
 	Login(username, password string) (string, error)
 }
@@ -6,25 +6,28 @@ import (
 	"time"
 
 	"payflow/internal/domain"
+	"payflow/pkg/database"
 	"payflow/pkg/logger"
 )
 
 type AuditLogRepository struct {
 	db     *sql.DB
+	conn   database.Router
 	logger logger.Logger
 }
 
-func NewAuditLogRepository(db *sql.DB, logger logger.Logger) domain.AuditLogRepository {
+func NewAuditLogRepository(conn database.Router, logger logger.Logger) domain.AuditLogRepository {
 	return &AuditLogRepository{
-		db:     db,
+		db:     conn.GetWriteDB(),
+		conn:   conn,
 		logger: logger,
 	}
 }
 
 func (r *AuditLogRepository) Create(log *domain.AuditLog) error {
 	query := `
-		INSERT INTO audit_logs (entity_type, entity_id, action, details, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO audit_logs (entity_type, entity_id, action, details, actor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id
 	`
 
@@ -36,11 +39,12 @@ func (r *AuditLogRepository) Create(log *domain.AuditLog) error {
 		log.EntityID,
 		string(log.Action),
 		log.Details,
+		log.ActorID,
 		log.CreatedAt,
 	).Scan(&log.ID)
 
 	if err != nil {
-		r.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"error": err.Error()})
+		r.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, nil)
 		return fmt.Errorf("denetim kaydı oluşturulamadı: %w", err)
 	}
 
@@ -49,13 +53,13 @@ func (r *AuditLogRepository) Create(log *domain.AuditLog) error {
 
 func (r *AuditLogRepository) FindByEntityID(entityType domain.EntityType, entityID int64) ([]*domain.AuditLog, error) {
 	query := `
-		SELECT id, entity_type, entity_id, action, details, created_at
+		SELECT id, entity_type, entity_id, action, details, actor_id, created_at
 		FROM audit_logs
 		WHERE entity_type = $1 AND entity_id = $2
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, string(entityType), entityID)
+	rows, err := r.conn.GetReadDB().Query(query, string(entityType), entityID)
 	if err != nil {
 		r.logger.Error("Denetim kayıtları bulunamadı", map[string]interface{}{
 			"entity_type": entityType,
@@ -77,10 +81,11 @@ func (r *AuditLogRepository) FindByEntityID(entityType domain.EntityType, entity
 			&log.EntityID,
 			&actionStr,
 			&log.Details,
+			&log.ActorID,
 			&log.CreatedAt,
 		)
 		if err != nil {
-			r.logger.Error("Denetim kaydı verileri okunamadı", map[string]interface{}{"error": err.Error()})
+			r.logger.ErrorWithErr("Denetim kaydı verileri okunamadı", err, nil)
 			return nil, fmt.Errorf("denetim kaydı verileri okunamadı: %w", err)
 		}
 
@@ -91,22 +96,32 @@ func (r *AuditLogRepository) FindByEntityID(entityType domain.EntityType, entity
 	}
 
 	if err = rows.Err(); err != nil {
-		r.logger.Error("Satır döngüsü sırasında hata oluştu", map[string]interface{}{"error": err.Error()})
+		r.logger.ErrorWithErr("Satır döngüsü sırasında hata oluştu", err, nil)
 		return nil, fmt.Errorf("denetim kaydı verileri okunamadı: %w", err)
 	}
 
 	return logs, nil
 }
 
+func (r *AuditLogRepository) Count() (int64, error) {
+	var count int64
+	if err := r.conn.GetReadDB().QueryRow(`SELECT COUNT(*) FROM audit_logs`).Scan(&count); err != nil {
+		r.logger.ErrorWithErr("Denetim kaydı sayısı alınamadı", err, nil)
+		return 0, fmt.Errorf("denetim kaydı sayısı alınamadı: %w", err)
+	}
+
+	return count, nil
+}
+
 func (r *AuditLogRepository) FindAll(limit, offset int) ([]*domain.AuditLog, error) {
 	query := `
-		SELECT id, entity_type, entity_id, action, details, created_at
+		SELECT id, entity_type, entity_id, action, details, actor_id, created_at
 		FROM audit_logs
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.conn.GetReadDB().Query(query, limit, offset)
 	if err != nil {
 		r.logger.Error("Denetim kayıtları bulunamadı", map[string]interface{}{
 			"limit":  limit,
@@ -128,10 +143,11 @@ func (r *AuditLogRepository) FindAll(limit, offset int) ([]*domain.AuditLog, err
 			&log.EntityID,
 			&actionStr,
 			&log.Details,
+			&log.ActorID,
 			&log.CreatedAt,
 		)
 		if err != nil {
-			r.logger.Error("Denetim kaydı verileri okunamadı", map[string]interface{}{"error": err.Error()})
+			r.logger.ErrorWithErr("Denetim kaydı verileri okunamadı", err, nil)
 			return nil, fmt.Errorf("denetim kaydı verileri okunamadı: %w", err)
 		}
 
@@ -142,9 +158,162 @@ func (r *AuditLogRepository) FindAll(limit, offset int) ([]*domain.AuditLog, err
 	}
 
 	if err = rows.Err(); err != nil {
-		r.logger.Error("Satır döngüsü sırasında hata oluştu", map[string]interface{}{"error": err.Error()})
+		r.logger.ErrorWithErr("Satır döngüsü sırasında hata oluştu", err, nil)
 		return nil, fmt.Errorf("denetim kaydı verileri okunamadı: %w", err)
 	}
 
 	return logs, nil
 }
+
+// StreamByDateRange calls fn for every log in [start, end) in creation order,
+// reading rows one at a time so large exports are not held in memory. A zero
+// start or end leaves that side open. Iteration stops at the first error
+// returned by fn.
+func (r *AuditLogRepository) StreamByDateRange(start, end time.Time, fn func(*domain.AuditLog) error) error {
+	query := `
+		SELECT id, entity_type, entity_id, action, details, actor_id, created_at
+		FROM audit_logs
+		WHERE (CAST($1 AS TIMESTAMP) IS NULL OR created_at >= $1)
+		  AND (CAST($2 AS TIMESTAMP) IS NULL OR created_at < $2)
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.conn.GetReadDB().Query(query, nullableTime(start), nullableTime(end))
+	if err != nil {
+		r.logger.ErrorWithErr("Denetim kayıtları dışa aktarılamadı", err, nil)
+		return fmt.Errorf("denetim kayıtları bulunamadı: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log domain.AuditLog
+		var entityTypeStr, actionStr string
+
+		err := rows.Scan(
+			&log.ID,
+			&entityTypeStr,
+			&log.EntityID,
+			&actionStr,
+			&log.Details,
+			&log.ActorID,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			r.logger.ErrorWithErr("Denetim kaydı verileri okunamadı", err, nil)
+			return fmt.Errorf("denetim kaydı verileri okunamadı: %w", err)
+		}
+
+		log.EntityType = domain.EntityType(entityTypeStr)
+		log.Action = domain.ActionType(actionStr)
+
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.ErrorWithErr("Satır döngüsü sırasında hata oluştu", err, nil)
+		return fmt.Errorf("denetim kaydı verileri okunamadı: %w", err)
+	}
+
+	return nil
+}
+
+// ArchiveOlderThan moves at most limit rows created before cutoff into
+// audit_logs_archive in a single statement and returns how many were moved.
+func (r *AuditLogRepository) ArchiveOlderThan(cutoff time.Time, limit int) (int64, error) {
+	if !r.conn.Dialect().SupportsWritableCTE() {
+		return r.archiveOlderThanInTx(cutoff, limit)
+	}
+
+	query := `
+		WITH moved AS (
+			DELETE FROM audit_logs
+			WHERE id IN (
+				SELECT id FROM audit_logs
+				WHERE created_at < $1
+				ORDER BY id
+				LIMIT $2
+			)
+			RETURNING id, entity_type, entity_id, action, details, actor_id, created_at
+		)
+		INSERT INTO audit_logs_archive (id, entity_type, entity_id, action, details, actor_id, created_at)
+		SELECT id, entity_type, entity_id, action, details, actor_id, created_at FROM moved
+	`
+
+	result, err := r.db.Exec(query, cutoff, limit)
+	if err != nil {
+		r.logger.ErrorWithErr("Denetim kayıtları arşivlenemedi", err, nil)
+		return 0, fmt.Errorf("denetim kayıtları arşivlenemedi: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// archiveOlderThanInTx is the two-statement form of ArchiveOlderThan. The
+// INSERT takes the write lock, so the DELETE sees the same batch.
+func (r *AuditLogRepository) archiveOlderThanInTx(cutoff time.Time, limit int) (int64, error) {
+	batch := `
+		SELECT id FROM audit_logs
+		WHERE created_at < $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("denetim kayıtları arşivlenemedi: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO audit_logs_archive (id, entity_type, entity_id, action, details, actor_id, created_at)
+		SELECT id, entity_type, entity_id, action, details, actor_id, created_at
+		FROM audit_logs
+		WHERE id IN (`+batch+`)
+	`, cutoff, limit)
+	if err != nil {
+		r.logger.ErrorWithErr("Denetim kayıtları arşivlenemedi", err, nil)
+		return 0, fmt.Errorf("denetim kayıtları arşivlenemedi: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM audit_logs WHERE id IN (`+batch+`)`, cutoff, limit); err != nil {
+		r.logger.ErrorWithErr("Denetim kayıtları arşivlenemedi", err, nil)
+		return 0, fmt.Errorf("denetim kayıtları arşivlenemedi: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("denetim kayıtları arşivlenemedi: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteOlderThan deletes at most limit rows created before cutoff and
+// returns how many were deleted.
+func (r *AuditLogRepository) DeleteOlderThan(cutoff time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM audit_logs
+		WHERE id IN (
+			SELECT id FROM audit_logs
+			WHERE created_at < $1
+			ORDER BY id
+			LIMIT $2
+		)
+	`
+
+	result, err := r.db.Exec(query, cutoff, limit)
+	if err != nil {
+		r.logger.ErrorWithErr("Denetim kayıtları silinemedi", err, nil)
+		return 0, fmt.Errorf("denetim kayıtları silinemedi: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"payflow/internal/domain"
+)
+
+func TestAuditLogRepositoryOnSQLite(t *testing.T) {
+	router := newTestDB(t)
+	repo := NewAuditLogRepository(router, newTestLogger())
+
+	before, err := repo.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, action := range []domain.ActionType{domain.ActionTypeCreate, domain.ActionTypeUpdate, domain.ActionTypeDelete} {
+		log := &domain.AuditLog{EntityType: domain.EntityTypeUser, EntityID: 42, Action: action, Details: "test", ActorID: int64(i + 1)}
+		if err := repo.Create(log); err != nil {
+			t.Fatal(err)
+		}
+		if log.ID == 0 {
+			t.Fatal("oluşturulan kayda ID atanmalı")
+		}
+	}
+
+	logs, err := repo.FindByEntityID(domain.EntityTypeUser, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 3 {
+		t.Fatalf("3 kayıt beklenirdi, alınan: %d", len(logs))
+	}
+
+	var streamed []domain.ActionType
+	err = repo.StreamByDateRange(time.Time{}, time.Time{}, func(log *domain.AuditLog) error {
+		if log.EntityID == 42 {
+			streamed = append(streamed, log.Action)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streamed) != 3 || streamed[0] != domain.ActionTypeCreate || streamed[2] != domain.ActionTypeDelete {
+		t.Fatalf("kayıtlar oluşturulma sırasıyla akmalı, alınan: %v", streamed)
+	}
+
+	// SQLite has no writable CTEs, so this takes the two-statement path.
+	moved, err := repo.ArchiveOlderThan(time.Now().Add(time.Second), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 2 {
+		t.Fatalf("2 kayıt arşivlenmeli, arşivlenen: %d", moved)
+	}
+
+	var archived int
+	if err := router.db.QueryRow(`SELECT COUNT(*) FROM audit_logs_archive`).Scan(&archived); err != nil {
+		t.Fatal(err)
+	}
+	if archived != 2 {
+		t.Fatalf("arşiv tablosunda 2 kayıt olmalı, bulunan: %d", archived)
+	}
+
+	after, err := repo.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != before+1 {
+		t.Fatalf("arşivlenen kayıtlar silinmeli, kalan: %d", after-before)
+	}
+}
@@ -1,38 +1,51 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"payflow/internal/domain"
+	"payflow/pkg/database"
 	"payflow/pkg/logger"
 )
 
 type BalanceRepository struct {
-	db     *sql.DB
-	logger logger.Logger
+	db      *sql.DB
+	conn    database.Router
+	logger  logger.Logger
+	timeout time.Duration
 }
 
-func NewBalanceRepository(db *sql.DB, logger logger.Logger) domain.BalanceRepository {
+func NewBalanceRepository(conn database.Router, logger logger.Logger, queryTimeout time.Duration) domain.BalanceRepository {
 	return &BalanceRepository{
-		db:     db,
-		logger: logger,
+		db:      conn.GetWriteDB(),
+		conn:    conn,
+		logger:  logger,
+		timeout: queryTimeout,
 	}
 }
 
-func (r *BalanceRepository) FindByUserID(userID int64) (*domain.Balance, error) {
+func (r *BalanceRepository) FindByUserAndCurrency(ctx context.Context, userID int64, currency string) (*domain.Balance, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		SELECT user_id, amount, last_updated_at
+		SELECT user_id, currency, amount, overdraft_limit, last_updated_at, created_at
 		FROM balances
-		WHERE user_id = $1
+		WHERE user_id = $1 AND currency = $2
 	`
 
 	var balance domain.Balance
-	err := r.db.QueryRow(query, userID).Scan(
+	err := r.db.QueryRowContext(ctx, query, userID, currency).Scan(
 		&balance.UserID,
+		&balance.Currency,
 		&balance.Amount,
+		&balance.OverdraftLimit,
 		&balance.LastUpdatedAt,
+		&balance.CreatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -41,8 +54,9 @@ func (r *BalanceRepository) FindByUserID(userID int64) (*domain.Balance, error)
 
 	if err != nil {
 		r.logger.Error("Bakiye bulunamadı", map[string]interface{}{
-			"user_id": userID,
-			"error":   err.Error(),
+			"user_id":  userID,
+			"currency": currency,
+			"error":    err.Error(),
 		})
 		return nil, err
 	}
@@ -50,107 +64,543 @@ func (r *BalanceRepository) FindByUserID(userID int64) (*domain.Balance, error)
 	return &balance, nil
 }
 
-func (r *BalanceRepository) Create(balance *domain.Balance) error {
+// FindTopByBalance ranks users of non-deleted accounts by their balance in
+// currency, highest first.
+func (r *BalanceRepository) FindTopByBalance(ctx context.Context, currency string, limit int) ([]*domain.Balance, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT b.user_id, b.currency, b.amount, b.overdraft_limit, b.last_updated_at, b.created_at
+		FROM balances b
+		JOIN users u ON u.id = b.user_id
+		WHERE b.currency = $1 AND u.deleted_at IS NULL
+		ORDER BY b.amount DESC, b.user_id
+		LIMIT $2
+	`
+
+	rows, err := r.conn.GetReadDB().QueryContext(ctx, query, currency, limit)
+	if err != nil {
+		r.logger.Error("En yüksek bakiyeler bulunamadı", map[string]interface{}{
+			"currency": currency,
+			"limit":    limit,
+			"error":    err.Error(),
+		})
+		return nil, fmt.Errorf("en yüksek bakiyeler bulunamadı: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make([]*domain.Balance, 0)
+	for rows.Next() {
+		var balance domain.Balance
+		if err := rows.Scan(
+			&balance.UserID,
+			&balance.Currency,
+			&balance.Amount,
+			&balance.OverdraftLimit,
+			&balance.LastUpdatedAt,
+			&balance.CreatedAt,
+		); err != nil {
+			r.logger.ErrorWithErr("Bakiye verileri okunamadı", err, nil)
+			return nil, fmt.Errorf("bakiye verileri okunamadı: %w", err)
+		}
+		balances = append(balances, &balance)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.ErrorWithErr("Satır döngüsü sırasında hata oluştu", err, nil)
+		return nil, fmt.Errorf("bakiye verileri okunamadı: %w", err)
+	}
+
+	return balances, nil
+}
+
+// FindByUsersAndCurrency returns the existing balances of userIDs in currency;
+// users without a balance are simply absent from the result.
+func (r *BalanceRepository) FindByUsersAndCurrency(ctx context.Context, userIDs []int64, currency string) ([]*domain.Balance, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// An explicit IN list instead of ANY($1) keeps the query portable across
+	// dialects; callers bound the number of ids.
+	args := make([]interface{}, 0, len(userIDs)+1)
+	args = append(args, currency)
+	placeholders := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		args = append(args, userID)
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+	}
+
+	query := `
+		SELECT user_id, currency, amount, overdraft_limit, last_updated_at, created_at
+		FROM balances
+		WHERE currency = $1 AND user_id IN (` + strings.Join(placeholders, ", ") + `)
+	`
+
+	rows, err := r.conn.GetReadDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Bakiyeler bulunamadı", map[string]interface{}{
+			"users":    len(userIDs),
+			"currency": currency,
+			"error":    err.Error(),
+		})
+		return nil, fmt.Errorf("bakiyeler bulunamadı: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make([]*domain.Balance, 0, len(userIDs))
+	for rows.Next() {
+		var balance domain.Balance
+		if err := rows.Scan(
+			&balance.UserID,
+			&balance.Currency,
+			&balance.Amount,
+			&balance.OverdraftLimit,
+			&balance.LastUpdatedAt,
+			&balance.CreatedAt,
+		); err != nil {
+			r.logger.ErrorWithErr("Bakiye verileri okunamadı", err, nil)
+			return nil, fmt.Errorf("bakiye verileri okunamadı: %w", err)
+		}
+		balances = append(balances, &balance)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.ErrorWithErr("Satır döngüsü sırasında hata oluştu", err, nil)
+		return nil, fmt.Errorf("bakiye verileri okunamadı: %w", err)
+	}
+
+	return balances, nil
+}
+
+func (r *BalanceRepository) Create(ctx context.Context, balance *domain.Balance) error {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		INSERT INTO balances (user_id, amount, last_updated_at)
-		VALUES ($1, $2, $3)
+		INSERT INTO balances (user_id, currency, amount, last_updated_at, created_at)
+		VALUES ($1, $2, $3, $4, $4)
 	`
 
 	balance.LastUpdatedAt = time.Now()
+	balance.CreatedAt = balance.LastUpdatedAt
 
-	_, err := r.db.Exec(
+	_, err := r.db.ExecContext(ctx,
 		query,
 		balance.UserID,
+		balance.Currency,
 		balance.Amount,
 		balance.LastUpdatedAt,
 	)
 
 	if err != nil {
-		r.logger.Error("Bakiye oluşturulamadı", map[string]interface{}{"user_id": balance.UserID, "error": err.Error()})
+		r.logger.ErrorWithErr("Bakiye oluşturulamadı", err, map[string]interface{}{"user_id": balance.UserID})
 		return fmt.Errorf("bakiye oluşturulamadı: %w", err)
 	}
 
+	r.conn.MarkUserWrite(balance.UserID)
 	return nil
 }
 
-func (r *BalanceRepository) Update(balance *domain.Balance) (*domain.Balance, error) {
+func (r *BalanceRepository) Update(ctx context.Context, balance *domain.Balance) (*domain.Balance, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		INSERT INTO balances (user_id, amount, last_updated_at)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (user_id) DO UPDATE
-		SET amount = $2, last_updated_at = $3
-		RETURNING user_id, amount, last_updated_at
+		INSERT INTO balances (user_id, currency, amount, last_updated_at, created_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (user_id, currency) DO UPDATE
+		SET amount = $3, last_updated_at = $4
+		RETURNING user_id, currency, amount, overdraft_limit, last_updated_at, created_at
 	`
 
 	var updatedBalance domain.Balance
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		query,
 		balance.UserID,
+		balance.Currency,
 		balance.Amount,
 		balance.LastUpdatedAt,
 	).Scan(
 		&updatedBalance.UserID,
+		&updatedBalance.Currency,
 		&updatedBalance.Amount,
+		&updatedBalance.OverdraftLimit,
 		&updatedBalance.LastUpdatedAt,
+		&updatedBalance.CreatedAt,
 	)
 
 	if err != nil {
 		r.logger.Error("Bakiye güncellenemedi", map[string]interface{}{
-			"user_id": balance.UserID,
-			"error":   err.Error(),
+			"user_id":  balance.UserID,
+			"currency": balance.Currency,
+			"error":    err.Error(),
 		})
 		return nil, err
 	}
 
+	r.conn.MarkUserWrite(balance.UserID)
 	return &updatedBalance, nil
 }
 
-func (r *BalanceRepository) InitializeBalance(userID int64) error {
+func (r *BalanceRepository) Deposit(ctx context.Context, userID int64, currency string, amount domain.Money, transactionID int64) (*domain.Balance, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.ErrorWithErr("Veritabanı işlemi başlatılamadı", err, nil)
+		return nil, fmt.Errorf("para yatırma başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	if err := r.ensureBalance(ctx, tx, userID, currency, now); err != nil {
+		return nil, err
+	}
+
+	balance, err := r.lockBalance(ctx, tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	previousAmount := balance.Amount
+	balance.Amount += amount
+	balance.LastUpdatedAt = now
+
+	if err := r.saveLockedBalance(ctx, tx, balance); err != nil {
+		return nil, err
+	}
+
+	if err := r.insertHistory(ctx, tx, balance, previousAmount, transactionID, "deposit"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.ErrorWithErr("Para yatırma işlemi commit edilemedi", err, map[string]interface{}{"user_id": userID})
+		return nil, fmt.Errorf("para yatırma tamamlanamadı: %w", err)
+	}
+
+	r.conn.MarkUserWrite(userID)
+	return balance, nil
+}
+
+func (r *BalanceRepository) Withdraw(ctx context.Context, userID int64, currency string, amount domain.Money, transactionID int64) (*domain.Balance, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.ErrorWithErr("Veritabanı işlemi başlatılamadı", err, nil)
+		return nil, fmt.Errorf("para çekme başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	balance, err := r.lockBalance(ctx, tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if !balance.CanWithdraw(amount) {
+		return nil, domain.InsufficientFundsError(balance)
+	}
+
+	previousAmount := balance.Amount
+	balance.Amount -= amount
+	balance.LastUpdatedAt = time.Now()
+
+	if err := r.saveLockedBalance(ctx, tx, balance); err != nil {
+		return nil, err
+	}
+
+	if err := r.insertHistory(ctx, tx, balance, previousAmount, transactionID, "withdraw"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.ErrorWithErr("Para çekme işlemi commit edilemedi", err, map[string]interface{}{"user_id": userID})
+		return nil, fmt.Errorf("para çekme tamamlanamadı: %w", err)
+	}
+
+	r.conn.MarkUserWrite(userID)
+	return balance, nil
+}
+
+func (r *BalanceRepository) Transfer(ctx context.Context, fromUserID, toUserID int64, fromCurrency, toCurrency string, amount, convertedAmount domain.Money, transactionID int64) (*domain.Balance, *domain.Balance, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.ErrorWithErr("Veritabanı işlemi başlatılamadı", err, nil)
+		return nil, nil, fmt.Errorf("transfer başlatılamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	if err := r.ensureBalance(ctx, tx, toUserID, toCurrency, now); err != nil {
+		return nil, nil, err
+	}
+
+	// Lock rows in a fixed order so concurrent transfers between the same two
+	// users cannot deadlock.
+	firstID, secondID := fromUserID, toUserID
+	if secondID < firstID {
+		firstID, secondID = secondID, firstID
+	}
+
+	currencies := map[int64]string{fromUserID: fromCurrency, toUserID: toCurrency}
+
+	balances := make(map[int64]*domain.Balance, 2)
+	for _, userID := range []int64{firstID, secondID} {
+		balance, err := r.lockBalance(ctx, tx, userID, currencies[userID])
+		if err != nil {
+			return nil, nil, err
+		}
+		balances[userID] = balance
+	}
+
+	fromBalance := balances[fromUserID]
+	toBalance := balances[toUserID]
+
+	if !fromBalance.CanWithdraw(amount) {
+		return nil, nil, domain.InsufficientFundsError(fromBalance)
+	}
+
+	fromPrevious, toPrevious := fromBalance.Amount, toBalance.Amount
+	fromBalance.Amount -= amount
+	fromBalance.LastUpdatedAt = now
+	toBalance.Amount += convertedAmount
+	toBalance.LastUpdatedAt = now
+
+	for _, balance := range []*domain.Balance{fromBalance, toBalance} {
+		if err := r.saveLockedBalance(ctx, tx, balance); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := r.insertHistory(ctx, tx, fromBalance, fromPrevious, transactionID, "transfer_out"); err != nil {
+		return nil, nil, err
+	}
+	if err := r.insertHistory(ctx, tx, toBalance, toPrevious, transactionID, "transfer_in"); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.ErrorWithErr("Transfer işlemi commit edilemedi", err, nil)
+		return nil, nil, fmt.Errorf("transfer tamamlanamadı: %w", err)
+	}
+
+	r.conn.MarkUserWrite(fromUserID)
+	r.conn.MarkUserWrite(toUserID)
+	return fromBalance, toBalance, nil
+}
+
+func (r *BalanceRepository) SetOverdraftLimit(ctx context.Context, userID int64, currency string, limit domain.Money) error {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE balances
+		SET overdraft_limit = $1, last_updated_at = $2
+		WHERE user_id = $3 AND currency = $4
+	`
+
+	result, err := r.db.ExecContext(ctx, query, limit, time.Now(), userID, currency)
+	if err != nil {
+		r.logger.ErrorWithErr("Kredi limiti güncellenemedi", err, map[string]interface{}{"user_id": userID, "currency": currency})
+		return fmt.Errorf("kredi limiti güncellenemedi: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("kredi limiti güncellenemedi: %w", err)
+	}
+
+	if affected == 0 {
+		return domain.ErrBalanceNotFound
+	}
+
+	r.conn.MarkUserWrite(userID)
+	return nil
+}
+
+func (r *BalanceRepository) ensureBalance(ctx context.Context, tx *sql.Tx, userID int64, currency string, now time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO balances (user_id, currency, amount, last_updated_at, created_at)
+		VALUES ($1, $2, 0, $3, $3)
+		ON CONFLICT (user_id, currency) DO NOTHING
+	`, userID, currency, now)
+	if err != nil {
+		r.logger.ErrorWithErr("Bakiye başlatılamadı", err, map[string]interface{}{"user_id": userID, "currency": currency})
+		return fmt.Errorf("bakiye başlatılamadı: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BalanceRepository) lockBalance(ctx context.Context, tx *sql.Tx, userID int64, currency string) (*domain.Balance, error) {
+	var balance domain.Balance
+	err := tx.QueryRowContext(ctx, `
+		SELECT user_id, currency, amount, overdraft_limit, last_updated_at, created_at
+		FROM balances
+		WHERE user_id = $1 AND currency = $2
+		`+r.conn.Dialect().ForUpdate(), userID, currency).Scan(&balance.UserID, &balance.Currency, &balance.Amount, &balance.OverdraftLimit, &balance.LastUpdatedAt, &balance.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrBalanceNotFound
+	}
+	if err != nil {
+		r.logger.ErrorWithErr("Bakiye kilitlenemedi", err, map[string]interface{}{"user_id": userID})
+		return nil, fmt.Errorf("bakiye kilitlenemedi: %w", err)
+	}
+
+	return &balance, nil
+}
+
+func (r *BalanceRepository) saveLockedBalance(ctx context.Context, tx *sql.Tx, balance *domain.Balance) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE balances
+		SET amount = $1, last_updated_at = $2
+		WHERE user_id = $3 AND currency = $4
+	`, balance.Amount, balance.LastUpdatedAt, balance.UserID, balance.Currency)
+	if err != nil {
+		r.logger.ErrorWithErr("Bakiye güncellenemedi", err, map[string]interface{}{"user_id": balance.UserID})
+		return fmt.Errorf("bakiye güncellenemedi: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BalanceRepository) insertHistory(ctx context.Context, tx *sql.Tx, balance *domain.Balance, previousAmount domain.Money, transactionID int64, operation string) error {
+	var txID sql.NullInt64
+	if transactionID > 0 {
+		txID = sql.NullInt64{Int64: transactionID, Valid: true}
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO balance_history (user_id, currency, amount, previous_amount, transaction_id, operation, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, balance.UserID, balance.Currency, balance.Amount, previousAmount, txID, operation, balance.LastUpdatedAt)
+	if err != nil {
+		r.logger.ErrorWithErr("Bakiye geçmişi kaydedilemedi", err, map[string]interface{}{"user_id": balance.UserID, "operation": operation})
+		return fmt.Errorf("bakiye geçmişi kaydedilemedi: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BalanceRepository) InitializeBalance(ctx context.Context, userID int64, currency string) error {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		INSERT INTO balances (user_id, amount, last_updated_at)
-		VALUES ($1, 0, $2)
-		ON CONFLICT (user_id) DO NOTHING
+		INSERT INTO balances (user_id, currency, amount, last_updated_at, created_at)
+		VALUES ($1, $2, 0, $3, $3)
+		ON CONFLICT (user_id, currency) DO NOTHING
 	`
 
-	_, err := r.db.Exec(query, userID, time.Now())
+	_, err := r.db.ExecContext(ctx, query, userID, currency, time.Now())
 	if err != nil {
 		r.logger.Error("Bakiye başlatılamadı", map[string]interface{}{
-			"user_id": userID,
-			"error":   err.Error(),
+			"user_id":  userID,
+			"currency": currency,
+			"error":    err.Error(),
 		})
 		return err
 	}
 
+	r.conn.MarkUserWrite(userID)
 	return nil
 }
 
-func (r *BalanceRepository) GetBalanceHistory(userID int64, startTime, endTime time.Time) ([]*domain.Balance, error) {
+func (r *BalanceRepository) GetBalanceHistory(ctx context.Context, userID int64, currency string, startTime, endTime time.Time) ([]*domain.BalanceHistory, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		SELECT user_id, amount, last_updated_at
+		SELECT id, user_id, currency, amount, previous_amount, transaction_id, operation, created_at
 		FROM balance_history
-		WHERE user_id = $1 AND created_at BETWEEN $2 AND $3
-		ORDER BY created_at ASC
+		WHERE user_id = $1 AND currency = $2 AND created_at BETWEEN $3 AND $4
+		ORDER BY created_at ASC, id ASC
 	`
 
-	rows, err := r.db.Query(query, userID, startTime, endTime)
+	rows, err := r.conn.GetReadDBForUser(userID).QueryContext(ctx, query, userID, currency, startTime, endTime)
 	if err != nil {
-		return nil, err
+		r.logger.ErrorWithErr("Bakiye geçmişi alınamadı", err, map[string]interface{}{"user_id": userID})
+		return nil, fmt.Errorf("bakiye geçmişi alınamadı: %w", err)
 	}
 	defer rows.Close()
 
-	var balances []*domain.Balance
+	var history []*domain.BalanceHistory
 	for rows.Next() {
-		var balance domain.Balance
+		var entry domain.BalanceHistory
+		var txID sql.NullInt64
 		err := rows.Scan(
-			&balance.UserID,
-			&balance.Amount,
-			&balance.LastUpdatedAt,
+			&entry.ID,
+			&entry.UserID,
+			&entry.Currency,
+			&entry.Amount,
+			&entry.PreviousAmount,
+			&txID,
+			&entry.Operation,
+			&entry.CreatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("bakiye geçmişi okunamadı: %w", err)
 		}
-		balances = append(balances, &balance)
+		if txID.Valid {
+			entry.TransactionID = txID.Int64
+		}
+		history = append(history, &entry)
 	}
 
-	return balances, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("bakiye geçmişi okunamadı: %w", err)
+	}
+
+	return history, nil
+}
+
+func (r *BalanceRepository) FindLatestHistoryAt(ctx context.Context, userID int64, currency string, at time.Time) (*domain.BalanceHistory, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, currency, amount, previous_amount, transaction_id, operation, created_at
+		FROM balance_history
+		WHERE user_id = $1 AND currency = $2 AND created_at <= $3
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	var entry domain.BalanceHistory
+	var txID sql.NullInt64
+	err := r.conn.GetReadDBForUser(userID).QueryRowContext(ctx, query, userID, currency, at).Scan(
+		&entry.ID,
+		&entry.UserID,
+		&entry.Currency,
+		&entry.Amount,
+		&entry.PreviousAmount,
+		&txID,
+		&entry.Operation,
+		&entry.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		r.logger.ErrorWithErr("Bakiye geçmişi alınamadı", err, map[string]interface{}{"user_id": userID, "at": at})
+		return nil, fmt.Errorf("bakiye geçmişi alınamadı: %w", err)
+	}
+
+	if txID.Valid {
+		entry.TransactionID = txID.Int64
+	}
+
+	return &entry, nil
 }
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"payflow/internal/domain"
+)
+
+func TestInitializeBalanceRecordsCreatedAt(t *testing.T) {
+	router := newTestDB(t)
+	repo := NewBalanceRepository(router, newTestLogger(), 0)
+	ctx := context.Background()
+
+	userID := createTestUser(t, router, "alice")
+
+	before := time.Now().Add(-time.Second)
+	if err := repo.InitializeBalance(ctx, userID, "TRY"); err != nil {
+		t.Fatal(err)
+	}
+
+	balance, err := repo.FindByUserAndCurrency(ctx, userID, "TRY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance == nil {
+		t.Fatal("bakiye oluşturulmalı")
+	}
+	if balance.CreatedAt.Before(before) {
+		t.Fatalf("created_at kaydedilmeli, alınan: %v", balance.CreatedAt)
+	}
+
+	entry, err := repo.FindLatestHistoryAt(ctx, userID, "TRY", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry != nil {
+		t.Fatalf("hareketsiz bakiyenin geçmişi olmamalı: %+v", entry)
+	}
+}
+
+func TestBalanceMovementsOnSQLite(t *testing.T) {
+	router := newTestDB(t)
+	repo := NewBalanceRepository(router, newTestLogger(), 0)
+	ctx := context.Background()
+
+	alice := createTestUser(t, router, "alice")
+	bob := createTestUser(t, router, "bob")
+	start := time.Now().Add(-time.Second)
+
+	// History rows reference the transaction that moved the money.
+	txRepo := NewTransactionRepository(router, newTestLogger(), 0)
+	newTx := func(txType domain.TransactionType) int64 {
+		tx := &domain.Transaction{
+			ToUserID: &alice,
+			Amount:   domain.NewMoneyFromFloat(1),
+			Currency: "TRY",
+			Type:     txType,
+			Status:   domain.TransactionStatusPending,
+		}
+		if err := txRepo.Create(ctx, tx); err != nil {
+			t.Fatal(err)
+		}
+		return tx.ID
+	}
+
+	balance, err := repo.Deposit(ctx, alice, "TRY", domain.NewMoneyFromFloat(100), newTx(domain.TransactionTypeDeposit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Amount != domain.NewMoneyFromFloat(100) {
+		t.Fatalf("bakiye 100 olmalı, alınan: %v", balance.Amount)
+	}
+
+	if _, err := repo.Withdraw(ctx, alice, "TRY", domain.NewMoneyFromFloat(150), newTx(domain.TransactionTypeWithdraw)); !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Fatalf("beklenen ErrInsufficientFunds, alınan: %v", err)
+	}
+	if balance, err = repo.Withdraw(ctx, alice, "TRY", domain.NewMoneyFromFloat(30), newTx(domain.TransactionTypeWithdraw)); err != nil {
+		t.Fatal(err)
+	}
+	if balance.Amount != domain.NewMoneyFromFloat(70) {
+		t.Fatalf("bakiye 70 olmalı, alınan: %v", balance.Amount)
+	}
+
+	// The recipient has no balance row yet; Transfer creates it.
+	from, to, err := repo.Transfer(ctx, alice, bob, "TRY", "TRY", domain.NewMoneyFromFloat(20), domain.NewMoneyFromFloat(20), newTx(domain.TransactionTypeTransfer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from.Amount != domain.NewMoneyFromFloat(50) || to.Amount != domain.NewMoneyFromFloat(20) {
+		t.Fatalf("beklenen 50 / 20, alınan: %v / %v", from.Amount, to.Amount)
+	}
+
+	history, err := repo.GetBalanceHistory(ctx, alice, "TRY", start, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("başarılı her hareket geçmişe yazılmalı, alınan: %d", len(history))
+	}
+}
+
+func TestParallelDepositsOnSQLite(t *testing.T) {
+	router := newTestFileDB(t)
+	repo := NewBalanceRepository(router, newTestLogger(), 0)
+	txRepo := NewTransactionRepository(router, newTestLogger(), 0)
+	ctx := context.Background()
+
+	alice := createTestUser(t, router, "alice")
+	if err := repo.InitializeBalance(ctx, alice, "TRY"); err != nil {
+		t.Fatal(err)
+	}
+
+	const deposits = 100
+	transactionIDs := make([]int64, deposits)
+	for i := range transactionIDs {
+		tx := &domain.Transaction{
+			ToUserID: &alice,
+			Amount:   domain.NewMoneyFromFloat(1),
+			Currency: "TRY",
+			Type:     domain.TransactionTypeDeposit,
+			Status:   domain.TransactionStatusPending,
+		}
+		if err := txRepo.Create(ctx, tx); err != nil {
+			t.Fatal(err)
+		}
+		transactionIDs[i] = tx.ID
+	}
+
+	errs := make(chan error, deposits)
+	var wg sync.WaitGroup
+	for _, transactionID := range transactionIDs {
+		wg.Add(1)
+		go func(transactionID int64) {
+			defer wg.Done()
+			if _, err := repo.Deposit(ctx, alice, "TRY", domain.NewMoneyFromFloat(1), transactionID); err != nil {
+				errs <- err
+			}
+		}(transactionID)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("para yatırma başarısız: %v", err)
+	}
+
+	balance, err := repo.FindByUserAndCurrency(ctx, alice, "TRY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Amount != domain.NewMoneyFromFloat(deposits) {
+		t.Fatalf("bakiye %d olmalı, alınan: %v", deposits, balance.Amount)
+	}
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// withQueryTimeout bounds a repository call by the configured per-query
+// timeout on top of any deadline the caller's context already carries, so a
+// hung query cannot hold a request or worker indefinitely.
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
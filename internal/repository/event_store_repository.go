@@ -6,17 +6,20 @@ import (
 	"time"
 
 	"payflow/internal/domain"
+	"payflow/pkg/database"
 	"payflow/pkg/logger"
 )
 
 type EventStoreRepository struct {
 	db     *sql.DB
+	conn   database.Router
 	logger logger.Logger
 }
 
-func NewEventStoreRepository(db *sql.DB, logger logger.Logger) domain.EventStoreRepository {
+func NewEventStoreRepository(conn database.Router, logger logger.Logger) domain.EventStoreRepository {
 	return &EventStoreRepository{
-		db:     db,
+		db:     conn.GetWriteDB(),
+		conn:   conn,
 		logger: logger,
 	}
 }
@@ -40,26 +43,34 @@ func (r *EventStoreRepository) Save(event *domain.Event) error {
 		return err
 	}
 
+	// The next version is computed in the insert itself; the unique index on
+	// (aggregate_type, aggregate_id, version) rejects a concurrent duplicate.
 	query := `
 		INSERT INTO event_store (
 			aggregate_id, aggregate_type, event_type, event_data, version, created_at, metadata
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id
+		)
+		SELECT $1, $2, $3, $4, COALESCE(MAX(version), 0) + 1, $5, $6
+		FROM event_store
+		WHERE aggregate_type = $2 AND aggregate_id = $1
+		RETURNING id, version
 	`
 
 	var id int64
+	var version int
 	err = r.db.QueryRow(
 		query,
 		event.AggregateID,
 		event.AggregateType,
 		event.EventType,
 		eventDataJSON,
-		event.Version,
 		event.CreatedAt,
 		metadataJSON,
-	).Scan(&id)
+	).Scan(&id, &version)
 
 	if err != nil {
+		if r.conn.Dialect().IsUniqueViolation(err) {
+			return domain.ErrConcurrentModification
+		}
 		r.logger.Error("Event kaydedilemedi", map[string]interface{}{
 			"error": err.Error(),
 			"event": event,
@@ -68,6 +79,7 @@ func (r *EventStoreRepository) Save(event *domain.Event) error {
 	}
 
 	event.ID = id
+	event.Version = version
 	return nil
 }
 
@@ -120,7 +132,7 @@ func (r *EventStoreRepository) GetEventsByType(eventType domain.EventType) ([]*d
 		ORDER BY created_at ASC
 	`
 
-	rows, err := r.db.Query(query, eventType)
+	rows, err := r.conn.GetReadDB().Query(query, eventType)
 	if err != nil {
 		return nil, err
 	}
@@ -161,7 +173,7 @@ func (r *EventStoreRepository) GetEventsByTimeRange(startTime, endTime time.Time
 		ORDER BY created_at ASC
 	`
 
-	rows, err := r.db.Query(query, startTime, endTime)
+	rows, err := r.conn.GetReadDB().Query(query, startTime, endTime)
 	if err != nil {
 		return nil, err
 	}
@@ -209,3 +221,184 @@ func (r *EventStoreRepository) GetLastVersion(aggregateType string, aggregateID
 
 	return version, nil
 }
+
+func (r *EventStoreRepository) GetEventsAfterVersion(aggregateType string, aggregateID string, version int) ([]*domain.Event, error) {
+	return r.GetEventsFromVersion(aggregateType, aggregateID, version+1)
+}
+
+func (r *EventStoreRepository) GetEventsFromVersion(aggregateType string, aggregateID string, fromVersion int) ([]*domain.Event, error) {
+	query := `
+		SELECT id, aggregate_id, aggregate_type, event_type, event_data, version, created_at, metadata
+		FROM event_store
+		WHERE aggregate_type = $1 AND aggregate_id = $2 AND version >= $3
+		ORDER BY version ASC
+	`
+
+	rows, err := r.db.Query(query, aggregateType, aggregateID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.Event
+	for rows.Next() {
+		event := &domain.Event{}
+		var eventData, metadata []byte
+
+		err := rows.Scan(
+			&event.ID,
+			&event.AggregateID,
+			&event.AggregateType,
+			&event.EventType,
+			&eventData,
+			&event.Version,
+			&event.CreatedAt,
+			&metadata,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		event.EventData = eventData
+		event.Metadata = metadata
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (r *EventStoreRepository) SaveSnapshot(snapshot *domain.Snapshot) error {
+	query := `
+		INSERT INTO snapshots (aggregate_id, aggregate_type, version, state, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (aggregate_type, aggregate_id, version) DO NOTHING
+	`
+
+	_, err := r.db.Exec(
+		query,
+		snapshot.AggregateID,
+		snapshot.AggregateType,
+		snapshot.Version,
+		[]byte(snapshot.State),
+		snapshot.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Snapshot kaydedilemedi", map[string]interface{}{
+			"error":          err.Error(),
+			"aggregate_type": snapshot.AggregateType,
+			"aggregate_id":   snapshot.AggregateID,
+			"version":        snapshot.Version,
+		})
+		return err
+	}
+
+	return nil
+}
+
+func (r *EventStoreRepository) GetLatestSnapshot(aggregateType string, aggregateID string) (*domain.Snapshot, error) {
+	query := `
+		SELECT id, aggregate_id, aggregate_type, version, state, created_at
+		FROM snapshots
+		WHERE aggregate_type = $1 AND aggregate_id = $2
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	snapshot := &domain.Snapshot{}
+	var state []byte
+
+	err := r.db.QueryRow(query, aggregateType, aggregateID).Scan(
+		&snapshot.ID,
+		&snapshot.AggregateID,
+		&snapshot.AggregateType,
+		&snapshot.Version,
+		&state,
+		&snapshot.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	snapshot.State = state
+	return snapshot, nil
+}
+
+func (r *EventStoreRepository) GetEventsByTypeAfterID(eventType domain.EventType, afterID int64, limit int) ([]*domain.Event, error) {
+	query := `
+		SELECT id, aggregate_id, aggregate_type, event_type, event_data, version, created_at, metadata
+		FROM event_store
+		WHERE event_type = $1 AND id > $2
+		ORDER BY id ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(query, eventType, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.Event
+	for rows.Next() {
+		event := &domain.Event{}
+		var eventData, metadata []byte
+
+		err := rows.Scan(
+			&event.ID,
+			&event.AggregateID,
+			&event.AggregateType,
+			&event.EventType,
+			&eventData,
+			&event.Version,
+			&event.CreatedAt,
+			&metadata,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		event.EventData = eventData
+		event.Metadata = metadata
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (r *EventStoreRepository) GetSubscriberCursor(subscriber string) (int64, error) {
+	query := `
+		SELECT last_event_id
+		FROM event_subscriber_cursors
+		WHERE subscriber = $1
+	`
+
+	var cursor int64
+	err := r.db.QueryRow(query, subscriber).Scan(&cursor)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return cursor, nil
+}
+
+func (r *EventStoreRepository) SaveSubscriberCursor(subscriber string, eventID int64) error {
+	query := `
+		INSERT INTO event_subscriber_cursors (subscriber, last_event_id, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (subscriber) DO UPDATE
+		SET last_event_id = CASE
+				WHEN EXCLUDED.last_event_id > event_subscriber_cursors.last_event_id THEN EXCLUDED.last_event_id
+				ELSE event_subscriber_cursors.last_event_id
+			END,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.Exec(query, subscriber, eventID, time.Now())
+	return err
+}
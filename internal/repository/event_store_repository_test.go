@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"payflow/internal/domain"
+)
+
+func TestEventStoreRepositoryOnSQLite(t *testing.T) {
+	repo := NewEventStoreRepository(newTestDB(t), newTestLogger())
+
+	for i := 0; i < 3; i++ {
+		event := &domain.Event{
+			AggregateID:   "1",
+			AggregateType: "balance",
+			EventType:     domain.EventTypeBalanceUpdated,
+			EventData:     json.RawMessage(`{"amount":"10.00"}`),
+			CreatedAt:     time.Now(),
+		}
+		if err := repo.Save(event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Version != i+1 {
+			t.Fatalf("sürüm %d olmalı, alınan: %d", i+1, event.Version)
+		}
+	}
+
+	version, err := repo.GetLastVersion("balance", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 3 {
+		t.Fatalf("son sürüm 3 olmalı, alınan: %d", version)
+	}
+
+	events, err := repo.GetEventsFromVersion("balance", "1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Version != 2 || events[1].Version != 3 {
+		t.Fatalf("2. sürümden itibaren olaylar dönmeli, alınan: %d", len(events))
+	}
+	var data map[string]string
+	if err := json.Unmarshal(events[0].EventData, &data); err != nil || data["amount"] != "10.00" {
+		t.Fatalf("olay verisi korunmalı: %s", events[0].EventData)
+	}
+
+	for _, v := range []int{2, 3} {
+		snapshot := &domain.Snapshot{AggregateID: "1", AggregateType: "balance", Version: v, State: json.RawMessage(`{}`), CreatedAt: time.Now()}
+		if err := repo.SaveSnapshot(snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A second snapshot for the same version is ignored.
+	if err := repo.SaveSnapshot(&domain.Snapshot{AggregateID: "1", AggregateType: "balance", Version: 3, State: json.RawMessage(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := repo.GetLatestSnapshot("balance", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot == nil || snapshot.Version != 3 {
+		t.Fatalf("en son snapshot dönmeli, alınan: %+v", snapshot)
+	}
+
+	if err := repo.SaveSubscriberCursor("projector", events[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	// Cursors only move forward.
+	if err := repo.SaveSubscriberCursor("projector", events[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := repo.GetSubscriberCursor("projector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor != events[1].ID {
+		t.Fatalf("imleç geri gitmemeli, alınan: %d", cursor)
+	}
+}
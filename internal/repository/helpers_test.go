@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"database/sql"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"payflow/internal/database"
+	"payflow/internal/domain"
+	sqldialect "payflow/pkg/database"
+	"payflow/pkg/logger"
+)
+
+// testRouter serves every query from one SQLite database.
+type testRouter struct {
+	db      *sql.DB
+	dialect sqldialect.Dialect
+}
+
+func (r *testRouter) GetWriteDB() *sql.DB            { return r.db }
+func (r *testRouter) GetReadDB() *sql.DB             { return r.db }
+func (r *testRouter) GetReadDBForUser(int64) *sql.DB { return r.db }
+func (r *testRouter) MarkUserWrite(int64)            {}
+func (r *testRouter) Dialect() sqldialect.Dialect    { return r.dialect }
+
+func newTestLogger() logger.Logger {
+	return logger.New(logger.ErrorLevel, logger.FormatJSON, io.Discard)
+}
+
+// newTestDB opens a private in-memory SQLite database with all migrations
+// applied.
+func newTestDB(t *testing.T) *testRouter {
+	t.Helper()
+
+	db, dialect := openTestDB(t, t.Name())
+	return &testRouter{db: db, dialect: dialect}
+}
+
+// newTestFileDB is newTestDB backed by a file, for tests that write from
+// several connections at once: shared-cache in-memory databases fail
+// concurrent writers with "table is locked" instead of waiting on the busy
+// timeout the way a file database does.
+func newTestFileDB(t *testing.T) *testRouter {
+	t.Helper()
+
+	db, dialect := openSQLite(t, filepath.Join(t.TempDir(), "payflow.db"))
+	return &testRouter{db: db, dialect: dialect}
+}
+
+// openTestDB opens the in-memory SQLite database called name, unique within
+// the test binary, and applies all migrations.
+func openTestDB(t *testing.T, name string) (*sql.DB, sqldialect.Dialect) {
+	t.Helper()
+
+	return openSQLite(t, "file:"+strings.ReplaceAll(name, "/", "_")+"?mode=memory&cache=shared")
+}
+
+func openSQLite(t *testing.T, dsn string) (*sql.DB, sqldialect.Dialect) {
+	t.Helper()
+
+	dialect, err := sqldialect.NewDialect(sqldialect.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(dialect.DriverName(), dialect.DSN("", "", "", "", dsn, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := database.NewMigrationService(db, dialect, newTestLogger(), "TRY", false).RunMigrations(); err != nil {
+		t.Fatalf("migrationlar uygulanamadı: %v", err)
+	}
+
+	return db, dialect
+}
+
+func createTestUser(t *testing.T, router *testRouter, username string) int64 {
+	t.Helper()
+
+	user := &domain.User{
+		Username:     username,
+		Email:        username + "@example.com",
+		PasswordHash: "hash",
+		Role:         "user",
+		IsActive:     true,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := NewUserRepository(router, newTestLogger()).Create(user); err != nil {
+		t.Fatalf("kullanıcı oluşturulamadı: %v", err)
+	}
+	return user.ID
+}
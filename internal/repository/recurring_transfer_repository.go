@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"payflow/internal/domain"
+	"payflow/pkg/database"
+	"payflow/pkg/logger"
+)
+
+const recurringTransferColumns = `id, from_user_id, to_user_id, amount, currency, run_interval, next_run_at, end_date, status, created_at`
+
+type RecurringTransferRepository struct {
+	db     *sql.DB
+	conn   database.Router
+	logger logger.Logger
+}
+
+func NewRecurringTransferRepository(conn database.Router, logger logger.Logger) domain.RecurringTransferRepository {
+	return &RecurringTransferRepository{
+		db:     conn.GetWriteDB(),
+		conn:   conn,
+		logger: logger,
+	}
+}
+
+func scanRecurringTransfer(row rowScanner) (*domain.RecurringTransfer, error) {
+	var transfer domain.RecurringTransfer
+	var interval, status string
+	var endDate sql.NullTime
+
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.FromUserID,
+		&transfer.ToUserID,
+		&transfer.Amount,
+		&transfer.Currency,
+		&interval,
+		&transfer.NextRunAt,
+		&endDate,
+		&status,
+		&transfer.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.Interval = domain.RecurringInterval(interval)
+	transfer.Status = domain.RecurringTransferStatus(status)
+
+	if endDate.Valid {
+		end := endDate.Time
+		transfer.EndDate = &end
+	}
+
+	return &transfer, nil
+}
+
+func (r *RecurringTransferRepository) Create(transfer *domain.RecurringTransfer) error {
+	query := `
+		INSERT INTO recurring_transfers (from_user_id, to_user_id, amount, currency, run_interval, next_run_at, end_date, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	var endDate interface{}
+	if transfer.EndDate != nil {
+		endDate = *transfer.EndDate
+	}
+
+	transfer.CreatedAt = time.Now()
+
+	err := r.db.QueryRow(
+		query,
+		transfer.FromUserID,
+		transfer.ToUserID,
+		transfer.Amount,
+		transfer.Currency,
+		string(transfer.Interval),
+		transfer.NextRunAt,
+		endDate,
+		string(transfer.Status),
+		transfer.CreatedAt,
+	).Scan(&transfer.ID)
+
+	if err != nil {
+		r.logger.ErrorWithErr("Düzenli transfer oluşturulamadı", err, map[string]interface{}{"from_user_id": transfer.FromUserID})
+		return fmt.Errorf("düzenli transfer oluşturulamadı: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RecurringTransferRepository) FindByID(id int64) (*domain.RecurringTransfer, error) {
+	query := `
+		SELECT ` + recurringTransferColumns + `
+		FROM recurring_transfers
+		WHERE id = $1
+	`
+
+	transfer, err := scanRecurringTransfer(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.ErrorWithErr("Düzenli transfer bulunamadı", err, map[string]interface{}{"id": id})
+		return nil, fmt.Errorf("düzenli transfer bulunamadı: %w", err)
+	}
+
+	return transfer, nil
+}
+
+func (r *RecurringTransferRepository) FindDue(dueBefore time.Time, limit int) ([]*domain.RecurringTransfer, error) {
+	query := `
+		SELECT ` + recurringTransferColumns + `
+		FROM recurring_transfers
+		WHERE status = $1 AND next_run_at <= $2
+		ORDER BY next_run_at ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(query, string(domain.RecurringTransferStatusActive), dueBefore, limit)
+	if err != nil {
+		r.logger.ErrorWithErr("Zamanı gelen düzenli transferler bulunamadı", err, nil)
+		return nil, fmt.Errorf("zamanı gelen düzenli transferler bulunamadı: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := make([]*domain.RecurringTransfer, 0)
+	for rows.Next() {
+		transfer, err := scanRecurringTransfer(rows)
+		if err != nil {
+			r.logger.ErrorWithErr("Düzenli transfer verileri okunamadı", err, nil)
+			return nil, fmt.Errorf("düzenli transfer verileri okunamadı: %w", err)
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("düzenli transfer verileri okunamadı: %w", err)
+	}
+
+	return transfers, nil
+}
+
+// Advance moves next_run_at forward only if it still equals currentRunAt, so
+// concurrent schedulers cannot advance the same occurrence twice.
+func (r *RecurringTransferRepository) Advance(id int64, currentRunAt, nextRunAt time.Time, status domain.RecurringTransferStatus) (bool, error) {
+	query := `
+		UPDATE recurring_transfers
+		SET next_run_at = $1, status = $2
+		WHERE id = $3 AND next_run_at = $4 AND status = $5
+	`
+
+	result, err := r.db.Exec(query, nextRunAt, string(status), id, currentRunAt, string(domain.RecurringTransferStatusActive))
+	if err != nil {
+		r.logger.ErrorWithErr("Düzenli transfer güncellenemedi", err, map[string]interface{}{"id": id})
+		return false, fmt.Errorf("düzenli transfer güncellenemedi: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("düzenli transfer güncellenemedi: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *RecurringTransferRepository) UpdateStatus(id int64, from, to domain.RecurringTransferStatus) (bool, error) {
+	query := `
+		UPDATE recurring_transfers
+		SET status = $1
+		WHERE id = $2 AND status = $3
+	`
+
+	result, err := r.db.Exec(query, string(to), id, string(from))
+	if err != nil {
+		r.logger.ErrorWithErr("Düzenli transfer durumu güncellenemedi", err, map[string]interface{}{"id": id})
+		return false, fmt.Errorf("düzenli transfer durumu güncellenemedi: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("düzenli transfer durumu güncellenemedi: %w", err)
+	}
+
+	return affected > 0, nil
+}
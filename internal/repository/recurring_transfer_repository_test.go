@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"payflow/internal/domain"
+)
+
+func TestRecurringTransferRepositoryOnSQLite(t *testing.T) {
+	router := newTestDB(t)
+	repo := NewRecurringTransferRepository(router, newTestLogger())
+
+	alice := createTestUser(t, router, "alice")
+	bob := createTestUser(t, router, "bob")
+
+	runAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	end := runAt.Add(30 * 7 * 24 * time.Hour)
+	transfer := &domain.RecurringTransfer{
+		FromUserID: alice,
+		ToUserID:   bob,
+		Amount:     domain.NewMoneyFromFloat(25),
+		Currency:   "TRY",
+		Interval:   domain.RecurringIntervalWeekly,
+		NextRunAt:  runAt,
+		EndDate:    &end,
+		Status:     domain.RecurringTransferStatusActive,
+	}
+	if err := repo.Create(transfer); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := repo.FindByID(transfer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || found.Amount != transfer.Amount || found.EndDate == nil || !found.EndDate.Equal(end) {
+		t.Fatalf("kaydedilen transfer okunmalı, alınan: %+v", found)
+	}
+
+	due, err := repo.FindDue(time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].ID != transfer.ID {
+		t.Fatalf("zamanı gelen transfer dönmeli, alınan: %d", len(due))
+	}
+
+	next := runAt.Add(7 * 24 * time.Hour)
+	if ok, err := repo.Advance(transfer.ID, found.NextRunAt, next, domain.RecurringTransferStatusActive); err != nil || !ok {
+		t.Fatalf("transfer ilerletilmeli: %v, %v", ok, err)
+	}
+	if ok, err := repo.Advance(transfer.ID, found.NextRunAt, next, domain.RecurringTransferStatusActive); err != nil || ok {
+		t.Fatalf("aynı çalıştırma iki kez ilerletilmemeli: %v, %v", ok, err)
+	}
+
+	if ok, err := repo.UpdateStatus(transfer.ID, domain.RecurringTransferStatusActive, domain.RecurringTransferStatusCancelled); err != nil || !ok {
+		t.Fatalf("durum güncellenmeli: %v, %v", ok, err)
+	}
+	due, err = repo.FindDue(next.Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Fatalf("iptal edilmiş transfer dönmemeli, alınan: %d", len(due))
+	}
+}
@@ -1,53 +1,64 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"payflow/internal/domain"
+	"payflow/pkg/database"
 	"payflow/pkg/logger"
 )
 
+const transactionColumns = `id, from_user_id, to_user_id, amount, currency, type, status, created_at, idempotency_key, to_currency, converted_amount, exchange_rate, scheduled_at`
+
 type TransactionRepository struct {
-	db     *sql.DB
-	logger logger.Logger
+	db      *sql.DB
+	conn    database.Router
+	logger  logger.Logger
+	timeout time.Duration
 }
 
-func NewTransactionRepository(db *sql.DB, logger logger.Logger) domain.TransactionRepository {
+func NewTransactionRepository(conn database.Router, logger logger.Logger, queryTimeout time.Duration) domain.TransactionRepository {
 	return &TransactionRepository{
-		db:     db,
-		logger: logger,
+		db:      conn.GetWriteDB(),
+		conn:    conn,
+		logger:  logger,
+		timeout: queryTimeout,
 	}
 }
 
-func (r *TransactionRepository) FindByID(id int64) (*domain.Transaction, error) {
-	query := `
-		SELECT id, from_user_id, to_user_id, amount, type, status, created_at
-		FROM transactions
-		WHERE id = $1
-	`
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+func scanTransaction(row rowScanner) (*domain.Transaction, error) {
 	var transaction domain.Transaction
 	var fromUserID, toUserID sql.NullInt64
 	var transactionType, status string
+	var idempotencyKey, toCurrency sql.NullString
+	var convertedAmount domain.Money
+	var exchangeRate sql.NullFloat64
+	var scheduledAt sql.NullTime
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&transaction.ID,
 		&fromUserID,
 		&toUserID,
 		&transaction.Amount,
+		&transaction.Currency,
 		&transactionType,
 		&status,
 		&transaction.CreatedAt,
+		&idempotencyKey,
+		&toCurrency,
+		&convertedAmount,
+		&exchangeRate,
+		&scheduledAt,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		r.logger.Error("İşlem ID'ye göre bulunamadı", map[string]interface{}{"id": id, "error": err.Error()})
-		return nil, fmt.Errorf("işlem bulunamadı: %w", err)
+		return nil, err
 	}
 
 	if fromUserID.Valid {
@@ -62,73 +73,340 @@ func (r *TransactionRepository) FindByID(id int64) (*domain.Transaction, error)
 
 	transaction.Type = domain.TransactionType(transactionType)
 	transaction.Status = domain.TransactionStatus(status)
+	transaction.IdempotencyKey = idempotencyKey.String
+	transaction.ToCurrency = toCurrency.String
+	transaction.ConvertedAmount = convertedAmount
+	transaction.ExchangeRate = exchangeRate.Float64
+
+	if scheduledAt.Valid {
+		at := scheduledAt.Time
+		transaction.ScheduledAt = &at
+	}
 
 	return &transaction, nil
 }
 
-func (r *TransactionRepository) FindByUserID(userID int64) ([]*domain.Transaction, error) {
+func (r *TransactionRepository) FindByID(ctx context.Context, id int64) (*domain.Transaction, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE id = $1
+	`
+
+	transaction, err := scanTransaction(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.ErrorWithErr("İşlem ID'ye göre bulunamadı", err, map[string]interface{}{"id": id})
+		return nil, fmt.Errorf("işlem bulunamadı: %w", err)
+	}
+
+	return transaction, nil
+}
+
+// FindByIdempotencyKey looks the key up among the transactions owned by userID
+// (see Transaction.IdempotencyOwnerID); keys of other users never match.
+func (r *TransactionRepository) FindByIdempotencyKey(ctx context.Context, userID int64, key string) (*domain.Transaction, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE COALESCE(from_user_id, to_user_id) = $1 AND idempotency_key = $2
+	`
+
+	transaction, err := scanTransaction(r.db.QueryRowContext(ctx, query, userID, key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.ErrorWithErr("İşlem idempotency anahtarına göre bulunamadı", err, nil)
+		return nil, fmt.Errorf("işlem bulunamadı: %w", err)
+	}
+
+	return transaction, nil
+}
+
+func (r *TransactionRepository) FindByUserID(ctx context.Context, userID int64) ([]*domain.Transaction, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		SELECT id, from_user_id, to_user_id, amount, type, status, created_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE from_user_id = $1 OR to_user_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.conn.GetReadDBForUser(userID).QueryContext(ctx, query, userID)
 	if err != nil {
-		r.logger.Error("Kullanıcı işlemleri bulunamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		r.logger.ErrorWithErr("Kullanıcı işlemleri bulunamadı", err, map[string]interface{}{"user_id": userID})
 		return nil, fmt.Errorf("kullanıcı işlemleri bulunamadı: %w", err)
 	}
 	defer rows.Close()
 
-	transactions := make([]*domain.Transaction, 0)
+	return r.scanTransactions(rows)
+}
+
+func (r *TransactionRepository) FindByUserIDPaginated(ctx context.Context, userID int64, filter domain.TransactionFilter, limit, offset int) ([]*domain.Transaction, int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	where := ` WHERE (from_user_id = $1 OR to_user_id = $1)`
+	args := []interface{}{userID}
+
+	if !filter.StartDate.IsZero() {
+		args = append(args, filter.StartDate)
+		where += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+
+	if !filter.EndDate.IsZero() {
+		args = append(args, filter.EndDate)
+		where += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	// Count and page from the same connection so the total matches the rows.
+	db := r.conn.GetReadDBForUser(userID)
+
+	var totalCount int64
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM transactions`+where, args...).Scan(&totalCount); err != nil {
+		r.logger.ErrorWithErr("Kullanıcı işlem sayısı alınamadı", err, map[string]interface{}{"user_id": userID})
+		return nil, 0, fmt.Errorf("kullanıcı işlem sayısı alınamadı: %w", err)
+	}
+
+	query := `SELECT ` + transactionColumns + ` FROM transactions` + where +
+		fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+
+	rows, err := db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		r.logger.Error("Kullanıcı işlemleri bulunamadı", map[string]interface{}{
+			"user_id": userID,
+			"limit":   limit,
+			"offset":  offset,
+			"error":   err.Error(),
+		})
+		return nil, 0, fmt.Errorf("kullanıcı işlemleri bulunamadı: %w", err)
+	}
+	defer rows.Close()
+
+	transactions, err := r.scanTransactions(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, totalCount, nil
+}
+
+func (r *TransactionRepository) FindByStatus(ctx context.Context, status domain.TransactionStatus, createdBefore time.Time, limit, offset int) ([]*domain.Transaction, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE status = $1
+	`
+	args := []interface{}{string(status)}
+
+	if !createdBefore.IsZero() {
+		args = append(args, createdBefore)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY created_at ASC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.conn.GetReadDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Duruma göre işlemler bulunamadı", map[string]interface{}{
+			"status": status,
+			"limit":  limit,
+			"offset": offset,
+			"error":  err.Error(),
+		})
+		return nil, fmt.Errorf("duruma göre işlemler bulunamadı: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanTransactions(rows)
+}
+
+// CountByStatus counts the transactions FindByStatus pages through.
+func (r *TransactionRepository) CountByStatus(ctx context.Context, status domain.TransactionStatus, createdBefore time.Time) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT COUNT(*) FROM transactions WHERE status = $1`
+	args := []interface{}{string(status)}
+
+	if !createdBefore.IsZero() {
+		args = append(args, createdBefore)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	var count int64
+	if err := r.conn.GetReadDB().QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		r.logger.ErrorWithErr("Duruma göre işlem sayısı alınamadı", err, map[string]interface{}{"status": status})
+		return 0, fmt.Errorf("duruma göre işlem sayısı alınamadı: %w", err)
+	}
+
+	return count, nil
+}
+
+// SumUserTransactionsSince totals the user's outgoing transactions and
+// deposits since the given time, per currency. Pending, processing and
+// scheduled rows count as well, so requests still in the queue cannot be used
+// to exceed a limit; only failed, cancelled and rolled back ones are left out.
+func (r *TransactionRepository) SumUserTransactionsSince(ctx context.Context, userID int64, since time.Time) (map[string]domain.Money, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT currency, COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE status NOT IN ($2, $3, $4)
+		AND created_at >= $5
+		AND (from_user_id = $1 OR (type = $6 AND to_user_id = $1))
+		GROUP BY currency
+	`
+
+	rows, err := r.db.QueryContext(ctx,
+		query,
+		userID,
+		string(domain.TransactionStatusFailed),
+		string(domain.TransactionStatusCancelled),
+		string(domain.TransactionStatusRolledBack),
+		since,
+		string(domain.TransactionTypeDeposit),
+	)
+	if err != nil {
+		r.logger.ErrorWithErr("Kullanıcı işlem toplamı hesaplanamadı", err, map[string]interface{}{"user_id": userID, "since": since})
+		return nil, fmt.Errorf("kullanıcı işlem toplamı hesaplanamadı: %w", err)
+	}
+	defer rows.Close()
+
+	totals := make(map[string]domain.Money)
 	for rows.Next() {
-		var transaction domain.Transaction
-		var fromUserID, toUserID sql.NullInt64
-		var transactionType, status string
-
-		err := rows.Scan(
-			&transaction.ID,
-			&fromUserID,
-			&toUserID,
-			&transaction.Amount,
-			&transactionType,
-			&status,
-			&transaction.CreatedAt,
-		)
-		if err != nil {
-			r.logger.Error("İşlem verileri okunamadı", map[string]interface{}{"error": err.Error()})
-			return nil, fmt.Errorf("işlem verileri okunamadı: %w", err)
+		var currency string
+		var total domain.Money
+		if err := rows.Scan(&currency, &total); err != nil {
+			r.logger.ErrorWithErr("Kullanıcı işlem toplamı okunamadı", err, map[string]interface{}{"user_id": userID})
+			return nil, fmt.Errorf("kullanıcı işlem toplamı hesaplanamadı: %w", err)
 		}
+		totals[currency] = total
+	}
 
-		if fromUserID.Valid {
-			fuid := fromUserID.Int64
-			transaction.FromUserID = &fuid
-		}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("kullanıcı işlem toplamı hesaplanamadı: %w", err)
+	}
+
+	return totals, nil
+}
+
+func (r *TransactionRepository) FindRecent(ctx context.Context, limit int) ([]*domain.Transaction, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1
+	`
+
+	rows, err := r.conn.GetReadDB().QueryContext(ctx, query, limit)
+	if err != nil {
+		r.logger.ErrorWithErr("Son işlemler bulunamadı", err, map[string]interface{}{"limit": limit})
+		return nil, fmt.Errorf("son işlemler bulunamadı: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanTransactions(rows)
+}
+
+func (r *TransactionRepository) Summarize(ctx context.Context, since time.Time) (*domain.TransactionSummary, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	summary := &domain.TransactionSummary{CompletedVolume: make(map[string]domain.Money)}
+
+	countQuery := `
+		SELECT
+			(SELECT COUNT(*) FROM transactions),
+			(SELECT COUNT(*) FROM (
+				SELECT from_user_id FROM transactions WHERE created_at >= $1 AND from_user_id IS NOT NULL
+				UNION
+				SELECT to_user_id FROM transactions WHERE created_at >= $1 AND to_user_id IS NOT NULL
+			) active)
+	`
+	if err := r.conn.GetReadDB().QueryRowContext(ctx, countQuery, since).Scan(&summary.TotalTransactions, &summary.ActiveUsers); err != nil {
+		r.logger.ErrorWithErr("İşlem özeti hesaplanamadı", err, map[string]interface{}{"since": since})
+		return nil, fmt.Errorf("işlem özeti hesaplanamadı: %w", err)
+	}
+
+	volumeQuery := `
+		SELECT currency, COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE status = $1
+		GROUP BY currency
+	`
+	rows, err := r.conn.GetReadDB().QueryContext(ctx, volumeQuery, string(domain.TransactionStatusCompleted))
+	if err != nil {
+		r.logger.ErrorWithErr("İşlem hacmi hesaplanamadı", err, nil)
+		return nil, fmt.Errorf("işlem hacmi hesaplanamadı: %w", err)
+	}
+	defer rows.Close()
 
-		if toUserID.Valid {
-			tuid := toUserID.Int64
-			transaction.ToUserID = &tuid
+	for rows.Next() {
+		var currency string
+		var volume domain.Money
+		if err := rows.Scan(&currency, &volume); err != nil {
+			r.logger.ErrorWithErr("İşlem hacmi okunamadı", err, nil)
+			return nil, fmt.Errorf("işlem hacmi okunamadı: %w", err)
 		}
+		summary.CompletedVolume[currency] = volume
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.ErrorWithErr("Satır döngüsü sırasında hata oluştu", err, nil)
+		return nil, fmt.Errorf("işlem hacmi okunamadı: %w", err)
+	}
+
+	return summary, nil
+}
 
-		transaction.Type = domain.TransactionType(transactionType)
-		transaction.Status = domain.TransactionStatus(status)
+func (r *TransactionRepository) scanTransactions(rows *sql.Rows) ([]*domain.Transaction, error) {
+	transactions := make([]*domain.Transaction, 0)
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			r.logger.ErrorWithErr("İşlem verileri okunamadı", err, nil)
+			return nil, fmt.Errorf("işlem verileri okunamadı: %w", err)
+		}
 
-		transactions = append(transactions, &transaction)
+		transactions = append(transactions, transaction)
 	}
 
-	if err = rows.Err(); err != nil {
-		r.logger.Error("Satır döngüsü sırasında hata oluştu", map[string]interface{}{"error": err.Error()})
+	if err := rows.Err(); err != nil {
+		r.logger.ErrorWithErr("Satır döngüsü sırasında hata oluştu", err, nil)
 		return nil, fmt.Errorf("işlem verileri okunamadı: %w", err)
 	}
 
 	return transactions, nil
 }
 
-func (r *TransactionRepository) Create(transaction *domain.Transaction) error {
+func (r *TransactionRepository) Create(ctx context.Context, transaction *domain.Transaction) error {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		INSERT INTO transactions (from_user_id, to_user_id, amount, type, status, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO transactions (from_user_id, to_user_id, amount, currency, type, status, created_at, idempotency_key, to_currency, converted_amount, exchange_rate, scheduled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
 	`
 
@@ -145,38 +423,120 @@ func (r *TransactionRepository) Create(transaction *domain.Transaction) error {
 		toUserID = nil
 	}
 
+	var idempotencyKey interface{}
+	if transaction.IdempotencyKey != "" {
+		idempotencyKey = transaction.IdempotencyKey
+	}
+
+	var toCurrency, convertedAmount, exchangeRate interface{}
+	if transaction.ToCurrency != "" {
+		toCurrency = transaction.ToCurrency
+		convertedAmount = transaction.ConvertedAmount
+		exchangeRate = transaction.ExchangeRate
+	}
+
+	var scheduledAt interface{}
+	if transaction.ScheduledAt != nil {
+		scheduledAt = *transaction.ScheduledAt
+	}
+
 	transaction.CreatedAt = time.Now()
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		query,
 		fromUserID,
 		toUserID,
 		transaction.Amount,
+		transaction.Currency,
 		string(transaction.Type),
 		string(transaction.Status),
 		transaction.CreatedAt,
+		idempotencyKey,
+		toCurrency,
+		convertedAmount,
+		exchangeRate,
+		scheduledAt,
 	).Scan(&transaction.ID)
 
 	if err != nil {
-		r.logger.Error("İşlem oluşturulamadı", map[string]interface{}{"error": err.Error()})
+		if r.conn.Dialect().IsUniqueViolation(err) && transaction.IdempotencyKey != "" {
+			return domain.ErrDuplicateIdempotencyKey
+		}
+		r.logger.ErrorWithErr("İşlem oluşturulamadı", err, nil)
 		return fmt.Errorf("işlem oluşturulamadı: %w", err)
 	}
 
+	if transaction.FromUserID != nil {
+		r.conn.MarkUserWrite(*transaction.FromUserID)
+	}
+	if transaction.ToUserID != nil {
+		r.conn.MarkUserWrite(*transaction.ToUserID)
+	}
+
 	return nil
 }
 
-func (r *TransactionRepository) UpdateStatus(id int64, status domain.TransactionStatus) error {
+func (r *TransactionRepository) UpdateStatus(ctx context.Context, id int64, status domain.TransactionStatus) error {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		UPDATE transactions
 		SET status = $1
 		WHERE id = $2
 	`
 
-	_, err := r.db.Exec(query, string(status), id)
+	_, err := r.db.ExecContext(ctx, query, string(status), id)
 	if err != nil {
-		r.logger.Error("İşlem durumu güncellenemedi", map[string]interface{}{"id": id, "error": err.Error()})
+		r.logger.ErrorWithErr("İşlem durumu güncellenemedi", err, map[string]interface{}{"id": id})
 		return fmt.Errorf("işlem durumu güncellenemedi: %w", err)
 	}
 
 	return nil
 }
+
+func (r *TransactionRepository) TransitionStatus(ctx context.Context, id int64, from, to domain.TransactionStatus) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE transactions
+		SET status = $1
+		WHERE id = $2 AND status = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, string(to), id, string(from))
+	if err != nil {
+		r.logger.ErrorWithErr("İşlem durumu güncellenemedi", err, map[string]interface{}{"id": id, "from": from, "to": to})
+		return false, fmt.Errorf("işlem durumu güncellenemedi: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("işlem durumu güncellenemedi: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *TransactionRepository) FindDueScheduled(ctx context.Context, dueBefore time.Time, limit int) ([]*domain.Transaction, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE status = $1 AND scheduled_at <= $2
+		ORDER BY scheduled_at ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, string(domain.TransactionStatusScheduled), dueBefore, limit)
+	if err != nil {
+		r.logger.ErrorWithErr("Zamanı gelen işlemler bulunamadı", err, nil)
+		return nil, fmt.Errorf("zamanı gelen işlemler bulunamadı: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanTransactions(rows)
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"payflow/internal/domain"
+)
+
+func TestIdempotencyKeyIsScopedToOwner(t *testing.T) {
+	router := newTestDB(t)
+	repo := NewTransactionRepository(router, newTestLogger(), 0)
+	ctx := context.Background()
+
+	alice := createTestUser(t, router, "alice")
+	bob := createTestUser(t, router, "bob")
+
+	deposit := func(userID int64) *domain.Transaction {
+		return &domain.Transaction{
+			ToUserID:       &userID,
+			Amount:         domain.NewMoneyFromFloat(10),
+			Currency:       "TRY",
+			Type:           domain.TransactionTypeDeposit,
+			Status:         domain.TransactionStatusPending,
+			IdempotencyKey: "key-1",
+		}
+	}
+
+	if err := repo.Create(ctx, deposit(alice)); err != nil {
+		t.Fatalf("alice: %v", err)
+	}
+	if err := repo.Create(ctx, deposit(bob)); err != nil {
+		t.Fatalf("bob aynı anahtarı kullanabilmeli: %v", err)
+	}
+	if err := repo.Create(ctx, deposit(alice)); !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+		t.Fatalf("beklenen ErrDuplicateIdempotencyKey, alınan: %v", err)
+	}
+
+	found, err := repo.FindByIdempotencyKey(ctx, bob, "key-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || *found.ToUserID != bob {
+		t.Fatalf("bob'un işlemi dönmeli, alınan: %+v", found)
+	}
+
+	if found, err := repo.FindByIdempotencyKey(ctx, bob+100, "key-1"); err != nil || found != nil {
+		t.Fatalf("başka kullanıcının anahtarı eşleşmemeli: %+v, %v", found, err)
+	}
+}
+
+func TestSumUserTransactionsSinceGroupsByCurrency(t *testing.T) {
+	router := newTestDB(t)
+	repo := NewTransactionRepository(router, newTestLogger(), 0)
+	ctx := context.Background()
+
+	alice := createTestUser(t, router, "alice")
+	since := time.Now().Add(-time.Hour)
+
+	create := func(amount float64, currency string, status domain.TransactionStatus) {
+		t.Helper()
+		tx := &domain.Transaction{
+			FromUserID: &alice,
+			Amount:     domain.NewMoneyFromFloat(amount),
+			Currency:   currency,
+			Type:       domain.TransactionTypeWithdraw,
+			Status:     status,
+		}
+		if err := repo.Create(ctx, tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	create(100, "TRY", domain.TransactionStatusCompleted)
+	create(50, "TRY", domain.TransactionStatusPending)
+	create(10, "USD", domain.TransactionStatusProcessing)
+	create(999, "TRY", domain.TransactionStatusFailed)
+
+	totals, err := repo.SumUserTransactionsSince(ctx, alice, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if totals["TRY"] != domain.NewMoneyFromFloat(150) {
+		t.Fatalf("TRY toplamı 150 olmalı, alınan: %s", totals["TRY"])
+	}
+	if totals["USD"] != domain.NewMoneyFromFloat(10) {
+		t.Fatalf("USD toplamı 10 olmalı, alınan: %s", totals["USD"])
+	}
+}
+
+func TestTransitionStatusOnSQLite(t *testing.T) {
+	router := newTestDB(t)
+	repo := NewTransactionRepository(router, newTestLogger(), 0)
+	ctx := context.Background()
+
+	alice := createTestUser(t, router, "alice")
+	tx := &domain.Transaction{
+		ToUserID: &alice,
+		Amount:   domain.NewMoneyFromFloat(10),
+		Currency: "TRY",
+		Type:     domain.TransactionTypeDeposit,
+		Status:   domain.TransactionStatusPending,
+	}
+	if err := repo.Create(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	cutoff := time.Now().Add(time.Second)
+	if count, err := repo.CountByStatus(ctx, domain.TransactionStatusPending, cutoff); err != nil || count != 1 {
+		t.Fatalf("1 bekleyen işlem olmalı: %d, %v", count, err)
+	}
+
+	if ok, err := repo.TransitionStatus(ctx, tx.ID, domain.TransactionStatusPending, domain.TransactionStatusCompleted); err != nil || !ok {
+		t.Fatalf("durum geçişi yapılmalı: %v, %v", ok, err)
+	}
+	if ok, err := repo.TransitionStatus(ctx, tx.ID, domain.TransactionStatusPending, domain.TransactionStatusFailed); err != nil || ok {
+		t.Fatalf("tamamlanmış işlem yeniden sahiplenilmemeli: %v, %v", ok, err)
+	}
+
+	found, err := repo.FindByID(ctx, tx.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || found.Status != domain.TransactionStatusCompleted || found.Amount != tx.Amount {
+		t.Fatalf("tamamlanmış işlem okunmalı, alınan: %+v", found)
+	}
+
+	pending, err := repo.FindByStatus(ctx, domain.TransactionStatusPending, cutoff, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("bekleyen işlem kalmamalı, alınan: %d", len(pending))
+	}
+}
@@ -3,152 +3,129 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"payflow/internal/domain"
+	"payflow/pkg/database"
 	"payflow/pkg/logger"
 )
 
 type UserRepository struct {
 	db     *sql.DB
+	conn   database.Router
 	logger logger.Logger
 }
 
-func NewUserRepository(db *sql.DB, logger logger.Logger) domain.UserRepository {
+func NewUserRepository(conn database.Router, logger logger.Logger) domain.UserRepository {
 	return &UserRepository{
-		db:     db,
+		db:     conn.GetWriteDB(),
+		conn:   conn,
 		logger: logger,
 	}
 }
 
 func (r *UserRepository) FindByID(id int64) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, role, api_key, created_at, updated_at
+		SELECT id, username, email, password_hash, role, api_key_hash, is_active, email_verified_at, password_changed_at, deleted_at, created_at, updated_at
 		FROM users
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	var user domain.User
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash,
-		&user.Role,
-		&user.ApiKey,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.ErrorWithErr("Kullanıcı ID'ye göre bulunamadı", err, map[string]interface{}{"id": id})
+		return nil, fmt.Errorf("kullanıcı bulunamadı: %w", err)
+	}
+
+	return user, nil
+}
+
+// FindByIDIncludingDeleted also returns soft-deleted users, for reporting on
+// history that still references them.
+func (r *UserRepository) FindByIDIncludingDeleted(id int64) (*domain.User, error) {
+	query := `
+		SELECT id, username, email, password_hash, role, api_key_hash, is_active, email_verified_at, password_changed_at, deleted_at, created_at, updated_at
+		FROM users
+		WHERE id = $1
+	`
 
+	user, err := scanUser(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
-		r.logger.Error("Kullanıcı ID'ye göre bulunamadı", map[string]interface{}{"id": id, "error": err.Error()})
+		r.logger.ErrorWithErr("Kullanıcı ID'ye göre bulunamadı", err, map[string]interface{}{"id": id})
 		return nil, fmt.Errorf("kullanıcı bulunamadı: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (r *UserRepository) FindByUsername(username string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, role, api_key, created_at, updated_at
+		SELECT id, username, email, password_hash, role, api_key_hash, is_active, email_verified_at, password_changed_at, deleted_at, created_at, updated_at
 		FROM users
-		WHERE username = $1
+		WHERE username = $1 AND deleted_at IS NULL
 	`
 
-	var user domain.User
-	err := r.db.QueryRow(query, username).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash,
-		&user.Role,
-		&user.ApiKey,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRow(query, username))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
-		r.logger.Error("Kullanıcı adına göre bulunamadı", map[string]interface{}{"username": username, "error": err.Error()})
+		r.logger.ErrorWithErr("Kullanıcı adına göre bulunamadı", err, map[string]interface{}{"username": username})
 		return nil, fmt.Errorf("kullanıcı bulunamadı: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (r *UserRepository) FindByEmail(email string) (*domain.User, error) {
-	var user domain.User
-
 	query := `
-		SELECT id, username, email, password_hash, role, api_key, created_at, updated_at
+		SELECT id, username, email, password_hash, role, api_key_hash, is_active, email_verified_at, password_changed_at, deleted_at, created_at, updated_at
 		FROM users
-		WHERE email = $1
+		WHERE email = $1 AND deleted_at IS NULL
 	`
 
-	err := r.db.QueryRow(query, email).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash,
-		&user.Role,
-		&user.ApiKey,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRow(query, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
-
-		r.logger.Error("Kullanıcı bulunamadı", map[string]interface{}{"email": email, "error": err.Error()})
+		r.logger.ErrorWithErr("Kullanıcı bulunamadı", err, map[string]interface{}{"email": email})
 		return nil, fmt.Errorf("kullanıcı bulunamadı: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
-func (r *UserRepository) FindByApiKey(apiKey string) (*domain.User, error) {
-	var user domain.User
-
+func (r *UserRepository) FindByApiKeyHash(apiKeyHash string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, role, api_key, created_at, updated_at
+		SELECT id, username, email, password_hash, role, api_key_hash, is_active, email_verified_at, password_changed_at, deleted_at, created_at, updated_at
 		FROM users
-		WHERE api_key = $1
+		WHERE api_key_hash = $1 AND deleted_at IS NULL
 	`
 
-	err := r.db.QueryRow(query, apiKey).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash,
-		&user.Role,
-		&user.ApiKey,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRow(query, apiKeyHash))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
-
-		r.logger.Error("Kullanıcı bulunamadı", map[string]interface{}{"api_key": apiKey, "error": err.Error()})
+		r.logger.ErrorWithErr("Kullanıcı bulunamadı", err, nil)
 		return nil, fmt.Errorf("kullanıcı bulunamadı: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (r *UserRepository) Create(user *domain.User) error {
 	query := `
-		INSERT INTO users (username, email, password_hash, role, api_key, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (username, email, password_hash, role, api_key_hash, is_active, email_verified_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id
 	`
 
@@ -166,13 +143,15 @@ func (r *UserRepository) Create(user *domain.User) error {
 		user.Email,
 		user.PasswordHash,
 		user.Role,
-		user.ApiKey,
+		nullableApiKeyHash(user.ApiKeyHash),
+		user.IsActive,
+		user.EmailVerifiedAt,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
 
 	if err != nil {
-		r.logger.Error("Kullanıcı oluşturulamadı", map[string]interface{}{"error": err.Error()})
+		r.logger.ErrorWithErr("Kullanıcı oluşturulamadı", err, nil)
 		return fmt.Errorf("kullanıcı oluşturulamadı: %w", err)
 	}
 
@@ -182,7 +161,7 @@ func (r *UserRepository) Create(user *domain.User) error {
 func (r *UserRepository) Update(user *domain.User) error {
 	query := `
 		UPDATE users
-		SET username = $1, email = $2, password_hash = $3, role = $4, api_key = $5, updated_at = $6
+		SET username = $1, email = $2, password_hash = $3, role = $4, api_key_hash = $5, updated_at = $6
 		WHERE id = $7
 	`
 
@@ -194,28 +173,282 @@ func (r *UserRepository) Update(user *domain.User) error {
 		user.Email,
 		user.PasswordHash,
 		user.Role,
-		user.ApiKey,
+		nullableApiKeyHash(user.ApiKeyHash),
 		user.UpdatedAt,
 		user.ID,
 	)
 
 	if err != nil {
-		r.logger.Error("Kullanıcı güncellenemedi", map[string]interface{}{"id": user.ID, "error": err.Error()})
+		r.logger.ErrorWithErr("Kullanıcı güncellenemedi", err, map[string]interface{}{"id": user.ID})
 		return fmt.Errorf("kullanıcı güncellenemedi: %w", err)
 	}
 
 	return nil
 }
 
+// Delete soft-deletes the user; the row stays so transactions and audit logs
+// referencing it remain intact.
 func (r *UserRepository) Delete(id int64) error {
-	query := `DELETE FROM users WHERE id = $1`
+	query := `UPDATE users SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND deleted_at IS NULL`
 
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.Exec(query, time.Now(), id)
 
 	if err != nil {
-		r.logger.Error("Kullanıcı silinemedi", map[string]interface{}{"id": id, "error": err.Error()})
+		r.logger.ErrorWithErr("Kullanıcı silinemedi", err, map[string]interface{}{"id": id})
 		return fmt.Errorf("kullanıcı silinemedi: %w", err)
 	}
 
 	return nil
 }
+
+func (r *UserRepository) Restore(id int64) error {
+	query := `UPDATE users SET deleted_at = NULL, updated_at = $1 WHERE id = $2 AND deleted_at IS NOT NULL`
+
+	_, err := r.db.Exec(query, time.Now(), id)
+
+	if err != nil {
+		r.logger.ErrorWithErr("Kullanıcı geri yüklenemedi", err, map[string]interface{}{"id": id})
+		return fmt.Errorf("kullanıcı geri yüklenemedi: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) CreateActivationToken(userID int64, tokenHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO user_activation_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.Exec(query, userID, tokenHash, expiresAt, time.Now())
+	if err != nil {
+		r.logger.ErrorWithErr("Aktivasyon kodu kaydedilemedi", err, map[string]interface{}{"user_id": userID})
+		return fmt.Errorf("aktivasyon kodu kaydedilemedi: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeActivationToken marks an unused, unexpired token as used and returns
+// its user. The single UPDATE makes redemption atomic, so a token works once.
+func (r *UserRepository) ConsumeActivationToken(tokenHash string, now time.Time) (int64, error) {
+	query := `
+		UPDATE user_activation_tokens
+		SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING user_id
+	`
+
+	var userID int64
+	err := r.db.QueryRow(query, tokenHash, now).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, domain.ErrInvalidActivationToken
+		}
+		r.logger.ErrorWithErr("Aktivasyon kodu kullanılamadı", err, nil)
+		return 0, fmt.Errorf("aktivasyon kodu kullanılamadı: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (r *UserRepository) Activate(userID int64, verifiedAt time.Time) error {
+	query := `
+		UPDATE users
+		SET is_active = TRUE, email_verified_at = COALESCE(email_verified_at, $1), updated_at = $1
+		WHERE id = $2
+	`
+
+	_, err := r.db.Exec(query, verifiedAt, userID)
+	if err != nil {
+		r.logger.ErrorWithErr("Kullanıcı aktifleştirilemedi", err, map[string]interface{}{"id": userID})
+		return fmt.Errorf("kullanıcı aktifleştirilemedi: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) CreatePasswordResetToken(userID int64, tokenHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.Exec(query, userID, tokenHash, expiresAt, time.Now())
+	if err != nil {
+		r.logger.ErrorWithErr("Şifre sıfırlama kodu kaydedilemedi", err, map[string]interface{}{"user_id": userID})
+		return fmt.Errorf("şifre sıfırlama kodu kaydedilemedi: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) ConsumePasswordResetToken(tokenHash string, now time.Time) (int64, error) {
+	query := `
+		UPDATE password_reset_tokens
+		SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING user_id
+	`
+
+	var userID int64
+	err := r.db.QueryRow(query, tokenHash, now).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, domain.ErrInvalidResetToken
+		}
+		r.logger.ErrorWithErr("Şifre sıfırlama kodu kullanılamadı", err, nil)
+		return 0, fmt.Errorf("şifre sıfırlama kodu kullanılamadı: %w", err)
+	}
+
+	return userID, nil
+}
+
+// ResetPassword stores the new hash, drops the API key and records the change
+// time so previously issued JWTs are rejected. Other pending reset tokens of
+// the user are invalidated as well.
+func (r *UserRepository) ResetPassword(userID int64, passwordHash string, changedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		r.logger.ErrorWithErr("Veritabanı işlemi başlatılamadı", err, nil)
+		return fmt.Errorf("şifre sıfırlanamadı: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET password_hash = $1, api_key_hash = NULL, password_changed_at = $2, updated_at = $2
+		WHERE id = $3
+	`
+
+	if _, err := tx.Exec(query, passwordHash, changedAt, userID); err != nil {
+		r.logger.ErrorWithErr("Şifre sıfırlanamadı", err, map[string]interface{}{"id": userID})
+		return fmt.Errorf("şifre sıfırlanamadı: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE password_reset_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL`, changedAt, userID); err != nil {
+		r.logger.ErrorWithErr("Şifre sıfırlama kodları geçersiz kılınamadı", err, map[string]interface{}{"id": userID})
+		return fmt.Errorf("şifre sıfırlanamadı: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.ErrorWithErr("Şifre sıfırlama commit edilemedi", err, map[string]interface{}{"id": userID})
+		return fmt.Errorf("şifre sıfırlanamadı: %w", err)
+	}
+
+	return nil
+}
+
+// Count returns the number of users that are not soft-deleted and match
+// search the same way FindAll does.
+func (r *UserRepository) Count(search string) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM users
+		WHERE deleted_at IS NULL AND ($1 = '' OR LOWER(username) LIKE LOWER($1) || '%' ESCAPE '\' OR LOWER(email) LIKE LOWER($1) || '%' ESCAPE '\')
+	`
+
+	var count int64
+	if err := r.conn.GetReadDB().QueryRow(query, escapeLikePattern(search)).Scan(&count); err != nil {
+		r.logger.ErrorWithErr("Kullanıcı sayısı alınamadı", err, nil)
+		return 0, fmt.Errorf("kullanıcı sayısı alınamadı: %w", err)
+	}
+
+	return count, nil
+}
+
+// FindAll lists users ordered by ID. A non-empty search matches the start of
+// the username or email, case-insensitively.
+func (r *UserRepository) FindAll(limit, offset int, search string) ([]*domain.User, error) {
+	query := `
+		SELECT id, username, email, password_hash, role, api_key_hash, is_active, email_verified_at, password_changed_at, deleted_at, created_at, updated_at
+		FROM users
+		WHERE deleted_at IS NULL AND ($3 = '' OR LOWER(username) LIKE LOWER($3) || '%' ESCAPE '\' OR LOWER(email) LIKE LOWER($3) || '%' ESCAPE '\')
+		ORDER BY id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.conn.GetReadDB().Query(query, limit, offset, escapeLikePattern(search))
+	if err != nil {
+		r.logger.Error("Kullanıcılar listelenemedi", map[string]interface{}{
+			"limit":  limit,
+			"offset": offset,
+			"error":  err.Error(),
+		})
+		return nil, fmt.Errorf("kullanıcılar listelenemedi: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			r.logger.ErrorWithErr("Kullanıcı verileri okunamadı", err, nil)
+			return nil, fmt.Errorf("kullanıcı verileri okunamadı: %w", err)
+		}
+
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.ErrorWithErr("Satır döngüsü sırasında hata oluştu", err, nil)
+		return nil, fmt.Errorf("kullanıcı verileri okunamadı: %w", err)
+	}
+
+	return users, nil
+}
+
+type userScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row userScanner) (*domain.User, error) {
+	var user domain.User
+	var apiKeyHash sql.NullString
+	var emailVerifiedAt, passwordChangedAt, deletedAt sql.NullTime
+
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&apiKeyHash,
+		&user.IsActive,
+		&emailVerifiedAt,
+		&passwordChangedAt,
+		&deletedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user.ApiKeyHash = apiKeyHash.String
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	if passwordChangedAt.Valid {
+		user.PasswordChangedAt = &passwordChangedAt.Time
+	}
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+
+	return &user, nil
+}
+
+// escapeLikePattern makes user input match literally inside a LIKE pattern.
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// nullableApiKeyHash stores users without an API key as NULL so they do not
+// collide on the unique index.
+func nullableApiKeyHash(apiKeyHash string) interface{} {
+	if apiKeyHash == "" {
+		return nil
+	}
+	return apiKeyHash
+}
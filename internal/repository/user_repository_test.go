@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"payflow/internal/domain"
+	sqldialect "payflow/pkg/database"
+)
+
+// splitRouter sends reads and writes to different databases, standing in for
+// a master with one replica that has not caught up.
+type splitRouter struct {
+	master  *sql.DB
+	replica *sql.DB
+	dialect sqldialect.Dialect
+}
+
+func (r *splitRouter) GetWriteDB() *sql.DB            { return r.master }
+func (r *splitRouter) GetReadDB() *sql.DB             { return r.replica }
+func (r *splitRouter) GetReadDBForUser(int64) *sql.DB { return r.replica }
+func (r *splitRouter) MarkUserWrite(int64)            {}
+func (r *splitRouter) Dialect() sqldialect.Dialect    { return r.dialect }
+
+func TestUserListingReadsFromReplica(t *testing.T) {
+	master, dialect := openTestDB(t, t.Name()+"/master")
+	replica, _ := openTestDB(t, t.Name()+"/replica")
+
+	onReplica := createTestUser(t, &testRouter{db: replica, dialect: dialect}, "replica_only")
+
+	repo := NewUserRepository(&splitRouter{master: master, replica: replica, dialect: dialect}, newTestLogger())
+	created := &domain.User{
+		Username:     "master_only",
+		Email:        "master_only@example.com",
+		PasswordHash: "hash",
+		Role:         "user",
+		IsActive:     true,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := repo.Create(created); err != nil {
+		t.Fatal(err)
+	}
+	onMaster := created.ID
+
+	// The insert went to the master only.
+	var masterCount, replicaCount int
+	master.QueryRow("SELECT COUNT(*) FROM users WHERE username = 'master_only'").Scan(&masterCount)
+	replica.QueryRow("SELECT COUNT(*) FROM users WHERE username = 'master_only'").Scan(&replicaCount)
+	if masterCount != 1 || replicaCount != 0 {
+		t.Fatalf("INSERT yalnızca master'a gitmeli, master: %d, replika: %d", masterCount, replicaCount)
+	}
+
+	users, err := repo.FindAll(10, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].ID != onReplica || users[0].Username != "replica_only" {
+		t.Fatalf("listeleme replikadan okunmalı, alınan: %+v", users)
+	}
+
+	count, err := repo.Count("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("sayım replikadan okunmalı, alınan: %d", count)
+	}
+
+	// Lookups by id stay on the master so callers see their own writes.
+	user, err := repo.FindByID(onMaster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user == nil || user.Username != "master_only" {
+		t.Fatalf("ID ile arama master'dan okunmalı, alınan: %+v", user)
+	}
+}
+
+func TestUserLifecycleOnSQLite(t *testing.T) {
+	router := newTestDB(t)
+	repo := NewUserRepository(router, newTestLogger())
+
+	id := createTestUser(t, router, "alice")
+
+	byName, err := repo.FindByUsername("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byEmail, err := repo.FindByEmail("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byName == nil || byEmail == nil || byName.ID != id || byEmail.ID != id {
+		t.Fatalf("kullanıcı ad ve e-posta ile bulunmalı: %+v, %+v", byName, byEmail)
+	}
+
+	byName.Email = "alice@example.org"
+	if err := repo.Update(byName); err != nil {
+		t.Fatal(err)
+	}
+	if user, err := repo.FindByEmail("alice@example.org"); err != nil || user == nil {
+		t.Fatalf("güncellenen e-posta kaydedilmeli: %+v, %v", user, err)
+	}
+
+	if err := repo.Delete(id); err != nil {
+		t.Fatal(err)
+	}
+	if user, err := repo.FindByID(id); err != nil || user != nil {
+		t.Fatalf("silinen kullanıcı bulunmamalı: %+v, %v", user, err)
+	}
+	if user, err := repo.FindByIDIncludingDeleted(id); err != nil || user == nil {
+		t.Fatalf("silinen kullanıcı geri yüklenebilmek için saklanmalı: %+v, %v", user, err)
+	}
+	if err := repo.Restore(id); err != nil {
+		t.Fatal(err)
+	}
+	if user, err := repo.FindByID(id); err != nil || user == nil {
+		t.Fatalf("geri yüklenen kullanıcı bulunmalı: %+v, %v", user, err)
+	}
+
+	now := time.Now()
+	if err := repo.CreateActivationToken(id, "token-hash", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	userID, err := repo.ConsumeActivationToken("token-hash", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID != id {
+		t.Fatalf("kod kullanıcıya ait olmalı, alınan: %d", userID)
+	}
+	if _, err := repo.ConsumeActivationToken("token-hash", now); !errors.Is(err, domain.ErrInvalidActivationToken) {
+		t.Fatalf("kod ikinci kez kullanılamamalı, alınan: %v", err)
+	}
+}
@@ -4,28 +4,41 @@ import (
 	"fmt"
 	"time"
 
+	"payflow/internal/config"
 	"payflow/internal/domain"
 	"payflow/pkg/logger"
 )
 
+const defaultRetentionBatch = 1000
+
 type AuditLogService struct {
 	repo   domain.AuditLogRepository
 	logger logger.Logger
+	cfg    config.AuditLogConfig
+
+	stop chan struct{}
+	done chan struct{}
 }
 
-func NewAuditLogService(repo domain.AuditLogRepository, logger logger.Logger) domain.AuditLogService {
+func NewAuditLogService(repo domain.AuditLogRepository, logger logger.Logger, cfg config.AuditLogConfig) domain.AuditLogService {
+	if cfg.RetentionBatch <= 0 {
+		cfg.RetentionBatch = defaultRetentionBatch
+	}
+
 	return &AuditLogService{
 		repo:   repo,
 		logger: logger,
+		cfg:    cfg,
 	}
 }
 
-func (s *AuditLogService) LogAction(entityType domain.EntityType, entityID int64, action domain.ActionType, details string) error {
+func (s *AuditLogService) LogAction(entityType domain.EntityType, entityID int64, action domain.ActionType, details string, actorID int64) error {
 	auditLog := &domain.AuditLog{
 		EntityType: entityType,
 		EntityID:   entityID,
 		Action:     action,
 		Details:    details,
+		ActorID:    actorID,
 		CreatedAt:  time.Now(),
 	}
 
@@ -56,7 +69,7 @@ func (s *AuditLogService) GetEntityLogs(entityType domain.EntityType, entityID i
 	return logs, nil
 }
 
-func (s *AuditLogService) GetAllLogs(page, pageSize int) ([]*domain.AuditLog, error) {
+func (s *AuditLogService) GetAllLogs(page, pageSize int) ([]*domain.AuditLog, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -74,8 +87,117 @@ func (s *AuditLogService) GetAllLogs(page, pageSize int) ([]*domain.AuditLog, er
 			"page_size": pageSize,
 			"error":     err.Error(),
 		})
-		return nil, fmt.Errorf("denetim kayıtları bulunamadı: %w", err)
+		return nil, 0, fmt.Errorf("denetim kayıtları bulunamadı: %w", err)
 	}
 
-	return logs, nil
+	totalCount, err := s.repo.Count()
+	if err != nil {
+		return nil, 0, fmt.Errorf("denetim kayıtları bulunamadı: %w", err)
+	}
+
+	return logs, totalCount, nil
+}
+
+func (s *AuditLogService) ExportLogs(start, end time.Time, fn func(*domain.AuditLog) error) error {
+	if err := s.repo.StreamByDateRange(start, end, fn); err != nil {
+		s.logger.Error("Denetim kayıtları dışa aktarılamadı", map[string]interface{}{
+			"start_date": start,
+			"end_date":   end,
+			"error":      err.Error(),
+		})
+		return fmt.Errorf("denetim kayıtları dışa aktarılamadı: %w", err)
+	}
+
+	return nil
+}
+
+// Start runs the retention job on a ticker. It is a no-op when no retention
+// window or interval is configured.
+func (s *AuditLogService) Start() {
+	if s.cfg.Retention <= 0 || s.cfg.RetentionInterval <= 0 || s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.cfg.RetentionInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.applyRetention()
+			}
+		}
+	}()
+}
+
+func (s *AuditLogService) Stop() {
+	if s.stop == nil {
+		return
+	}
+
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+// applyRetention archives (or deletes) rows older than the retention window
+// in batches, so each statement only locks a bounded number of rows. The run
+// itself is recorded as an audit log.
+func (s *AuditLogService) applyRetention() {
+	cutoff := time.Now().Add(-s.cfg.Retention)
+
+	purge := s.repo.ArchiveOlderThan
+	action := domain.ActionTypeArchive
+	if !s.cfg.ArchiveEnabled {
+		purge = s.repo.DeleteOlderThan
+		action = domain.ActionTypeDelete
+	}
+
+	var total int64
+batches:
+	for {
+		select {
+		case <-s.stop:
+			break batches
+		default:
+		}
+
+		n, err := purge(cutoff, s.cfg.RetentionBatch)
+		if err != nil {
+			s.logger.Error("Denetim kaydı saklama işlemi başarısız", map[string]interface{}{
+				"cutoff":    cutoff,
+				"processed": total,
+				"error":     err.Error(),
+			})
+			break
+		}
+
+		total += n
+		if n < int64(s.cfg.RetentionBatch) {
+			break
+		}
+	}
+
+	if total == 0 {
+		return
+	}
+
+	s.logger.Info("Süresi dolan denetim kayıtları temizlendi", map[string]interface{}{
+		"action": action,
+		"count":  total,
+		"cutoff": cutoff,
+	})
+
+	details := fmt.Sprintf("%s öncesine ait %d denetim kaydı işlendi (%s)", cutoff.Format(time.RFC3339), total, action)
+	if err := s.LogAction(domain.EntityTypeAuditLog, 0, action, details, domain.SystemActorID); err != nil {
+		s.logger.ErrorWithErr("Saklama işlemi denetim kaydına yazılamadı", err, nil)
+	}
 }
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -19,7 +21,9 @@ type BalanceService struct {
 	auditLogRepo domain.AuditLogRepository
 	eventStore   domain.EventStoreService
 	logger       logger.Logger
-	redisClient  *redis.Client
+	redisClient  redis.UniversalClient
+
+	defaultCurrency string
 }
 
 func NewBalanceService(
@@ -27,50 +31,151 @@ func NewBalanceService(
 	auditLogRepo domain.AuditLogRepository,
 	eventStore domain.EventStoreService,
 	logger logger.Logger,
-	redisClient *redis.Client,
+	redisClient redis.UniversalClient,
+	defaultCurrency string,
 ) domain.BalanceService {
 	return &BalanceService{
-		repo:         repo,
-		auditLogRepo: auditLogRepo,
-		eventStore:   eventStore,
-		logger:       logger,
-		redisClient:  redisClient,
+		repo:            repo,
+		auditLogRepo:    auditLogRepo,
+		eventStore:      eventStore,
+		logger:          logger,
+		redisClient:     redisClient,
+		defaultCurrency: defaultCurrency,
 	}
 }
 
-func (s *BalanceService) saveEvent(balance *domain.Balance, eventType domain.EventType) error {
-	eventData, err := json.Marshal(balance)
-	if err != nil {
-		return err
-	}
-
-	lastVersion, err := s.eventStore.GetLastVersion("balance", fmt.Sprintf("%d", balance.UserID))
+func (s *BalanceService) saveEvent(userID int64, currency string, operation domain.BalanceOperation, amount domain.Money, transactionID int64) error {
+	eventData, err := json.Marshal(&domain.BalanceEvent{
+		UserID:        userID,
+		Currency:      currency,
+		Operation:     operation,
+		Amount:        amount,
+		TransactionID: transactionID,
+		OccurredAt:    time.Now(),
+	})
 	if err != nil {
 		return err
 	}
 
 	event := &domain.Event{
-		AggregateID:   fmt.Sprintf("%d", balance.UserID),
+		AggregateID:   fmt.Sprintf("%d", userID),
 		AggregateType: "balance",
-		EventType:     eventType,
+		EventType:     domain.EventTypeBalanceUpdated,
 		EventData:     eventData,
-		Version:       lastVersion + 1,
 		CreatedAt:     time.Now(),
 	}
 
-	return s.eventStore.SaveEvent(event)
+	if err := s.eventStore.SaveEvent(event); err != nil {
+		return err
+	}
+
+	if s.eventStore.ShouldSnapshot(event.Version) {
+		s.snapshotBalanceState(userID)
+	}
+
+	return nil
+}
+
+// loadBalanceState folds the balance events of a user into the latest balance
+// per currency, starting from the most recent snapshot when there is one.
+func (s *BalanceService) loadBalanceState(userID int64) (map[string]*domain.Balance, int, error) {
+	return s.reconstructBalanceState(userID, math.MaxInt)
+}
+
+// reconstructBalanceState returns the balances as of version upToVersion,
+// folding from the latest snapshot that does not exceed it, or from zero.
+func (s *BalanceService) reconstructBalanceState(userID int64, upToVersion int) (map[string]*domain.Balance, int, error) {
+	aggregateID := fmt.Sprintf("%d", userID)
+	state := make(map[string]*domain.Balance)
+	version := 0
+
+	snapshot, err := s.eventStore.GetLatestSnapshot("balance", aggregateID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if snapshot != nil && snapshot.Version <= upToVersion {
+		if err := json.Unmarshal(snapshot.State, &state); err != nil {
+			return nil, 0, err
+		}
+		version = snapshot.Version
+	}
+
+	events, err := s.eventStore.GetEventsAfterVersion("balance", aggregateID, version)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, event := range events {
+		if event.Version > upToVersion {
+			break
+		}
+		version = event.Version
+
+		if err := s.applyBalanceEvent(userID, state, event); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return state, version, nil
+}
+
+func (s *BalanceService) applyBalanceEvent(userID int64, state map[string]*domain.Balance, event *domain.Event) error {
+	if event.EventType != domain.EventTypeBalanceUpdated {
+		return nil
+	}
+
+	var balanceEvent domain.BalanceEvent
+	if err := json.Unmarshal(event.EventData, &balanceEvent); err != nil {
+		return err
+	}
+
+	if balanceEvent.Currency == "" {
+		balanceEvent.Currency = s.defaultCurrency
+	}
+
+	if balanceEvent.OccurredAt.IsZero() {
+		balanceEvent.OccurredAt = event.CreatedAt
+	}
+
+	balance, ok := state[balanceEvent.Currency]
+	if !ok {
+		balance = &domain.Balance{UserID: userID, Currency: balanceEvent.Currency}
+		state[balanceEvent.Currency] = balance
+	}
+
+	balanceEvent.Apply(balance)
+	return nil
+}
+
+func (s *BalanceService) snapshotBalanceState(userID int64) {
+	state, version, err := s.loadBalanceState(userID)
+	if err != nil {
+		s.logger.ErrorWithErr("Bakiye snapshot'ı için durum hesaplanamadı", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+
+	if err := s.eventStore.SaveSnapshot("balance", fmt.Sprintf("%d", userID), version, state); err != nil {
+		s.logger.ErrorWithErr("Bakiye snapshot'ı kaydedilemedi", err, map[string]interface{}{"user_id": userID, "version": version})
+	}
 }
 
-func (s *BalanceService) GetBalance(userID int64) (*domain.Balance, error) {
-	_, span := tracing.StartSpan(context.Background(), "BalanceService.GetBalance")
+func (s *BalanceService) GetBalance(ctx context.Context, userID int64, currency string) (*domain.Balance, error) {
+	ctx, span := tracing.StartSpan(ctx, "BalanceService.GetBalance")
 	defer span.End()
 
+	currency, err := domain.NormalizeCurrency(currency, s.defaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
 	tracing.AddAttribute(span, "user_id", userID)
+	tracing.AddAttribute(span, "currency", currency)
 
 	startTime := time.Now()
-	balance, err := s.repo.FindByUserID(userID)
+	balance, err := s.repo.FindByUserAndCurrency(ctx, userID, currency)
 	if err != nil {
-		s.logger.Error("Bakiye bulunamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		s.logger.ErrorWithErr("Bakiye bulunamadı", err, map[string]interface{}{"user_id": userID})
 		return nil, err
 	}
 	metrics.RecordDatabaseOperation("find", "balance", time.Since(startTime))
@@ -78,61 +183,89 @@ func (s *BalanceService) GetBalance(userID int64) (*domain.Balance, error) {
 	return balance, nil
 }
 
-func (s *BalanceService) DepositAtomically(userID int64, amount float64) (*domain.Balance, error) {
-	_, span := tracing.StartSpan(context.Background(), "BalanceService.DepositAtomically")
+// GetBalances returns the balances of userIDs in currency in one query. Users
+// without a balance get a zero balance instead of failing the whole batch.
+func (s *BalanceService) GetBalances(ctx context.Context, userIDs []int64, currency string) (map[int64]*domain.Balance, error) {
+	ctx, span := tracing.StartSpan(ctx, "BalanceService.GetBalances")
 	defer span.End()
 
-	tracing.AddAttribute(span, "user_id", userID)
-	tracing.AddAttribute(span, "amount", amount)
+	currency, err := domain.NormalizeCurrency(currency, s.defaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	tracing.AddAttribute(span, "users", len(userIDs))
+	tracing.AddAttribute(span, "currency", currency)
+
+	result := make(map[int64]*domain.Balance, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
 
 	startTime := time.Now()
-	balance, err := s.repo.FindByUserID(userID)
+	balances, err := s.repo.FindByUsersAndCurrency(ctx, userIDs, currency)
 	if err != nil {
-		s.logger.Error("Bakiye bulunamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		s.logger.ErrorWithErr("Bakiyeler bulunamadı", err, map[string]interface{}{"users": len(userIDs)})
 		return nil, err
 	}
-	metrics.RecordDatabaseOperation("find", "balance", time.Since(startTime))
+	metrics.RecordDatabaseOperation("find_many", "balance", time.Since(startTime))
 
-	if balance == nil {
-		balance = &domain.Balance{
-			UserID:        userID,
-			Amount:        0,
-			LastUpdatedAt: time.Now(),
+	for _, balance := range balances {
+		result[balance.UserID] = balance
+	}
+	for _, userID := range userIDs {
+		if _, ok := result[userID]; !ok {
+			result[userID] = &domain.Balance{UserID: userID, Currency: currency}
 		}
 	}
 
-	newAmount := balance.Amount + amount
-	balance.Amount = newAmount
-	balance.LastUpdatedAt = time.Now()
+	return result, nil
+}
 
-	startTime = time.Now()
-	balanceUpdated, err := s.repo.Update(balance)
+func (s *BalanceService) DepositAtomically(userID int64, currency string, amount domain.Money, transactionID int64) (*domain.Balance, error) {
+	_, span := tracing.StartSpan(context.Background(), "BalanceService.DepositAtomically")
+	defer span.End()
+
+	currency, err := domain.NormalizeCurrency(currency, s.defaultCurrency)
 	if err != nil {
-		s.logger.Error("Bakiye güncellenemedi", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+
+	tracing.AddAttribute(span, "user_id", userID)
+	tracing.AddAttribute(span, "currency", currency)
+	tracing.AddAttribute(span, "amount", amount)
+
+	startTime := time.Now()
+	balanceUpdated, err := s.repo.Deposit(context.Background(), userID, currency, amount, transactionID)
+	if err != nil {
+		s.logger.ErrorWithErr("Bakiye güncellenemedi", err, map[string]interface{}{"user_id": userID})
 		return nil, err
 	}
 	metrics.RecordDatabaseOperation("update", "balance", time.Since(startTime))
 
-	if err := s.saveEvent(balanceUpdated, domain.EventTypeBalanceUpdated); err != nil {
-		s.logger.Error("Event kaydedilemedi", map[string]interface{}{"error": err.Error()})
+	newAmount := balanceUpdated.Amount
+
+	if err := s.saveEvent(userID, currency, domain.BalanceOperationDeposit, amount, transactionID); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
 	}
 
 	auditLog := &domain.AuditLog{
 		EntityType: domain.EntityTypeBalance,
 		EntityID:   userID,
 		Action:     domain.ActionTypeUpdate,
-		Details:    fmt.Sprintf("Atomik para yatırma: +%.2f", amount),
+		Details:    fmt.Sprintf("Atomik para yatırma: +%s %s", amount, currency),
 		CreatedAt:  time.Now(),
 	}
 
 	startTime = time.Now()
 	if err := s.auditLogRepo.Create(auditLog); err != nil {
-		s.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": userID})
 	}
 	metrics.RecordDatabaseOperation("create", "audit_log", time.Since(startTime))
 
 	s.logger.InfoContext(context.Background(), "Para yatırma işlemi başarıyla tamamlandı", map[string]interface{}{
 		"user_id":     userID,
+		"currency":    currency,
 		"amount":      amount,
 		"new_balance": newAmount,
 	})
@@ -140,63 +273,57 @@ func (s *BalanceService) DepositAtomically(userID int64, amount float64) (*domai
 	return balanceUpdated, nil
 }
 
-func (s *BalanceService) WithdrawAtomically(userID int64, amount float64) (*domain.Balance, error) {
+func (s *BalanceService) WithdrawAtomically(userID int64, currency string, amount domain.Money, transactionID int64) (*domain.Balance, error) {
 	_, span := tracing.StartSpan(context.Background(), "BalanceService.WithdrawAtomically")
 	defer span.End()
 
-	tracing.AddAttribute(span, "user_id", userID)
-	tracing.AddAttribute(span, "amount", amount)
-
-	startTime := time.Now()
-	balance, err := s.repo.FindByUserID(userID)
+	currency, err := domain.NormalizeCurrency(currency, s.defaultCurrency)
 	if err != nil {
-		s.logger.Error("Bakiye bulunamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
 		return nil, err
 	}
-	metrics.RecordDatabaseOperation("find", "balance", time.Since(startTime))
-
-	if balance == nil {
-		s.logger.Error("Bakiye bulunamadı", map[string]interface{}{"user_id": userID})
-		return nil, domain.ErrBalanceNotFound
-	}
 
-	if balance.Amount < amount {
-		s.logger.Error("Yetersiz bakiye", map[string]interface{}{"user_id": userID, "balance": balance.Amount, "amount": amount})
-		return nil, domain.ErrInsufficientFunds
-	}
-
-	newAmount := balance.Amount - amount
-	balance.Amount = newAmount
-	balance.LastUpdatedAt = time.Now()
+	tracing.AddAttribute(span, "user_id", userID)
+	tracing.AddAttribute(span, "currency", currency)
+	tracing.AddAttribute(span, "amount", amount)
 
-	startTime = time.Now()
-	balanceUpdated, err := s.repo.Update(balance)
+	startTime := time.Now()
+	balanceUpdated, err := s.repo.Withdraw(context.Background(), userID, currency, amount, transactionID)
 	if err != nil {
-		s.logger.Error("Bakiye güncellenemedi", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		switch {
+		case errors.Is(err, domain.ErrBalanceNotFound):
+			s.logger.Error("Bakiye bulunamadı", map[string]interface{}{"user_id": userID, "currency": currency})
+		case errors.Is(err, domain.ErrInsufficientFunds):
+			s.logger.ErrorWithErr("Yetersiz bakiye", err, map[string]interface{}{"user_id": userID, "amount": amount})
+		default:
+			s.logger.ErrorWithErr("Bakiye güncellenemedi", err, map[string]interface{}{"user_id": userID})
+		}
 		return nil, err
 	}
 	metrics.RecordDatabaseOperation("update", "balance", time.Since(startTime))
 
-	if err := s.saveEvent(balanceUpdated, domain.EventTypeBalanceUpdated); err != nil {
-		s.logger.Error("Event kaydedilemedi", map[string]interface{}{"error": err.Error()})
+	newAmount := balanceUpdated.Amount
+
+	if err := s.saveEvent(userID, currency, domain.BalanceOperationWithdraw, amount, transactionID); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
 	}
 
 	auditLog := &domain.AuditLog{
 		EntityType: domain.EntityTypeBalance,
 		EntityID:   userID,
 		Action:     domain.ActionTypeUpdate,
-		Details:    fmt.Sprintf("Atomik para çekme: -%.2f", amount),
+		Details:    fmt.Sprintf("Atomik para çekme: -%s %s", amount, currency),
 		CreatedAt:  time.Now(),
 	}
 
 	startTime = time.Now()
 	if err := s.auditLogRepo.Create(auditLog); err != nil {
-		s.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": userID})
 	}
 	metrics.RecordDatabaseOperation("create", "audit_log", time.Since(startTime))
 
 	s.logger.InfoContext(context.Background(), "Para çekme işlemi başarıyla tamamlandı", map[string]interface{}{
 		"user_id":     userID,
+		"currency":    currency,
 		"amount":      amount,
 		"new_balance": newAmount,
 	})
@@ -204,87 +331,275 @@ func (s *BalanceService) WithdrawAtomically(userID int64, amount float64) (*doma
 	return balanceUpdated, nil
 }
 
-func (s *BalanceService) InitializeBalance(userID int64) error {
-	_, span := tracing.StartSpan(context.Background(), "BalanceService.InitializeBalance")
+func (s *BalanceService) TransferAtomically(fromUserID, toUserID int64, fromCurrency, toCurrency string, amount, convertedAmount domain.Money, transactionID int64) error {
+	_, span := tracing.StartSpan(context.Background(), "BalanceService.TransferAtomically")
 	defer span.End()
 
-	tracing.AddAttribute(span, "user_id", userID)
+	fromCurrency, err := domain.NormalizeCurrency(fromCurrency, s.defaultCurrency)
+	if err != nil {
+		return err
+	}
+
+	toCurrency, err = domain.NormalizeCurrency(toCurrency, fromCurrency)
+	if err != nil {
+		return err
+	}
+
+	if toCurrency == fromCurrency {
+		convertedAmount = amount
+	} else if convertedAmount <= 0 {
+		return fmt.Errorf("%w: çevrilmiş tutar %s", domain.ErrInvalidAmount, convertedAmount)
+	}
+
+	tracing.AddAttribute(span, "from_user_id", fromUserID)
+	tracing.AddAttribute(span, "to_user_id", toUserID)
+	tracing.AddAttribute(span, "from_currency", fromCurrency)
+	tracing.AddAttribute(span, "to_currency", toCurrency)
+	tracing.AddAttribute(span, "amount", amount)
 
 	startTime := time.Now()
-	err := s.repo.InitializeBalance(userID)
+	_, _, err = s.repo.Transfer(context.Background(), fromUserID, toUserID, fromCurrency, toCurrency, amount, convertedAmount, transactionID)
 	if err != nil {
-		s.logger.Error("Bakiye başlatılamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		s.logger.Error("Atomik transfer başarısız", map[string]interface{}{
+			"from_user_id":  fromUserID,
+			"to_user_id":    toUserID,
+			"from_currency": fromCurrency,
+			"to_currency":   toCurrency,
+			"amount":        amount,
+			"error":         err.Error(),
+		})
 		return err
 	}
-	metrics.RecordDatabaseOperation("initialize", "balance", time.Since(startTime))
+	metrics.RecordDatabaseOperation("transfer", "balance", time.Since(startTime))
 
-	balance := &domain.Balance{
-		UserID:        userID,
-		Amount:        0,
-		LastUpdatedAt: time.Now(),
+	if err := s.saveEvent(fromUserID, fromCurrency, domain.BalanceOperationTransferOut, amount, transactionID); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
+	}
+
+	if err := s.saveEvent(toUserID, toCurrency, domain.BalanceOperationTransferIn, convertedAmount, transactionID); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
+	}
+
+	auditLogs := []*domain.AuditLog{
+		{
+			EntityType: domain.EntityTypeBalance,
+			EntityID:   fromUserID,
+			Action:     domain.ActionTypeUpdate,
+			Details:    fmt.Sprintf("Atomik transfer: -%s %s, alıcı: %d", amount, fromCurrency, toUserID),
+			CreatedAt:  time.Now(),
+		},
+		{
+			EntityType: domain.EntityTypeBalance,
+			EntityID:   toUserID,
+			Action:     domain.ActionTypeUpdate,
+			Details:    fmt.Sprintf("Atomik transfer: +%s %s, gönderen: %d", convertedAmount, toCurrency, fromUserID),
+			CreatedAt:  time.Now(),
+		},
 	}
 
-	if err := s.saveEvent(balance, domain.EventTypeBalanceUpdated); err != nil {
-		s.logger.Error("Event kaydedilemedi", map[string]interface{}{"error": err.Error()})
+	startTime = time.Now()
+	for _, auditLog := range auditLogs {
+		if err := s.auditLogRepo.Create(auditLog); err != nil {
+			s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": auditLog.EntityID})
+		}
 	}
+	metrics.RecordDatabaseOperation("create", "audit_log", time.Since(startTime))
+
+	s.logger.InfoContext(context.Background(), "Transfer işlemi başarıyla tamamlandı", map[string]interface{}{
+		"from_user_id":     fromUserID,
+		"to_user_id":       toUserID,
+		"from_currency":    fromCurrency,
+		"to_currency":      toCurrency,
+		"amount":           amount,
+		"converted_amount": convertedAmount,
+	})
+
+	return nil
+}
+
+func (s *BalanceService) SetOverdraftLimit(ctx context.Context, userID int64, currency string, limit domain.Money) error {
+	if limit < 0 {
+		return fmt.Errorf("kredi limiti negatif olamaz: %s", limit)
+	}
+
+	currency, err := domain.NormalizeCurrency(currency, s.defaultCurrency)
+	if err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	if err := s.repo.SetOverdraftLimit(ctx, userID, currency, limit); err != nil {
+		s.logger.ErrorWithErr("Kredi limiti güncellenemedi", err, map[string]interface{}{"user_id": userID, "currency": currency, "limit": limit})
+		return err
+	}
+	metrics.RecordDatabaseOperation("update", "balance", time.Since(startTime))
 
 	auditLog := &domain.AuditLog{
 		EntityType: domain.EntityTypeBalance,
 		EntityID:   userID,
-		Action:     domain.ActionTypeCreate,
-		Details:    "Bakiye başlatıldı",
+		Action:     domain.ActionTypeUpdate,
+		Details:    fmt.Sprintf("Kredi limiti güncellendi: %s %s", limit, currency),
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.auditLogRepo.Create(auditLog); err != nil {
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": userID})
+	}
+
+	s.logger.Info("Kredi limiti güncellendi", map[string]interface{}{"user_id": userID, "currency": currency, "limit": limit})
+
+	return nil
+}
+
+func (s *BalanceService) InitializeBalance(ctx context.Context, userID int64, currency string) error {
+	ctx, span := tracing.StartSpan(ctx, "BalanceService.InitializeBalance")
+	defer span.End()
+
+	currency, err := domain.NormalizeCurrency(currency, s.defaultCurrency)
+	if err != nil {
+		return err
+	}
+
+	tracing.AddAttribute(span, "user_id", userID)
+	tracing.AddAttribute(span, "currency", currency)
+
+	startTime := time.Now()
+	err = s.repo.InitializeBalance(ctx, userID, currency)
+	if err != nil {
+		s.logger.ErrorWithErr("Bakiye başlatılamadı", err, map[string]interface{}{"user_id": userID, "currency": currency})
+		return err
+	}
+	metrics.RecordDatabaseOperation("initialize", "balance", time.Since(startTime))
+
+	if err := s.saveEvent(userID, currency, domain.BalanceOperationInitialize, 0, 0); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
+	}
+
+	auditLog := &domain.AuditLog{
+		EntityType: domain.EntityTypeBalance,
+		EntityID:   userID,
+		Action:     domain.ActionTypeInitialize,
+		Details:    fmt.Sprintf("Bakiye başlatıldı: %s", currency),
 		CreatedAt:  time.Now(),
 	}
 
 	startTime = time.Now()
 	if err := s.auditLogRepo.Create(auditLog); err != nil {
-		s.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": userID})
 	}
 	metrics.RecordDatabaseOperation("create", "audit_log", time.Since(startTime))
 
-	s.logger.InfoContext(context.Background(), "Bakiye başarıyla başlatıldı", map[string]interface{}{
-		"user_id": userID,
+	s.logger.InfoContext(ctx, "Bakiye başarıyla başlatıldı", map[string]interface{}{
+		"user_id":  userID,
+		"currency": currency,
 	})
 
 	return nil
 }
 
-func (s *BalanceService) GetBalanceHistory(userID int64, startTime, endTime time.Time) ([]*domain.Balance, error) {
-	_, span := tracing.StartSpan(context.Background(), "BalanceService.GetBalanceHistory")
+func (s *BalanceService) GetBalanceHistory(ctx context.Context, userID int64, currency string, startTime, endTime time.Time) ([]*domain.BalanceHistory, error) {
+	ctx, span := tracing.StartSpan(ctx, "BalanceService.GetBalanceHistory")
 	defer span.End()
 
+	currency, err := domain.NormalizeCurrency(currency, s.defaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
 	tracing.AddAttribute(span, "user_id", userID)
+	tracing.AddAttribute(span, "currency", currency)
 	tracing.AddAttribute(span, "start_time", startTime)
 	tracing.AddAttribute(span, "end_time", endTime)
 
-	startTime = time.Now()
-	history, err := s.repo.GetBalanceHistory(userID, startTime, endTime)
+	queryStart := time.Now()
+	history, err := s.repo.GetBalanceHistory(ctx, userID, currency, startTime, endTime)
 	if err != nil {
-		s.logger.Error("Bakiye geçmişi alınamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		s.logger.ErrorWithErr("Bakiye geçmişi alınamadı", err, map[string]interface{}{"user_id": userID})
 		return nil, err
 	}
-	metrics.RecordDatabaseOperation("find", "balance_history", time.Since(startTime))
+	metrics.RecordDatabaseOperation("find", "balance_history", time.Since(queryStart))
 
 	return history, nil
 }
 
-func (s *BalanceService) ReplayBalanceEvents(userID int64) error {
-	events, err := s.eventStore.GetAggregateEvents("balance", fmt.Sprintf("%d", userID))
+func (s *BalanceService) GetBalanceAt(ctx context.Context, userID int64, currency string, at time.Time) (*domain.BalanceSnapshot, error) {
+	ctx, span := tracing.StartSpan(ctx, "BalanceService.GetBalanceAt")
+	defer span.End()
+
+	currency, err := domain.NormalizeCurrency(currency, s.defaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	tracing.AddAttribute(span, "user_id", userID)
+	tracing.AddAttribute(span, "currency", currency)
+	tracing.AddAttribute(span, "at", at)
+
+	queryStart := time.Now()
+	entry, err := s.repo.FindLatestHistoryAt(ctx, userID, currency, at)
+	if err != nil {
+		s.logger.ErrorWithErr("Geçmiş bakiye alınamadı", err, map[string]interface{}{"user_id": userID, "at": at})
+		return nil, err
+	}
+	metrics.RecordDatabaseOperation("find", "balance_history", time.Since(queryStart))
+
+	balance, err := s.repo.FindByUserAndCurrency(ctx, userID, currency)
+	if err != nil {
+		s.logger.ErrorWithErr("Bakiye bulunamadı", err, map[string]interface{}{"user_id": userID, "currency": currency})
+		return nil, err
+	}
+
+	snapshot := &domain.BalanceSnapshot{
+		UserID:   userID,
+		Currency: currency,
+		At:       at,
+	}
+
+	// A balance opened before at exists even if it never moved; its amount
+	// is whatever the latest history entry says, or zero without one.
+	snapshot.AccountExists = balance != nil && !balance.CreatedAt.After(at)
+
+	if entry != nil {
+		snapshot.Amount = entry.Amount
+		snapshot.AccountExists = true
+		snapshot.LastChangedAt = &entry.CreatedAt
+	}
+
+	return snapshot, nil
+}
+
+// ReplayBalanceEvents reconstructs the user's balances by folding their
+// balance deltas and overwrites the stored amounts with the result. With
+// fromVersion set, the events from that version on are folded on top of the
+// state at fromVersion-1; 0 replays the whole stream from zero.
+func (s *BalanceService) ReplayBalanceEvents(userID int64, fromVersion int) error {
+	if fromVersion < 1 {
+		fromVersion = 1
+	}
+
+	state := make(map[string]*domain.Balance)
+	if fromVersion > 1 {
+		var err error
+		state, _, err = s.reconstructBalanceState(userID, fromVersion-1)
+		if err != nil {
+			return err
+		}
+	}
+
+	events, err := s.eventStore.GetEventsFromVersion("balance", fmt.Sprintf("%d", userID), fromVersion)
 	if err != nil {
 		return err
 	}
 
 	for _, event := range events {
-		var balance domain.Balance
-		if err := json.Unmarshal(event.EventData, &balance); err != nil {
+		if err := s.applyBalanceEvent(userID, state, event); err != nil {
 			return err
 		}
+	}
 
-		switch event.EventType {
-		case domain.EventTypeBalanceUpdated:
-			if _, err := s.repo.Update(&balance); err != nil {
-				return err
-			}
+	for _, balance := range state {
+		if _, err := s.repo.Update(context.Background(), balance); err != nil {
+			return err
 		}
 	}
 
@@ -292,23 +607,15 @@ func (s *BalanceService) ReplayBalanceEvents(userID int64) error {
 }
 
 func (s *BalanceService) RebuildBalanceState(userID int64) error {
-	events, err := s.eventStore.GetAggregateEvents("balance", fmt.Sprintf("%d", userID))
+	state, _, err := s.loadBalanceState(userID)
 	if err != nil {
 		return err
 	}
 
-	var balance domain.Balance
-	for _, event := range events {
-		if err := json.Unmarshal(event.EventData, &balance); err != nil {
+	for _, balance := range state {
+		if _, err := s.repo.Update(context.Background(), balance); err != nil {
 			return err
 		}
-
-		switch event.EventType {
-		case domain.EventTypeBalanceUpdated:
-			if _, err := s.repo.Update(&balance); err != nil {
-				return err
-			}
-		}
 	}
 
 	return nil
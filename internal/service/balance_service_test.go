@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"payflow/internal/domain"
+)
+
+func saveBalanceEvent(t *testing.T, store *fakeEventStore, userID int64, operation domain.BalanceOperation, amount float64) {
+	t.Helper()
+
+	data, err := json.Marshal(&domain.BalanceEvent{
+		UserID:    userID,
+		Currency:  "TRY",
+		Operation: operation,
+		Amount:    domain.NewMoneyFromFloat(amount),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := &domain.Event{
+		AggregateID:   fmt.Sprintf("%d", userID),
+		AggregateType: "balance",
+		EventType:     domain.EventTypeBalanceUpdated,
+		EventData:     data,
+	}
+	if err := store.SaveEvent(event); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayBalanceEventsFromVersionFoldsOnPriorState(t *testing.T) {
+	store := &fakeEventStore{}
+	saveBalanceEvent(t, store, 1, domain.BalanceOperationDeposit, 100)
+	saveBalanceEvent(t, store, 1, domain.BalanceOperationWithdraw, 30)
+	saveBalanceEvent(t, store, 1, domain.BalanceOperationDeposit, 5)
+
+	for _, withSnapshot := range []bool{false, true} {
+		balances := newFakeBalanceRepo(&domain.Balance{UserID: 1, Currency: "TRY", Amount: domain.NewMoneyFromFloat(999)})
+		svc := NewBalanceService(balances, &fakeAuditLogRepo{}, store, newTestLogger(), nil, "TRY")
+
+		if withSnapshot {
+			state := map[string]*domain.Balance{"TRY": {UserID: 1, Currency: "TRY", Amount: domain.NewMoneyFromFloat(100)}}
+			if err := store.SaveSnapshot("balance", "1", 1, state); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := svc.ReplayBalanceEvents(1, 3); err != nil {
+			t.Fatal(err)
+		}
+
+		balance, _ := balances.FindByUserAndCurrency(context.Background(), 1, "TRY")
+		if want := domain.NewMoneyFromFloat(75); balance.Amount != want {
+			t.Fatalf("snapshot=%v: beklenen %s, alınan %s", withSnapshot, want, balance.Amount)
+		}
+	}
+}
+
+func TestReplayBalanceEventsIgnoresStoredAmount(t *testing.T) {
+	store := &fakeEventStore{}
+	saveBalanceEvent(t, store, 1, domain.BalanceOperationDeposit, 100)
+	saveBalanceEvent(t, store, 1, domain.BalanceOperationWithdraw, 30)
+
+	for _, prior := range []float64{0, 70, 12345.67, -500} {
+		balances := newFakeBalanceRepo(&domain.Balance{UserID: 1, Currency: "TRY", Amount: domain.NewMoneyFromFloat(prior)})
+		svc := NewBalanceService(balances, &fakeAuditLogRepo{}, store, newTestLogger(), nil, "TRY")
+
+		if err := svc.ReplayBalanceEvents(1, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		balance, _ := balances.FindByUserAndCurrency(context.Background(), 1, "TRY")
+		if want := domain.NewMoneyFromFloat(70); balance.Amount != want {
+			t.Fatalf("önceki bakiye %v: beklenen %s, alınan %s", prior, want, balance.Amount)
+		}
+	}
+}
@@ -32,30 +32,90 @@ func NewCachedBalanceService(
 	}
 }
 
-func (s *CachedBalanceService) GetBalance(userID int64) (*domain.Balance, error) {
-	ctx := context.Background()
-	key := cache.BalanceCacheKey(userID)
+func (s *CachedBalanceService) GetBalance(ctx context.Context, userID int64, currency string) (*domain.Balance, error) {
+	key := cache.BalanceCacheKey(userID, currency)
 
 	var balance *domain.Balance
 	err := s.cacheManager.ReadThrough(ctx, key, &balance, func() (interface{}, error) {
-		return s.balanceService.GetBalance(userID)
+		return s.balanceService.GetBalance(ctx, userID, currency)
 	}, cache.MediumExpiration)
 
 	if err != nil {
 		s.logger.Error("Cache read-through error for balance", map[string]interface{}{
-			"userID": userID,
-			"error":  err.Error(),
+			"userID":   userID,
+			"currency": currency,
+			"error":    err.Error(),
 		})
 		// Fallback to direct service call
-		return s.balanceService.GetBalance(userID)
+		return s.balanceService.GetBalance(ctx, userID, currency)
 	}
 
 	return balance, nil
 }
 
-func (s *CachedBalanceService) DepositAtomically(userID int64, amount float64) (*domain.Balance, error) {
+func (s *CachedBalanceService) GetBalances(ctx context.Context, userIDs []int64, currency string) (map[int64]*domain.Balance, error) {
+
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = cache.BalanceCacheKey(userID, currency)
+	}
+
+	cached, err := cache.GetMultipleAs[*domain.Balance](ctx, s.cache, keys)
+	if err != nil {
+		s.logger.Error("Cache get multiple error for balances", map[string]interface{}{
+			"users":    len(userIDs),
+			"currency": currency,
+			"error":    err.Error(),
+		})
+		return s.balanceService.GetBalances(ctx, userIDs, currency)
+	}
+
+	result := make(map[int64]*domain.Balance, len(userIDs))
+	var misses []int64
+	missed := make(map[int64]bool)
+	for i, userID := range userIDs {
+		if cached[i] != nil {
+			result[userID] = cached[i]
+		} else if !missed[userID] {
+			missed[userID] = true
+			misses = append(misses, userID)
+		}
+	}
+
+	if len(misses) == 0 {
+		return result, nil
+	}
+
+	fetched, err := s.balanceService.GetBalances(ctx, misses, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]interface{}, len(fetched))
+	for userID, balance := range fetched {
+		result[userID] = balance
+		// Zero balances stand in for users without one and are never cached,
+		// so GetBalance keeps reporting them as missing.
+		if !balance.LastUpdatedAt.IsZero() {
+			items[cache.BalanceCacheKey(userID, currency)] = balance
+		}
+	}
+
+	if len(items) > 0 {
+		if setErr := s.cache.SetMultiple(ctx, items, cache.MediumExpiration); setErr != nil {
+			s.logger.Error("Error caching balances", map[string]interface{}{
+				"count": len(items),
+				"error": setErr.Error(),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func (s *CachedBalanceService) DepositAtomically(userID int64, currency string, amount domain.Money, transactionID int64) (*domain.Balance, error) {
 	// Perform the deposit operation
-	balance, err := s.balanceService.DepositAtomically(userID, amount)
+	balance, err := s.balanceService.DepositAtomically(userID, currency, amount, transactionID)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +130,7 @@ func (s *CachedBalanceService) DepositAtomically(userID int64, amount float64) (
 	}
 
 	// Cache the new balance
-	key := cache.BalanceCacheKey(userID)
+	key := cache.BalanceCacheKey(userID, balance.Currency)
 	if setErr := s.cache.Set(ctx, key, balance, cache.MediumExpiration); setErr != nil {
 		s.logger.Error("Error caching balance after deposit", map[string]interface{}{
 			"userID": userID,
@@ -81,9 +141,9 @@ func (s *CachedBalanceService) DepositAtomically(userID int64, amount float64) (
 	return balance, nil
 }
 
-func (s *CachedBalanceService) WithdrawAtomically(userID int64, amount float64) (*domain.Balance, error) {
+func (s *CachedBalanceService) WithdrawAtomically(userID int64, currency string, amount domain.Money, transactionID int64) (*domain.Balance, error) {
 	// Perform the withdrawal operation
-	balance, err := s.balanceService.WithdrawAtomically(userID, amount)
+	balance, err := s.balanceService.WithdrawAtomically(userID, currency, amount, transactionID)
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +158,7 @@ func (s *CachedBalanceService) WithdrawAtomically(userID int64, amount float64)
 	}
 
 	// Cache the new balance
-	key := cache.BalanceCacheKey(userID)
+	key := cache.BalanceCacheKey(userID, balance.Currency)
 	if setErr := s.cache.Set(ctx, key, balance, cache.MediumExpiration); setErr != nil {
 		s.logger.Error("Error caching balance after withdrawal", map[string]interface{}{
 			"userID": userID,
@@ -109,15 +169,51 @@ func (s *CachedBalanceService) WithdrawAtomically(userID int64, amount float64)
 	return balance, nil
 }
 
-func (s *CachedBalanceService) InitializeBalance(userID int64) error {
-	err := s.balanceService.InitializeBalance(userID)
+func (s *CachedBalanceService) TransferAtomically(fromUserID, toUserID int64, fromCurrency, toCurrency string, amount, convertedAmount domain.Money, transactionID int64) error {
+	// Perform the transfer operation
+	if err := s.balanceService.TransferAtomically(fromUserID, toUserID, fromCurrency, toCurrency, amount, convertedAmount, transactionID); err != nil {
+		return err
+	}
+
+	// Invalidate cache entries for both sides of the transfer
+	ctx := context.Background()
+	for _, userID := range []int64{fromUserID, toUserID} {
+		if cacheErr := cache.InvalidateBalanceCache(ctx, s.cache, userID); cacheErr != nil {
+			s.logger.Error("Error invalidating balance cache after transfer", map[string]interface{}{
+				"userID": userID,
+				"error":  cacheErr.Error(),
+			})
+		}
+	}
+
+	return nil
+}
+
+func (s *CachedBalanceService) SetOverdraftLimit(ctx context.Context, userID int64, currency string, limit domain.Money) error {
+	if err := s.balanceService.SetOverdraftLimit(ctx, userID, currency, limit); err != nil {
+		return err
+	}
+
+	// Invalidate cached balance so the new limit is visible. The write has
+	// committed, so this must not be cut short by the caller's context.
+	if cacheErr := cache.InvalidateBalanceCache(context.Background(), s.cache, userID); cacheErr != nil {
+		s.logger.Error("Error invalidating balance cache after overdraft limit change", map[string]interface{}{
+			"userID": userID,
+			"error":  cacheErr.Error(),
+		})
+	}
+
+	return nil
+}
+
+func (s *CachedBalanceService) InitializeBalance(ctx context.Context, userID int64, currency string) error {
+	err := s.balanceService.InitializeBalance(ctx, userID, currency)
 	if err != nil {
 		return err
 	}
 
 	// Invalidate cache as new balance has been initialized
-	ctx := context.Background()
-	if cacheErr := cache.InvalidateBalanceCache(ctx, s.cache, userID); cacheErr != nil {
+	if cacheErr := cache.InvalidateBalanceCache(context.Background(), s.cache, userID); cacheErr != nil {
 		s.logger.Error("Error invalidating balance cache after initialization", map[string]interface{}{
 			"userID": userID,
 			"error":  cacheErr.Error(),
@@ -127,29 +223,34 @@ func (s *CachedBalanceService) InitializeBalance(userID int64) error {
 	return nil
 }
 
-func (s *CachedBalanceService) GetBalanceHistory(userID int64, startTime, endTime time.Time) ([]*domain.Balance, error) {
-	ctx := context.Background()
-	key := cache.BalanceHistoryCacheKey(userID)
+func (s *CachedBalanceService) GetBalanceHistory(ctx context.Context, userID int64, currency string, startTime, endTime time.Time) ([]*domain.BalanceHistory, error) {
+	key := cache.BalanceHistoryCacheKey(userID, currency)
 
-	var history []*domain.Balance
+	var history []*domain.BalanceHistory
 	err := s.cacheManager.ReadThrough(ctx, key, &history, func() (interface{}, error) {
-		return s.balanceService.GetBalanceHistory(userID, startTime, endTime)
+		return s.balanceService.GetBalanceHistory(ctx, userID, currency, startTime, endTime)
 	}, cache.LongExpiration)
 
 	if err != nil {
 		s.logger.Error("Cache read-through error for balance history", map[string]interface{}{
-			"userID": userID,
-			"error":  err.Error(),
+			"userID":   userID,
+			"currency": currency,
+			"error":    err.Error(),
 		})
 		// Fallback to direct service call
-		return s.balanceService.GetBalanceHistory(userID, startTime, endTime)
+		return s.balanceService.GetBalanceHistory(ctx, userID, currency, startTime, endTime)
 	}
 
 	return history, nil
 }
 
-func (s *CachedBalanceService) ReplayBalanceEvents(userID int64) error {
-	err := s.balanceService.ReplayBalanceEvents(userID)
+// GetBalanceAt bypasses the cache; point-in-time lookups are rare and keyed by arbitrary timestamps
+func (s *CachedBalanceService) GetBalanceAt(ctx context.Context, userID int64, currency string, at time.Time) (*domain.BalanceSnapshot, error) {
+	return s.balanceService.GetBalanceAt(ctx, userID, currency, at)
+}
+
+func (s *CachedBalanceService) ReplayBalanceEvents(userID int64, fromVersion int) error {
+	err := s.balanceService.ReplayBalanceEvents(userID, fromVersion)
 	if err != nil {
 		return err
 	}
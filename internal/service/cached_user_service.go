@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"payflow/internal/domain"
 	"payflow/pkg/cache"
@@ -31,15 +32,26 @@ func NewCachedUserService(
 	}
 }
 
+// notFoundAware marks a missing user for negative caching
+func notFoundAware(user *domain.User, err error) (interface{}, error) {
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, cache.NotFound(err)
+	}
+	return user, err
+}
+
 func (s *CachedUserService) GetUserByID(id int64) (*domain.User, error) {
 	ctx := context.Background()
 	key := cache.UserCacheKey(id)
 
 	var user *domain.User
 	err := s.cacheManager.ReadThrough(ctx, key, &user, func() (interface{}, error) {
-		return s.userService.GetUserByID(id)
+		return notFoundAware(s.userService.GetUserByID(id))
 	}, cache.LongExpiration)
 
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, domain.ErrUserNotFound
+	}
 	if err != nil {
 		s.logger.Error("Cache read-through error for user by ID", map[string]interface{}{
 			"userID": id,
@@ -58,9 +70,12 @@ func (s *CachedUserService) GetUserByUsername(username string) (*domain.User, er
 
 	var user *domain.User
 	err := s.cacheManager.ReadThrough(ctx, key, &user, func() (interface{}, error) {
-		return s.userService.GetUserByUsername(username)
+		return notFoundAware(s.userService.GetUserByUsername(username))
 	}, cache.LongExpiration)
 
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, domain.ErrUserNotFound
+	}
 	if err != nil {
 		s.logger.Error("Cache read-through error for user by username", map[string]interface{}{
 			"username": username,
@@ -78,9 +93,12 @@ func (s *CachedUserService) GetUserByEmail(email string) (*domain.User, error) {
 
 	var user *domain.User
 	err := s.cacheManager.ReadThrough(ctx, key, &user, func() (interface{}, error) {
-		return s.userService.GetUserByEmail(email)
+		return notFoundAware(s.userService.GetUserByEmail(email))
 	}, cache.LongExpiration)
 
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, domain.ErrUserNotFound
+	}
 	if err != nil {
 		s.logger.Error("Cache read-through error for user by email", map[string]interface{}{
 			"email": email,
@@ -97,53 +115,49 @@ func (s *CachedUserService) GetUserByApiKey(apiKey string) (*domain.User, error)
 	return s.userService.GetUserByApiKey(apiKey)
 }
 
-func (s *CachedUserService) CreateUser(user *domain.User) error {
-	ctx := context.Background()
-
-	err := s.cacheManager.WriteThrough(ctx, cache.UserCacheKey(user.ID), user, func(value interface{}) error {
-		return s.userService.CreateUser(user)
-	}, cache.LongExpiration)
-
-	if err != nil {
-		s.logger.Error("Cache write-through error for user creation", map[string]interface{}{
-			"userID": user.ID,
-			"error":  err.Error(),
-		})
-		return s.userService.CreateUser(user)
+func (s *CachedUserService) CreateUser(user *domain.User, actorID int64) error {
+	// The ID is assigned by the insert, so the user is cached afterwards
+	if err := s.userService.CreateUser(user, actorID); err != nil {
+		return err
 	}
 
-	// Cache by username and email as well
+	// Overwriting the keys also clears negative entries left by earlier
+	// lookups; a key that cannot be written is deleted instead
+	ctx := context.Background()
+	keys := []string{cache.UserCacheKey(user.ID)}
 	if user.Username != "" {
-		usernameKey := cache.UserCacheKeyByUsername(user.Username)
-		if setErr := s.cache.Set(ctx, usernameKey, user, cache.LongExpiration); setErr != nil {
-			s.logger.Error("Error caching user by username", map[string]interface{}{
-				"username": user.Username,
-				"error":    setErr.Error(),
-			})
-		}
+		keys = append(keys, cache.UserCacheKeyByUsername(user.Username))
 	}
-
 	if user.Email != "" {
-		emailKey := cache.UserCacheKeyByEmail(user.Email)
-		if setErr := s.cache.Set(ctx, emailKey, user, cache.LongExpiration); setErr != nil {
-			s.logger.Error("Error caching user by email", map[string]interface{}{
-				"email": user.Email,
+		keys = append(keys, cache.UserCacheKeyByEmail(user.Email))
+	}
+
+	for _, key := range keys {
+		if setErr := s.cache.Set(ctx, key, user, cache.LongExpiration); setErr != nil {
+			s.logger.Error("Error caching created user", map[string]interface{}{
+				"key":   key,
 				"error": setErr.Error(),
 			})
+			if delErr := s.cache.Delete(ctx, key); delErr != nil {
+				s.logger.Error("Error invalidating created user cache", map[string]interface{}{
+					"key":   key,
+					"error": delErr.Error(),
+				})
+			}
 		}
 	}
 
 	return nil
 }
 
-func (s *CachedUserService) UpdateUser(user *domain.User) error {
+func (s *CachedUserService) UpdateUser(user *domain.User, actorID int64) error {
 	ctx := context.Background()
 
 	// Get old user data to invalidate old cache keys
 	oldUser, _ := s.userService.GetUserByID(user.ID)
 
 	err := s.cacheManager.WriteThrough(ctx, cache.UserCacheKey(user.ID), user, func(value interface{}) error {
-		return s.userService.UpdateUser(user)
+		return s.userService.UpdateUser(user, actorID)
 	}, cache.LongExpiration)
 
 	if err != nil {
@@ -151,7 +165,7 @@ func (s *CachedUserService) UpdateUser(user *domain.User) error {
 			"userID": user.ID,
 			"error":  err.Error(),
 		})
-		return s.userService.UpdateUser(user)
+		return s.userService.UpdateUser(user, actorID)
 	}
 
 	// Invalidate old cache keys if username or email changed
@@ -201,13 +215,13 @@ func (s *CachedUserService) UpdateUser(user *domain.User) error {
 	return nil
 }
 
-func (s *CachedUserService) DeleteUser(id int64) error {
+func (s *CachedUserService) DeleteUser(id, actorID int64) error {
 	ctx := context.Background()
 
 	// Get user data to invalidate cache keys
 	user, _ := s.userService.GetUserByID(id)
 
-	err := s.userService.DeleteUser(id)
+	err := s.userService.DeleteUser(id, actorID)
 	if err != nil {
 		return err
 	}
@@ -264,6 +278,95 @@ func (s *CachedUserService) GenerateApiKey(userID int64) (string, error) {
 	return apiKey, nil
 }
 
+func (s *CachedUserService) GenerateActivationToken(userID int64) (string, error) {
+	return s.userService.GenerateActivationToken(userID)
+}
+
+func (s *CachedUserService) ActivateUser(token string) (*domain.User, error) {
+	user, err := s.userService.ActivateUser(token)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	keys := []string{
+		cache.UserCacheKeyByUsername(user.Username),
+		cache.UserCacheKeyByEmail(user.Email),
+	}
+	if cacheErr := cache.InvalidateUserCache(ctx, s.cache, user.ID); cacheErr != nil {
+		s.logger.Error("Error invalidating user cache after activation", map[string]interface{}{
+			"userID": user.ID,
+			"error":  cacheErr.Error(),
+		})
+	}
+	if cacheErr := s.cache.DeleteMultiple(ctx, keys); cacheErr != nil {
+		s.logger.Error("Error invalidating user lookup cache after activation", map[string]interface{}{
+			"userID": user.ID,
+			"error":  cacheErr.Error(),
+		})
+	}
+
+	return user, nil
+}
+
+func (s *CachedUserService) RequestPasswordReset(email string) error {
+	return s.userService.RequestPasswordReset(email)
+}
+
+func (s *CachedUserService) ResetPassword(token, newPassword string) (*domain.User, error) {
+	user, err := s.userService.ResetPassword(token, newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cached user still carries the old password change time, which would
+	// let revoked JWTs through.
+	ctx := context.Background()
+	if cacheErr := cache.InvalidateUserCache(ctx, s.cache, user.ID); cacheErr != nil {
+		s.logger.Error("Error invalidating user cache after password reset", map[string]interface{}{
+			"userID": user.ID,
+			"error":  cacheErr.Error(),
+		})
+	}
+
+	return user, nil
+}
+
+func (s *CachedUserService) ListUsers(page, pageSize int, search string) ([]*domain.User, int64, error) {
+	return s.userService.ListUsers(page, pageSize, search)
+}
+
+func (s *CachedUserService) RestoreUser(id, actorID int64) error {
+	if err := s.userService.RestoreUser(id, actorID); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if cacheErr := cache.InvalidateUserCache(ctx, s.cache, id); cacheErr != nil {
+		s.logger.Error("Error invalidating user cache after restore", map[string]interface{}{
+			"userID": id,
+			"error":  cacheErr.Error(),
+		})
+	}
+
+	// Lookups by username or email may have cached the user as not found
+	if user, err := s.userService.GetUserByID(id); err == nil {
+		keys := []string{cache.UserCacheKeyByUsername(user.Username), cache.UserCacheKeyByEmail(user.Email)}
+		if cacheErr := s.cache.DeleteMultiple(ctx, keys); cacheErr != nil {
+			s.logger.Error("Error invalidating user lookup cache after restore", map[string]interface{}{
+				"userID": id,
+				"error":  cacheErr.Error(),
+			})
+		}
+	}
+
+	return nil
+}
+
+func (s *CachedUserService) GetUserByIDIncludingDeleted(id int64) (*domain.User, error) {
+	return s.userService.GetUserByIDIncludingDeleted(id)
+}
+
 func (s *CachedUserService) HasAdminRole(userID int64) (bool, error) {
 	// This could be cached but admin checks are usually not frequent enough to warrant caching
 	return s.userService.HasAdminRole(userID)
@@ -273,6 +376,14 @@ func (s *CachedUserService) CheckPermission(userID int64, requiredRole string) (
 	return s.userService.CheckPermission(userID, requiredRole)
 }
 
+func (s *CachedUserService) HasPermission(userID int64, permission string) (bool, error) {
+	return s.userService.HasPermission(userID, permission)
+}
+
+func (s *CachedUserService) HashPassword(password string) (string, error) {
+	return s.userService.HashPassword(password)
+}
+
 func (s *CachedUserService) Login(username, password string) (string, error) {
 	// Login should not be cached for security reasons
 	return s.userService.Login(username, password)
@@ -1,54 +1,126 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"payflow/internal/domain"
 	"payflow/pkg/logger"
 )
 
+const maxEventAppendAttempts = 3
+
 type EventStoreService struct {
-	repo   domain.EventStoreRepository
-	logger logger.Logger
+	repo             domain.EventStoreRepository
+	logger           logger.Logger
+	snapshotInterval int
+
+	subscribersMu sync.RWMutex
+	subscribers   map[domain.EventType][]*eventSubscriber
+	subscriberSet map[string]struct{}
 }
 
-func NewEventStoreService(repo domain.EventStoreRepository, logger logger.Logger) domain.EventStoreService {
+func NewEventStoreService(repo domain.EventStoreRepository, logger logger.Logger, snapshotInterval int) domain.EventStoreService {
 	return &EventStoreService{
-		repo:   repo,
-		logger: logger,
+		repo:             repo,
+		logger:           logger,
+		snapshotInterval: snapshotInterval,
+		subscribers:      make(map[domain.EventType][]*eventSubscriber),
+		subscriberSet:    make(map[string]struct{}),
 	}
 }
 
+// SaveEvent appends the event with the next version of its aggregate. The
+// version is assigned by the repository in the same statement as the insert;
+// when a concurrent writer takes that version first, the append is retried.
 func (s *EventStoreService) SaveEvent(event *domain.Event) error {
-	lastVersion, err := s.repo.GetLastVersion(event.AggregateType, event.AggregateID)
+	var err error
+	for attempt := 1; attempt <= maxEventAppendAttempts; attempt++ {
+		err = s.repo.Save(event)
+		if !errors.Is(err, domain.ErrConcurrentModification) {
+			break
+		}
+
+		s.logger.Warn("Event versiyon çakışması, tekrar deneniyor", map[string]interface{}{
+			"aggregateType": event.AggregateType,
+			"aggregateID":   event.AggregateID,
+			"attempt":       attempt,
+		})
+	}
+
 	if err != nil {
-		s.logger.Error("Son versiyon alınamadı", map[string]interface{}{
+		s.logger.Error("Event kaydedilemedi", map[string]interface{}{
 			"error": err.Error(),
 			"event": event,
 		})
 		return err
 	}
 
-	if event.Version != lastVersion+1 {
-		s.logger.Error("Versiyon uyumsuzluğu", map[string]interface{}{
-			"expected_version": lastVersion + 1,
-			"actual_version":   event.Version,
-			"event":            event,
-		})
-		return domain.ErrConcurrentModification
+	s.dispatch(event)
+
+	return nil
+}
+
+// Subscribe registers a named handler for an event type. The subscriber first
+// catches up on events stored since its persisted cursor, then receives new
+// events as they are saved.
+func (s *EventStoreService) Subscribe(subscriber string, eventType domain.EventType, handler func(*domain.Event) error) error {
+	s.subscribersMu.Lock()
+	if _, exists := s.subscriberSet[subscriber]; exists {
+		s.subscribersMu.Unlock()
+		return fmt.Errorf("%w: %s", domain.ErrDuplicateSubscriber, subscriber)
 	}
+	s.subscriberSet[subscriber] = struct{}{}
+	s.subscribersMu.Unlock()
 
-	if err := s.repo.Save(event); err != nil {
-		s.logger.Error("Event kaydedilemedi", map[string]interface{}{
-			"error": err.Error(),
-			"event": event,
-		})
+	cursor, err := s.repo.GetSubscriberCursor(subscriber)
+	if err != nil {
+		s.subscribersMu.Lock()
+		delete(s.subscriberSet, subscriber)
+		s.subscribersMu.Unlock()
+
+		s.logger.ErrorWithErr("Abone imleci alınamadı", err, map[string]interface{}{"subscriber": subscriber})
 		return err
 	}
 
+	sub := &eventSubscriber{
+		name:      subscriber,
+		eventType: eventType,
+		handler:   handler,
+		repo:      s.repo,
+		logger:    s.logger,
+		settle:    subscriberSettleWindow,
+		cursor:    cursor,
+	}
+
+	// Register before catching up so events saved meanwhile are not missed;
+	// the subscriber lock serializes them behind the catch-up.
+	sub.mu.Lock()
+	s.subscribersMu.Lock()
+	s.subscribers[eventType] = append(s.subscribers[eventType], sub)
+	s.subscribersMu.Unlock()
+
+	sub.catchUpLocked()
+	sub.mu.Unlock()
+
+	s.logger.Info("Event abonesi kaydedildi", map[string]interface{}{"subscriber": subscriber, "event_type": eventType, "cursor": sub.cursor})
+
 	return nil
 }
 
+func (s *EventStoreService) dispatch(event *domain.Event) {
+	s.subscribersMu.RLock()
+	subscribers := s.subscribers[event.EventType]
+	s.subscribersMu.RUnlock()
+
+	for _, sub := range subscribers {
+		sub.deliver()
+	}
+}
+
 func (s *EventStoreService) GetAggregateEvents(aggregateType string, aggregateID string) ([]*domain.Event, error) {
 	events, err := s.repo.GetEvents(aggregateType, aggregateID)
 	if err != nil {
@@ -112,3 +184,70 @@ func (s *EventStoreService) ReplayEvents(aggregateType string, aggregateID strin
 func (s *EventStoreService) GetLastVersion(aggregateType string, aggregateID string) (int, error) {
 	return s.repo.GetLastVersion(aggregateType, aggregateID)
 }
+
+func (s *EventStoreService) GetEventsAfterVersion(aggregateType string, aggregateID string, version int) ([]*domain.Event, error) {
+	events, err := s.repo.GetEventsAfterVersion(aggregateType, aggregateID, version)
+	if err != nil {
+		s.logger.Error("Aggregate eventleri alınamadı", map[string]interface{}{
+			"error":         err.Error(),
+			"aggregateType": aggregateType,
+			"aggregateID":   aggregateID,
+			"version":       version,
+		})
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (s *EventStoreService) GetEventsFromVersion(aggregateType string, aggregateID string, fromVersion int) ([]*domain.Event, error) {
+	events, err := s.repo.GetEventsFromVersion(aggregateType, aggregateID, fromVersion)
+	if err != nil {
+		s.logger.Error("Aggregate eventleri alınamadı", map[string]interface{}{
+			"error":         err.Error(),
+			"aggregateType": aggregateType,
+			"aggregateID":   aggregateID,
+			"fromVersion":   fromVersion,
+		})
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (s *EventStoreService) SaveSnapshot(aggregateType string, aggregateID string, version int, state interface{}) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &domain.Snapshot{
+		AggregateID:   aggregateID,
+		AggregateType: aggregateType,
+		Version:       version,
+		State:         data,
+		CreatedAt:     time.Now(),
+	}
+
+	return s.repo.SaveSnapshot(snapshot)
+}
+
+func (s *EventStoreService) GetLatestSnapshot(aggregateType string, aggregateID string) (*domain.Snapshot, error) {
+	snapshot, err := s.repo.GetLatestSnapshot(aggregateType, aggregateID)
+	if err != nil {
+		s.logger.Error("Snapshot alınamadı", map[string]interface{}{
+			"error":         err.Error(),
+			"aggregateType": aggregateType,
+			"aggregateID":   aggregateID,
+		})
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// ShouldSnapshot reports whether an aggregate that just reached version should
+// be snapshotted. A non-positive interval disables snapshots.
+func (s *EventStoreService) ShouldSnapshot(version int) bool {
+	return s.snapshotInterval > 0 && version > 0 && version%s.snapshotInterval == 0
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"payflow/internal/domain"
+	"payflow/pkg/logger"
+)
+
+const (
+	subscriberCatchUpBatchSize = 100
+
+	// subscriberSettleWindow bounds how long an event insert may stay
+	// uncommitted and still be delivered; see eventSubscriber.
+	subscriberSettleWindow = time.Minute
+)
+
+// eventSubscriber delivers events of one type to a handler, in id order and at
+// least once, and remembers its progress in a persisted cursor.
+//
+// Event ids are assigned at insert but become visible at commit, so a lower id
+// can appear after a higher one has been read. The cursor is therefore a
+// low-water mark: it only moves past events older than the settle window, and
+// events above it that were already handled are remembered in memory so they
+// are not handled twice. An event whose insert stays uncommitted for longer
+// than the window can still be skipped.
+type eventSubscriber struct {
+	name      string
+	eventType domain.EventType
+	handler   func(*domain.Event) error
+	repo      domain.EventStoreRepository
+	logger    logger.Logger
+	settle    time.Duration
+
+	mu      sync.Mutex
+	cursor  int64
+	handled map[int64]struct{}
+}
+
+// deliver is called after an event of the subscriber's type is saved. The
+// subscriber reads from the store rather than taking the saved event, so it
+// also picks up events that committed out of id order or were saved by
+// another instance.
+func (sub *eventSubscriber) deliver() {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	sub.catchUpLocked()
+}
+
+func (sub *eventSubscriber) catchUpLocked() {
+	if sub.handled == nil {
+		sub.handled = make(map[int64]struct{})
+	}
+
+	settled := time.Now().Add(-sub.settle)
+	cursor := sub.cursor
+	advancing := true
+	defer func() { sub.advanceLocked(cursor) }()
+
+	for after := sub.cursor; ; {
+		events, err := sub.repo.GetEventsByTypeAfterID(sub.eventType, after, subscriberCatchUpBatchSize)
+		if err != nil {
+			sub.logger.ErrorWithErr("Abone için eventler alınamadı", err, map[string]interface{}{"subscriber": sub.name})
+			return
+		}
+
+		for _, event := range events {
+			after = event.ID
+
+			if _, done := sub.handled[event.ID]; !done {
+				if err := sub.handler(event); err != nil {
+					sub.logger.Error("Event abonesi eventi işleyemedi", map[string]interface{}{
+						"subscriber": sub.name,
+						"event_id":   event.ID,
+						"error":      err.Error(),
+					})
+					return
+				}
+			}
+
+			if advancing && event.CreatedAt.Before(settled) {
+				cursor = event.ID
+				delete(sub.handled, event.ID)
+				continue
+			}
+
+			advancing = false
+			sub.handled[event.ID] = struct{}{}
+		}
+
+		if len(events) < subscriberCatchUpBatchSize {
+			return
+		}
+	}
+}
+
+func (sub *eventSubscriber) advanceLocked(eventID int64) {
+	if eventID <= sub.cursor {
+		return
+	}
+
+	sub.cursor = eventID
+	if err := sub.repo.SaveSubscriberCursor(sub.name, eventID); err != nil {
+		sub.logger.ErrorWithErr("Abone imleci kaydedilemedi", err, map[string]interface{}{"subscriber": sub.name, "event_id": eventID})
+	}
+}
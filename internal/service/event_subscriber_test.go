@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"payflow/internal/domain"
+)
+
+func TestEventSubscriberHandlesEventsCommittedOutOfOrder(t *testing.T) {
+	repo := &fakeEventRepo{}
+	var handled []int64
+	sub := &eventSubscriber{
+		name:      "projector",
+		eventType: domain.EventTypeTransactionCreated,
+		handler: func(event *domain.Event) error {
+			handled = append(handled, event.ID)
+			return nil
+		},
+		repo:   repo,
+		logger: newTestLogger(),
+		settle: time.Minute,
+	}
+
+	newEvent := func(id int64, age time.Duration) *domain.Event {
+		return &domain.Event{ID: id, EventType: domain.EventTypeTransactionCreated, CreatedAt: time.Now().Add(-age)}
+	}
+
+	// Event 2 is still uncommitted when 3 is read.
+	repo.commit(newEvent(1, time.Hour))
+	repo.commit(newEvent(3, 0))
+	sub.deliver()
+
+	if repo.cursor != 1 {
+		t.Fatalf("imleç yeni eventi geçmemeli, beklenen 1, alınan: %d", repo.cursor)
+	}
+
+	repo.commit(newEvent(2, 0))
+	sub.deliver()
+
+	// Once the window has passed, the cursor moves without handling again.
+	sub.settle = 0
+	sub.deliver()
+
+	want := []int64{1, 3, 2}
+	if len(handled) != len(want) {
+		t.Fatalf("beklenen eventler %v, alınan: %v", want, handled)
+	}
+	for i := range want {
+		if handled[i] != want[i] {
+			t.Fatalf("beklenen eventler %v, alınan: %v", want, handled)
+		}
+	}
+	if repo.cursor != 3 {
+		t.Fatalf("imleç 3 olmalı, alınan: %d", repo.cursor)
+	}
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"payflow/internal/config"
+	"payflow/internal/domain"
+	"payflow/pkg/logger"
+)
+
+// The fakes below embed the domain interfaces they stand in for, so a test
+// that reaches an unexpected method fails with a nil-pointer panic.
+
+type fakeTransactionRepo struct {
+	domain.TransactionRepository
+
+	// beforeCreate, when set, runs at the start of every Create outside the
+	// lock, so tests can hold callers back until they have all arrived.
+	beforeCreate func()
+
+	mu           sync.Mutex
+	nextID       int64
+	transactions map[int64]*domain.Transaction
+}
+
+func newFakeTransactionRepo() *fakeTransactionRepo {
+	return &fakeTransactionRepo{transactions: make(map[int64]*domain.Transaction)}
+}
+
+// Create enforces the unique (owner, idempotency key) index of the real table.
+func (r *fakeTransactionRepo) Create(ctx context.Context, tx *domain.Transaction) error {
+	if r.beforeCreate != nil {
+		r.beforeCreate()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tx.IdempotencyKey != "" {
+		for _, stored := range r.transactions {
+			if stored.IdempotencyKey == tx.IdempotencyKey && stored.IdempotencyOwnerID() == tx.IdempotencyOwnerID() {
+				return domain.ErrDuplicateIdempotencyKey
+			}
+		}
+	}
+
+	r.nextID++
+	tx.ID = r.nextID
+	stored := *tx
+	r.transactions[tx.ID] = &stored
+	return nil
+}
+
+func (r *fakeTransactionRepo) FindByID(ctx context.Context, id int64) (*domain.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, ok := r.transactions[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *tx
+	return &copied, nil
+}
+
+func (r *fakeTransactionRepo) FindByIdempotencyKey(ctx context.Context, userID int64, key string) (*domain.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, tx := range r.transactions {
+		if tx.IdempotencyKey == key && tx.IdempotencyOwnerID() == userID {
+			copied := *tx
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeTransactionRepo) FindByStatus(ctx context.Context, status domain.TransactionStatus, createdBefore time.Time, limit, offset int) ([]*domain.Transaction, error) {
+	return nil, nil
+}
+
+func (r *fakeTransactionRepo) FindDueScheduled(ctx context.Context, dueBefore time.Time, limit int) ([]*domain.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []*domain.Transaction
+	for _, tx := range r.transactions {
+		if tx.Status == domain.TransactionStatusScheduled && tx.ScheduledAt != nil && !tx.ScheduledAt.After(dueBefore) {
+			copied := *tx
+			due = append(due, &copied)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeTransactionRepo) CountByStatus(ctx context.Context, status domain.TransactionStatus, createdBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, tx := range r.transactions {
+		if tx.Status == status {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeTransactionRepo) UpdateStatus(ctx context.Context, id int64, status domain.TransactionStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tx, ok := r.transactions[id]; ok {
+		tx.Status = status
+	}
+	return nil
+}
+
+func (r *fakeTransactionRepo) TransitionStatus(ctx context.Context, id int64, from, to domain.TransactionStatus) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, ok := r.transactions[id]
+	if !ok || tx.Status != from {
+		return false, nil
+	}
+	tx.Status = to
+	return true, nil
+}
+
+func (r *fakeTransactionRepo) SumUserTransactionsSince(ctx context.Context, userID int64, since time.Time) (map[string]domain.Money, error) {
+	return nil, nil
+}
+
+func (r *fakeTransactionRepo) status(id int64) domain.TransactionStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tx, ok := r.transactions[id]; ok {
+		return tx.Status
+	}
+	return ""
+}
+
+// fakeBalanceService moves no money; deposit and withdraw delegate to the
+// optional hooks so tests can count calls or inject failures.
+type fakeBalanceService struct {
+	domain.BalanceService
+
+	deposit  func(userID int64, amount domain.Money, transactionID int64) error
+	withdraw func(userID int64, amount domain.Money, transactionID int64) error
+}
+
+func (s *fakeBalanceService) DepositAtomically(userID int64, currency string, amount domain.Money, transactionID int64) (*domain.Balance, error) {
+	if s.deposit != nil {
+		if err := s.deposit(userID, amount, transactionID); err != nil {
+			return nil, err
+		}
+	}
+	return &domain.Balance{UserID: userID, Currency: currency}, nil
+}
+
+func (s *fakeBalanceService) WithdrawAtomically(userID int64, currency string, amount domain.Money, transactionID int64) (*domain.Balance, error) {
+	if s.withdraw != nil {
+		if err := s.withdraw(userID, amount, transactionID); err != nil {
+			return nil, err
+		}
+	}
+	return &domain.Balance{UserID: userID, Currency: currency}, nil
+}
+
+type fakeBalanceRepo struct {
+	domain.BalanceRepository
+
+	mu       sync.Mutex
+	balances map[string]*domain.Balance
+}
+
+func newFakeBalanceRepo(balances ...*domain.Balance) *fakeBalanceRepo {
+	r := &fakeBalanceRepo{balances: make(map[string]*domain.Balance)}
+	for _, balance := range balances {
+		r.balances[balanceKey(balance.UserID, balance.Currency)] = balance
+	}
+	return r
+}
+
+func balanceKey(userID int64, currency string) string {
+	return fmt.Sprintf("%d/%s", userID, currency)
+}
+
+func (r *fakeBalanceRepo) FindByUserAndCurrency(ctx context.Context, userID int64, currency string) (*domain.Balance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	balance, ok := r.balances[balanceKey(userID, currency)]
+	if !ok {
+		return nil, nil
+	}
+	copied := *balance
+	return &copied, nil
+}
+
+func (r *fakeBalanceRepo) Update(ctx context.Context, balance *domain.Balance) (*domain.Balance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *balance
+	r.balances[balanceKey(balance.UserID, balance.Currency)] = &stored
+	return balance, nil
+}
+
+type fakeUserRepo struct {
+	domain.UserRepository
+
+	mu    sync.Mutex
+	users map[int64]*domain.User
+}
+
+func newFakeUserRepo(users ...*domain.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: make(map[int64]*domain.User)}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *fakeUserRepo) FindByID(id int64) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) Update(user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+type fakeAuditLogRepo struct {
+	domain.AuditLogRepository
+
+	mu   sync.Mutex
+	logs []*domain.AuditLog
+}
+
+func (r *fakeAuditLogRepo) Create(log *domain.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+// fakeEventStore keeps events in memory and numbers them per aggregate the
+// way the real store does.
+type fakeEventStore struct {
+	domain.EventStoreService
+
+	mu        sync.Mutex
+	events    []*domain.Event
+	snapshots map[string]*domain.Snapshot
+}
+
+func (s *fakeEventStore) SaveEvent(event *domain.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.Version = 1
+	for _, stored := range s.events {
+		if stored.AggregateType == event.AggregateType && stored.AggregateID == event.AggregateID {
+			event.Version = stored.Version + 1
+		}
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *fakeEventStore) ShouldSnapshot(version int) bool { return false }
+
+func (s *fakeEventStore) SaveSnapshot(aggregateType, aggregateID string, version int, state interface{}) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.snapshots == nil {
+		s.snapshots = make(map[string]*domain.Snapshot)
+	}
+	s.snapshots[aggregateType+"/"+aggregateID] = &domain.Snapshot{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Version:       version,
+		State:         data,
+	}
+	return nil
+}
+
+func (s *fakeEventStore) GetLatestSnapshot(aggregateType, aggregateID string) (*domain.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.snapshots[aggregateType+"/"+aggregateID], nil
+}
+
+func (s *fakeEventStore) GetEventsAfterVersion(aggregateType, aggregateID string, version int) ([]*domain.Event, error) {
+	return s.GetEventsFromVersion(aggregateType, aggregateID, version+1)
+}
+
+func (s *fakeEventStore) GetEventsFromVersion(aggregateType, aggregateID string, fromVersion int) ([]*domain.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []*domain.Event
+	for _, event := range s.events {
+		if event.AggregateType == aggregateType && event.AggregateID == aggregateID && event.Version >= fromVersion {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func newTestLogger() logger.Logger {
+	return logger.New(logger.ErrorLevel, logger.FormatJSON, io.Discard)
+}
+
+// newTestTransactionService wires a TransactionService to the fakes above with
+// one active user (ID 1).
+func newTestTransactionService(t *testing.T, repo *fakeTransactionRepo, balanceSvc *fakeBalanceService) *TransactionService {
+	t.Helper()
+
+	users := newFakeUserRepo(&domain.User{ID: 1, Username: "alice", IsActive: true})
+	balances := newFakeBalanceRepo(&domain.Balance{UserID: 1, Currency: "TRY", Amount: domain.NewMoneyFromFloat(1_000_000)})
+	svc := NewTransactionService(repo, balances, balanceSvc, users, &fakeAuditLogRepo{}, &fakeEventStore{},
+		newTestLogger(), newTestLogger(), config.TransactionConfig{DrainTimeout: 5 * time.Second}, "TRY", nil).(*TransactionService)
+	t.Cleanup(svc.Shutdown)
+
+	return svc
+}
+
+// fakeEventRepo serves only the events a test has made visible, to mimic
+// inserts that commit out of id order.
+type fakeEventRepo struct {
+	domain.EventStoreRepository
+
+	mu      sync.Mutex
+	visible []*domain.Event
+	cursor  int64
+}
+
+func (r *fakeEventRepo) commit(event *domain.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.visible = append(r.visible, event)
+}
+
+func (r *fakeEventRepo) GetEventsByTypeAfterID(eventType domain.EventType, afterID int64, limit int) ([]*domain.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []*domain.Event
+	for _, event := range r.visible {
+		if event.EventType == eventType && event.ID > afterID {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *fakeEventRepo) SaveSubscriberCursor(subscriber string, eventID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if eventID > r.cursor {
+		r.cursor = eventID
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payflow/internal/domain"
+	"payflow/pkg/auth"
+	"payflow/pkg/logger"
+)
+
+const recurringBatchSize = 100
+
+type RecurringTransferService struct {
+	repo            domain.RecurringTransferRepository
+	transactionRepo domain.TransactionRepository
+	transactionSvc  domain.TransactionService
+	auditLogRepo    domain.AuditLogRepository
+	logger          logger.Logger
+
+	interval        time.Duration
+	defaultCurrency string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewRecurringTransferService(
+	repo domain.RecurringTransferRepository,
+	transactionRepo domain.TransactionRepository,
+	transactionSvc domain.TransactionService,
+	auditLogRepo domain.AuditLogRepository,
+	logger logger.Logger,
+	interval time.Duration,
+	defaultCurrency string,
+) domain.RecurringTransferService {
+	return &RecurringTransferService{
+		repo:            repo,
+		transactionRepo: transactionRepo,
+		transactionSvc:  transactionSvc,
+		auditLogRepo:    auditLogRepo,
+		logger:          logger,
+		interval:        interval,
+		defaultCurrency: defaultCurrency,
+	}
+}
+
+func (s *RecurringTransferService) CreateRecurringTransfer(ctx context.Context, fromUserID, toUserID int64, amount domain.Money, interval domain.RecurringInterval, startAt time.Time, endDate *time.Time) (*domain.RecurringTransfer, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("geçersiz miktar: %s", amount)
+	}
+
+	if fromUserID == toUserID {
+		return nil, fmt.Errorf("aynı kullanıcıya transfer yapılamaz")
+	}
+
+	if !interval.IsValid() {
+		return nil, fmt.Errorf("düzenli transfer oluşturulamadı: %w: %s", domain.ErrInvalidRecurringInterval, interval)
+	}
+
+	if !startAt.After(time.Now()) {
+		return nil, fmt.Errorf("düzenli transfer oluşturulamadı: %w", domain.ErrInvalidScheduleTime)
+	}
+
+	if endDate != nil && endDate.Before(startAt) {
+		return nil, fmt.Errorf("düzenli transfer oluşturulamadı: %w: bitiş tarihi başlangıçtan önce olamaz", domain.ErrInvalidScheduleTime)
+	}
+
+	if err := s.transactionSvc.ValidateTransfer(ctx, fromUserID, toUserID, amount, s.defaultCurrency); err != nil {
+		return nil, fmt.Errorf("düzenli transfer oluşturulamadı: %w", err)
+	}
+
+	// Stored as TIMESTAMP, so next_run_at must survive a round trip unchanged
+	// for the conditional advance to match.
+	firstRun := startAt.Local().Truncate(time.Second)
+	transfer := &domain.RecurringTransfer{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     amount,
+		Currency:   s.defaultCurrency,
+		Interval:   interval,
+		NextRunAt:  firstRun,
+		Status:     domain.RecurringTransferStatusActive,
+	}
+
+	if endDate != nil {
+		end := endDate.Local()
+		transfer.EndDate = &end
+	}
+
+	if err := s.repo.Create(transfer); err != nil {
+		return nil, err
+	}
+
+	if err := s.materialize(ctx, transfer, firstRun); err != nil {
+		s.logger.ErrorWithErr("İlk düzenli transfer oluşturulamadı", err, map[string]interface{}{"recurring_transfer_id": transfer.ID})
+	}
+
+	auditLog := &domain.AuditLog{
+		EntityType: domain.EntityTypeRecurringTransfer,
+		EntityID:   transfer.ID,
+		Action:     domain.ActionTypeCreate,
+		Details:    fmt.Sprintf("Düzenli transfer oluşturuldu: %s %s, %s, %d -> %d", amount, transfer.Currency, interval, fromUserID, toUserID),
+		ActorID:    auth.ActorIDFromContext(ctx),
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.auditLogRepo.Create(auditLog); err != nil {
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"recurring_transfer_id": transfer.ID})
+	}
+
+	s.logger.Info("Düzenli transfer oluşturuldu", map[string]interface{}{"recurring_transfer_id": transfer.ID, "interval": interval, "next_run_at": firstRun})
+
+	return transfer, nil
+}
+
+func (s *RecurringTransferService) GetRecurringTransfer(id int64) (*domain.RecurringTransfer, error) {
+	transfer, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if transfer == nil {
+		return nil, domain.ErrRecurringTransferNotFound
+	}
+
+	return transfer, nil
+}
+
+func (s *RecurringTransferService) CancelRecurringTransfer(ctx context.Context, id int64) error {
+	transfer, err := s.GetRecurringTransfer(id)
+	if err != nil {
+		return fmt.Errorf("düzenli transfer iptal edilemedi: %w", err)
+	}
+
+	cancelled, err := s.repo.UpdateStatus(id, domain.RecurringTransferStatusActive, domain.RecurringTransferStatusCancelled)
+	if err != nil {
+		return fmt.Errorf("düzenli transfer iptal edilemedi: %w", err)
+	}
+
+	if !cancelled {
+		return fmt.Errorf("düzenli transfer iptal edilemedi: %w", domain.ErrRecurringTransferNotActive)
+	}
+
+	upcoming, err := s.transactionRepo.FindByIdempotencyKey(ctx, transfer.FromUserID, transfer.OccurrenceKey(transfer.NextRunAt))
+	if err != nil {
+		s.logger.ErrorWithErr("Bekleyen düzenli transfer bulunamadı", err, map[string]interface{}{"recurring_transfer_id": id})
+	} else if upcoming != nil && upcoming.Status == domain.TransactionStatusScheduled {
+		if err := s.transactionSvc.CancelScheduledTransfer(ctx, upcoming.ID); err != nil {
+			s.logger.Warn("Bekleyen düzenli transfer iptal edilemedi", map[string]interface{}{"transaction_id": upcoming.ID, "error": err.Error()})
+		}
+	}
+
+	auditLog := &domain.AuditLog{
+		EntityType: domain.EntityTypeRecurringTransfer,
+		EntityID:   id,
+		Action:     domain.ActionTypeUpdate,
+		Details:    "Düzenli transfer iptal edildi",
+		ActorID:    auth.ActorIDFromContext(ctx),
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.auditLogRepo.Create(auditLog); err != nil {
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"recurring_transfer_id": id})
+	}
+
+	return nil
+}
+
+func (s *RecurringTransferService) Start() {
+	if s.interval <= 0 || s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.advanceDue()
+			}
+		}
+	}()
+}
+
+func (s *RecurringTransferService) Stop() {
+	if s.stop == nil {
+		return
+	}
+
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+// advanceDue materializes the next occurrence of every recurring transfer whose
+// current occurrence has come due. The occurrence is created before next_run_at
+// is advanced; after a crash in between, the retry hits the occurrence's
+// idempotency key and only the advance is repeated.
+func (s *RecurringTransferService) advanceDue() {
+	transfers, err := s.repo.FindDue(time.Now(), recurringBatchSize)
+	if err != nil {
+		s.logger.ErrorWithErr("Zamanı gelen düzenli transferler sorgulanamadı", err, nil)
+		return
+	}
+
+	for _, transfer := range transfers {
+		next := transfer.Interval.Next(transfer.NextRunAt)
+		status := domain.RecurringTransferStatusActive
+
+		if transfer.EndDate != nil && next.After(*transfer.EndDate) {
+			status = domain.RecurringTransferStatusCompleted
+		} else if err := s.materialize(context.Background(), transfer, next); err != nil {
+			if !rejectsOccurrence(err) {
+				s.logger.ErrorWithErr("Düzenli transfer oluşturulamadı", err, map[string]interface{}{"recurring_transfer_id": transfer.ID})
+				continue
+			}
+
+			// A rejected occurrence is skipped rather than retried, so the
+			// schedule keeps moving and a later occurrence can still run.
+			s.logger.Warn("Düzenli transfer tekrarı atlandı", map[string]interface{}{
+				"recurring_transfer_id": transfer.ID,
+				"run_at":                next,
+				"reason":                err.Error(),
+			})
+		}
+
+		if _, err := s.repo.Advance(transfer.ID, transfer.NextRunAt, next, status); err != nil {
+			s.logger.ErrorWithErr("Düzenli transfer ilerletilemedi", err, map[string]interface{}{"recurring_transfer_id": transfer.ID})
+			continue
+		}
+
+		if status == domain.RecurringTransferStatusCompleted {
+			s.logger.Info("Düzenli transfer tamamlandı", map[string]interface{}{"recurring_transfer_id": transfer.ID})
+		}
+	}
+}
+
+// materialize stores the occurrence at the given time as a scheduled transfer,
+// after the same sender, recipient and limit checks as any other transfer.
+func (s *RecurringTransferService) materialize(ctx context.Context, transfer *domain.RecurringTransfer, at time.Time) error {
+	fromUserID, toUserID := transfer.FromUserID, transfer.ToUserID
+
+	if err := s.transactionSvc.ValidateTransfer(ctx, fromUserID, toUserID, transfer.Amount, transfer.Currency); err != nil {
+		return err
+	}
+	scheduledAt := at
+
+	transaction := &domain.Transaction{
+		FromUserID:     &fromUserID,
+		ToUserID:       &toUserID,
+		Amount:         transfer.Amount,
+		Currency:       transfer.Currency,
+		Type:           domain.TransactionTypeTransfer,
+		Status:         domain.TransactionStatusScheduled,
+		ScheduledAt:    &scheduledAt,
+		IdempotencyKey: transfer.OccurrenceKey(at),
+	}
+
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// rejectsOccurrence reports whether err is a validation failure rather than
+// an infrastructure error worth retrying.
+func rejectsOccurrence(err error) bool {
+	return errors.Is(err, domain.ErrUserNotFound) ||
+		errors.Is(err, domain.ErrUserInactive) ||
+		errors.Is(err, domain.ErrTransactionLimit)
+}
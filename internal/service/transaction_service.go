@@ -1,46 +1,83 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"payflow/internal/concurrent"
+	"payflow/internal/config"
 	"payflow/internal/domain"
+	"payflow/pkg/auth"
+	"payflow/pkg/fx"
 	"payflow/pkg/logger"
+	"payflow/pkg/metrics"
+)
+
+const (
+	reaperBatchSize = 100
+	maxWorkers      = 100
 )
 
 type TransactionService struct {
 	repo         domain.TransactionRepository
 	balanceRepo  domain.BalanceRepository
 	balanceSvc   domain.BalanceService
+	userRepo     domain.UserRepository
 	auditLogRepo domain.AuditLogRepository
 	eventStore   domain.EventStoreService
 	logger       logger.Logger
+	// workerLogger is handed to the worker pool; it may be sampled.
+	workerLogger logger.Logger
+	config       config.TransactionConfig
+
+	defaultCurrency string
+	converter       fx.CurrencyConverter
 
 	workerPool          *concurrent.WorkerPool
 	pendingTransactions sync.Map // ID -> Transaction
-	initialized         bool
+	initialized         atomic.Bool
 	initMutex           sync.Mutex
+
+	reaperStop chan struct{}
+	reaperDone chan struct{}
+
+	schedulerStop chan struct{}
+	schedulerDone chan struct{}
+
+	statusBroker *statusBroker
 }
 
 func NewTransactionService(
 	repo domain.TransactionRepository,
 	balanceRepo domain.BalanceRepository,
 	balanceSvc domain.BalanceService,
+	userRepo domain.UserRepository,
 	auditLogRepo domain.AuditLogRepository,
 	eventStore domain.EventStoreService,
 	logger logger.Logger,
+	workerLogger logger.Logger,
+	cfg config.TransactionConfig,
+	defaultCurrency string,
+	converter fx.CurrencyConverter,
 ) domain.TransactionService {
 	svc := &TransactionService{
-		repo:         repo,
-		balanceRepo:  balanceRepo,
-		balanceSvc:   balanceSvc,
-		auditLogRepo: auditLogRepo,
-		eventStore:   eventStore,
-		logger:       logger,
-		initialized:  false,
+		repo:            repo,
+		balanceRepo:     balanceRepo,
+		balanceSvc:      balanceSvc,
+		userRepo:        userRepo,
+		auditLogRepo:    auditLogRepo,
+		eventStore:      eventStore,
+		logger:          logger,
+		workerLogger:    workerLogger,
+		config:          cfg,
+		defaultCurrency: defaultCurrency,
+		converter:       converter,
+		statusBroker:    newStatusBroker(),
 	}
 
 	return svc
@@ -50,40 +87,256 @@ func (s *TransactionService) initWorkerPool() {
 	s.initMutex.Lock()
 	defer s.initMutex.Unlock()
 
-	if s.initialized {
+	if s.initialized.Load() {
 		return
 	}
 
-	processor := func(tx *domain.Transaction) error {
-		switch tx.Type {
-		case domain.TransactionTypeDeposit:
-			return s.processDeposit(tx)
-		case domain.TransactionTypeWithdraw:
-			return s.processWithdraw(tx)
-		case domain.TransactionTypeTransfer:
-			return s.processTransfer(tx)
-		default:
-			return fmt.Errorf("bilinmeyen işlem tipi: %s", tx.Type)
-		}
-	}
-
-	s.workerPool = concurrent.NewWorkerPool(5, 100, processor, s.logger)
+	s.workerPool = concurrent.NewWorkerPool(5, 100, s.processQueuedTransaction, s.workerLogger)
 	s.workerPool.Start()
-	s.initialized = true
+	s.recoverPending()
+	s.startReaper()
+	s.startScheduler()
+	s.initialized.Store(true)
 
 	s.logger.Info("İşlem worker pool'u başlatıldı", map[string]interface{}{})
 }
 
+// processQueuedTransaction is the worker pool entry point. Queued transactions
+// are processed at least once: after a restart, pending rows are re-enqueued by
+// recoverPending and the reaper, so the same transaction may reach a worker more
+// than once. A worker first claims the row by moving it from pending to
+// processing; only one claim can succeed, so a repeated run is a no-op. A crash
+// after the claim leaves the row in processing, and such rows are reported by
+// recoverPending rather than retried, because the balance may already have
+// moved.
+func (s *TransactionService) processQueuedTransaction(ctx context.Context, tx *domain.Transaction) error {
+	defer s.untrackPending(tx.ID)
+
+	claimed, err := s.repo.TransitionStatus(ctx, tx.ID, domain.TransactionStatusPending, domain.TransactionStatusProcessing)
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		s.logger.InfoContext(ctx, "İşlem zaten sonuçlanmış, tekrar işlenmeyecek", map[string]interface{}{"transaction_id": tx.ID})
+		return nil
+	}
+
+	tx.Status = domain.TransactionStatusProcessing
+	s.statusBroker.publish(tx)
+
+	return s.processTransaction(ctx, tx)
+}
+
+func (s *TransactionService) processTransaction(ctx context.Context, tx *domain.Transaction) error {
+	var err error
+
+	switch tx.Type {
+	case domain.TransactionTypeDeposit:
+		err = s.processDeposit(ctx, tx)
+	case domain.TransactionTypeWithdraw:
+		err = s.processWithdraw(ctx, tx)
+	case domain.TransactionTypeTransfer:
+		err = s.processTransfer(ctx, tx)
+	default:
+		err = fmt.Errorf("bilinmeyen işlem tipi: %s", tx.Type)
+	}
+
+	status := domain.TransactionStatusCompleted
+	if err != nil {
+		status = domain.TransactionStatusFailed
+	}
+	s.recordOutcome(tx, status)
+
+	return err
+}
+
+func (s *TransactionService) recordOutcome(tx *domain.Transaction, status domain.TransactionStatus) {
+	metrics.RecordTransaction(string(tx.Type), string(status))
+	metrics.RecordTransactionVolume(string(tx.Type), string(status), tx.Currency, tx.Amount.Float64())
+}
+
 func (s *TransactionService) ensureWorkerPoolInitialized() {
-	if !s.initialized {
+	if !s.initialized.Load() {
 		s.initWorkerPool()
 	}
 }
 
-func (s *TransactionService) GetTransactionByID(id int64) (*domain.Transaction, error) {
-	transaction, err := s.repo.FindByID(id)
+func (s *TransactionService) startReaper() {
+	if s.config.ReaperInterval <= 0 || s.config.PendingTimeout <= 0 {
+		return
+	}
+
+	s.reaperStop = make(chan struct{})
+	s.reaperDone = make(chan struct{})
+
+	go func() {
+		defer close(s.reaperDone)
+
+		ticker := time.NewTicker(s.config.ReaperInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.reaperStop:
+				return
+			case <-ticker.C:
+				s.reapStuckTransactions()
+			}
+		}
+	}()
+}
+
+func (s *TransactionService) stopReaper() {
+	if s.reaperStop == nil {
+		return
+	}
+
+	close(s.reaperStop)
+	<-s.reaperDone
+	s.reaperStop = nil
+}
+
+func (s *TransactionService) reapStuckTransactions() {
+	createdBefore := time.Now().Add(-s.config.PendingTimeout)
+
+	transactions, err := s.repo.FindByStatus(context.Background(), domain.TransactionStatusPending, createdBefore, reaperBatchSize, 0)
 	if err != nil {
-		s.logger.Error("İşlem bulunamadı", map[string]interface{}{"id": id, "error": err.Error()})
+		s.logger.ErrorWithErr("Takılı kalan işlemler sorgulanamadı", err, nil)
+		return
+	}
+
+	for _, tx := range transactions {
+		if _, inFlight := s.pendingTransactions.Load(tx.ID); inFlight {
+			continue
+		}
+
+		if err := s.submitTransaction(context.Background(), tx); err != nil {
+			tx.Status = domain.TransactionStatusFailed
+			if err := s.saveEvent(tx, domain.EventTypeTransactionFailed); err != nil {
+				s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
+			}
+
+			s.logger.Warn("Takılı kalan işlem başarısız olarak işaretlendi", map[string]interface{}{"transaction_id": tx.ID})
+			metrics.RecordReapedTransaction("failed")
+			metrics.RecordTransactionVolume(string(tx.Type), string(domain.TransactionStatusFailed), tx.Currency, tx.Amount.Float64())
+			continue
+		}
+
+		s.logger.Info("Takılı kalan işlem yeniden kuyruğa alındı", map[string]interface{}{"transaction_id": tx.ID})
+		metrics.RecordReapedTransaction("recovered")
+	}
+}
+
+func (s *TransactionService) startScheduler() {
+	if s.config.SchedulerInterval <= 0 {
+		return
+	}
+
+	s.schedulerStop = make(chan struct{})
+	s.schedulerDone = make(chan struct{})
+
+	go func() {
+		defer close(s.schedulerDone)
+
+		ticker := time.NewTicker(s.config.SchedulerInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.schedulerStop:
+				return
+			case <-ticker.C:
+				s.submitDueScheduled()
+			}
+		}
+	}()
+}
+
+func (s *TransactionService) stopScheduler() {
+	if s.schedulerStop == nil {
+		return
+	}
+
+	close(s.schedulerStop)
+	<-s.schedulerDone
+	s.schedulerStop = nil
+}
+
+// submitDueScheduled moves due scheduled transactions to pending and hands
+// them to the worker pool. The status transition is conditional, so a transfer
+// cancelled in the meantime is never submitted.
+func (s *TransactionService) submitDueScheduled() {
+	transactions, err := s.repo.FindDueScheduled(context.Background(), time.Now(), reaperBatchSize)
+	if err != nil {
+		s.logger.ErrorWithErr("Zamanı gelen işlemler sorgulanamadı", err, nil)
+		return
+	}
+
+	for _, tx := range transactions {
+		if err := s.checkScheduledParties(tx); err != nil {
+			if errors.Is(err, domain.ErrUserNotFound) || errors.Is(err, domain.ErrUserInactive) {
+				s.failScheduled(tx, err)
+			} else {
+				s.logger.ErrorWithErr("Zamanlanmış işlemin kullanıcıları kontrol edilemedi", err, map[string]interface{}{"transaction_id": tx.ID})
+			}
+			continue
+		}
+
+		promoted, err := s.repo.TransitionStatus(context.Background(), tx.ID, domain.TransactionStatusScheduled, domain.TransactionStatusPending)
+		if err != nil || !promoted {
+			continue
+		}
+		tx.Status = domain.TransactionStatusPending
+		s.statusBroker.publish(tx)
+
+		if err := s.submitTransaction(context.Background(), tx); err != nil {
+			s.logger.ErrorWithErr("Zamanlanmış işlem kuyruğa alınamadı", err, map[string]interface{}{"transaction_id": tx.ID})
+			continue
+		}
+
+		s.logger.Info("Zamanlanmış işlem kuyruğa alındı", map[string]interface{}{"transaction_id": tx.ID, "scheduled_at": tx.ScheduledAt})
+	}
+}
+
+// checkScheduledParties repeats the sender and recipient checks for a transfer
+// that comes due, since either user may have been deactivated or deleted
+// since it was scheduled. Limits were checked when the row was created and
+// the row already counts towards that day's total.
+func (s *TransactionService) checkScheduledParties(tx *domain.Transaction) error {
+	if tx.Type != domain.TransactionTypeTransfer || tx.FromUserID == nil || tx.ToUserID == nil {
+		return nil
+	}
+
+	if err := s.checkUserActive(*tx.FromUserID); err != nil {
+		return err
+	}
+
+	return s.checkRecipient(*tx.ToUserID)
+}
+
+// failScheduled marks a due scheduled transaction failed instead of running
+// it. The transition is conditional, like the promotion to pending.
+func (s *TransactionService) failScheduled(tx *domain.Transaction, reason error) {
+	failed, err := s.repo.TransitionStatus(context.Background(), tx.ID, domain.TransactionStatusScheduled, domain.TransactionStatusFailed)
+	if err != nil || !failed {
+		return
+	}
+
+	tx.Status = domain.TransactionStatusFailed
+	s.statusBroker.publish(tx)
+	s.recordOutcome(tx, domain.TransactionStatusFailed)
+	if err := s.saveEvent(tx, domain.EventTypeTransactionFailed); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
+	}
+
+	s.logger.Warn("Zamanlanmış işlem çalıştırılmadı", map[string]interface{}{"transaction_id": tx.ID, "reason": reason.Error()})
+}
+
+func (s *TransactionService) GetTransactionByID(ctx context.Context, id int64) (*domain.Transaction, error) {
+	transaction, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		s.logger.ErrorWithErr("İşlem bulunamadı", err, map[string]interface{}{"id": id})
 		return nil, fmt.Errorf("işlem bulunamadı: %w", err)
 	}
 
@@ -95,23 +348,107 @@ func (s *TransactionService) GetTransactionByID(id int64) (*domain.Transaction,
 	return transaction, nil
 }
 
-func (s *TransactionService) GetUserTransactions(userID int64) ([]*domain.Transaction, error) {
-	transactions, err := s.repo.FindByUserID(userID)
+func (s *TransactionService) GetUserTransactions(ctx context.Context, userID int64) ([]*domain.Transaction, error) {
+	transactions, err := s.repo.FindByUserID(ctx, userID)
 	if err != nil {
-		s.logger.Error("Kullanıcı işlemleri bulunamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		s.logger.ErrorWithErr("Kullanıcı işlemleri bulunamadı", err, map[string]interface{}{"user_id": userID})
 		return nil, fmt.Errorf("kullanıcı işlemleri bulunamadı: %w", err)
 	}
 
 	return transactions, nil
 }
 
-func (s *TransactionService) saveEvent(transaction *domain.Transaction, eventType domain.EventType) error {
-	eventData, err := json.Marshal(transaction)
+func (s *TransactionService) GetUserTransactionsPaginated(ctx context.Context, userID int64, filter domain.TransactionFilter, page, pageSize int) (*domain.TransactionPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 50
+	}
+
+	if !filter.StartDate.IsZero() && !filter.EndDate.IsZero() && !filter.StartDate.Before(filter.EndDate) {
+		return nil, fmt.Errorf("başlangıç tarihi bitiş tarihinden önce olmalı")
+	}
+
+	offset := (page - 1) * pageSize
+
+	transactions, totalCount, err := s.repo.FindByUserIDPaginated(ctx, userID, filter, pageSize, offset)
+	if err != nil {
+		s.logger.Error("Kullanıcı işlemleri bulunamadı", map[string]interface{}{
+			"user_id":   userID,
+			"page":      page,
+			"page_size": pageSize,
+			"error":     err.Error(),
+		})
+		return nil, fmt.Errorf("kullanıcı işlemleri bulunamadı: %w", err)
+	}
+
+	return &domain.TransactionPage{
+		Transactions: transactions,
+		TotalCount:   totalCount,
+		Page:         page,
+		PageSize:     pageSize,
+	}, nil
+}
+
+func (s *TransactionService) GetTransactionsByStatus(ctx context.Context, status domain.TransactionStatus, olderThan time.Duration, page, pageSize int) ([]*domain.Transaction, int64, error) {
+	if !status.IsValid() {
+		return nil, 0, fmt.Errorf("geçersiz işlem durumu: %s", status)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 50
+	}
+
+	var createdBefore time.Time
+	if olderThan > 0 {
+		createdBefore = time.Now().Add(-olderThan)
+	}
+
+	offset := (page - 1) * pageSize
+
+	transactions, err := s.repo.FindByStatus(ctx, status, createdBefore, pageSize, offset)
+	if err != nil {
+		s.logger.Error("Duruma göre işlemler alınamadı", map[string]interface{}{
+			"status":    status,
+			"page":      page,
+			"page_size": pageSize,
+			"error":     err.Error(),
+		})
+		return nil, 0, fmt.Errorf("duruma göre işlemler alınamadı: %w", err)
+	}
+
+	totalCount, err := s.repo.CountByStatus(ctx, status, createdBefore)
 	if err != nil {
+		return nil, 0, fmt.Errorf("duruma göre işlemler alınamadı: %w", err)
+	}
+
+	return transactions, totalCount, nil
+}
+
+// updateStatus persists the new status and notifies status subscribers. It
+// deliberately ignores the request context: once balances have moved, the
+// status has to be recorded even if the caller has gone away.
+func (s *TransactionService) updateStatus(tx *domain.Transaction, status domain.TransactionStatus) error {
+	if err := s.repo.UpdateStatus(context.Background(), tx.ID, status); err != nil {
 		return err
 	}
 
-	lastVersion, err := s.eventStore.GetLastVersion("transaction", fmt.Sprintf("%d", transaction.ID))
+	tx.Status = status
+	s.statusBroker.publish(tx)
+
+	return nil
+}
+
+func (s *TransactionService) SubscribeStatus(transactionID int64) (<-chan *domain.Transaction, func()) {
+	return s.statusBroker.subscribe(transactionID)
+}
+
+func (s *TransactionService) saveEvent(transaction *domain.Transaction, eventType domain.EventType) error {
+	eventData, err := json.Marshal(transaction)
 	if err != nil {
 		return err
 	}
@@ -121,66 +458,136 @@ func (s *TransactionService) saveEvent(transaction *domain.Transaction, eventTyp
 		AggregateType: "transaction",
 		EventType:     eventType,
 		EventData:     eventData,
-		Version:       lastVersion + 1,
 		CreatedAt:     time.Now(),
 	}
 
-	return s.eventStore.SaveEvent(event)
+	if err := s.eventStore.SaveEvent(event); err != nil {
+		return err
+	}
+
+	if s.eventStore.ShouldSnapshot(event.Version) {
+		s.snapshotTransactionState(transaction.ID)
+	}
+
+	return nil
+}
+
+type transactionState struct {
+	Status domain.TransactionStatus `json:"status,omitempty"`
+}
+
+func transactionEventStatus(eventType domain.EventType) (domain.TransactionStatus, bool) {
+	switch eventType {
+	case domain.EventTypeTransactionCompleted:
+		return domain.TransactionStatusCompleted, true
+	case domain.EventTypeTransactionFailed:
+		return domain.TransactionStatusFailed, true
+	case domain.EventTypeTransactionCancelled:
+		return domain.TransactionStatusCancelled, true
+	case domain.EventTypeTransactionRolledBack:
+		return domain.TransactionStatusRolledBack, true
+	}
+	return "", false
+}
+
+// loadTransactionState folds the status events of a transaction, starting from
+// the most recent snapshot when there is one.
+func (s *TransactionService) loadTransactionState(transactionID int64) (transactionState, int, error) {
+	aggregateID := fmt.Sprintf("%d", transactionID)
+	var state transactionState
+	version := 0
+
+	snapshot, err := s.eventStore.GetLatestSnapshot("transaction", aggregateID)
+	if err != nil {
+		return state, 0, err
+	}
+
+	if snapshot != nil {
+		if err := json.Unmarshal(snapshot.State, &state); err != nil {
+			return state, 0, err
+		}
+		version = snapshot.Version
+	}
+
+	events, err := s.eventStore.GetEventsAfterVersion("transaction", aggregateID, version)
+	if err != nil {
+		return state, 0, err
+	}
+
+	for _, event := range events {
+		version = event.Version
+		if status, ok := transactionEventStatus(event.EventType); ok {
+			state.Status = status
+		}
+	}
+
+	return state, version, nil
+}
+
+func (s *TransactionService) snapshotTransactionState(transactionID int64) {
+	state, version, err := s.loadTransactionState(transactionID)
+	if err != nil {
+		s.logger.ErrorWithErr("İşlem snapshot'ı için durum hesaplanamadı", err, map[string]interface{}{"transaction_id": transactionID})
+		return
+	}
+
+	if err := s.eventStore.SaveSnapshot("transaction", fmt.Sprintf("%d", transactionID), version, state); err != nil {
+		s.logger.ErrorWithErr("İşlem snapshot'ı kaydedilemedi", err, map[string]interface{}{"transaction_id": transactionID, "version": version})
+	}
 }
 
-func (s *TransactionService) processDeposit(tx *domain.Transaction) error {
+func (s *TransactionService) processDeposit(ctx context.Context, tx *domain.Transaction) error {
 	userID := *tx.ToUserID
 
-	_, err := s.balanceSvc.DepositAtomically(userID, tx.Amount)
+	_, err := s.balanceSvc.DepositAtomically(userID, tx.Currency, tx.Amount, tx.ID)
 	if err != nil {
-		s.logger.Error("Para yatırma işlemi başarısız oldu", map[string]interface{}{"transaction_id": tx.ID, "error": err.Error()})
-		s.repo.UpdateStatus(tx.ID, domain.TransactionStatusFailed)
+		s.logger.ErrorWithErr("Para yatırma işlemi başarısız oldu", err, map[string]interface{}{"transaction_id": tx.ID})
+		s.updateStatus(tx, domain.TransactionStatusFailed)
 
 		if err := s.saveEvent(tx, domain.EventTypeTransactionFailed); err != nil {
-			s.logger.Error("Event kaydedilemedi", map[string]interface{}{"error": err.Error()})
+			s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
 		}
 
 		return err
 	}
 
-	if err := s.repo.UpdateStatus(tx.ID, domain.TransactionStatusCompleted); err != nil {
-		s.logger.Error("İşlem durumu güncellenemedi", map[string]interface{}{"id": tx.ID, "error": err.Error()})
+	if err := s.updateStatus(tx, domain.TransactionStatusCompleted); err != nil {
+		s.logger.ErrorWithErr("İşlem durumu güncellenemedi", err, map[string]interface{}{"id": tx.ID})
 		return err
 	}
 
 	if err := s.saveEvent(tx, domain.EventTypeTransactionCompleted); err != nil {
-		s.logger.Error("Event kaydedilemedi", map[string]interface{}{"error": err.Error()})
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
 	}
 
 	auditLog := &domain.AuditLog{
 		EntityType: domain.EntityTypeTransaction,
 		EntityID:   tx.ID,
 		Action:     domain.ActionTypeCreate,
-		Details:    fmt.Sprintf("Para yatırma işlemi: %.2f", tx.Amount),
+		Details:    fmt.Sprintf("Para yatırma işlemi: %s %s", tx.Amount, tx.Currency),
+		ActorID:    auth.ActorIDFromContext(ctx),
 		CreatedAt:  time.Now(),
 	}
 
 	if err := s.auditLogRepo.Create(auditLog); err != nil {
-		s.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"transaction_id": tx.ID, "error": err.Error()})
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"transaction_id": tx.ID})
 	}
 
-	s.pendingTransactions.Delete(tx.ID)
-
 	return nil
 }
 
-func (s *TransactionService) processWithdraw(tx *domain.Transaction) error {
+func (s *TransactionService) processWithdraw(ctx context.Context, tx *domain.Transaction) error {
 	userID := *tx.FromUserID
 
-	_, err := s.balanceSvc.WithdrawAtomically(userID, tx.Amount)
+	_, err := s.balanceSvc.WithdrawAtomically(userID, tx.Currency, tx.Amount, tx.ID)
 	if err != nil {
-		s.logger.Error("Para çekme işlemi başarısız oldu", map[string]interface{}{"transaction_id": tx.ID, "error": err.Error()})
-		s.repo.UpdateStatus(tx.ID, domain.TransactionStatusFailed)
+		s.logger.ErrorWithErr("Para çekme işlemi başarısız oldu", err, map[string]interface{}{"transaction_id": tx.ID})
+		s.updateStatus(tx, domain.TransactionStatusFailed)
 		return err
 	}
 
-	if err := s.repo.UpdateStatus(tx.ID, domain.TransactionStatusCompleted); err != nil {
-		s.logger.Error("İşlem durumu güncellenemedi", map[string]interface{}{"id": tx.ID, "error": err.Error()})
+	if err := s.updateStatus(tx, domain.TransactionStatusCompleted); err != nil {
+		s.logger.ErrorWithErr("İşlem durumu güncellenemedi", err, map[string]interface{}{"id": tx.ID})
 		return err
 	}
 
@@ -188,57 +595,35 @@ func (s *TransactionService) processWithdraw(tx *domain.Transaction) error {
 		EntityType: domain.EntityTypeTransaction,
 		EntityID:   tx.ID,
 		Action:     domain.ActionTypeCreate,
-		Details:    fmt.Sprintf("Para çekme işlemi: %.2f", tx.Amount),
+		Details:    fmt.Sprintf("Para çekme işlemi: %s %s", tx.Amount, tx.Currency),
+		ActorID:    auth.ActorIDFromContext(ctx),
 		CreatedAt:  time.Now(),
 	}
 
 	if err := s.auditLogRepo.Create(auditLog); err != nil {
-		s.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"transaction_id": tx.ID, "error": err.Error()})
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"transaction_id": tx.ID})
 	}
 
-	s.pendingTransactions.Delete(tx.ID)
-
 	return nil
 }
 
-func (s *TransactionService) processTransfer(tx *domain.Transaction) error {
+func (s *TransactionService) processTransfer(ctx context.Context, tx *domain.Transaction) error {
 	fromUserID := *tx.FromUserID
 	toUserID := *tx.ToUserID
 
-	_, err := s.balanceSvc.WithdrawAtomically(fromUserID, tx.Amount)
-	if err != nil {
-		s.logger.Error("Transfer işlemi sırasında para çekme başarısız oldu", map[string]interface{}{
+	if err := s.balanceSvc.TransferAtomically(fromUserID, toUserID, tx.Currency, tx.TargetCurrency(), tx.Amount, tx.TargetAmount(), tx.ID); err != nil {
+		s.logger.Error("Transfer işlemi başarısız oldu", map[string]interface{}{
 			"transaction_id": tx.ID,
 			"from_user_id":   fromUserID,
-			"error":          err.Error(),
-		})
-		s.repo.UpdateStatus(tx.ID, domain.TransactionStatusFailed)
-		return err
-	}
-
-	_, err = s.balanceSvc.DepositAtomically(toUserID, tx.Amount)
-	if err != nil {
-
-		_, rollbackErr := s.balanceSvc.DepositAtomically(fromUserID, tx.Amount)
-		if rollbackErr != nil {
-			s.logger.Error("Geri alma işlemi başarısız oldu", map[string]interface{}{
-				"transaction_id": tx.ID,
-				"from_user_id":   fromUserID,
-				"error":          rollbackErr.Error(),
-			})
-		}
-
-		s.logger.Error("Transfer işlemi sırasında para yatırma başarısız oldu", map[string]interface{}{
-			"transaction_id": tx.ID,
 			"to_user_id":     toUserID,
 			"error":          err.Error(),
 		})
-		s.repo.UpdateStatus(tx.ID, domain.TransactionStatusFailed)
+		s.updateStatus(tx, domain.TransactionStatusFailed)
 		return err
 	}
 
-	if err := s.repo.UpdateStatus(tx.ID, domain.TransactionStatusCompleted); err != nil {
-		s.logger.Error("İşlem durumu güncellenemedi", map[string]interface{}{"id": tx.ID, "error": err.Error()})
+	if err := s.updateStatus(tx, domain.TransactionStatusCompleted); err != nil {
+		s.logger.ErrorWithErr("İşlem durumu güncellenemedi", err, map[string]interface{}{"id": tx.ID})
 		return err
 	}
 
@@ -246,16 +631,15 @@ func (s *TransactionService) processTransfer(tx *domain.Transaction) error {
 		EntityType: domain.EntityTypeTransaction,
 		EntityID:   tx.ID,
 		Action:     domain.ActionTypeCreate,
-		Details:    fmt.Sprintf("Para transferi: %.2f, %d -> %d", tx.Amount, fromUserID, toUserID),
+		Details:    fmt.Sprintf("Para transferi: %s %s -> %s %s, %d -> %d", tx.Amount, tx.Currency, tx.TargetAmount(), tx.TargetCurrency(), fromUserID, toUserID),
+		ActorID:    auth.ActorIDFromContext(ctx),
 		CreatedAt:  time.Now(),
 	}
 
 	if err := s.auditLogRepo.Create(auditLog); err != nil {
-		s.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"transaction_id": tx.ID, "error": err.Error()})
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"transaction_id": tx.ID})
 	}
 
-	s.pendingTransactions.Delete(tx.ID)
-
 	return nil
 }
 
@@ -263,78 +647,152 @@ func (s *TransactionService) GetWorkerPoolStats() (domain.TransactionStats, erro
 	s.ensureWorkerPoolInitialized()
 
 	concurrentStats := s.workerPool.GetStats()
+	numWorkers := s.workerPool.NumWorkers()
+	busyWorkers := s.workerPool.BusyWorkers()
+	idleWorkers := numWorkers - busyWorkers
+	if idleWorkers < 0 {
+		idleWorkers = 0
+	}
+
 	stats := domain.TransactionStats{
 		Submitted:      concurrentStats.Submitted,
 		Completed:      concurrentStats.Completed,
 		Failed:         concurrentStats.Failed,
 		Rejected:       concurrentStats.Rejected,
 		AvgProcessTime: concurrentStats.AvgProcessTime,
+		P50ProcessTime: concurrentStats.P50ProcessTime,
+		P95ProcessTime: concurrentStats.P95ProcessTime,
+		P99ProcessTime: concurrentStats.P99ProcessTime,
 		QueueLength:    s.workerPool.QueueLength(),
 		QueueCapacity:  s.workerPool.QueueCapacity(),
+		NumWorkers:     numWorkers,
+		BusyWorkers:    busyWorkers,
+		IdleWorkers:    idleWorkers,
 	}
 
 	return stats, nil
 }
 
-func (s *TransactionService) ProcessBatchTransactions(transactions []*domain.Transaction) (processed int, failed int, err error) {
+func (s *TransactionService) ResizeWorkerPool(numWorkers int) error {
+	s.ensureWorkerPoolInitialized()
+
+	if numWorkers < 1 || numWorkers > maxWorkers {
+		return fmt.Errorf("geçersiz worker sayısı: %d, 1-%d arası olmalı", numWorkers, maxWorkers)
+	}
+
+	s.workerPool.Resize(numWorkers)
+
+	s.logger.Info("İşlem worker pool'u yeniden boyutlandırıldı", map[string]interface{}{"num_workers": numWorkers})
+
+	return nil
+}
+
+// ProcessBatchTransactions runs each item through the same validation,
+// persistence and claim path as a single request, bounded by the pool size.
+func (s *TransactionService) ProcessBatchTransactions(ctx context.Context, transactions []*domain.Transaction) (processed int, failed int, err error) {
 	s.ensureWorkerPoolInitialized()
 
 	if len(transactions) == 0 {
 		return 0, 0, nil
 	}
 
-	var wg sync.WaitGroup
-	processMutex := sync.Mutex{}
+	// Items keep the caller's values (actor, trace) but finish even if the
+	// client disconnects, as queued transactions do.
+	batchCtx := context.WithoutCancel(ctx)
 
-	processedCount := 0
-	failedCount := 0
+	numWorkers := s.workerPool.NumWorkers()
+	if numWorkers > len(transactions) {
+		numWorkers = len(transactions)
+	}
 
-	for _, tx := range transactions {
-		wg.Add(1)
+	jobs := make(chan *domain.Transaction)
+	results := make(chan error)
 
-		go func(transaction *domain.Transaction) {
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
 			defer wg.Done()
-
-			var processErr error
-			switch transaction.Type {
-			case domain.TransactionTypeDeposit:
-				processErr = s.processDeposit(transaction)
-			case domain.TransactionTypeWithdraw:
-				processErr = s.processWithdraw(transaction)
-			case domain.TransactionTypeTransfer:
-				processErr = s.processTransfer(transaction)
-			default:
-				processErr = fmt.Errorf("bilinmeyen işlem tipi: %s", transaction.Type)
+			for transaction := range jobs {
+				results <- s.processBatchItem(batchCtx, transaction)
 			}
+		}()
+	}
 
-			processMutex.Lock()
-			if processErr != nil {
-				failedCount++
-			} else {
-				processedCount++
-			}
-			processMutex.Unlock()
-		}(tx)
+	go func() {
+		for _, tx := range transactions {
+			jobs <- tx
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	processedCount := 0
+	failedCount := 0
+	for processErr := range results {
+		if processErr != nil {
+			failedCount++
+		} else {
+			processedCount++
+		}
 	}
-	wg.Wait()
 
 	return processedCount, failedCount, nil
 }
 
+func (s *TransactionService) processBatchItem(ctx context.Context, item *domain.Transaction) error {
+	var (
+		tx  *domain.Transaction
+		err error
+	)
+
+	switch item.Type {
+	case domain.TransactionTypeDeposit:
+		if item.ToUserID == nil {
+			return fmt.Errorf("para yatırma için alıcı kullanıcı gerekli")
+		}
+		tx, _, err = s.prepareDeposit(ctx, *item.ToUserID, item.Amount, item.Currency, "")
+	case domain.TransactionTypeWithdraw:
+		if item.FromUserID == nil {
+			return fmt.Errorf("para çekme için gönderen kullanıcı gerekli")
+		}
+		tx, _, err = s.prepareWithdraw(ctx, *item.FromUserID, item.Amount, item.Currency, "")
+	case domain.TransactionTypeTransfer:
+		if item.FromUserID == nil || item.ToUserID == nil {
+			return fmt.Errorf("transfer için gönderen ve alıcı kullanıcı gerekli")
+		}
+		tx, _, err = s.prepareTransfer(ctx, *item.FromUserID, *item.ToUserID, item.Amount, item.Currency, item.Currency, "")
+	default:
+		return fmt.Errorf("bilinmeyen işlem tipi: %s", item.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.processQueuedTransaction(ctx, tx)
+}
+
 func (s *TransactionService) Shutdown() {
-	if s.initialized {
-		s.workerPool.Stop()
+	if s.initialized.Load() {
+		s.stopScheduler()
+		s.stopReaper()
+		if !s.workerPool.Drain(s.config.DrainTimeout) {
+			s.logger.Warn("Kuyrukta işlenmemiş işlemler kaldı, yeniden başlatmada kurtarılacak", map[string]interface{}{})
+		}
 		s.logger.Info("İşlem worker pool'u durduruldu", map[string]interface{}{})
 	}
 }
 
-func (s *TransactionService) RollbackTransaction(transactionID int64) error {
-	tx, err := s.GetTransactionByID(transactionID)
+// RollbackTransaction reverses a completed transaction; actorID is recorded
+// in the audit log as the user who requested it.
+func (s *TransactionService) RollbackTransaction(ctx context.Context, transactionID, actorID int64) error {
+	tx, err := s.GetTransactionByID(ctx, transactionID)
 	if err != nil {
 		return fmt.Errorf("işlem geri alınamadı: %w", err)
 	}
 
-	eligible, err := s.IsTransactionEligibleForRollback(transactionID)
+	eligible, err := s.IsTransactionEligibleForRollback(ctx, transactionID)
 	if err != nil {
 		return err
 	}
@@ -343,6 +801,11 @@ func (s *TransactionService) RollbackTransaction(transactionID int64) error {
 		return fmt.Errorf("işlem geri alınamaz: %d", transactionID)
 	}
 
+	currency, err := domain.NormalizeCurrency(tx.Currency, s.defaultCurrency)
+	if err != nil {
+		return fmt.Errorf("işlem geri alınamadı: %w", err)
+	}
+
 	var rollbackErr error
 
 	switch tx.Type {
@@ -350,44 +813,53 @@ func (s *TransactionService) RollbackTransaction(transactionID int64) error {
 		if tx.ToUserID == nil {
 			return fmt.Errorf("geçersiz işlem: alıcı ID'si bulunamadı")
 		}
-		balance, err := s.balanceRepo.FindByUserID(*tx.ToUserID)
+		balance, err := s.balanceRepo.FindByUserAndCurrency(ctx, *tx.ToUserID, currency)
 		if err != nil {
 			return fmt.Errorf("bakiye kontrol edilemedi: %w", err)
 		}
 
-		if balance.Amount < tx.Amount {
-			return fmt.Errorf("geri alma için yetersiz bakiye: %.2f", balance.Amount)
+		if balance == nil {
+			return fmt.Errorf("bakiye kontrol edilemedi: %w", domain.ErrBalanceNotFound)
+		}
+
+		if !balance.CanWithdraw(tx.Amount) {
+			return fmt.Errorf("geri alma için yetersiz bakiye: %s", balance.Amount)
 		}
 
-		_, rollbackErr = s.balanceSvc.WithdrawAtomically(*tx.ToUserID, tx.Amount)
+		_, rollbackErr = s.balanceSvc.WithdrawAtomically(*tx.ToUserID, currency, tx.Amount, tx.ID)
 
 	case domain.TransactionTypeWithdraw:
 		if tx.FromUserID == nil {
 			return fmt.Errorf("geçersiz işlem: gönderen ID'si bulunamadı")
 		}
 
-		_, rollbackErr = s.balanceSvc.DepositAtomically(*tx.FromUserID, tx.Amount)
+		_, rollbackErr = s.balanceSvc.DepositAtomically(*tx.FromUserID, currency, tx.Amount, tx.ID)
 
 	case domain.TransactionTypeTransfer:
 		if tx.FromUserID == nil || tx.ToUserID == nil {
 			return fmt.Errorf("geçersiz işlem: gönderen veya alıcı ID'si bulunamadı")
 		}
 
-		balance, err := s.balanceRepo.FindByUserID(*tx.ToUserID)
+		targetCurrency, err := domain.NormalizeCurrency(tx.TargetCurrency(), currency)
+		if err != nil {
+			return fmt.Errorf("işlem geri alınamadı: %w", err)
+		}
+
+		balance, err := s.balanceRepo.FindByUserAndCurrency(ctx, *tx.ToUserID, targetCurrency)
 		if err != nil {
 			return fmt.Errorf("bakiye kontrol edilemedi: %w", err)
 		}
 
-		if balance.Amount < tx.Amount {
-			return fmt.Errorf("geri alma için yetersiz bakiye: %.2f", balance.Amount)
+		if balance == nil {
+			return fmt.Errorf("bakiye kontrol edilemedi: %w", domain.ErrBalanceNotFound)
 		}
 
-		_, err = s.balanceSvc.WithdrawAtomically(*tx.ToUserID, tx.Amount)
-		if err != nil {
-			return fmt.Errorf("geri alma sırasında para çekme işlemi başarısız: %w", err)
+		if !balance.CanWithdraw(tx.TargetAmount()) {
+			return fmt.Errorf("geri alma için yetersiz bakiye: %s", balance.Amount)
 		}
 
-		_, rollbackErr = s.balanceSvc.DepositAtomically(*tx.FromUserID, tx.Amount)
+		// The reversal moves back exactly what was credited, at the original rate.
+		rollbackErr = s.balanceSvc.TransferAtomically(*tx.ToUserID, *tx.FromUserID, targetCurrency, currency, tx.TargetAmount(), tx.Amount, tx.ID)
 	default:
 		return fmt.Errorf("bilinmeyen işlem tipi: %s", tx.Type)
 	}
@@ -396,7 +868,7 @@ func (s *TransactionService) RollbackTransaction(transactionID int64) error {
 		return fmt.Errorf("işlem geri alma sırasında hata: %w", rollbackErr)
 	}
 
-	if err := s.repo.UpdateStatus(transactionID, domain.TransactionStatusRolledBack); err != nil {
+	if err := s.updateStatus(tx, domain.TransactionStatusRolledBack); err != nil {
 		s.logger.Error("İşlem durumu güncellenemedi", map[string]interface{}{
 			"transaction_id": transactionID,
 			"error":          err.Error(),
@@ -404,11 +876,16 @@ func (s *TransactionService) RollbackTransaction(transactionID int64) error {
 		return fmt.Errorf("işlem durumu güncellenemedi: %w", err)
 	}
 
+	if err := s.saveEvent(tx, domain.EventTypeTransactionRolledBack); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, map[string]interface{}{"transaction_id": transactionID})
+	}
+
 	auditLog := &domain.AuditLog{
 		EntityType: domain.EntityTypeTransaction,
 		EntityID:   transactionID,
-		Action:     "rollback",
+		Action:     domain.ActionTypeRollback,
 		Details:    fmt.Sprintf("İşlem geri alındı: %d", transactionID),
+		ActorID:    actorID,
 		CreatedAt:  time.Now(),
 	}
 
@@ -419,8 +896,11 @@ func (s *TransactionService) RollbackTransaction(transactionID int64) error {
 		})
 	}
 
+	s.recordOutcome(tx, domain.TransactionStatusRolledBack)
+
 	s.logger.Info("İşlem başarıyla geri alındı", map[string]interface{}{
 		"transaction_id": transactionID,
+		"actor_id":       actorID,
 		"type":           tx.Type,
 		"amount":         tx.Amount,
 	})
@@ -428,8 +908,8 @@ func (s *TransactionService) RollbackTransaction(transactionID int64) error {
 	return nil
 }
 
-func (s *TransactionService) IsTransactionEligibleForRollback(transactionID int64) (bool, error) {
-	tx, err := s.GetTransactionByID(transactionID)
+func (s *TransactionService) IsTransactionEligibleForRollback(ctx context.Context, transactionID int64) (bool, error) {
+	tx, err := s.GetTransactionByID(ctx, transactionID)
 	if err != nil {
 		return false, fmt.Errorf("işlem kontrol edilemedi: %w", err)
 	}
@@ -438,11 +918,7 @@ func (s *TransactionService) IsTransactionEligibleForRollback(transactionID int6
 		return false, nil
 	}
 
-	if tx.Status == domain.TransactionStatusRolledBack {
-		return false, nil
-	}
-
-	rollbackDeadline := time.Now().Add(-24 * time.Hour)
+	rollbackDeadline := time.Now().Add(-s.config.RollbackWindow)
 	if tx.CreatedAt.Before(rollbackDeadline) {
 		return false, nil
 	}
@@ -450,187 +926,619 @@ func (s *TransactionService) IsTransactionEligibleForRollback(transactionID int6
 	return true, nil
 }
 
-func (s *TransactionService) DepositFunds(userID int64, amount float64) (*domain.Transaction, error) {
+func (s *TransactionService) DepositFunds(ctx context.Context, userID int64, amount domain.Money, currency, idempotencyKey string) (*domain.Transaction, error) {
+	s.ensureWorkerPoolInitialized()
+
+	transaction, created, err := s.prepareDeposit(ctx, userID, amount, currency, idempotencyKey)
+	if err != nil || !created {
+		return transaction, err
+	}
+
+	if err := s.submitTransaction(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// prepareDeposit validates a deposit and stores it as pending. created is
+// false when an earlier request with the same idempotency key is returned.
+func (s *TransactionService) prepareDeposit(ctx context.Context, userID int64, amount domain.Money, currency, idempotencyKey string) (*domain.Transaction, bool, error) {
+	if amount <= 0 {
+		return nil, false, fmt.Errorf("geçersiz miktar: %s", amount)
+	}
+
+	currency, err := domain.NormalizeCurrency(currency, s.defaultCurrency)
+	if err != nil {
+		return nil, false, fmt.Errorf("para yatırma işlemi yapılamadı: %w", err)
+	}
+
 	transaction := &domain.Transaction{
-		ToUserID:  &userID,
-		Amount:    amount,
-		Type:      domain.TransactionTypeDeposit,
-		Status:    domain.TransactionStatusPending,
-		CreatedAt: time.Now(),
+		ToUserID:       &userID,
+		Amount:         amount,
+		Currency:       currency,
+		Type:           domain.TransactionTypeDeposit,
+		Status:         domain.TransactionStatusPending,
+		CreatedAt:      time.Now(),
+		IdempotencyKey: idempotencyKey,
 	}
 
-	if err := s.repo.Create(transaction); err != nil {
-		s.logger.Error("İşlem oluşturulamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
-		return nil, fmt.Errorf("para yatırma işlemi yapılamadı: %w", err)
+	if existing, err := s.findByIdempotencyKey(ctx, transaction); err != nil {
+		return nil, false, fmt.Errorf("para yatırma işlemi yapılamadı: %w", err)
+	} else if existing != nil {
+		return existing, false, nil
 	}
 
-	if err := s.saveEvent(transaction, domain.EventTypeTransactionCreated); err != nil {
-		s.logger.Error("Event kaydedilemedi", map[string]interface{}{"error": err.Error()})
+	if err := s.checkUserActive(userID); err != nil {
+		return nil, false, fmt.Errorf("para yatırma işlemi yapılamadı: %w", err)
 	}
 
-	submitted := s.workerPool.Submit(transaction)
-	if !submitted {
-		s.logger.Error("İşlem kuyruğa eklenemedi", map[string]interface{}{"transaction_id": transaction.ID})
-		s.repo.UpdateStatus(transaction.ID, domain.TransactionStatusFailed)
-		return nil, fmt.Errorf("işlem şu anda işlenemiyor, lütfen daha sonra tekrar deneyin")
+	if err := s.checkLimits(ctx, userID, amount, currency); err != nil {
+		return nil, false, fmt.Errorf("para yatırma işlemi yapılamadı: %w", err)
 	}
 
-	s.pendingTransactions.Store(transaction.ID, transaction)
+	transaction, created, err := s.createTransaction(ctx, transaction)
+	if err != nil {
+		s.logger.ErrorWithErr("İşlem oluşturulamadı", err, map[string]interface{}{"user_id": userID})
+		return nil, false, fmt.Errorf("para yatırma işlemi yapılamadı: %w", err)
+	}
 
-	return transaction, nil
+	if !created {
+		return transaction, false, nil
+	}
+
+	if err := s.saveEvent(transaction, domain.EventTypeTransactionCreated); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
+	}
+
+	return transaction, true, nil
 }
 
-func (s *TransactionService) WithdrawFunds(userID int64, amount float64) (*domain.Transaction, error) {
+func (s *TransactionService) WithdrawFunds(ctx context.Context, userID int64, amount domain.Money, currency, idempotencyKey string) (*domain.Transaction, error) {
 	s.ensureWorkerPoolInitialized()
 
+	transaction, created, err := s.prepareWithdraw(ctx, userID, amount, currency, idempotencyKey)
+	if err != nil || !created {
+		return transaction, err
+	}
+
+	if err := s.submitTransaction(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// prepareWithdraw validates a withdrawal against the current balance and
+// stores it as pending.
+func (s *TransactionService) prepareWithdraw(ctx context.Context, userID int64, amount domain.Money, currency, idempotencyKey string) (*domain.Transaction, bool, error) {
 	if amount <= 0 {
-		return nil, fmt.Errorf("geçersiz miktar: %.2f", amount)
+		return nil, false, fmt.Errorf("geçersiz miktar: %s", amount)
 	}
 
-	balance, err := s.balanceRepo.FindByUserID(userID)
+	currency, err := domain.NormalizeCurrency(currency, s.defaultCurrency)
 	if err != nil {
-		s.logger.Error("Bakiye bulunamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
-		return nil, fmt.Errorf("para çekme işlemi yapılamadı: %w", err)
+		return nil, false, fmt.Errorf("para çekme işlemi yapılamadı: %w", err)
 	}
 
-	if balance == nil {
-		s.logger.Error("Bakiye bulunamadı", map[string]interface{}{"user_id": userID})
-		return nil, fmt.Errorf("kullanıcının bakiyesi bulunamadı: %d", userID)
+	transaction := &domain.Transaction{
+		FromUserID:     &userID,
+		Amount:         amount,
+		Currency:       currency,
+		Type:           domain.TransactionTypeWithdraw,
+		Status:         domain.TransactionStatusPending,
+		CreatedAt:      time.Now(),
+		IdempotencyKey: idempotencyKey,
 	}
 
-	if balance.Amount < amount {
-		s.logger.Error("Yetersiz bakiye", map[string]interface{}{"user_id": userID, "balance": balance.Amount, "amount": amount})
-		return nil, fmt.Errorf("yetersiz bakiye: %.2f, çekilmek istenen: %.2f", balance.Amount, amount)
+	if existing, err := s.findByIdempotencyKey(ctx, transaction); err != nil {
+		return nil, false, fmt.Errorf("para çekme işlemi yapılamadı: %w", err)
+	} else if existing != nil {
+		return existing, false, nil
 	}
 
-	transaction := &domain.Transaction{
-		FromUserID: &userID,
-		Amount:     amount,
-		Type:       domain.TransactionTypeWithdraw,
-		Status:     domain.TransactionStatusPending,
-		CreatedAt:  time.Now(),
+	if err := s.checkUserActive(userID); err != nil {
+		return nil, false, fmt.Errorf("para çekme işlemi yapılamadı: %w", err)
 	}
 
-	if err := s.repo.Create(transaction); err != nil {
-		s.logger.Error("İşlem oluşturulamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
-		return nil, fmt.Errorf("para çekme işlemi yapılamadı: %w", err)
+	if err := s.checkLimits(ctx, userID, amount, currency); err != nil {
+		return nil, false, fmt.Errorf("para çekme işlemi yapılamadı: %w", err)
 	}
 
-	submitted := s.workerPool.Submit(transaction)
-	if !submitted {
-		s.logger.Error("İşlem kuyruğa eklenemedi", map[string]interface{}{"transaction_id": transaction.ID})
-		s.repo.UpdateStatus(transaction.ID, domain.TransactionStatusFailed)
-		return nil, fmt.Errorf("işlem şu anda işlenemiyor, lütfen daha sonra tekrar deneyin")
+	balance, err := s.balanceRepo.FindByUserAndCurrency(ctx, userID, currency)
+	if err != nil {
+		s.logger.ErrorWithErr("Bakiye bulunamadı", err, map[string]interface{}{"user_id": userID, "currency": currency})
+		return nil, false, fmt.Errorf("para çekme işlemi yapılamadı: %w", err)
 	}
 
-	s.pendingTransactions.Store(transaction.ID, transaction)
+	if balance == nil {
+		s.logger.Error("Bakiye bulunamadı", map[string]interface{}{"user_id": userID, "currency": currency})
+		return nil, false, fmt.Errorf("kullanıcının %s bakiyesi bulunamadı: %d", currency, userID)
+	}
 
-	return transaction, nil
+	if !balance.CanWithdraw(amount) {
+		s.logger.Error("Yetersiz bakiye", map[string]interface{}{"user_id": userID, "balance": balance.Amount, "overdraft_limit": balance.OverdraftLimit, "amount": amount})
+		return nil, false, fmt.Errorf("yetersiz bakiye: %s, çekilmek istenen: %s", balance.Amount, amount)
+	}
+
+	transaction, created, err := s.createTransaction(ctx, transaction)
+	if err != nil {
+		s.logger.ErrorWithErr("İşlem oluşturulamadı", err, map[string]interface{}{"user_id": userID})
+		return nil, false, fmt.Errorf("para çekme işlemi yapılamadı: %w", err)
+	}
+
+	return transaction, created, nil
 }
 
-func (s *TransactionService) TransferFunds(fromUserID, toUserID int64, amount float64) (*domain.Transaction, error) {
+func (s *TransactionService) TransferFunds(ctx context.Context, fromUserID, toUserID int64, amount domain.Money, fromCurrency, toCurrency, idempotencyKey string) (*domain.Transaction, error) {
 	s.ensureWorkerPoolInitialized()
 
+	transaction, created, err := s.prepareTransfer(ctx, fromUserID, toUserID, amount, fromCurrency, toCurrency, idempotencyKey)
+	if err != nil || !created {
+		return transaction, err
+	}
+
+	if err := s.submitTransaction(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// prepareTransfer validates a transfer, fixes its exchange rate and stores it
+// as pending.
+func (s *TransactionService) prepareTransfer(ctx context.Context, fromUserID, toUserID int64, amount domain.Money, fromCurrency, toCurrency, idempotencyKey string) (*domain.Transaction, bool, error) {
 	if amount <= 0 {
-		return nil, fmt.Errorf("geçersiz miktar: %.2f", amount)
+		return nil, false, fmt.Errorf("geçersiz miktar: %s", amount)
 	}
 
 	if fromUserID == toUserID {
-		return nil, fmt.Errorf("aynı kullanıcıya transfer yapılamaz")
+		return nil, false, fmt.Errorf("aynı kullanıcıya transfer yapılamaz")
 	}
 
-	fromBalance, err := s.balanceRepo.FindByUserID(fromUserID)
+	currency, err := domain.NormalizeCurrency(fromCurrency, s.defaultCurrency)
 	if err != nil {
-		s.logger.Error("Gönderen bakiyesi bulunamadı", map[string]interface{}{"user_id": fromUserID, "error": err.Error()})
-		return nil, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+		return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+	}
+
+	targetCurrency, err := domain.NormalizeCurrency(toCurrency, currency)
+	if err != nil {
+		return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+	}
+
+	convertedAmount, rate := amount, 1.0
+	if targetCurrency != currency {
+		rate, err = s.converter.Rate(currency, targetCurrency)
+		if err != nil {
+			s.logger.ErrorWithErr("Döviz kuru bulunamadı, transfer reddedildi", err, map[string]interface{}{"from_currency": currency, "to_currency": targetCurrency})
+			return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+		}
+
+		convertedAmount = fx.ApplyRate(amount, rate)
+	}
+
+	transaction := &domain.Transaction{
+		FromUserID:     &fromUserID,
+		ToUserID:       &toUserID,
+		Amount:         amount,
+		Currency:       currency,
+		Type:           domain.TransactionTypeTransfer,
+		Status:         domain.TransactionStatusPending,
+		CreatedAt:      time.Now(),
+		IdempotencyKey: idempotencyKey,
+	}
+
+	if targetCurrency != currency {
+		transaction.ToCurrency = targetCurrency
+		transaction.ConvertedAmount = convertedAmount
+		transaction.ExchangeRate = rate
+	}
+
+	if existing, err := s.findByIdempotencyKey(ctx, transaction); err != nil {
+		return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+	} else if existing != nil {
+		return existing, false, nil
+	}
+
+	if err := s.checkUserActive(fromUserID); err != nil {
+		return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+	}
+
+	if err := s.checkRecipient(toUserID); err != nil {
+		return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+	}
+
+	if err := s.checkLimits(ctx, fromUserID, amount, currency); err != nil {
+		return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+	}
+
+	fromBalance, err := s.balanceRepo.FindByUserAndCurrency(ctx, fromUserID, currency)
+	if err != nil {
+		s.logger.ErrorWithErr("Gönderen bakiyesi bulunamadı", err, map[string]interface{}{"user_id": fromUserID, "currency": currency})
+		return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
 	}
 
 	if fromBalance == nil {
-		s.logger.Error("Gönderen bakiyesi bulunamadı", map[string]interface{}{"user_id": fromUserID})
-		return nil, fmt.Errorf("gönderen kullanıcının bakiyesi bulunamadı: %d", fromUserID)
+		s.logger.Error("Gönderen bakiyesi bulunamadı", map[string]interface{}{"user_id": fromUserID, "currency": currency})
+		return nil, false, fmt.Errorf("gönderen kullanıcının %s bakiyesi bulunamadı: %d", currency, fromUserID)
 	}
 
-	if fromBalance.Amount < amount {
-		s.logger.Error("Yetersiz bakiye", map[string]interface{}{"user_id": fromUserID, "balance": fromBalance.Amount, "amount": amount})
-		return nil, fmt.Errorf("yetersiz bakiye: %.2f, transfer edilmek istenen: %.2f", fromBalance.Amount, amount)
+	if !fromBalance.CanWithdraw(amount) {
+		s.logger.Error("Yetersiz bakiye", map[string]interface{}{"user_id": fromUserID, "balance": fromBalance.Amount, "overdraft_limit": fromBalance.OverdraftLimit, "amount": amount})
+		return nil, false, fmt.Errorf("yetersiz bakiye: %s, transfer edilmek istenen: %s", fromBalance.Amount, amount)
 	}
 
-	toBalance, err := s.balanceRepo.FindByUserID(toUserID)
+	toBalance, err := s.balanceRepo.FindByUserAndCurrency(ctx, toUserID, targetCurrency)
 	if err != nil {
-		s.logger.Error("Alıcı bakiyesi bulunamadı", map[string]interface{}{"user_id": toUserID, "error": err.Error()})
-		return nil, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+		s.logger.ErrorWithErr("Alıcı bakiyesi bulunamadı", err, map[string]interface{}{"user_id": toUserID, "currency": targetCurrency})
+		return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
 	}
 
 	if toBalance == nil {
-		if err := s.balanceSvc.InitializeBalance(toUserID); err != nil {
-			s.logger.Error("Alıcı bakiyesi başlatılamadı", map[string]interface{}{"user_id": toUserID, "error": err.Error()})
-			return nil, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+		if err := s.balanceSvc.InitializeBalance(ctx, toUserID, targetCurrency); err != nil {
+			s.logger.ErrorWithErr("Alıcı bakiyesi başlatılamadı", err, map[string]interface{}{"user_id": toUserID})
+			return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+		}
+	}
+
+	transaction, created, err := s.createTransaction(ctx, transaction)
+	if err != nil {
+		s.logger.ErrorWithErr("İşlem oluşturulamadı", err, map[string]interface{}{"from_user_id": fromUserID, "to_user_id": toUserID})
+		return nil, false, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+	}
+
+	if !created {
+		return transaction, false, nil
+	}
+
+	if transaction.ToCurrency != "" {
+		auditLog := &domain.AuditLog{
+			EntityType: domain.EntityTypeTransaction,
+			EntityID:   transaction.ID,
+			Action:     domain.ActionTypeCreate,
+			Details:    fmt.Sprintf("Döviz çevrimi: %s %s -> %s %s, kur: %.6f", amount, currency, convertedAmount, targetCurrency, rate),
+			ActorID:    auth.ActorIDFromContext(ctx),
+			CreatedAt:  time.Now(),
 		}
+
+		if err := s.auditLogRepo.Create(auditLog); err != nil {
+			s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"transaction_id": transaction.ID})
+		}
+	}
+
+	return transaction, true, nil
+}
+
+func (s *TransactionService) ScheduleTransfer(ctx context.Context, fromUserID, toUserID int64, amount domain.Money, at time.Time) (*domain.Transaction, error) {
+	s.ensureWorkerPoolInitialized()
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("geçersiz miktar: %s", amount)
 	}
 
+	if fromUserID == toUserID {
+		return nil, fmt.Errorf("aynı kullanıcıya transfer yapılamaz")
+	}
+
+	if !at.After(time.Now()) {
+		return nil, fmt.Errorf("transfer zamanlanamadı: %w", domain.ErrInvalidScheduleTime)
+	}
+
+	if err := s.ValidateTransfer(ctx, fromUserID, toUserID, amount, s.defaultCurrency); err != nil {
+		return nil, fmt.Errorf("transfer zamanlanamadı: %w", err)
+	}
+
+	scheduledAt := at.Local()
 	transaction := &domain.Transaction{
-		FromUserID: &fromUserID,
-		ToUserID:   &toUserID,
-		Amount:     amount,
-		Type:       domain.TransactionTypeTransfer,
-		Status:     domain.TransactionStatusPending,
-		CreatedAt:  time.Now(),
+		FromUserID:  &fromUserID,
+		ToUserID:    &toUserID,
+		Amount:      amount,
+		Currency:    s.defaultCurrency,
+		Type:        domain.TransactionTypeTransfer,
+		Status:      domain.TransactionStatusScheduled,
+		ScheduledAt: &scheduledAt,
 	}
 
-	if err := s.repo.Create(transaction); err != nil {
-		s.logger.Error("İşlem oluşturulamadı", map[string]interface{}{"from_user_id": fromUserID, "to_user_id": toUserID, "error": err.Error()})
-		return nil, fmt.Errorf("transfer işlemi yapılamadı: %w", err)
+	if err := s.repo.Create(ctx, transaction); err != nil {
+		s.logger.ErrorWithErr("Zamanlanmış transfer oluşturulamadı", err, map[string]interface{}{"from_user_id": fromUserID, "to_user_id": toUserID})
+		return nil, fmt.Errorf("transfer zamanlanamadı: %w", err)
 	}
 
-	submitted := s.workerPool.Submit(transaction)
-	if !submitted {
-		s.logger.Error("İşlem kuyruğa eklenemedi", map[string]interface{}{"transaction_id": transaction.ID})
-		s.repo.UpdateStatus(transaction.ID, domain.TransactionStatusFailed)
-		return nil, fmt.Errorf("işlem şu anda işlenemiyor, lütfen daha sonra tekrar deneyin")
+	if err := s.saveEvent(transaction, domain.EventTypeTransactionCreated); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
 	}
 
-	s.pendingTransactions.Store(transaction.ID, transaction)
+	s.logger.Info("Transfer zamanlandı", map[string]interface{}{"transaction_id": transaction.ID, "scheduled_at": scheduledAt})
 
 	return transaction, nil
 }
 
-func (s *TransactionService) ReplayTransactionEvents(transactionID int64) error {
-	events, err := s.eventStore.GetAggregateEvents("transaction", fmt.Sprintf("%d", transactionID))
+func (s *TransactionService) CancelScheduledTransfer(ctx context.Context, id int64) error {
+	tx, err := s.GetTransactionByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("zamanlanmış transfer iptal edilemedi: %w", err)
+	}
+
+	cancelled, err := s.repo.TransitionStatus(ctx, id, domain.TransactionStatusScheduled, domain.TransactionStatusCancelled)
 	if err != nil {
+		return fmt.Errorf("zamanlanmış transfer iptal edilemedi: %w", err)
+	}
+
+	if !cancelled {
+		return fmt.Errorf("zamanlanmış transfer iptal edilemedi: %w", domain.ErrTransactionNotScheduled)
+	}
+
+	tx.Status = domain.TransactionStatusCancelled
+	s.statusBroker.publish(tx)
+	if err := s.saveEvent(tx, domain.EventTypeTransactionCancelled); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, nil)
+	}
+
+	auditLog := &domain.AuditLog{
+		EntityType: domain.EntityTypeTransaction,
+		EntityID:   id,
+		Action:     domain.ActionTypeUpdate,
+		Details:    fmt.Sprintf("Zamanlanmış transfer iptal edildi: %s %s", tx.Amount, tx.Currency),
+		ActorID:    auth.ActorIDFromContext(ctx),
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.auditLogRepo.Create(auditLog); err != nil {
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"transaction_id": id})
+	}
+
+	return nil
+}
+
+// ValidateTransfer runs the checks every transfer passes before it is stored:
+// the sender is active, the recipient exists and the amount is within the
+// limits.
+func (s *TransactionService) ValidateTransfer(ctx context.Context, fromUserID, toUserID int64, amount domain.Money, currency string) error {
+	if err := s.checkUserActive(fromUserID); err != nil {
 		return err
 	}
 
-	for _, event := range events {
-		var transaction domain.Transaction
-		if err := json.Unmarshal(event.EventData, &transaction); err != nil {
+	if err := s.checkRecipient(toUserID); err != nil {
+		return err
+	}
+
+	return s.checkLimits(ctx, fromUserID, amount, currency)
+}
+
+func (s *TransactionService) checkUserActive(userID int64) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return domain.ErrUserNotFound
+	}
+
+	if !user.IsActive {
+		return domain.ErrUserInactive
+	}
+
+	return nil
+}
+
+// checkRecipient rejects transfers to unknown or soft-deleted users; unlike
+// senders, recipients do not need to be activated.
+func (s *TransactionService) checkRecipient(userID int64) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// checkLimits enforces the configured limits, which are expressed in the
+// default currency. The amount and the user's daily totals in other
+// currencies are converted before they are compared.
+func (s *TransactionService) checkLimits(ctx context.Context, userID int64, amount domain.Money, currency string) error {
+	limits := s.config.Limits
+	minAmount := domain.NewMoneyFromFloat(limits.MinAmount)
+	maxAmount := domain.NewMoneyFromFloat(limits.MaxAmount)
+	dailyMax := domain.NewMoneyFromFloat(limits.DailyMax)
+
+	if minAmount <= 0 && maxAmount <= 0 && dailyMax <= 0 {
+		return nil
+	}
+
+	baseAmount, err := s.toDefaultCurrency(amount, currency)
+	if err != nil {
+		return err
+	}
+
+	if minAmount > 0 && baseAmount < minAmount {
+		return fmt.Errorf("%w: tutar en az %s %s olmalı, istenen: %s %s", domain.ErrTransactionLimit, minAmount, s.defaultCurrency, baseAmount, s.defaultCurrency)
+	}
+
+	if maxAmount > 0 && baseAmount > maxAmount {
+		return fmt.Errorf("%w: tutar en fazla %s %s olabilir, istenen: %s %s", domain.ErrTransactionLimit, maxAmount, s.defaultCurrency, baseAmount, s.defaultCurrency)
+	}
+
+	if dailyMax <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	totals, err := s.repo.SumUserTransactionsSince(ctx, userID, dayStart)
+	if err != nil {
+		return err
+	}
+
+	var total domain.Money
+	for totalCurrency, sum := range totals {
+		converted, err := s.toDefaultCurrency(sum, totalCurrency)
+		if err != nil {
 			return err
 		}
+		total += converted
+	}
 
-		switch event.EventType {
-		case domain.EventTypeTransactionCreated:
-			// İşlem zaten oluşturulmuş, tekrar oluşturmaya gerek yok
-		case domain.EventTypeTransactionCompleted:
-			if err := s.repo.UpdateStatus(transaction.ID, domain.TransactionStatusCompleted); err != nil {
-				return err
-			}
-		case domain.EventTypeTransactionFailed:
-			if err := s.repo.UpdateStatus(transaction.ID, domain.TransactionStatusFailed); err != nil {
-				return err
+	if total+baseAmount > dailyMax {
+		s.logger.Warn("Günlük işlem limiti aşıldı", map[string]interface{}{"user_id": userID, "daily_total": total, "amount": baseAmount, "daily_max": dailyMax, "currency": s.defaultCurrency})
+		return fmt.Errorf("%w: günlük limit %s %s, bugünkü toplam: %s, istenen: %s", domain.ErrTransactionLimit, dailyMax, s.defaultCurrency, total, baseAmount)
+	}
+
+	return nil
+}
+
+func (s *TransactionService) toDefaultCurrency(amount domain.Money, currency string) (domain.Money, error) {
+	if currency == s.defaultCurrency {
+		return amount, nil
+	}
+
+	rate, err := s.converter.Rate(currency, s.defaultCurrency)
+	if err != nil {
+		s.logger.ErrorWithErr("Limit kontrolü için döviz kuru bulunamadı", err, map[string]interface{}{"from_currency": currency, "to_currency": s.defaultCurrency})
+		return 0, err
+	}
+
+	return fx.ApplyRate(amount, rate), nil
+}
+
+// findByIdempotencyKey returns the transaction already created with request's
+// idempotency key by the same user. A key reused with different parameters is
+// rejected with ErrIdempotencyKeyMismatch instead of returning the original.
+func (s *TransactionService) findByIdempotencyKey(ctx context.Context, request *domain.Transaction) (*domain.Transaction, error) {
+	if request.IdempotencyKey == "" {
+		return nil, nil
+	}
+
+	transaction, err := s.repo.FindByIdempotencyKey(ctx, request.IdempotencyOwnerID(), request.IdempotencyKey)
+	if err != nil {
+		s.logger.ErrorWithErr("Idempotency anahtarı kontrol edilemedi", err, nil)
+		return nil, err
+	}
+
+	if transaction == nil {
+		return nil, nil
+	}
+
+	if !transaction.SameRequest(request) {
+		s.logger.Warn("Idempotency anahtarı farklı parametrelerle tekrar kullanıldı", map[string]interface{}{"transaction_id": transaction.ID})
+		return nil, domain.ErrIdempotencyKeyMismatch
+	}
+
+	s.logger.Info("Aynı idempotency anahtarı ile mevcut işlem döndürüldü", map[string]interface{}{"transaction_id": transaction.ID})
+	return transaction, nil
+}
+
+// createTransaction persists the transaction. If a concurrent request with the
+// same idempotency key won the insert race, the stored transaction is returned
+// with created=false so the caller does not enqueue it a second time.
+func (s *TransactionService) createTransaction(ctx context.Context, transaction *domain.Transaction) (*domain.Transaction, bool, error) {
+	err := s.repo.Create(ctx, transaction)
+	if err == nil {
+		return transaction, true, nil
+	}
+
+	if !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+		return nil, false, err
+	}
+
+	existing, findErr := s.findByIdempotencyKey(ctx, transaction)
+	if findErr != nil {
+		return nil, false, findErr
+	}
+
+	if existing == nil {
+		return nil, false, err
+	}
+
+	return existing, false, nil
+}
+
+// submitTransaction enqueues the transaction unless it is already pending in
+// the worker pool, so the same transaction is never processed twice.
+func (s *TransactionService) submitTransaction(ctx context.Context, transaction *domain.Transaction) error {
+	if !s.enqueueTransaction(ctx, transaction) {
+		s.logger.ErrorContext(ctx, "İşlem kuyruğa eklenemedi", map[string]interface{}{"transaction_id": transaction.ID})
+		s.updateStatus(transaction, domain.TransactionStatusFailed)
+		return fmt.Errorf("işlem şu anda işlenemiyor, lütfen daha sonra tekrar deneyin")
+	}
+
+	return nil
+}
+
+func (s *TransactionService) enqueueTransaction(ctx context.Context, transaction *domain.Transaction) bool {
+	if _, loaded := s.pendingTransactions.LoadOrStore(transaction.ID, transaction); loaded {
+		return true
+	}
+	metrics.IncPendingTransactions()
+
+	if !s.workerPool.Submit(ctx, transaction) {
+		s.untrackPending(transaction.ID)
+		return false
+	}
+
+	return true
+}
+
+// untrackPending removes id from pendingTransactions and keeps the pending
+// gauge equal to the size of the map.
+func (s *TransactionService) untrackPending(id int64) {
+	if _, loaded := s.pendingTransactions.LoadAndDelete(id); loaded {
+		metrics.DecPendingTransactions()
+	}
+}
+
+// recoverPending re-enqueues transactions left pending by a previous run. It
+// stops once the queue is full; whatever is left is picked up by the reaper.
+// Transactions interrupted while processing are only reported.
+func (s *TransactionService) recoverPending() {
+	if interrupted, err := s.repo.CountByStatus(context.Background(), domain.TransactionStatusProcessing, time.Time{}); err != nil {
+		s.logger.ErrorWithErr("İşlenmekte olan işlemler sorgulanamadı", err, nil)
+	} else if interrupted > 0 {
+		s.logger.Warn("İşlenirken yarıda kalan işlemler var, bakiye etkisi elle kontrol edilmeli", map[string]interface{}{"count": interrupted, "status": domain.TransactionStatusProcessing})
+	}
+
+	recovered := 0
+
+	for offset := 0; ; offset += reaperBatchSize {
+		transactions, err := s.repo.FindByStatus(context.Background(), domain.TransactionStatusPending, time.Time{}, reaperBatchSize, offset)
+		if err != nil {
+			s.logger.ErrorWithErr("Bekleyen işlemler yüklenemedi", err, nil)
+			return
+		}
+
+		for _, tx := range transactions {
+			if !s.enqueueTransaction(context.Background(), tx) {
+				s.logger.Warn("İşlem kuyruğu dolu, kalan bekleyen işlemler daha sonra işlenecek", map[string]interface{}{"recovered": recovered})
+				return
 			}
+			recovered++
+		}
+
+		if len(transactions) < reaperBatchSize {
+			break
 		}
 	}
 
-	return nil
+	if recovered > 0 {
+		s.logger.Info("Bekleyen işlemler kuyruğa yeniden yüklendi", map[string]interface{}{"count": recovered})
+	}
 }
 
-func (s *TransactionService) RebuildTransactionState(transactionID int64) error {
-	events, err := s.eventStore.GetAggregateEvents("transaction", fmt.Sprintf("%d", transactionID))
+// ReplayTransactionEvents reapplies the transaction's events starting at
+// fromVersion; 0 replays the whole stream.
+func (s *TransactionService) ReplayTransactionEvents(transactionID int64, fromVersion int) error {
+	events, err := s.eventStore.GetEventsFromVersion("transaction", fmt.Sprintf("%d", transactionID), fromVersion)
 	if err != nil {
 		return err
 	}
 
-	var transaction domain.Transaction
 	for _, event := range events {
+		var transaction domain.Transaction
 		if err := json.Unmarshal(event.EventData, &transaction); err != nil {
 			return err
 		}
@@ -639,11 +1547,19 @@ func (s *TransactionService) RebuildTransactionState(transactionID int64) error
 		case domain.EventTypeTransactionCreated:
 			// İşlem zaten oluşturulmuş, tekrar oluşturmaya gerek yok
 		case domain.EventTypeTransactionCompleted:
-			if err := s.repo.UpdateStatus(transaction.ID, domain.TransactionStatusCompleted); err != nil {
+			if err := s.repo.UpdateStatus(context.Background(), transaction.ID, domain.TransactionStatusCompleted); err != nil {
 				return err
 			}
 		case domain.EventTypeTransactionFailed:
-			if err := s.repo.UpdateStatus(transaction.ID, domain.TransactionStatusFailed); err != nil {
+			if err := s.repo.UpdateStatus(context.Background(), transaction.ID, domain.TransactionStatusFailed); err != nil {
+				return err
+			}
+		case domain.EventTypeTransactionCancelled:
+			if err := s.repo.UpdateStatus(context.Background(), transaction.ID, domain.TransactionStatusCancelled); err != nil {
+				return err
+			}
+		case domain.EventTypeTransactionRolledBack:
+			if err := s.repo.UpdateStatus(context.Background(), transaction.ID, domain.TransactionStatusRolledBack); err != nil {
 				return err
 			}
 		}
@@ -651,3 +1567,16 @@ func (s *TransactionService) RebuildTransactionState(transactionID int64) error
 
 	return nil
 }
+
+func (s *TransactionService) RebuildTransactionState(transactionID int64) error {
+	state, _, err := s.loadTransactionState(transactionID)
+	if err != nil {
+		return err
+	}
+
+	if state.Status == "" {
+		return nil
+	}
+
+	return s.repo.UpdateStatus(context.Background(), transactionID, state.Status)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"payflow/internal/domain"
+	"payflow/pkg/auth"
+	"payflow/pkg/metrics"
+)
+
+func TestProcessBatchTransactionsRespectsPoolSize(t *testing.T) {
+	var running, maxRunning, calls atomic.Int64
+	balanceSvc := &fakeBalanceService{
+		deposit: func(int64, domain.Money, int64) error {
+			calls.Add(1)
+			current := running.Add(1)
+			defer running.Add(-1)
+
+			for {
+				seen := maxRunning.Load()
+				if current <= seen || maxRunning.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(100 * time.Microsecond)
+			return nil
+		},
+	}
+
+	svc := newTestTransactionService(t, newFakeTransactionRepo(), balanceSvc)
+
+	const batchSize = 1000
+	userID := int64(1)
+	batch := make([]*domain.Transaction, batchSize)
+	for i := range batch {
+		batch[i] = &domain.Transaction{
+			ToUserID: &userID,
+			Amount:   domain.NewMoneyFromFloat(1),
+			Currency: "TRY",
+			Type:     domain.TransactionTypeDeposit,
+			Status:   domain.TransactionStatusPending,
+		}
+	}
+
+	processed, failed, err := svc.ProcessBatchTransactions(context.Background(), batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if processed != batchSize || failed != 0 {
+		t.Fatalf("beklenen %d işlenmiş / 0 başarısız, alınan: %d / %d", batchSize, processed, failed)
+	}
+	if calls.Load() != batchSize {
+		t.Fatalf("her işlem bir kez işlenmeli, alınan: %d", calls.Load())
+	}
+	if workers := int64(svc.workerPool.NumWorkers()); maxRunning.Load() > workers {
+		t.Fatalf("eşzamanlılık havuz boyutunu aştı: %d > %d", maxRunning.Load(), workers)
+	}
+}
+
+func TestProcessQueuedTransactionClaimsOnce(t *testing.T) {
+	var deposits atomic.Int64
+	balanceSvc := &fakeBalanceService{
+		deposit: func(int64, domain.Money, int64) error {
+			deposits.Add(1)
+			return nil
+		},
+	}
+
+	repo := newFakeTransactionRepo()
+	svc := newTestTransactionService(t, repo, balanceSvc)
+
+	userID := int64(1)
+	tx := &domain.Transaction{
+		ToUserID: &userID,
+		Amount:   domain.NewMoneyFromFloat(10),
+		Currency: "TRY",
+		Type:     domain.TransactionTypeDeposit,
+		Status:   domain.TransactionStatusPending,
+	}
+	if err := repo.Create(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			copied := *tx
+			svc.processQueuedTransaction(context.Background(), &copied)
+		}()
+	}
+	wg.Wait()
+
+	if deposits.Load() != 1 {
+		t.Fatalf("işlem bir kez işlenmeli, alınan: %d", deposits.Load())
+	}
+	if status := repo.status(tx.ID); status != domain.TransactionStatusCompleted {
+		t.Fatalf("beklenen durum completed, alınan: %s", status)
+	}
+}
+
+func TestFailedDepositRecordsFailedMetric(t *testing.T) {
+	balanceSvc := &fakeBalanceService{
+		deposit: func(int64, domain.Money, int64) error {
+			return errors.New("bakiye güncellenemedi")
+		},
+	}
+
+	repo := newFakeTransactionRepo()
+	svc := newTestTransactionService(t, repo, balanceSvc)
+
+	userID := int64(1)
+	tx := &domain.Transaction{
+		ToUserID: &userID,
+		Amount:   domain.NewMoneyFromFloat(10),
+		Currency: "TRY",
+		Type:     domain.TransactionTypeDeposit,
+		Status:   domain.TransactionStatusPending,
+	}
+	if err := repo.Create(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := metrics.TransactionProcessed.WithLabelValues("deposit", "failed")
+	completed := metrics.TransactionProcessed.WithLabelValues("deposit", "completed")
+	failedBefore, completedBefore := testutil.ToFloat64(failed), testutil.ToFloat64(completed)
+
+	if err := svc.processQueuedTransaction(context.Background(), tx); err == nil {
+		t.Fatal("başarısız para yatırma hata döndürmeli")
+	}
+
+	if got := testutil.ToFloat64(failed) - failedBefore; got != 1 {
+		t.Fatalf("failed serisi 1 artmalı, artış: %v", got)
+	}
+	if got := testutil.ToFloat64(completed) - completedBefore; got != 0 {
+		t.Fatalf("completed serisi değişmemeli, artış: %v", got)
+	}
+	if status := repo.status(tx.ID); status != domain.TransactionStatusFailed {
+		t.Fatalf("beklenen durum failed, alınan: %s", status)
+	}
+}
+
+func TestProcessBatchTransactionsValidatesEachItem(t *testing.T) {
+	var deposits atomic.Int64
+	balanceSvc := &fakeBalanceService{
+		deposit: func(int64, domain.Money, int64) error {
+			deposits.Add(1)
+			return nil
+		},
+	}
+
+	repo := newFakeTransactionRepo()
+	svc := newTestTransactionService(t, repo, balanceSvc)
+
+	activeUser, unknownUser := int64(1), int64(2)
+	batch := []*domain.Transaction{
+		{ToUserID: &activeUser, Amount: domain.NewMoneyFromFloat(5), Currency: "TRY", Type: domain.TransactionTypeDeposit},
+		{ToUserID: &unknownUser, Amount: domain.NewMoneyFromFloat(5), Currency: "TRY", Type: domain.TransactionTypeDeposit},
+		{ToUserID: &activeUser, Amount: domain.NewMoneyFromFloat(-5), Currency: "TRY", Type: domain.TransactionTypeDeposit},
+	}
+
+	processed, failed, err := svc.ProcessBatchTransactions(context.Background(), batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if processed != 1 || failed != 2 {
+		t.Fatalf("beklenen 1 işlenmiş / 2 başarısız, alınan: %d / %d", processed, failed)
+	}
+	if deposits.Load() != 1 {
+		t.Fatalf("yalnızca geçerli işlem bakiyeye yansımalı, alınan: %d", deposits.Load())
+	}
+	if len(repo.transactions) != 1 {
+		t.Fatalf("yalnızca geçerli işlem kaydedilmeli, kaydedilen: %d", len(repo.transactions))
+	}
+	for id := range repo.transactions {
+		if status := repo.status(id); status != domain.TransactionStatusCompleted {
+			t.Fatalf("beklenen durum completed, alınan: %s", status)
+		}
+	}
+}
+
+func TestDepositAuditRecordsActorFromContext(t *testing.T) {
+	svc := newTestTransactionService(t, newFakeTransactionRepo(), &fakeBalanceService{})
+
+	actor := &domain.User{ID: 7, Username: "operator"}
+	ctx := auth.WithUser(context.Background(), actor)
+
+	tx, err := svc.DepositFunds(ctx, 1, domain.NewMoneyFromFloat(10), "TRY", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.Shutdown()
+
+	audits := svc.auditLogRepo.(*fakeAuditLogRepo)
+	audits.mu.Lock()
+	defer audits.mu.Unlock()
+
+	for _, log := range audits.logs {
+		if log.EntityID == tx.ID {
+			if log.ActorID != actor.ID {
+				t.Fatalf("denetim kaydı işlemi başlatanı içermeli, beklenen %d, alınan %d", actor.ID, log.ActorID)
+			}
+			return
+		}
+	}
+	t.Fatal("para yatırma için denetim kaydı oluşturulmadı")
+}
+
+func TestDueScheduledTransferFailsWhenRecipientIsGone(t *testing.T) {
+	var withdrawals atomic.Int64
+	balanceSvc := &fakeBalanceService{
+		withdraw: func(int64, domain.Money, int64) error {
+			withdrawals.Add(1)
+			return nil
+		},
+	}
+
+	repo := newFakeTransactionRepo()
+	svc := newTestTransactionService(t, repo, balanceSvc)
+
+	fromUserID, toUserID := int64(1), int64(2)
+	scheduledAt := time.Now().Add(-time.Minute)
+	tx := &domain.Transaction{
+		FromUserID:  &fromUserID,
+		ToUserID:    &toUserID,
+		Amount:      domain.NewMoneyFromFloat(10),
+		Currency:    "TRY",
+		Type:        domain.TransactionTypeTransfer,
+		Status:      domain.TransactionStatusScheduled,
+		ScheduledAt: &scheduledAt,
+	}
+	if err := repo.Create(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.submitDueScheduled()
+	svc.Shutdown()
+
+	if status := repo.status(tx.ID); status != domain.TransactionStatusFailed {
+		t.Fatalf("beklenen durum failed, alınan: %s", status)
+	}
+	if withdrawals.Load() != 0 {
+		t.Fatalf("alıcısı olmayan transfer için para çekilmemeli, alınan: %d", withdrawals.Load())
+	}
+}
+
+func TestConcurrentDepositsWithSameIdempotencyKey(t *testing.T) {
+	var deposits atomic.Int64
+	balanceSvc := &fakeBalanceService{
+		deposit: func(int64, domain.Money, int64) error {
+			deposits.Add(1)
+			return nil
+		},
+	}
+
+	const callers = 20
+
+	// Every caller misses the idempotency lookup before any of them inserts,
+	// so all but one hit the unique key and fall back to the stored row.
+	repo := newFakeTransactionRepo()
+	var arrived sync.WaitGroup
+	arrived.Add(callers)
+	repo.beforeCreate = func() {
+		arrived.Done()
+		arrived.Wait()
+	}
+	svc := newTestTransactionService(t, repo, balanceSvc)
+
+	ids := make([]int64, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tx, err := svc.DepositFunds(context.Background(), 1, domain.NewMoneyFromFloat(10), "TRY", "same-key")
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			ids[i] = tx.ID
+		}(i)
+	}
+	wg.Wait()
+	svc.Shutdown()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("çağrı %d başarısız: %v", i, err)
+		}
+		if ids[i] != ids[0] {
+			t.Fatalf("her çağrı aynı işlemi döndürmeli, alınan: %d ve %d", ids[0], ids[i])
+		}
+	}
+
+	repo.mu.Lock()
+	rows := len(repo.transactions)
+	repo.mu.Unlock()
+	if rows != 1 {
+		t.Fatalf("tek işlem kaydı oluşturulmalı, alınan: %d", rows)
+	}
+	if deposits.Load() != 1 {
+		t.Fatalf("işlem bir kez kuyruğa alınıp işlenmeli, alınan: %d", deposits.Load())
+	}
+}
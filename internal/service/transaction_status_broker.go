@@ -0,0 +1,67 @@
+package service
+
+import (
+	"sync"
+
+	"payflow/internal/domain"
+)
+
+const statusSubscriberBuffer = 8
+
+// statusBroker fans transaction status changes out to in-process subscribers.
+// Publishing never blocks: a subscriber whose buffer is full misses the update.
+type statusBroker struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan *domain.Transaction]struct{}
+}
+
+func newStatusBroker() *statusBroker {
+	return &statusBroker{
+		subscribers: make(map[int64]map[chan *domain.Transaction]struct{}),
+	}
+}
+
+func (b *statusBroker) subscribe(transactionID int64) (<-chan *domain.Transaction, func()) {
+	ch := make(chan *domain.Transaction, statusSubscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[transactionID] == nil {
+		b.subscribers[transactionID] = make(map[chan *domain.Transaction]struct{})
+	}
+	b.subscribers[transactionID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subscribers[transactionID], ch)
+			if len(b.subscribers[transactionID]) == 0 {
+				delete(b.subscribers, transactionID)
+			}
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+func (b *statusBroker) publish(tx *domain.Transaction) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscribers := b.subscribers[tx.ID]
+	if len(subscribers) == 0 {
+		return
+	}
+
+	snapshot := *tx
+	for ch := range subscribers {
+		select {
+		case ch <- &snapshot:
+		default:
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"time"
+
+	"payflow/internal/domain"
+	"payflow/pkg/logger"
+)
+
+// LogUserNotifier stands in for an email sender: it only logs that a token
+// was issued. The token itself is logged at debug level for local setups.
+type LogUserNotifier struct {
+	logger logger.Logger
+}
+
+func NewLogUserNotifier(logger logger.Logger) domain.UserNotifier {
+	return &LogUserNotifier{logger: logger}
+}
+
+func (n *LogUserNotifier) SendActivationToken(user *domain.User, token string, expiresAt time.Time) error {
+	n.logger.Info("Aktivasyon kodu oluşturuldu", map[string]interface{}{
+		"user_id":    user.ID,
+		"email":      user.Email,
+		"expires_at": expiresAt,
+	})
+	n.logger.Debug("Aktivasyon kodu", map[string]interface{}{"user_id": user.ID, "token": token})
+
+	return nil
+}
+
+func (n *LogUserNotifier) SendPasswordResetToken(user *domain.User, token string, expiresAt time.Time) error {
+	n.logger.Info("Şifre sıfırlama kodu oluşturuldu", map[string]interface{}{
+		"user_id":    user.ID,
+		"email":      user.Email,
+		"expires_at": expiresAt,
+	})
+	n.logger.Debug("Şifre sıfırlama kodu", map[string]interface{}{"user_id": user.ID, "token": token})
+
+	return nil
+}
@@ -1,45 +1,81 @@
 package service
 
 import (
-	"crypto/sha256"
+	"context"
+	"encoding/json"
 	"fmt"
-	"math/rand"
+	"strings"
 	"time"
 
+	"payflow/internal/config"
 	"payflow/internal/domain"
 	"payflow/pkg/logger"
+	"payflow/pkg/password"
 )
 
 type UserService struct {
 	repo         domain.UserRepository
 	balanceSvc   domain.BalanceService
 	auditLogRepo domain.AuditLogRepository
+	eventStore   domain.EventStoreService
 	logger       logger.Logger
+	config       config.SecurityConfig
+	permissions  domain.RolePermissions
+	notifier     domain.UserNotifier
 }
 
 func NewUserService(
 	repo domain.UserRepository,
 	balanceSvc domain.BalanceService,
 	auditLogRepo domain.AuditLogRepository,
+	eventStore domain.EventStoreService,
 	logger logger.Logger,
+	cfg config.SecurityConfig,
+	permissions domain.RolePermissions,
+	notifier domain.UserNotifier,
 ) domain.UserService {
 	return &UserService{
 		repo:         repo,
 		balanceSvc:   balanceSvc,
 		auditLogRepo: auditLogRepo,
+		eventStore:   eventStore,
 		logger:       logger,
+		config:       cfg,
+		permissions:  permissions,
+		notifier:     notifier,
 	}
 }
 
+func (s *UserService) saveEvent(user *domain.User, eventType domain.EventType, changes map[string]domain.UserChange) error {
+	eventData, err := json.Marshal(&domain.UserEvent{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		Changes:  changes,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.eventStore.SaveEvent(&domain.Event{
+		AggregateID:   fmt.Sprintf("%d", user.ID),
+		AggregateType: "user",
+		EventType:     eventType,
+		EventData:     eventData,
+		CreatedAt:     time.Now(),
+	})
+}
+
 func (s *UserService) GetUserByID(id int64) (*domain.User, error) {
 	user, err := s.repo.FindByID(id)
 	if err != nil {
-		s.logger.Error("Kullanıcı ID'ye göre bulunamadı", map[string]interface{}{"id": id, "error": err.Error()})
+		s.logger.ErrorWithErr("Kullanıcı ID'ye göre bulunamadı", err, map[string]interface{}{"id": id})
 		return nil, fmt.Errorf("kullanıcı bulunamadı: %w", err)
 	}
 
 	if user == nil {
-		return nil, fmt.Errorf("kullanıcı ID'ye göre bulunamadı: %d", id)
+		return nil, fmt.Errorf("kullanıcı ID'ye göre bulunamadı: %d: %w", id, domain.ErrUserNotFound)
 	}
 
 	return user, nil
@@ -48,12 +84,12 @@ func (s *UserService) GetUserByID(id int64) (*domain.User, error) {
 func (s *UserService) GetUserByUsername(username string) (*domain.User, error) {
 	user, err := s.repo.FindByUsername(username)
 	if err != nil {
-		s.logger.Error("Kullanıcı adına göre bulunamadı", map[string]interface{}{"username": username, "error": err.Error()})
+		s.logger.ErrorWithErr("Kullanıcı adına göre bulunamadı", err, map[string]interface{}{"username": username})
 		return nil, fmt.Errorf("kullanıcı bulunamadı: %w", err)
 	}
 
 	if user == nil {
-		return nil, fmt.Errorf("kullanıcı adına göre bulunamadı: %s", username)
+		return nil, fmt.Errorf("kullanıcı adına göre bulunamadı: %s: %w", username, domain.ErrUserNotFound)
 	}
 
 	return user, nil
@@ -62,21 +98,49 @@ func (s *UserService) GetUserByUsername(username string) (*domain.User, error) {
 func (s *UserService) GetUserByEmail(email string) (*domain.User, error) {
 	user, err := s.repo.FindByEmail(email)
 	if err != nil {
-		s.logger.Error("Kullanıcı e-posta adresine göre bulunamadı", map[string]interface{}{"email": email, "error": err.Error()})
+		s.logger.ErrorWithErr("Kullanıcı e-posta adresine göre bulunamadı", err, map[string]interface{}{"email": email})
 		return nil, fmt.Errorf("kullanıcı bulunamadı: %w", err)
 	}
 
 	if user == nil {
-		return nil, fmt.Errorf("kullanıcı e-posta adresine göre bulunamadı: %s", email)
+		return nil, fmt.Errorf("kullanıcı e-posta adresine göre bulunamadı: %s: %w", email, domain.ErrUserNotFound)
 	}
 
 	return user, nil
 }
 
-func (s *UserService) CreateUser(user *domain.User) error {
+func (s *UserService) ListUsers(page, pageSize int, search string) ([]*domain.User, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	search = strings.TrimSpace(search)
+
+	users, err := s.repo.FindAll(pageSize, (page-1)*pageSize, search)
+	if err != nil {
+		s.logger.Error("Kullanıcılar listelenemedi", map[string]interface{}{
+			"page":      page,
+			"page_size": pageSize,
+			"error":     err.Error(),
+		})
+		return nil, 0, fmt.Errorf("kullanıcılar listelenemedi: %w", err)
+	}
+
+	totalCount, err := s.repo.Count(search)
+	if err != nil {
+		return nil, 0, fmt.Errorf("kullanıcılar listelenemedi: %w", err)
+	}
+
+	return users, totalCount, nil
+}
+
+func (s *UserService) CreateUser(user *domain.User, actorID int64) error {
 	existingUser, err := s.repo.FindByEmail(user.Email)
 	if err != nil {
-		s.logger.Error("E-posta adresi kontrolü sırasında hata oluştu", map[string]interface{}{"email": user.Email, "error": err.Error()})
+		s.logger.ErrorWithErr("E-posta adresi kontrolü sırasında hata oluştu", err, map[string]interface{}{"email": user.Email})
 		return fmt.Errorf("kullanıcı oluşturulamadı: %w", err)
 	}
 
@@ -86,7 +150,7 @@ func (s *UserService) CreateUser(user *domain.User) error {
 
 	existingUser, err = s.repo.FindByUsername(user.Username)
 	if err != nil {
-		s.logger.Error("Kullanıcı adı kontrolü sırasında hata oluştu", map[string]interface{}{"username": user.Username, "error": err.Error()})
+		s.logger.ErrorWithErr("Kullanıcı adı kontrolü sırasında hata oluştu", err, map[string]interface{}{"username": user.Username})
 		return fmt.Errorf("kullanıcı oluşturulamadı: %w", err)
 	}
 
@@ -94,13 +158,19 @@ func (s *UserService) CreateUser(user *domain.User) error {
 		return fmt.Errorf("bu kullanıcı adı zaten kullanılıyor: %s", user.Username)
 	}
 
+	user.IsActive = !s.config.RequireActivation
+
 	if err := s.repo.Create(user); err != nil {
-		s.logger.Error("Kullanıcı oluşturma sırasında hata oluştu", map[string]interface{}{"error": err.Error()})
+		s.logger.ErrorWithErr("Kullanıcı oluşturma sırasında hata oluştu", err, nil)
 		return fmt.Errorf("kullanıcı oluşturulamadı: %w", err)
 	}
 
-	if err := s.balanceSvc.InitializeBalance(user.ID); err != nil {
-		s.logger.Error("Bakiye başlatılamadı", map[string]interface{}{"user_id": user.ID, "error": err.Error()})
+	if err := s.balanceSvc.InitializeBalance(context.Background(), user.ID, ""); err != nil {
+		s.logger.ErrorWithErr("Bakiye başlatılamadı", err, map[string]interface{}{"user_id": user.ID})
+	}
+
+	if err := s.saveEvent(user, domain.EventTypeUserCreated, nil); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, map[string]interface{}{"user_id": user.ID})
 	}
 
 	auditLog := &domain.AuditLog{
@@ -108,20 +178,28 @@ func (s *UserService) CreateUser(user *domain.User) error {
 		EntityID:   user.ID,
 		Action:     domain.ActionTypeCreate,
 		Details:    fmt.Sprintf("Kullanıcı oluşturuldu: %s", user.Username),
+		ActorID:    actorID,
 		CreatedAt:  time.Now(),
 	}
 
 	if err := s.auditLogRepo.Create(auditLog); err != nil {
-		s.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"user_id": user.ID, "error": err.Error()})
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": user.ID})
+	}
+
+	// A failed issuance does not undo the signup; a new token can be requested.
+	if !user.IsActive {
+		if _, err := s.issueActivationToken(user); err != nil {
+			s.logger.ErrorWithErr("Aktivasyon kodu gönderilemedi", err, map[string]interface{}{"user_id": user.ID})
+		}
 	}
 
 	return nil
 }
 
-func (s *UserService) UpdateUser(user *domain.User) error {
+func (s *UserService) UpdateUser(user *domain.User, actorID int64) error {
 	existingUser, err := s.repo.FindByID(user.ID)
 	if err != nil {
-		s.logger.Error("Kullanıcı güncellemesi sırasında hata oluştu", map[string]interface{}{"id": user.ID, "error": err.Error()})
+		s.logger.ErrorWithErr("Kullanıcı güncellemesi sırasında hata oluştu", err, map[string]interface{}{"id": user.ID})
 		return fmt.Errorf("kullanıcı güncellenemedi: %w", err)
 	}
 
@@ -132,7 +210,7 @@ func (s *UserService) UpdateUser(user *domain.User) error {
 	if existingUser.Email != user.Email {
 		emailUser, err := s.repo.FindByEmail(user.Email)
 		if err != nil {
-			s.logger.Error("E-posta adresi kontrolü sırasında hata oluştu", map[string]interface{}{"email": user.Email, "error": err.Error()})
+			s.logger.ErrorWithErr("E-posta adresi kontrolü sırasında hata oluştu", err, map[string]interface{}{"email": user.Email})
 			return fmt.Errorf("kullanıcı güncellenemedi: %w", err)
 		}
 
@@ -144,7 +222,7 @@ func (s *UserService) UpdateUser(user *domain.User) error {
 	if existingUser.Username != user.Username {
 		usernameUser, err := s.repo.FindByUsername(user.Username)
 		if err != nil {
-			s.logger.Error("Kullanıcı adı kontrolü sırasında hata oluştu", map[string]interface{}{"username": user.Username, "error": err.Error()})
+			s.logger.ErrorWithErr("Kullanıcı adı kontrolü sırasında hata oluştu", err, map[string]interface{}{"username": user.Username})
 			return fmt.Errorf("kullanıcı güncellenemedi: %w", err)
 		}
 
@@ -153,30 +231,44 @@ func (s *UserService) UpdateUser(user *domain.User) error {
 		}
 	}
 
+	// Credentials, activation and password reset state are not editable here.
+	// The hashes are never decoded from the request body, so without this the
+	// update would blank them.
+	user.PasswordHash = existingUser.PasswordHash
+	user.ApiKeyHash = existingUser.ApiKeyHash
+	user.IsActive = existingUser.IsActive
+	user.EmailVerifiedAt = existingUser.EmailVerifiedAt
+	user.PasswordChangedAt = existingUser.PasswordChangedAt
+
 	if err := s.repo.Update(user); err != nil {
-		s.logger.Error("Kullanıcı güncelleme sırasında hata oluştu", map[string]interface{}{"id": user.ID, "error": err.Error()})
+		s.logger.ErrorWithErr("Kullanıcı güncelleme sırasında hata oluştu", err, map[string]interface{}{"id": user.ID})
 		return fmt.Errorf("kullanıcı güncellenemedi: %w", err)
 	}
 
+	if err := s.saveEvent(user, domain.EventTypeUserUpdated, domain.DiffUsers(existingUser, user)); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, map[string]interface{}{"user_id": user.ID})
+	}
+
 	auditLog := &domain.AuditLog{
 		EntityType: domain.EntityTypeUser,
 		EntityID:   user.ID,
 		Action:     domain.ActionTypeUpdate,
 		Details:    fmt.Sprintf("Kullanıcı güncellendi: %s", user.Username),
+		ActorID:    actorID,
 		CreatedAt:  time.Now(),
 	}
 
 	if err := s.auditLogRepo.Create(auditLog); err != nil {
-		s.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"user_id": user.ID, "error": err.Error()})
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": user.ID})
 	}
 
 	return nil
 }
 
-func (s *UserService) DeleteUser(id int64) error {
+func (s *UserService) DeleteUser(id, actorID int64) error {
 	existingUser, err := s.repo.FindByID(id)
 	if err != nil {
-		s.logger.Error("Kullanıcı silme sırasında hata oluştu", map[string]interface{}{"id": id, "error": err.Error()})
+		s.logger.ErrorWithErr("Kullanıcı silme sırasında hata oluştu", err, map[string]interface{}{"id": id})
 		return fmt.Errorf("kullanıcı silinemedi: %w", err)
 	}
 
@@ -185,25 +277,88 @@ func (s *UserService) DeleteUser(id int64) error {
 	}
 
 	if err := s.repo.Delete(id); err != nil {
-		s.logger.Error("Kullanıcı silme sırasında hata oluştu", map[string]interface{}{"id": id, "error": err.Error()})
+		s.logger.ErrorWithErr("Kullanıcı silme sırasında hata oluştu", err, map[string]interface{}{"id": id})
 		return fmt.Errorf("kullanıcı silinemedi: %w", err)
 	}
 
+	if err := s.saveEvent(existingUser, domain.EventTypeUserDeleted, nil); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, map[string]interface{}{"user_id": id})
+	}
+
 	auditLog := &domain.AuditLog{
 		EntityType: domain.EntityTypeUser,
 		EntityID:   id,
 		Action:     domain.ActionTypeDelete,
 		Details:    fmt.Sprintf("Kullanıcı silindi: %s", existingUser.Username),
+		ActorID:    actorID,
 		CreatedAt:  time.Now(),
 	}
 
 	if err := s.auditLogRepo.Create(auditLog); err != nil {
-		s.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"user_id": id, "error": err.Error()})
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": id})
 	}
 
 	return nil
 }
 
+// RestoreUser undoes a soft delete.
+func (s *UserService) RestoreUser(id, actorID int64) error {
+	existingUser, err := s.repo.FindByIDIncludingDeleted(id)
+	if err != nil {
+		s.logger.ErrorWithErr("Kullanıcı geri yükleme sırasında hata oluştu", err, map[string]interface{}{"id": id})
+		return fmt.Errorf("kullanıcı geri yüklenemedi: %w", err)
+	}
+
+	if existingUser == nil {
+		return fmt.Errorf("geri yüklenecek kullanıcı bulunamadı: %w", domain.ErrUserNotFound)
+	}
+
+	if existingUser.DeletedAt == nil {
+		return fmt.Errorf("kullanıcı geri yüklenemedi: %w", domain.ErrUserNotDeleted)
+	}
+
+	if err := s.repo.Restore(id); err != nil {
+		s.logger.ErrorWithErr("Kullanıcı geri yükleme sırasında hata oluştu", err, map[string]interface{}{"id": id})
+		return fmt.Errorf("kullanıcı geri yüklenemedi: %w", err)
+	}
+
+	existingUser.DeletedAt = nil
+	if err := s.saveEvent(existingUser, domain.EventTypeUserRestored, nil); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, map[string]interface{}{"user_id": id})
+	}
+
+	auditLog := &domain.AuditLog{
+		EntityType: domain.EntityTypeUser,
+		EntityID:   id,
+		Action:     domain.ActionTypeUpdate,
+		Details:    fmt.Sprintf("Kullanıcı geri yüklendi: %s", existingUser.Username),
+		ActorID:    actorID,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.auditLogRepo.Create(auditLog); err != nil {
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": id})
+	}
+
+	return nil
+}
+
+// GetUserByIDIncludingDeleted resolves users referenced by history even after
+// they were deleted.
+func (s *UserService) GetUserByIDIncludingDeleted(id int64) (*domain.User, error) {
+	user, err := s.repo.FindByIDIncludingDeleted(id)
+	if err != nil {
+		s.logger.ErrorWithErr("Kullanıcı ID'ye göre bulunamadı", err, map[string]interface{}{"id": id})
+		return nil, fmt.Errorf("kullanıcı bulunamadı: %w", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("kullanıcı ID'ye göre bulunamadı: %d", id)
+	}
+
+	return user, nil
+}
+
 func (s *UserService) HasAdminRole(userID int64) (bool, error) {
 	user, err := s.GetUserByID(userID)
 	if err != nil {
@@ -229,21 +384,28 @@ func (s *UserService) CheckPermission(userID int64, requiredRole string) (bool,
 	return user.Role == requiredRole, nil
 }
 
+func (s *UserService) HasPermission(userID int64, permission string) (bool, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return false, fmt.Errorf("yetki kontrolü yapılamadı: %w", err)
+	}
+
+	return s.permissions.Allows(user.Role, permission), nil
+}
+
 func (s *UserService) GenerateApiKey(userID int64) (string, error) {
 	user, err := s.GetUserByID(userID)
 	if err != nil {
 		return "", fmt.Errorf("API anahtarı oluşturulamadı: %w", err)
 	}
 
-	b := make([]byte, 32)
-	_, err = rand.Read(b)
+	apiKey, err := password.GenerateToken()
 	if err != nil {
 		return "", fmt.Errorf("API anahtarı oluşturulamadı: %w", err)
 	}
 
-	apiKey := fmt.Sprintf("%x", b)
-
-	user.ApiKey = apiKey
+	// Only the hash is persisted; the plaintext key is returned once.
+	user.ApiKeyHash = password.HashApiKey(apiKey)
 	user.UpdatedAt = time.Now()
 
 	if err := s.repo.Update(user); err != nil {
@@ -255,11 +417,12 @@ func (s *UserService) GenerateApiKey(userID int64) (string, error) {
 		EntityID:   userID,
 		Action:     domain.ActionTypeUpdate,
 		Details:    "API anahtarı yenilendi",
+		ActorID:    userID,
 		CreatedAt:  time.Now(),
 	}
 
 	if err := s.auditLogRepo.Create(auditLog); err != nil {
-		s.logger.Error("Denetim kaydı oluşturulamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": userID})
 	}
 
 	return apiKey, nil
@@ -270,20 +433,42 @@ func (s *UserService) GetUserByApiKey(apiKey string) (*domain.User, error) {
 		return nil, fmt.Errorf("API anahtarı boş olamaz")
 	}
 
-	user, err := s.repo.FindByApiKey(apiKey)
+	user, err := s.repo.FindByApiKeyHash(password.HashApiKey(apiKey))
 	if err != nil {
-		s.logger.Error("API anahtarı ile kullanıcı bulunamadı", map[string]interface{}{"error": err.Error()})
+		s.logger.ErrorWithErr("API anahtarı ile kullanıcı bulunamadı", err, nil)
 		return nil, fmt.Errorf("kullanıcı bulunamadı: %w", err)
 	}
 
-	if user == nil {
+	if user == nil || !password.VerifyApiKey(user.ApiKeyHash, apiKey) {
 		return nil, fmt.Errorf("geçersiz API anahtarı")
 	}
 
 	return user, nil
 }
 
-func (s *UserService) Login(username, password string) (string, error) {
+func (s *UserService) HashPassword(plainPassword string) (string, error) {
+	return password.HashPassword(plainPassword, s.config.PasswordHashCost)
+}
+
+// rehashPassword upgrades a legacy or weaker stored hash after a successful
+// login; failures are logged and the old hash stays valid.
+func (s *UserService) rehashPassword(user *domain.User, plainPassword string) {
+	passwordHash, err := s.HashPassword(plainPassword)
+	if err != nil {
+		s.logger.ErrorWithErr("Şifre yeniden hashlenemedi", err, map[string]interface{}{"user_id": user.ID})
+		return
+	}
+
+	user.PasswordHash = passwordHash
+	if err := s.repo.Update(user); err != nil {
+		s.logger.ErrorWithErr("Yeni şifre hash'i kaydedilemedi", err, map[string]interface{}{"user_id": user.ID})
+		return
+	}
+
+	s.logger.Info("Şifre hash'i güncellendi", map[string]interface{}{"user_id": user.ID})
+}
+
+func (s *UserService) Login(username, plainPassword string) (string, error) {
 	user, err := s.repo.FindByUsername(username)
 	if err != nil {
 		return "", fmt.Errorf("giriş yapılamadı: %w", err)
@@ -293,20 +478,192 @@ func (s *UserService) Login(username, password string) (string, error) {
 		return "", fmt.Errorf("geçersiz kullanıcı adı veya şifre")
 	}
 
-	passwordHash := fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
-
-	if user.PasswordHash != passwordHash {
+	if !password.VerifyPassword(user.PasswordHash, plainPassword) {
 		s.logger.Error("Şifre eşleşmiyor", map[string]interface{}{"username": username})
 		return "", fmt.Errorf("geçersiz kullanıcı adı veya şifre")
 	}
 
-	if user.ApiKey == "" {
-		apiKey, err := s.GenerateApiKey(user.ID)
-		if err != nil {
-			return "", fmt.Errorf("API anahtarı oluşturulamadı: %w", err)
-		}
-		return apiKey, nil
+	if !user.IsActive {
+		s.logger.Warn("Aktif olmayan kullanıcı giriş denedi", map[string]interface{}{"user_id": user.ID})
+		return "", domain.ErrUserInactive
+	}
+
+	if password.NeedsRehash(user.PasswordHash, s.config.PasswordHashCost) {
+		s.rehashPassword(user, plainPassword)
+	}
+
+	// Stored keys are hashed and cannot be handed back, so every login issues
+	// a fresh key and invalidates the previous one.
+	apiKey, err := s.GenerateApiKey(user.ID)
+	if err != nil {
+		return "", fmt.Errorf("API anahtarı oluşturulamadı: %w", err)
 	}
 
-	return user.ApiKey, nil
+	return apiKey, nil
+}
+
+// GenerateActivationToken issues a new activation token for an inactive user
+// and hands it to the notifier. Earlier unused tokens stay valid until expiry.
+func (s *UserService) GenerateActivationToken(userID int64) (string, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return "", fmt.Errorf("aktivasyon kodu oluşturulamadı: %w", err)
+	}
+
+	if user.IsActive {
+		return "", fmt.Errorf("kullanıcı zaten aktif: %d", userID)
+	}
+
+	return s.issueActivationToken(user)
+}
+
+func (s *UserService) issueActivationToken(user *domain.User) (string, error) {
+	token, err := password.GenerateToken()
+	if err != nil {
+		return "", fmt.Errorf("aktivasyon kodu oluşturulamadı: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.config.ActivationTokenTTL)
+	if err := s.repo.CreateActivationToken(user.ID, password.HashToken(token), expiresAt); err != nil {
+		return "", fmt.Errorf("aktivasyon kodu oluşturulamadı: %w", err)
+	}
+
+	if err := s.notifier.SendActivationToken(user, token, expiresAt); err != nil {
+		return "", fmt.Errorf("aktivasyon kodu gönderilemedi: %w", err)
+	}
+
+	return token, nil
+}
+
+func (s *UserService) ActivateUser(token string) (*domain.User, error) {
+	if token == "" {
+		return nil, domain.ErrInvalidActivationToken
+	}
+
+	now := time.Now()
+
+	userID, err := s.repo.ConsumeActivationToken(password.HashToken(token), now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Activate(userID, now); err != nil {
+		return nil, fmt.Errorf("kullanıcı aktifleştirilemedi: %w", err)
+	}
+
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("kullanıcı aktifleştirilemedi: %w", err)
+	}
+
+	changes := map[string]domain.UserChange{"is_active": {Old: "false", New: "true"}}
+	if err := s.saveEvent(user, domain.EventTypeUserUpdated, changes); err != nil {
+		s.logger.ErrorWithErr("Event kaydedilemedi", err, map[string]interface{}{"user_id": userID})
+	}
+
+	auditLog := &domain.AuditLog{
+		EntityType: domain.EntityTypeUser,
+		EntityID:   userID,
+		Action:     domain.ActionTypeUpdate,
+		Details:    fmt.Sprintf("Kullanıcı hesabı aktifleştirildi: %s", user.Username),
+		ActorID:    userID,
+		CreatedAt:  now,
+	}
+
+	if err := s.auditLogRepo.Create(auditLog); err != nil {
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": userID})
+	}
+
+	s.logger.Info("Kullanıcı hesabı aktifleştirildi", map[string]interface{}{"user_id": userID})
+
+	return user, nil
+}
+
+// RequestPasswordReset sends a reset token to the user with the given email.
+// Unknown emails are not reported so the endpoint cannot be used to probe
+// for accounts.
+func (s *UserService) RequestPasswordReset(email string) error {
+	user, err := s.repo.FindByEmail(email)
+	if err != nil {
+		return fmt.Errorf("şifre sıfırlama isteği oluşturulamadı: %w", err)
+	}
+
+	if user == nil {
+		s.logger.Warn("Bilinmeyen e-posta için şifre sıfırlama istendi", map[string]interface{}{"email": email})
+		return nil
+	}
+
+	token, err := password.GenerateToken()
+	if err != nil {
+		return fmt.Errorf("şifre sıfırlama isteği oluşturulamadı: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.config.PasswordResetTokenTTL)
+	if err := s.repo.CreatePasswordResetToken(user.ID, password.HashToken(token), expiresAt); err != nil {
+		return fmt.Errorf("şifre sıfırlama isteği oluşturulamadı: %w", err)
+	}
+
+	if err := s.notifier.SendPasswordResetToken(user, token, expiresAt); err != nil {
+		return fmt.Errorf("şifre sıfırlama kodu gönderilemedi: %w", err)
+	}
+
+	auditLog := &domain.AuditLog{
+		EntityType: domain.EntityTypeUser,
+		EntityID:   user.ID,
+		Action:     domain.ActionTypeUpdate,
+		Details:    "Şifre sıfırlama kodu oluşturuldu",
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.auditLogRepo.Create(auditLog); err != nil {
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": user.ID})
+	}
+
+	return nil
+}
+
+// ResetPassword redeems a reset token and sets a new password. The user's API
+// key and all JWTs issued before the reset stop working.
+func (s *UserService) ResetPassword(token, newPassword string) (*domain.User, error) {
+	if token == "" {
+		return nil, domain.ErrInvalidResetToken
+	}
+
+	passwordHash, err := s.HashPassword(newPassword)
+	if err != nil {
+		return nil, fmt.Errorf("şifre sıfırlanamadı: %w", err)
+	}
+
+	now := time.Now()
+
+	userID, err := s.repo.ConsumePasswordResetToken(password.HashToken(token), now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ResetPassword(userID, passwordHash, now); err != nil {
+		return nil, fmt.Errorf("şifre sıfırlanamadı: %w", err)
+	}
+
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("şifre sıfırlanamadı: %w", err)
+	}
+
+	auditLog := &domain.AuditLog{
+		EntityType: domain.EntityTypeUser,
+		EntityID:   userID,
+		Action:     domain.ActionTypeUpdate,
+		Details:    "Şifre sıfırlandı; API anahtarı ve oturumlar iptal edildi",
+		ActorID:    userID,
+		CreatedAt:  now,
+	}
+
+	if err := s.auditLogRepo.Create(auditLog); err != nil {
+		s.logger.ErrorWithErr("Denetim kaydı oluşturulamadı", err, map[string]interface{}{"user_id": userID})
+	}
+
+	s.logger.Info("Kullanıcı şifresi sıfırlandı", map[string]interface{}{"user_id": userID})
+
+	return user, nil
 }
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"payflow/internal/config"
+	"payflow/internal/domain"
+	"payflow/pkg/password"
+)
+
+func TestGenerateApiKeyReturnsDistinctHexKeys(t *testing.T) {
+	users := newFakeUserRepo(&domain.User{ID: 1, Username: "alice", IsActive: true})
+	svc := NewUserService(users, nil, &fakeAuditLogRepo{}, &fakeEventStore{}, newTestLogger(),
+		config.SecurityConfig{}, nil, nil)
+
+	first, err := svc.GenerateApiKey(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := svc.GenerateApiKey(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Fatal("ardışık API anahtarları farklı olmalı")
+	}
+	for _, key := range []string{first, second} {
+		if len(key) != 64 {
+			t.Fatalf("API anahtarı 64 karakter olmalı, alınan: %d", len(key))
+		}
+		if _, err := hex.DecodeString(key); err != nil {
+			t.Fatalf("API anahtarı hex olmalı: %q", key)
+		}
+	}
+
+	stored, _ := users.FindByID(1)
+	if !password.VerifyApiKey(stored.ApiKeyHash, second) {
+		t.Fatal("saklanan hash son anahtarla eşleşmeli")
+	}
+	if password.VerifyApiKey(stored.ApiKeyHash, first) {
+		t.Fatal("önceki anahtar geçersiz olmalı")
+	}
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+
+	"payflow/internal/domain"
+)
+
+type contextKey struct{}
+
+func WithUser(ctx context.Context, user *domain.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+func UserFromContext(ctx context.Context) (*domain.User, bool) {
+	user, ok := ctx.Value(contextKey{}).(*domain.User)
+	return user, ok && user != nil
+}
+
+// ActorIDFromContext returns the authenticated user's ID for audit logs, or
+// domain.SystemActorID when the request is unauthenticated.
+func ActorIDFromContext(ctx context.Context) int64 {
+	if user, ok := UserFromContext(ctx); ok {
+		return user.ID
+	}
+	return domain.SystemActorID
+}
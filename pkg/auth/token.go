@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"payflow/internal/domain"
+)
+
+const issuer = "payflow"
+
+var ErrInvalidToken = errors.New("geçersiz veya süresi dolmuş token")
+
+type Claims struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager issues and validates HS256-signed JWTs.
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewTokenManager creates a token manager. An empty secret is replaced with a
+// random one, so tokens do not survive a restart and are not shared between
+// instances.
+func NewTokenManager(secret string, ttl time.Duration) (*TokenManager, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("JWT anahtarı oluşturulamadı: %w", err)
+		}
+	}
+
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+
+	return &TokenManager{
+		secret: key,
+		ttl:    ttl,
+	}, nil
+}
+
+func (m *TokenManager) Issue(user *domain.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+
+	claims := Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("token imzalanamadı: %w", err)
+	}
+
+	return token, expiresAt, nil
+}
+
+func (m *TokenManager) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return claims, nil
+}
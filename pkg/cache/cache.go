@@ -3,13 +3,24 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
 	"payflow/pkg/logger"
+	"payflow/pkg/metrics"
+)
+
+// Metric tiers: l1 is the in-process TieredCache layer, l2 is Redis.
+const (
+	tierL1 = "l1"
+	tierL2 = "l2"
 )
 
 // Cache interface - caching operations
@@ -22,11 +33,15 @@ type Cache interface {
 
 	// Pattern-based operations
 	DeletePattern(ctx context.Context, pattern string) error
-	GetKeys(ctx context.Context, pattern string) ([]string, error)
+	// GetKeys returns at most limit keys (limit <= 0 means all)
+	GetKeys(ctx context.Context, pattern string, limit int) ([]string, error)
 
 	// Batch operations
 	SetMultiple(ctx context.Context, items map[string]interface{}, expiration time.Duration) error
 	GetMultiple(ctx context.Context, keys []string) (map[string]interface{}, error)
+	// GetMultipleInto decodes the values of keys into dest, a pointer to a
+	// slice, in key order; missing or undecodable entries are left zero
+	GetMultipleInto(ctx context.Context, keys []string, dest interface{}) error
 	DeleteMultiple(ctx context.Context, keys []string) error
 
 	// Cache warm-up and invalidation
@@ -39,18 +54,46 @@ type Cache interface {
 
 // RedisCache implements Cache interface
 type RedisCache struct {
-	client *redis.Client
+	client redis.UniversalClient
 	logger logger.Logger
 	prefix string
+	ttl    *TTLSettings
 }
 
-// NewRedisCache creates a new Redis cache instance
-func NewRedisCache(client *redis.Client, logger logger.Logger, prefix string) Cache {
+// NewRedisCache creates a new Redis cache instance. Expirations are spread by
+// the jitter in ttl so keys written together do not expire together.
+func NewRedisCache(client redis.UniversalClient, logger logger.Logger, prefix string, ttl *TTLSettings) Cache {
 	return &RedisCache{
 		client: client,
 		logger: logger,
 		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+type exactTTLKey struct{}
+
+// WithExactTTL opts cache writes made with ctx out of expiration jitter
+func WithExactTTL(ctx context.Context) context.Context {
+	return context.WithValue(ctx, exactTTLKey{}, true)
+}
+
+// jitteredTTL applies the configured jitter to expiration unless ctx asks for exact TTLs
+func (r *RedisCache) jitteredTTL(ctx context.Context, expiration time.Duration) time.Duration {
+	jitter := r.ttl.Jitter()
+	if expiration <= 0 || jitter == 0 {
+		return expiration
 	}
+	if exact, _ := ctx.Value(exactTTLKey{}).(bool); exact {
+		return expiration
+	}
+
+	delta := (rand.Float64()*2 - 1) * jitter
+	jittered := expiration + time.Duration(float64(expiration)*delta)
+	if jittered <= 0 {
+		return expiration
+	}
+	return jittered
 }
 
 // makeKey adds prefix to the key
@@ -61,6 +104,110 @@ func (r *RedisCache) makeKey(key string) string {
 	return fmt.Sprintf("%s:%s", r.prefix, key)
 }
 
+// scanBatchSize is the COUNT hint per SCAN call and the DEL chunk size.
+const scanBatchSize = 500
+
+// errStopScan ends a scan early without reporting an error.
+var errStopScan = errors.New("scan durduruldu")
+
+// scan walks the keys matching pattern with SCAN, handing them to fn one
+// batch at a time. In cluster mode every master is scanned, since each node
+// only knows the keys of its own slots; fn is never called concurrently.
+// SCAN may return a key more than once.
+func (r *RedisCache) scan(ctx context.Context, pattern string, fn func(keys []string) error) error {
+	cluster, ok := r.client.(*redis.ClusterClient)
+	if !ok {
+		return ignoreStopScan(scanNode(ctx, r.client, pattern, fn))
+	}
+
+	var mu sync.Mutex
+	stopped := false
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		return scanNode(ctx, node, pattern, func(keys []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if stopped {
+				return errStopScan
+			}
+			if err := fn(keys); err != nil {
+				stopped = true
+				return err
+			}
+			return nil
+		})
+	})
+
+	return ignoreStopScan(err)
+}
+
+func scanNode(ctx context.Context, node redis.Cmdable, pattern string, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := node.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func ignoreStopScan(err error) error {
+	if errors.Is(err, errStopScan) {
+		return nil
+	}
+	return err
+}
+
+// deleteKeys deletes keys one command per key in a pipeline; a multi-key DEL
+// fails with CROSSSLOT when the keys live on different cluster slots.
+func (r *RedisCache) deleteKeys(ctx context.Context, keys []string) error {
+	pipe := r.client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// getValues is MGET without the single-slot restriction; missing keys yield nil.
+func (r *RedisCache) getValues(ctx context.Context, keys []string) ([]interface{}, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		val, err := cmd.Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[i] = val
+	}
+
+	return values, nil
+}
+
 // Set stores a value in cache
 func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 	data, err := json.Marshal(value)
@@ -73,6 +220,7 @@ func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, exp
 	}
 
 	fullKey := r.makeKey(key)
+	expiration = r.jitteredTTL(ctx, expiration)
 	err = r.client.Set(ctx, fullKey, data, expiration).Err()
 	if err != nil {
 		r.logger.Error("Cache set hatası", map[string]interface{}{
@@ -95,6 +243,7 @@ func (r *RedisCache) Get(ctx context.Context, key string, dest interface{}) erro
 	data, err := r.client.Get(ctx, fullKey).Result()
 	if err != nil {
 		if err == redis.Nil {
+			metrics.RecordCacheMiss(tierL2)
 			r.logger.Debug("Cache miss", map[string]interface{}{"key": fullKey})
 			return ErrCacheMiss
 		}
@@ -114,6 +263,7 @@ func (r *RedisCache) Get(ctx context.Context, key string, dest interface{}) erro
 		return err
 	}
 
+	metrics.RecordCacheHit(tierL2)
 	r.logger.Debug("Cache hit", map[string]interface{}{"key": fullKey})
 	return nil
 }
@@ -149,46 +299,66 @@ func (r *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
 	return count > 0, nil
 }
 
-// DeletePattern deletes all keys matching a pattern
+// DeletePattern deletes all keys matching a pattern, one SCAN batch at a time
 func (r *RedisCache) DeletePattern(ctx context.Context, pattern string) error {
 	fullPattern := r.makeKey(pattern)
-	keys, err := r.client.Keys(ctx, fullPattern).Result()
+
+	deleted := 0
+	err := r.scan(ctx, fullPattern, func(keys []string) error {
+		if err := r.deleteKeys(ctx, keys); err != nil {
+			return err
+		}
+		deleted += len(keys)
+		return nil
+	})
 	if err != nil {
 		r.logger.Error("Cache delete pattern hatası", map[string]interface{}{
-			"pattern": fullPattern,
-			"error":   err.Error(),
+			"pattern":      fullPattern,
+			"deleted_keys": deleted,
+			"error":        err.Error(),
 		})
 		return err
 	}
 
-	if len(keys) == 0 {
+	if deleted == 0 {
 		r.logger.Debug("Cache delete pattern - anahtar bulunamadı", map[string]interface{}{
 			"pattern": fullPattern,
 		})
 		return nil
 	}
 
-	err = r.client.Del(ctx, keys...).Err()
-	if err != nil {
-		r.logger.Error("Cache delete pattern hatası", map[string]interface{}{
-			"pattern": fullPattern,
-			"keys":    len(keys),
-			"error":   err.Error(),
-		})
-		return err
-	}
-
 	r.logger.Info("Cache delete pattern başarılı", map[string]interface{}{
 		"pattern":      fullPattern,
-		"deleted_keys": len(keys),
+		"deleted_keys": deleted,
 	})
 	return nil
 }
 
-// GetKeys returns all keys matching a pattern
-func (r *RedisCache) GetKeys(ctx context.Context, pattern string) ([]string, error) {
+// GetKeys returns keys matching a pattern, stopping after limit keys when limit > 0
+func (r *RedisCache) GetKeys(ctx context.Context, pattern string, limit int) ([]string, error) {
 	fullPattern := r.makeKey(pattern)
-	keys, err := r.client.Keys(ctx, fullPattern).Result()
+
+	seen := make(map[string]struct{})
+	keys := []string{}
+	err := r.scan(ctx, fullPattern, func(batch []string) error {
+		for _, key := range batch {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+
+			// Remove prefix from keys
+			if r.prefix != "" {
+				key = strings.TrimPrefix(key, r.prefix+":")
+			}
+			keys = append(keys, key)
+
+			if limit > 0 && len(keys) >= limit {
+				return errStopScan
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		r.logger.Error("Cache get keys hatası", map[string]interface{}{
 			"pattern": fullPattern,
@@ -197,13 +367,6 @@ func (r *RedisCache) GetKeys(ctx context.Context, pattern string) ([]string, err
 		return nil, err
 	}
 
-	// Remove prefix from keys
-	if r.prefix != "" {
-		for i, key := range keys {
-			keys[i] = strings.TrimPrefix(key, r.prefix+":")
-		}
-	}
-
 	return keys, nil
 }
 
@@ -222,7 +385,7 @@ func (r *RedisCache) SetMultiple(ctx context.Context, items map[string]interface
 		}
 
 		fullKey := r.makeKey(key)
-		pipe.Set(ctx, fullKey, data, expiration)
+		pipe.Set(ctx, fullKey, data, r.jitteredTTL(ctx, expiration))
 	}
 
 	_, err := pipe.Exec(ctx)
@@ -252,7 +415,7 @@ func (r *RedisCache) GetMultiple(ctx context.Context, keys []string) (map[string
 		fullKeys[i] = r.makeKey(key)
 	}
 
-	values, err := r.client.MGet(ctx, fullKeys...).Result()
+	values, err := r.getValues(ctx, fullKeys)
 	if err != nil {
 		r.logger.Error("Cache get multiple hatası", map[string]interface{}{
 			"keys":  len(keys),
@@ -280,6 +443,75 @@ func (r *RedisCache) GetMultiple(ctx context.Context, keys []string) (map[string
 	return result, nil
 }
 
+// GetMultipleInto gets multiple values by keys, decoding each into the element type of dest
+func (r *RedisCache) GetMultipleInto(ctx context.Context, keys []string, dest interface{}) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() || dv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("hedef bir slice pointer'ı olmalı: %T", dest)
+	}
+
+	sliceType := dv.Elem().Type()
+	elemType := sliceType.Elem()
+	result := reflect.MakeSlice(sliceType, len(keys), len(keys))
+
+	if len(keys) == 0 {
+		dv.Elem().Set(result)
+		return nil
+	}
+
+	fullKeys := make([]string, len(keys))
+	for i, key := range keys {
+		fullKeys[i] = r.makeKey(key)
+	}
+
+	values, err := r.getValues(ctx, fullKeys)
+	if err != nil {
+		r.logger.Error("Cache get multiple hatası", map[string]interface{}{
+			"keys":  len(keys),
+			"error": err.Error(),
+		})
+		return err
+	}
+
+	found := 0
+	for i, val := range values {
+		strVal, ok := val.(string)
+		if !ok {
+			continue
+		}
+
+		elem := reflect.New(elemType)
+		if err := json.Unmarshal([]byte(strVal), elem.Interface()); err != nil {
+			r.logger.Debug("Cache get multiple unmarshal hatası", map[string]interface{}{
+				"key":   fullKeys[i],
+				"error": err.Error(),
+			})
+			continue
+		}
+
+		result.Index(i).Set(elem.Elem())
+		found++
+	}
+
+	dv.Elem().Set(result)
+
+	r.logger.Debug("Cache get multiple başarılı", map[string]interface{}{
+		"requested": len(keys),
+		"found":     found,
+	})
+	return nil
+}
+
+// GetMultipleAs is the typed form of GetMultipleInto: the result holds one
+// element per key, zero (nil for pointers) where the key was missing.
+func GetMultipleAs[T any](ctx context.Context, c Cache, keys []string) ([]T, error) {
+	var values []T
+	if err := c.GetMultipleInto(ctx, keys, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
 // DeleteMultiple deletes multiple keys
 func (r *RedisCache) DeleteMultiple(ctx context.Context, keys []string) error {
 	if len(keys) == 0 {
@@ -291,7 +523,7 @@ func (r *RedisCache) DeleteMultiple(ctx context.Context, keys []string) error {
 		fullKeys[i] = r.makeKey(key)
 	}
 
-	err := r.client.Del(ctx, fullKeys...).Err()
+	err := r.deleteKeys(ctx, fullKeys)
 	if err != nil {
 		r.logger.Error("Cache delete multiple hatası", map[string]interface{}{
 			"keys":  len(keys),
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestJitteredTTLSpreadsWithinWindow(t *testing.T) {
+	const (
+		expiration = time.Hour
+		jitter     = 0.1
+		samples    = 1000
+	)
+
+	r := NewRedisCache(nil, newTestLogger(), "test", NewTTLSettings(time.Minute, jitter)).(*RedisCache)
+
+	lower := expiration - time.Duration(float64(expiration)*jitter)
+	upper := expiration + time.Duration(float64(expiration)*jitter)
+
+	var below, above int
+	distinct := make(map[time.Duration]struct{})
+	for i := 0; i < samples; i++ {
+		ttl := r.jitteredTTL(context.Background(), expiration)
+		if ttl < lower || ttl > upper {
+			t.Fatalf("TTL %s, [%s, %s] aralığının dışında", ttl, lower, upper)
+		}
+		switch {
+		case ttl < expiration-time.Duration(float64(expiration)*jitter/2):
+			below++
+		case ttl > expiration+time.Duration(float64(expiration)*jitter/2):
+			above++
+		}
+		distinct[ttl] = struct{}{}
+	}
+
+	// Each outer half of the window holds about a quarter of the samples.
+	if below < samples/10 || above < samples/10 {
+		t.Fatalf("TTL'ler pencereye yayılmalı, alt: %d, üst: %d", below, above)
+	}
+	if len(distinct) < samples/2 {
+		t.Fatalf("TTL'ler çoğunlukla farklı olmalı, farklı değer sayısı: %d", len(distinct))
+	}
+}
+
+func TestJitteredTTLExactCases(t *testing.T) {
+	r := NewRedisCache(nil, newTestLogger(), "test", NewTTLSettings(time.Minute, 0.2)).(*RedisCache)
+
+	if ttl := r.jitteredTTL(WithExactTTL(context.Background()), time.Hour); ttl != time.Hour {
+		t.Fatalf("WithExactTTL ile TTL değişmemeli, alınan: %s", ttl)
+	}
+	if ttl := r.jitteredTTL(context.Background(), 0); ttl != 0 {
+		t.Fatalf("süresiz kayıt süresiz kalmalı, alınan: %s", ttl)
+	}
+
+	r.ttl.SetJitter(0)
+	if ttl := r.jitteredTTL(context.Background(), time.Hour); ttl != time.Hour {
+		t.Fatalf("jitter kapalıyken TTL değişmemeli, alınan: %s", ttl)
+	}
+}
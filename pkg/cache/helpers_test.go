@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"payflow/pkg/logger"
+)
+
+// memCache stores JSON-encoded values in a map. It embeds Cache so a test
+// that reaches an unimplemented method fails with a nil-pointer panic.
+type memCache struct {
+	Cache
+
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newMemCache() *memCache {
+	return &memCache{values: make(map[string][]byte)}
+}
+
+func (c *memCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = data
+	return nil
+}
+
+func (c *memCache) Get(ctx context.Context, key string, dest interface{}) error {
+	c.mu.Lock()
+	data, ok := c.values[key]
+	c.mu.Unlock()
+
+	if !ok {
+		return ErrCacheMiss
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func newTestLogger() logger.Logger {
+	return logger.New(logger.ErrorLevel, logger.FormatJSON, io.Discard)
+}
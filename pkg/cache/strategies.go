@@ -1,11 +1,19 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"payflow/pkg/logger"
+	"reflect"
 	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"payflow/pkg/fallback"
+	"payflow/pkg/logger"
+	"payflow/pkg/metrics"
 )
 
 // Cache key constants
@@ -18,8 +26,8 @@ const (
 
 	// Balance cache keys
 	BalancePrefix     = "balance"
-	BalanceByUserKey  = "balance:user:%d"
-	BalanceHistoryKey = "balance:history:user:%d"
+	BalanceByUserKey  = "balance:user:%d:%s"
+	BalanceHistoryKey = "balance:history:user:%d:%s"
 
 	// Transaction cache keys
 	TransactionPrefix    = "transaction"
@@ -44,8 +52,22 @@ const (
 	MediumExpiration   = 30 * time.Minute // Moderately changing data
 	LongExpiration     = 2 * time.Hour    // Rarely changing data
 	VeryLongExpiration = 24 * time.Hour   // Static or rarely updated data
+
+	NegativeExpiration = 30 * time.Second // Not-found results
 )
 
+// ErrNotFound marks a fetch result as "does not exist". ReadThrough caches it
+// for NegativeExpiration and returns it on later lookups without fetching.
+var ErrNotFound = errors.New("kaynakta bulunamadı")
+
+// NotFound wraps err so that it matches both ErrNotFound and err
+func NotFound(err error) error {
+	return fmt.Errorf("%w: %w", ErrNotFound, err)
+}
+
+// negativeEntry is the cached sentinel for ErrNotFound results
+var negativeEntry = json.RawMessage(`"__payflow_not_found__"`)
+
 // CacheStrategy defines different caching patterns
 type CacheStrategy interface {
 	// Read-through: Check cache first, if miss then fetch from source and cache it
@@ -59,30 +81,48 @@ type CacheStrategy interface {
 
 	// Cache-aside: Manual cache management
 	CacheAside(ctx context.Context, key string, dest interface{}, fetchFunc func() (interface{}, error), expiration time.Duration) error
+
+	// Flush waits for pending write-behind source writes, retrying them without backoff
+	Flush(ctx context.Context) error
 }
 
+// Write-behind source writes are retried with exponential backoff
+const (
+	writeBehindMaxRetries    = 5
+	writeBehindRetryInterval = time.Second
+)
+
 // CacheManager implements various caching strategies
 type CacheManager struct {
-	cache  Cache
-	logger logger.Logger
+	cache      Cache
+	writeQueue *fallback.RetryQueue
+	logger     logger.Logger
+	flight     singleflight.Group
 }
 
-// NewCacheManager creates a new cache manager
-func NewCacheManager(cache Cache, logger logger.Logger) CacheStrategy {
+// NewCacheManager creates a new cache manager; writeQueue carries write-behind source writes
+func NewCacheManager(cache Cache, writeQueue *fallback.RetryQueue, logger logger.Logger) CacheStrategy {
 	return &CacheManager{
-		cache:  cache,
-		logger: logger,
+		cache:      cache,
+		writeQueue: writeQueue,
+		logger:     logger,
 	}
 }
 
 // ReadThrough implements read-through caching pattern
 func (cm *CacheManager) ReadThrough(ctx context.Context, key string, dest interface{}, fetchFunc func() (interface{}, error), expiration time.Duration) error {
 	// Try to get from cache first
-	err := cm.cache.Get(ctx, key, dest)
+	var raw json.RawMessage
+	err := cm.cache.Get(ctx, key, &raw)
 	if err == nil {
 		// Cache hit
+		if bytes.Equal(raw, negativeEntry) {
+			cm.logger.Debug("Negative cache hit for read-through", map[string]interface{}{"key": key})
+			return ErrNotFound
+		}
+
 		cm.logger.Debug("Cache hit for read-through", map[string]interface{}{"key": key})
-		return nil
+		return json.Unmarshal(raw, dest)
 	}
 
 	if err != ErrCacheMiss {
@@ -94,24 +134,49 @@ func (cm *CacheManager) ReadThrough(ctx context.Context, key string, dest interf
 		// Continue to fetch from source despite cache error
 	}
 
-	// Cache miss or error, fetch from source
-	cm.logger.Debug("Cache miss, fetching from source", map[string]interface{}{"key": key})
-	data, err := fetchFunc()
+	// Cache miss or error, fetch from source. Concurrent misses for the same
+	// key share a single fetch and cache write.
+	data, err, shared := cm.flight.Do(key, func() (interface{}, error) {
+		cm.logger.Debug("Cache miss, fetching from source", map[string]interface{}{"key": key})
+		data, err := fetchFunc()
+		if errors.Is(err, ErrNotFound) {
+			if setErr := cm.cache.Set(ctx, key, negativeEntry, NegativeExpiration); setErr != nil {
+				cm.logger.Error("Negative cache set error in read-through", map[string]interface{}{
+					"key":   key,
+					"error": setErr.Error(),
+				})
+			}
+			return nil, err
+		}
+		if err != nil {
+			cm.logger.Error("Source fetch error in read-through", map[string]interface{}{
+				"key":   key,
+				"error": err.Error(),
+			})
+			return nil, err
+		}
+
+		// Store in cache for next time
+		if err := cm.cache.Set(ctx, key, data, expiration); err != nil {
+			cm.logger.Error("Cache set error in read-through", map[string]interface{}{
+				"key":   key,
+				"error": err.Error(),
+			})
+			// Don't fail the request if cache set fails
+		}
+
+		return data, nil
+	})
 	if err != nil {
-		cm.logger.Error("Source fetch error in read-through", map[string]interface{}{
-			"key":   key,
-			"error": err.Error(),
-		})
 		return err
 	}
 
-	// Store in cache for next time
-	if err := cm.cache.Set(ctx, key, data, expiration); err != nil {
-		cm.logger.Error("Cache set error in read-through", map[string]interface{}{
-			"key":   key,
-			"error": err.Error(),
-		})
-		// Don't fail the request if cache set fails
+	if shared {
+		cm.logger.Debug("Shared in-flight fetch for read-through", map[string]interface{}{"key": key})
+		// Callers must not share (and mutate) the same pointer.
+		if data, err = cloneData(data); err != nil {
+			return err
+		}
 	}
 
 	// Copy data to destination
@@ -154,22 +219,47 @@ func (cm *CacheManager) WriteBehind(ctx context.Context, key string, value inter
 		return err
 	}
 
-	// Write to source asynchronously
-	go func() {
-		if err := writeFunc(value); err != nil {
-			cm.logger.Error("Async source write error in write-behind", map[string]interface{}{
-				"key":   key,
-				"error": err.Error(),
-			})
-			// In production, you might want to retry or queue for later
-		} else {
+	// Write to source asynchronously; failures are retried through the queue
+	metrics.WriteBehindPending.Inc()
+	accepted := cm.writeQueue.Add(&fallback.RetryItem{
+		ID:         "write-behind:" + key,
+		Function:   func() error { return writeFunc(value) },
+		MaxRetries: writeBehindMaxRetries,
+		Interval:   writeBehindRetryInterval,
+		OnDone: func(err error) {
+			metrics.WriteBehindPending.Dec()
+			if err != nil {
+				cm.logger.Error("Write-behind source write failed permanently", map[string]interface{}{
+					"key":   key,
+					"error": err.Error(),
+				})
+				return
+			}
 			cm.logger.Debug("Async write-behind completed", map[string]interface{}{"key": key})
-		}
-	}()
+		},
+	})
+	if accepted {
+		return nil
+	}
+
+	// Queue is full: write synchronously rather than lose the write
+	metrics.WriteBehindPending.Dec()
+	if err := writeFunc(value); err != nil {
+		cm.logger.Error("Source write error in write-behind", map[string]interface{}{
+			"key":   key,
+			"error": err.Error(),
+		})
+		return err
+	}
 
 	return nil
 }
 
+// Flush drains pending write-behind writes, typically on shutdown
+func (cm *CacheManager) Flush(ctx context.Context) error {
+	return cm.writeQueue.Flush(ctx)
+}
+
 // CacheAside implements cache-aside pattern
 func (cm *CacheManager) CacheAside(ctx context.Context, key string, dest interface{}, fetchFunc func() (interface{}, error), expiration time.Duration) error {
 	// Try to get from cache
@@ -216,12 +306,12 @@ func UserCacheKeyByEmail(email string) string {
 	return fmt.Sprintf(UserByEmailKey, email)
 }
 
-func BalanceCacheKey(userID int64) string {
-	return fmt.Sprintf(BalanceByUserKey, userID)
+func BalanceCacheKey(userID int64, currency string) string {
+	return fmt.Sprintf(BalanceByUserKey, userID, currency)
 }
 
-func BalanceHistoryCacheKey(userID int64) string {
-	return fmt.Sprintf(BalanceHistoryKey, userID)
+func BalanceHistoryCacheKey(userID int64, currency string) string {
+	return fmt.Sprintf(BalanceHistoryKey, userID, currency)
 }
 
 func TransactionCacheKey(transactionID int64) string {
@@ -246,23 +336,40 @@ func EventTypeCacheKey(eventType string) string {
 
 // Cache invalidation helpers
 func InvalidateUserCache(ctx context.Context, cache Cache, userID int64) error {
+	if err := invalidateBalanceKeys(ctx, cache, userID); err != nil {
+		return err
+	}
+
 	keys := []string{
 		UserCacheKey(userID),
-		BalanceCacheKey(userID),
-		BalanceHistoryCacheKey(userID),
 		TransactionUserCacheKey(userID),
 		TransactionStatsCacheKey(userID),
 	}
 	return cache.DeleteMultiple(ctx, keys)
 }
 
+// InvalidateBalanceCache drops the cached balances and histories of every currency the user holds
 func InvalidateBalanceCache(ctx context.Context, cache Cache, userID int64) error {
-	keys := []string{
-		BalanceCacheKey(userID),
-		BalanceHistoryCacheKey(userID),
-		TransactionStatsCacheKey(userID),
+	if err := invalidateBalanceKeys(ctx, cache, userID); err != nil {
+		return err
 	}
-	return cache.DeleteMultiple(ctx, keys)
+
+	return cache.Delete(ctx, TransactionStatsCacheKey(userID))
+}
+
+func invalidateBalanceKeys(ctx context.Context, cache Cache, userID int64) error {
+	patterns := []string{
+		BalanceCacheKey(userID, "*"),
+		BalanceHistoryCacheKey(userID, "*"),
+	}
+
+	for _, pattern := range patterns {
+		if err := cache.DeletePattern(ctx, pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func InvalidateTransactionCache(ctx context.Context, cache Cache, userID int64, transactionID int64) error {
@@ -276,20 +383,85 @@ func InvalidateTransactionCache(ctx context.Context, cache Cache, userID int64,
 	return cache.DeleteMultiple(ctx, keys)
 }
 
-// Helper function to copy data between interfaces
+// copyData stores src into the value dest points to. Values of the same type
+// (or a pointer to/from it, e.g. *User into **User) are assigned directly;
+// generic JSON values such as map[string]interface{} are decoded into a fresh
+// value first. dest is left untouched on error.
 func copyData(src, dest interface{}) error {
-	// This is a simple implementation. In production, you might want to use reflection
-	// or a more sophisticated copying mechanism
-	switch d := dest.(type) {
-	case *interface{}:
-		*d = src
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("önbellek hedefi nil olmayan bir pointer olmalı: %T", dest)
+	}
+	target := dv.Elem()
+
+	if src == nil {
+		target.Set(reflect.Zero(target.Type()))
 		return nil
-	default:
-		// For typed destinations, you might need JSON marshal/unmarshal
-		data, err := json.Marshal(src)
-		if err != nil {
-			return err
+	}
+
+	sv := reflect.ValueOf(src)
+	switch {
+	case sv.Type().AssignableTo(target.Type()):
+		target.Set(sv)
+		return nil
+	case sv.Kind() == reflect.Ptr && sv.Type().Elem().AssignableTo(target.Type()):
+		if sv.IsNil() {
+			target.Set(reflect.Zero(target.Type()))
+		} else {
+			target.Set(sv.Elem())
 		}
-		return json.Unmarshal(data, dest)
+		return nil
+	case target.Kind() == reflect.Ptr && sv.Type().AssignableTo(target.Type().Elem()):
+		ptr := reflect.New(target.Type().Elem())
+		ptr.Elem().Set(sv)
+		target.Set(ptr)
+		return nil
+	}
+
+	if !isGenericJSON(src) {
+		return fmt.Errorf("önbellek verisi %T, %T hedefine kopyalanamaz", src, dest)
+	}
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+
+	decoded := reflect.New(target.Type())
+	if err := json.Unmarshal(data, decoded.Interface()); err != nil {
+		return fmt.Errorf("önbellek verisi %T hedefine çözümlenemedi: %w", dest, err)
+	}
+
+	target.Set(decoded.Elem())
+	return nil
+}
+
+// cloneData deep-copies v through a JSON round trip, keeping its type.
+func cloneData(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := reflect.New(reflect.TypeOf(v))
+	if err := json.Unmarshal(data, clone.Interface()); err != nil {
+		return nil, err
+	}
+
+	return clone.Elem().Interface(), nil
+}
+
+// isGenericJSON reports whether v is one of the types encoding/json produces
+// when decoding into interface{}.
+func isGenericJSON(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}, string, float64, bool, json.RawMessage:
+		return true
+	default:
+		return false
 	}
 }
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"payflow/internal/domain"
+)
+
+type cachedUser struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestReadThroughCollapsesConcurrentMisses(t *testing.T) {
+	cm := NewCacheManager(newMemCache(), nil, newTestLogger())
+
+	var fetches atomic.Int64
+	fetch := func() (interface{}, error) {
+		fetches.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		return &cachedUser{ID: 1, Name: "alice"}, nil
+	}
+
+	const callers = 50
+	start := make(chan struct{})
+	errs := make(chan error, callers)
+	results := make([]*cachedUser, callers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs <- cm.ReadThrough(context.Background(), UserCacheKey(1), &results[i], fetch, time.Minute)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fetches.Load() != 1 {
+		t.Fatalf("kaynak bir kez çağrılmalı, çağrı sayısı: %d", fetches.Load())
+	}
+
+	seen := make(map[*cachedUser]bool)
+	for _, user := range results {
+		if user == nil || user.ID != 1 || user.Name != "alice" {
+			t.Fatalf("beklenmeyen sonuç: %+v", user)
+		}
+		if seen[user] {
+			t.Fatal("çağıranlar aynı pointer'ı paylaşmamalı")
+		}
+		seen[user] = true
+	}
+}
+
+func TestCopyData(t *testing.T) {
+	userID := int64(1)
+	user := &domain.User{ID: 1, Username: "alice", Email: "alice@example.com", Role: domain.UserRoleUser}
+	transactions := []*domain.Transaction{
+		{ID: 1, ToUserID: &userID, Amount: domain.NewMoneyFromFloat(10), Currency: "TRY", Type: domain.TransactionTypeDeposit},
+		{ID: 2, FromUserID: &userID, Amount: domain.NewMoneyFromFloat(5), Currency: "TRY", Type: domain.TransactionTypeWithdraw},
+	}
+
+	tests := []struct {
+		name    string
+		src     interface{}
+		dest    func() interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "pointer into value",
+			src:  user,
+			dest: func() interface{} { return new(domain.User) },
+			want: *user,
+		},
+		{
+			name: "pointer into pointer",
+			src:  user,
+			dest: func() interface{} { return new(*domain.User) },
+			want: user,
+		},
+		{
+			name: "value into pointer",
+			src:  *user,
+			dest: func() interface{} { return new(*domain.User) },
+			want: user,
+		},
+		{
+			name: "generic JSON into user",
+			src:  map[string]interface{}{"id": float64(1), "username": "alice", "email": "alice@example.com", "role": "user"},
+			dest: func() interface{} { return new(domain.User) },
+			want: *user,
+		},
+		{
+			name: "nil into user pointer",
+			src:  nil,
+			dest: func() interface{} { return new(*domain.User) },
+			want: (*domain.User)(nil),
+		},
+		{
+			name: "transaction slice",
+			src:  transactions,
+			dest: func() interface{} { return new([]*domain.Transaction) },
+			want: transactions,
+		},
+		{
+			name: "generic JSON into transaction slice",
+			src: []interface{}{
+				map[string]interface{}{"id": float64(1), "to_user_id": float64(1), "amount": "10.00", "currency": "TRY", "type": "deposit"},
+				map[string]interface{}{"id": float64(2), "from_user_id": float64(1), "amount": "5.00", "currency": "TRY", "type": "withdraw"},
+			},
+			dest: func() interface{} { return new([]*domain.Transaction) },
+			want: transactions,
+		},
+		{
+			name: "typed map",
+			src:  map[string]domain.Money{"TRY": domain.NewMoneyFromFloat(10)},
+			dest: func() interface{} { return new(map[string]domain.Money) },
+			want: map[string]domain.Money{"TRY": domain.NewMoneyFromFloat(10)},
+		},
+		{
+			name: "generic JSON into typed map",
+			src:  map[string]interface{}{"TRY": "10.00", "USD": "2.50"},
+			dest: func() interface{} { return new(map[string]domain.Money) },
+			want: map[string]domain.Money{"TRY": domain.NewMoneyFromFloat(10), "USD": domain.NewMoneyFromFloat(2.5)},
+		},
+		{
+			name: "generic map",
+			src:  map[string]interface{}{"count": float64(3)},
+			dest: func() interface{} { return new(map[string]interface{}) },
+			want: map[string]interface{}{"count": float64(3)},
+		},
+		{
+			name:    "mismatched type",
+			src:     transactions,
+			dest:    func() interface{} { return new(domain.User) },
+			want:    domain.User{},
+			wantErr: true,
+		},
+		{
+			name:    "non-pointer destination",
+			src:     user,
+			dest:    func() interface{} { return domain.User{} },
+			want:    domain.User{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := tt.dest()
+			err := copyData(tt.src, dest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("beklenen hata: %v, alınan: %v", tt.wantErr, err)
+			}
+
+			got := dest
+			if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr {
+				got = v.Elem().Interface()
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("beklenen %#v, alınan %#v", tt.want, got)
+			}
+		})
+	}
+}
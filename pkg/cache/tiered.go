@@ -0,0 +1,256 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"payflow/pkg/logger"
+	"payflow/pkg/metrics"
+)
+
+// TieredCache keeps a small in-process LRU (L1) in front of a shared cache
+// (L2). Invalidations only reach the local L1, so other instances may serve a
+// stale entry until its L1 TTL runs out; keep that TTL short.
+type TieredCache struct {
+	l1     *lruCache
+	l2     Cache
+	ttl    *TTLSettings
+	logger logger.Logger
+}
+
+// NewTieredCache wraps l2 with an L1 holding at most size entries for the L1
+// TTL in ttl.
+func NewTieredCache(l2 Cache, size int, ttl *TTLSettings, logger logger.Logger) Cache {
+	return &TieredCache{
+		l1:     newLRUCache(size),
+		l2:     l2,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+func (t *TieredCache) l1TTL(expiration time.Duration) time.Duration {
+	ttl := t.ttl.L1TTL()
+	if expiration > 0 && expiration < ttl {
+		return expiration
+	}
+	return ttl
+}
+
+func (t *TieredCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if err := t.l2.Set(ctx, key, json.RawMessage(data), expiration); err != nil {
+		t.l1.delete(key)
+		return err
+	}
+
+	t.l1.set(key, data, t.l1TTL(expiration))
+	return nil
+}
+
+func (t *TieredCache) Get(ctx context.Context, key string, dest interface{}) error {
+	if data, ok := t.l1.get(key); ok {
+		metrics.RecordCacheHit(tierL1)
+		t.logger.Debug("L1 cache hit", map[string]interface{}{"key": key})
+		return json.Unmarshal(data, dest)
+	}
+	metrics.RecordCacheMiss(tierL1)
+
+	var raw json.RawMessage
+	if err := t.l2.Get(ctx, key, &raw); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(raw, dest); err != nil {
+		return err
+	}
+
+	t.l1.set(key, raw, t.ttl.L1TTL())
+	return nil
+}
+
+func (t *TieredCache) Delete(ctx context.Context, key string) error {
+	err := t.l2.Delete(ctx, key)
+	t.l1.delete(key)
+	return err
+}
+
+func (t *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if _, ok := t.l1.get(key); ok {
+		return true, nil
+	}
+	return t.l2.Exists(ctx, key)
+}
+
+func (t *TieredCache) DeletePattern(ctx context.Context, pattern string) error {
+	err := t.l2.DeletePattern(ctx, pattern)
+	t.l1.deleteFunc(func(key string) bool {
+		matched, matchErr := path.Match(pattern, key)
+		// An invalid pattern clears L1 rather than risk keeping stale entries.
+		return matched || matchErr != nil
+	})
+	return err
+}
+
+func (t *TieredCache) GetKeys(ctx context.Context, pattern string, limit int) ([]string, error) {
+	return t.l2.GetKeys(ctx, pattern, limit)
+}
+
+func (t *TieredCache) SetMultiple(ctx context.Context, items map[string]interface{}, expiration time.Duration) error {
+	encoded := make(map[string][]byte, len(items))
+	values := make(map[string]interface{}, len(items))
+	for key, value := range items {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		encoded[key] = data
+		values[key] = json.RawMessage(data)
+	}
+
+	if err := t.l2.SetMultiple(ctx, values, expiration); err != nil {
+		for key := range items {
+			t.l1.delete(key)
+		}
+		return err
+	}
+
+	for key, data := range encoded {
+		t.l1.set(key, data, t.l1TTL(expiration))
+	}
+	return nil
+}
+
+func (t *TieredCache) GetMultiple(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	return t.l2.GetMultiple(ctx, keys)
+}
+
+func (t *TieredCache) GetMultipleInto(ctx context.Context, keys []string, dest interface{}) error {
+	return t.l2.GetMultipleInto(ctx, keys, dest)
+}
+
+func (t *TieredCache) DeleteMultiple(ctx context.Context, keys []string) error {
+	err := t.l2.DeleteMultiple(ctx, keys)
+	for _, key := range keys {
+		t.l1.delete(key)
+	}
+	return err
+}
+
+func (t *TieredCache) WarmUp(ctx context.Context, warmUpFunc func(ctx context.Context) error) error {
+	return t.l2.WarmUp(ctx, warmUpFunc)
+}
+
+func (t *TieredCache) InvalidatePrefix(ctx context.Context, prefix string) error {
+	err := t.l2.InvalidatePrefix(ctx, prefix)
+	t.l1.deleteFunc(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+	return err
+}
+
+func (t *TieredCache) Ping(ctx context.Context) error {
+	return t.l2.Ping(ctx)
+}
+
+type lruEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+// lruCache is a fixed-size, mutex-guarded LRU with per-entry expiry.
+type lruCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newLRUCache(size int) *lruCache {
+	if size < 1 {
+		size = 1
+	}
+
+	return &lruCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *lruCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.data, true
+}
+
+func (c *lruCache) set(key string, data []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.data = data
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, data: data, expiresAt: expiresAt})
+	if c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *lruCache) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *lruCache) deleteFunc(match func(key string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.entries {
+		if match(key) {
+			c.removeElement(elem)
+		}
+	}
+}
+
+func (c *lruCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// TTLSettings holds the expiration settings that may change at runtime. It is
+// shared, so a change applies to every cache built with it at once.
+type TTLSettings struct {
+	l1TTL  atomic.Int64
+	jitter atomic.Uint64
+}
+
+// NewTTLSettings returns settings with the given L1 TTL and Redis expiration
+// jitter; see SetJitter.
+func NewTTLSettings(l1TTL time.Duration, jitter float64) *TTLSettings {
+	s := &TTLSettings{}
+	s.SetL1TTL(l1TTL)
+	s.SetJitter(jitter)
+	return s
+}
+
+func (s *TTLSettings) L1TTL() time.Duration {
+	return time.Duration(s.l1TTL.Load())
+}
+
+func (s *TTLSettings) SetL1TTL(ttl time.Duration) {
+	s.l1TTL.Store(int64(ttl))
+}
+
+func (s *TTLSettings) Jitter() float64 {
+	return math.Float64frombits(s.jitter.Load())
+}
+
+// SetJitter sets the random ±fraction (e.g. 0.1 = ±10%) applied to Redis
+// expirations, clamped to [0, 1]; 0 disables it.
+func (s *TTLSettings) SetJitter(jitter float64) {
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	s.jitter.Store(math.Float64bits(jitter))
+}
@@ -10,6 +10,30 @@ import (
 	"payflow/pkg/logger"
 )
 
+// Sizes of the cached dashboard lists
+const (
+	recentTransactionsLimit = 20
+	topUsersListLimit       = 10
+)
+
+// DashboardStats is cached under DashboardStatsKey
+type DashboardStats struct {
+	TotalUsers        int64                   `json:"total_users"`
+	TotalTransactions int64                   `json:"total_transactions"`
+	TotalVolume       map[string]domain.Money `json:"total_volume"`
+	ActiveUsersToday  int64                   `json:"active_users_today"`
+	UpdatedAt         time.Time               `json:"updated_at"`
+}
+
+// TopUser is an entry of the list cached under TopUsersKey
+type TopUser struct {
+	ID       int64        `json:"id"`
+	Username string       `json:"username"`
+	Balance  domain.Money `json:"balance"`
+	Currency string       `json:"currency"`
+	Rank     int          `json:"rank"`
+}
+
 // WarmUpManager handles cache warming strategies
 type WarmUpManager struct {
 	cache          Cache
@@ -17,22 +41,36 @@ type WarmUpManager struct {
 	userService    domain.UserService
 	balanceService domain.BalanceService
 	txService      domain.TransactionService
+
+	userRepo        domain.UserRepository
+	balanceRepo     domain.BalanceRepository
+	txRepo          domain.TransactionRepository
+	defaultCurrency string
 }
 
-// NewWarmUpManager creates a new warm-up manager
+// NewWarmUpManager creates a new warm-up manager; users are ranked by their
+// balance in defaultCurrency
 func NewWarmUpManager(
 	cache Cache,
 	logger logger.Logger,
 	userService domain.UserService,
 	balanceService domain.BalanceService,
 	txService domain.TransactionService,
+	userRepo domain.UserRepository,
+	balanceRepo domain.BalanceRepository,
+	txRepo domain.TransactionRepository,
+	defaultCurrency string,
 ) *WarmUpManager {
 	return &WarmUpManager{
-		cache:          cache,
-		logger:         logger,
-		userService:    userService,
-		balanceService: balanceService,
-		txService:      txService,
+		cache:           cache,
+		logger:          logger,
+		userService:     userService,
+		balanceService:  balanceService,
+		txService:       txService,
+		userRepo:        userRepo,
+		balanceRepo:     balanceRepo,
+		txRepo:          txRepo,
+		defaultCurrency: defaultCurrency,
 	}
 }
 
@@ -88,16 +126,19 @@ func (w *WarmUpManager) WarmUpUserData(ctx context.Context, userID int64) error
 	return nil
 }
 
-// WarmUpTopUsers warms up data for top active users
+// WarmUpTopUsers warms up data for the users with the highest balances
 func (w *WarmUpManager) WarmUpTopUsers(ctx context.Context, limit int) error {
 	w.logger.Info("Top users warm-up başlatılıyor", map[string]interface{}{"limit": limit})
 
-	// For this example, we'll warm up users with IDs 1 to limit
-	// In production, you'd fetch actual top user IDs from database
+	balances, err := w.balanceRepo.FindTopByBalance(ctx, w.defaultCurrency, limit)
+	if err != nil {
+		return fmt.Errorf("top users alınamadı: %w", err)
+	}
+
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, 5) // Limit concurrent warm-ups
 
-	for i := int64(1); i <= int64(limit); i++ {
+	for _, balance := range balances {
 		wg.Add(1)
 		go func(userID int64) {
 			defer wg.Done()
@@ -110,11 +151,11 @@ func (w *WarmUpManager) WarmUpTopUsers(ctx context.Context, limit int) error {
 					"error":  err.Error(),
 				})
 			}
-		}(i)
+		}(balance.UserID)
 	}
 
 	wg.Wait()
-	w.logger.Info("Top users warm-up tamamlandı", map[string]interface{}{"limit": limit})
+	w.logger.Info("Top users warm-up tamamlandı", map[string]interface{}{"limit": limit, "users": len(balances)})
 	return nil
 }
 
@@ -229,21 +270,22 @@ func (w *WarmUpManager) warmUpUser(ctx context.Context, userID int64) error {
 
 // warmUpBalance warms up balance cache
 func (w *WarmUpManager) warmUpBalance(ctx context.Context, userID int64) error {
-	balance, err := w.balanceService.GetBalance(userID)
+	// An empty currency resolves to the default currency, matching requests that omit it
+	balance, err := w.balanceService.GetBalance(ctx, userID, "")
 	if err != nil {
 		return err
 	}
 
 	// Cache current balance
-	key := BalanceCacheKey(userID)
+	key := BalanceCacheKey(userID, "")
 	if err := w.cache.Set(ctx, key, balance, MediumExpiration); err != nil {
 		return err
 	}
 
 	// Cache balance history
-	history, err := w.balanceService.GetBalanceHistory(userID, time.Now().Add(-30*24*time.Hour), time.Now())
+	history, err := w.balanceService.GetBalanceHistory(ctx, userID, "", time.Now().Add(-30*24*time.Hour), time.Now())
 	if err == nil {
-		historyKey := BalanceHistoryCacheKey(userID)
+		historyKey := BalanceHistoryCacheKey(userID, "")
 		if err := w.cache.Set(ctx, historyKey, history, LongExpiration); err != nil {
 			w.logger.Error("Balance history cache set hatası", map[string]interface{}{
 				"userID": userID,
@@ -259,7 +301,7 @@ func (w *WarmUpManager) warmUpBalance(ctx context.Context, userID int64) error {
 // warmUpTransactions warms up transaction cache
 func (w *WarmUpManager) warmUpTransactions(ctx context.Context, userID int64) error {
 	// Cache recent user transactions
-	transactions, err := w.txService.GetUserTransactions(userID)
+	transactions, err := w.txService.GetUserTransactions(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -286,13 +328,24 @@ func (w *WarmUpManager) warmUpTransactions(ctx context.Context, userID int64) er
 
 // warmUpDashboardStats warms up dashboard statistics
 func (w *WarmUpManager) warmUpDashboardStats(ctx context.Context) error {
-	// Mock dashboard stats - in production, you'd fetch real stats
-	stats := map[string]interface{}{
-		"total_users":        1000,
-		"total_transactions": 5000,
-		"total_volume":       1000000.0,
-		"active_users_today": 150,
-		"updated_at":         time.Now(),
+	totalUsers, err := w.userRepo.Count("")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	summary, err := w.txRepo.Summarize(ctx, startOfDay)
+	if err != nil {
+		return err
+	}
+
+	stats := DashboardStats{
+		TotalUsers:        totalUsers,
+		TotalTransactions: summary.TotalTransactions,
+		TotalVolume:       summary.CompletedVolume,
+		ActiveUsersToday:  summary.ActiveUsers,
+		UpdatedAt:         now,
 	}
 
 	if err := w.cache.Set(ctx, DashboardStatsKey, stats, ShortExpiration); err != nil {
@@ -305,33 +358,49 @@ func (w *WarmUpManager) warmUpDashboardStats(ctx context.Context) error {
 
 // warmUpRecentTransactions warms up recent transactions list
 func (w *WarmUpManager) warmUpRecentTransactions(ctx context.Context) error {
-	// Mock recent transactions - in production, you'd fetch real recent transactions
-	recentTxs := []map[string]interface{}{
-		{"id": 1, "amount": 100.0, "type": "deposit", "timestamp": time.Now()},
-		{"id": 2, "amount": 50.0, "type": "withdrawal", "timestamp": time.Now().Add(-time.Hour)},
+	recentTxs, err := w.txRepo.FindRecent(ctx, recentTransactionsLimit)
+	if err != nil {
+		return err
 	}
 
 	if err := w.cache.Set(ctx, RecentTransactionsKey, recentTxs, ShortExpiration); err != nil {
 		return err
 	}
 
-	w.logger.Debug("Recent transactions cache warmed up", map[string]interface{}{})
+	w.logger.Debug("Recent transactions cache warmed up", map[string]interface{}{"count": len(recentTxs)})
 	return nil
 }
 
-// warmUpTopUsersList warms up top users list
+// warmUpTopUsersList warms up the list of users with the highest balances
 func (w *WarmUpManager) warmUpTopUsersList(ctx context.Context) error {
-	// Mock top users - in production, you'd fetch real top users by activity/balance
-	topUsers := []map[string]interface{}{
-		{"id": 1, "username": "user1", "balance": 1000.0, "rank": 1},
-		{"id": 2, "username": "user2", "balance": 800.0, "rank": 2},
-		{"id": 3, "username": "user3", "balance": 600.0, "rank": 3},
+	balances, err := w.balanceRepo.FindTopByBalance(ctx, w.defaultCurrency, topUsersListLimit)
+	if err != nil {
+		return err
+	}
+
+	topUsers := make([]TopUser, 0, len(balances))
+	for _, balance := range balances {
+		user, err := w.userRepo.FindByID(balance.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			continue
+		}
+
+		topUsers = append(topUsers, TopUser{
+			ID:       user.ID,
+			Username: user.Username,
+			Balance:  balance.Amount,
+			Currency: balance.Currency,
+			Rank:     len(topUsers) + 1,
+		})
 	}
 
 	if err := w.cache.Set(ctx, TopUsersKey, topUsers, MediumExpiration); err != nil {
 		return err
 	}
 
-	w.logger.Debug("Top users cache warmed up", map[string]interface{}{})
+	w.logger.Debug("Top users cache warmed up", map[string]interface{}{"count": len(topUsers)})
 	return nil
 }
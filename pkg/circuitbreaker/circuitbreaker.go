@@ -1,9 +1,12 @@
 package circuitbreaker
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"time"
+
+	"payflow/pkg/metrics"
 )
 
 var (
@@ -32,6 +35,17 @@ func (s State) String() string {
 	}
 }
 
+// TripPolicy selects how a closed breaker decides to open.
+type TripPolicy int
+
+const (
+	// TripOnConsecutiveFailures consults ReadyToTrip after every failure.
+	TripOnConsecutiveFailures TripPolicy = iota
+	// TripOnFailureRate opens once the failure rate over the last WindowSize
+	// requests of the current interval reaches FailureRateThreshold.
+	TripOnFailureRate
+)
+
 type Settings struct {
 	Name          string
 	MaxRequests   uint32
@@ -40,6 +54,12 @@ type Settings struct {
 	ReadyToTrip   func(counts Counts) bool
 	OnStateChange func(name string, from State, to State)
 	IsSuccessful  func(err error) bool
+	// Registry, when set, receives the breaker from New.
+	Registry *Registry
+
+	TripPolicy           TripPolicy
+	WindowSize           uint32
+	FailureRateThreshold float64
 }
 
 type Counts struct {
@@ -88,6 +108,12 @@ type CircuitBreaker struct {
 	generation uint64
 	counts     Counts
 	expiry     time.Time
+	// forced pins the state set by ForceOpen or ForceClose until Reset.
+	forced bool
+
+	// window is only set for TripOnFailureRate.
+	window               *outcomeWindow
+	failureRateThreshold float64
 }
 
 func New(st Settings) *CircuitBreaker {
@@ -121,19 +147,56 @@ func New(st Settings) *CircuitBreaker {
 		cb.isSuccessful = defaultIsSuccessful
 	}
 
+	if st.TripPolicy == TripOnFailureRate {
+		if st.WindowSize == 0 {
+			st.WindowSize = defaultWindowSize
+		}
+		cb.window = newOutcomeWindow(st.WindowSize)
+
+		cb.failureRateThreshold = st.FailureRateThreshold
+		if cb.failureRateThreshold <= 0 || cb.failureRateThreshold > 1 {
+			cb.failureRateThreshold = defaultFailureRateThreshold
+		}
+	}
+
 	cb.toNewGeneration(time.Now())
+	metrics.SetCircuitBreakerState(cb.name, int(cb.state))
+
+	if st.Registry != nil {
+		st.Registry.Register(cb)
+	}
 
 	return cb
 }
 
+const (
+	defaultWindowSize           = 20
+	defaultFailureRateThreshold = 0.5
+)
+
 func defaultReadyToTrip(counts Counts) bool {
 	return counts.ConsecutiveFailures > 5
 }
 
+// ConsecutiveFailuresOver is a ReadyToTrip that opens the breaker once
+// consecutive failures exceed threshold.
+func ConsecutiveFailuresOver(threshold int) func(counts Counts) bool {
+	return func(counts Counts) bool {
+		return counts.ConsecutiveFailures > uint32(threshold)
+	}
+}
+
 func defaultIsSuccessful(err error) bool {
 	return err == nil
 }
 
+// IgnoreContextErrors is an IsSuccessful that does not count cancelled or
+// timed-out contexts as failures, so callers going away (for example while a
+// deploy drains requests) cannot trip the breaker.
+func IgnoreContextErrors(err error) bool {
+	return err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
 func (cb *CircuitBreaker) Execute(req func() (interface{}, error)) (interface{}, error) {
 	generation, err := cb.beforeRequest()
 	if err != nil {
@@ -153,6 +216,19 @@ func (cb *CircuitBreaker) Execute(req func() (interface{}, error)) (interface{},
 	return result, err
 }
 
+// ExecuteContext is Execute for requests bound to ctx. A ctx that is already
+// done is returned as is without reaching the breaker; req receives ctx and is
+// expected to honour its cancellation.
+func (cb *CircuitBreaker) ExecuteContext(ctx context.Context, req func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return cb.Execute(func() (interface{}, error) {
+		return req(ctx)
+	})
+}
+
 func (cb *CircuitBreaker) Call(req func() (interface{}, error)) (interface{}, error) {
 	return cb.Execute(req)
 }
@@ -194,6 +270,14 @@ func (cb *CircuitBreaker) afterRequest(before uint64, success bool) {
 func (cb *CircuitBreaker) onSuccess(state State, now time.Time) {
 	cb.counts.onSuccess()
 
+	if cb.forced {
+		return
+	}
+
+	if state == StateClosed && cb.window != nil {
+		cb.window.record(false)
+	}
+
 	if state == StateHalfOpen && cb.counts.ConsecutiveSuccesses >= cb.maxRequests {
 		cb.setState(StateClosed, now)
 	}
@@ -202,12 +286,32 @@ func (cb *CircuitBreaker) onSuccess(state State, now time.Time) {
 func (cb *CircuitBreaker) onFailure(state State, now time.Time) {
 	cb.counts.onFailure()
 
-	if cb.readyToTrip(cb.counts) {
+	if cb.forced {
+		return
+	}
+
+	// A single failed probe while half-open means the dependency is still
+	// unhealthy; reopen without consulting the trip policy.
+	if state == StateHalfOpen || cb.shouldTrip() {
 		cb.setState(StateOpen, now)
 	}
 }
 
+func (cb *CircuitBreaker) shouldTrip() bool {
+	if cb.window == nil {
+		return cb.readyToTrip(cb.counts)
+	}
+
+	cb.window.record(true)
+	rate, ok := cb.window.failureRate()
+	return ok && rate >= cb.failureRateThreshold
+}
+
 func (cb *CircuitBreaker) currentState(now time.Time) (State, uint64) {
+	if cb.forced {
+		return cb.state, cb.generation
+	}
+
 	switch cb.state {
 	case StateClosed:
 		if !cb.expiry.IsZero() && cb.expiry.Before(now) {
@@ -231,6 +335,11 @@ func (cb *CircuitBreaker) setState(state State, now time.Time) {
 
 	cb.toNewGeneration(now)
 
+	metrics.SetCircuitBreakerState(cb.name, int(state))
+	if state == StateOpen {
+		metrics.RecordCircuitBreakerTrip(cb.name)
+	}
+
 	if cb.onStateChange != nil {
 		cb.onStateChange(cb.name, prev, state)
 	}
@@ -239,6 +348,9 @@ func (cb *CircuitBreaker) setState(state State, now time.Time) {
 func (cb *CircuitBreaker) toNewGeneration(now time.Time) {
 	cb.generation++
 	cb.counts.clear()
+	if cb.window != nil {
+		cb.window.clear()
+	}
 
 	var zero time.Time
 	switch cb.state {
@@ -274,3 +386,75 @@ func (cb *CircuitBreaker) Counts() Counts {
 
 	return cb.counts
 }
+
+// Forced reports whether the state is pinned by ForceOpen or ForceClose.
+func (cb *CircuitBreaker) Forced() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	return cb.forced
+}
+
+// UpdateThresholds replaces the half-open request limit, the open timeout and
+// the trip condition; zero values keep the current setting. State and counts
+// are kept, a new timeout applies the next time the breaker opens.
+func (cb *CircuitBreaker) UpdateThresholds(maxRequests uint32, timeout time.Duration, readyToTrip func(counts Counts) bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if maxRequests > 0 {
+		cb.maxRequests = maxRequests
+	}
+	if timeout > 0 {
+		cb.timeout = timeout
+	}
+	if readyToTrip != nil {
+		cb.readyToTrip = readyToTrip
+	}
+}
+
+// Reset clears a forced state, closes the breaker and clears its counts.
+func (cb *CircuitBreaker) Reset() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.forced = false
+
+	now := time.Now()
+	if cb.state == StateClosed {
+		cb.toNewGeneration(now)
+		return
+	}
+	cb.setState(StateClosed, now)
+}
+
+// Trip opens the breaker; unlike ForceOpen it moves to half-open after the
+// usual timeout.
+func (cb *CircuitBreaker) Trip() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.forced = false
+	cb.setState(StateOpen, time.Now())
+}
+
+// ForceOpen opens the breaker and keeps it open, rejecting every request,
+// until Reset is called.
+func (cb *CircuitBreaker) ForceOpen() {
+	cb.force(StateOpen)
+}
+
+// ForceClose closes the breaker and keeps it closed regardless of failures
+// until Reset is called.
+func (cb *CircuitBreaker) ForceClose() {
+	cb.force(StateClosed)
+}
+
+func (cb *CircuitBreaker) force(state State) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.forced = false
+	cb.setState(state, time.Now())
+	cb.forced = true
+}
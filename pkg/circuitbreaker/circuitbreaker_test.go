@@ -0,0 +1,130 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+var errBackend = errors.New("backend hatası")
+
+func succeed() (interface{}, error) { return nil, nil }
+func fail() (interface{}, error)    { return nil, errBackend }
+
+func newTestBreaker(t *testing.T, transitions *[]State) *CircuitBreaker {
+	t.Helper()
+
+	return New(Settings{
+		Name:        t.Name(),
+		MaxRequests: 2,
+		Timeout:     20 * time.Millisecond,
+		ReadyToTrip: func(counts Counts) bool { return counts.ConsecutiveFailures >= 3 },
+		OnStateChange: func(name string, from, to State) {
+			*transitions = append(*transitions, to)
+		},
+	})
+}
+
+func expectState(t *testing.T, cb *CircuitBreaker, want State) {
+	t.Helper()
+
+	if got := cb.State(); got != want {
+		t.Fatalf("beklenen durum %s, alınan %s", want, got)
+	}
+}
+
+func TestClosedOpenHalfOpenClosed(t *testing.T) {
+	var transitions []State
+	cb := newTestBreaker(t, &transitions)
+	expectState(t, cb, StateClosed)
+
+	for i := 0; i < 3; i++ {
+		cb.Execute(fail)
+	}
+	expectState(t, cb, StateOpen)
+
+	if _, err := cb.Execute(succeed); !errors.Is(err, ErrCircuitBreakerOpen) {
+		t.Fatalf("açık devre istekleri reddetmeli, alınan: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	expectState(t, cb, StateHalfOpen)
+
+	// MaxRequests probes are let through; the next one is rejected until
+	// they have all succeeded.
+	generation, err := cb.beforeRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cb.Execute(succeed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cb.Execute(succeed); !errors.Is(err, ErrTooManyRequests) {
+		t.Fatalf("yarı açık devre fazla denemeyi reddetmeli, alınan: %v", err)
+	}
+	cb.afterRequest(generation, true)
+	expectState(t, cb, StateClosed)
+
+	want := []State{StateOpen, StateHalfOpen, StateClosed}
+	if !slices.Equal(transitions, want) {
+		t.Fatalf("beklenen geçişler %v, alınan %v", want, transitions)
+	}
+}
+
+func TestHalfOpenFailureReopens(t *testing.T) {
+	var transitions []State
+	cb := newTestBreaker(t, &transitions)
+
+	for i := 0; i < 3; i++ {
+		cb.Execute(fail)
+	}
+	time.Sleep(30 * time.Millisecond)
+	expectState(t, cb, StateHalfOpen)
+
+	cb.Execute(fail)
+	expectState(t, cb, StateOpen)
+
+	if _, err := cb.Execute(succeed); !errors.Is(err, ErrCircuitBreakerOpen) {
+		t.Fatalf("yeniden açılan devre istekleri reddetmeli, alınan: %v", err)
+	}
+
+	want := []State{StateOpen, StateHalfOpen, StateOpen}
+	if !slices.Equal(transitions, want) {
+		t.Fatalf("beklenen geçişler %v, alınan %v", want, transitions)
+	}
+}
+
+func TestFailureRateTripsOnIntermittentFailures(t *testing.T) {
+	consecutive := New(Settings{Name: t.Name() + "/consecutive"})
+	rate := New(Settings{
+		Name:                 t.Name() + "/rate",
+		TripPolicy:           TripOnFailureRate,
+		WindowSize:           10,
+		FailureRateThreshold: 0.5,
+	})
+
+	// Every other call fails, so there are never two failures in a row.
+	var calls int
+	flaky := func() (interface{}, error) {
+		calls++
+		if calls%2 == 0 {
+			return fail()
+		}
+		return succeed()
+	}
+
+	for i := 0; i < 100; i++ {
+		consecutive.Execute(flaky)
+	}
+	expectState(t, consecutive, StateClosed)
+
+	calls = 0
+	for i := 0; i < 100; i++ {
+		rate.Execute(flaky)
+	}
+	expectState(t, rate, StateOpen)
+	if calls != 10 {
+		t.Fatalf("devre pencere dolunca açılmalı, geçen istek: %d", calls)
+	}
+}
@@ -0,0 +1,102 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"payflow/pkg/metrics"
+)
+
+var ErrNotFound = errors.New("circuit breaker not found")
+
+// DefaultRegistry holds the application's breakers so they can be inspected
+// and controlled from one place.
+var DefaultRegistry = NewRegistry()
+
+// Registry indexes circuit breakers by name. Registering a name again
+// replaces the previous breaker.
+type Registry struct {
+	mutex    sync.RWMutex
+	breakers map[string]*CircuitBreaker
+}
+
+func NewRegistry() *Registry {
+	return &Registry{breakers: make(map[string]*CircuitBreaker)}
+}
+
+func (r *Registry) Register(cb *CircuitBreaker) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.breakers[cb.name] = cb
+}
+
+// Unregister removes cb, leaving any other breaker registered under its name.
+func (r *Registry) Unregister(cb *CircuitBreaker) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.breakers[cb.name] == cb {
+		delete(r.breakers, cb.name)
+		metrics.RemoveCircuitBreaker(cb.name)
+	}
+}
+
+func (r *Registry) Get(name string) (*CircuitBreaker, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	cb, ok := r.breakers[name]
+	return cb, ok
+}
+
+// All returns the registered breakers ordered by name.
+func (r *Registry) All() []*CircuitBreaker {
+	r.mutex.RLock()
+	breakers := make([]*CircuitBreaker, 0, len(r.breakers))
+	for _, cb := range r.breakers {
+		breakers = append(breakers, cb)
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(breakers, func(i, j int) bool {
+		return breakers[i].name < breakers[j].name
+	})
+	return breakers
+}
+
+func (r *Registry) Reset(name string) error {
+	return r.apply(name, (*CircuitBreaker).Reset)
+}
+
+func (r *Registry) Trip(name string) error {
+	return r.apply(name, (*CircuitBreaker).Trip)
+}
+
+func (r *Registry) ForceOpen(name string) error {
+	return r.apply(name, (*CircuitBreaker).ForceOpen)
+}
+
+func (r *Registry) ForceClose(name string) error {
+	return r.apply(name, (*CircuitBreaker).ForceClose)
+}
+
+// UpdateThresholds applies CircuitBreaker.UpdateThresholds to every
+// registered breaker.
+func (r *Registry) UpdateThresholds(maxRequests uint32, timeout time.Duration, readyToTrip func(counts Counts) bool) {
+	for _, cb := range r.All() {
+		cb.UpdateThresholds(maxRequests, timeout, readyToTrip)
+	}
+}
+
+func (r *Registry) apply(name string, fn func(cb *CircuitBreaker)) error {
+	cb, ok := r.Get(name)
+	if !ok {
+		return ErrNotFound
+	}
+
+	fn(cb)
+	return nil
+}
@@ -0,0 +1,32 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistryUpdateThresholdsAppliesToEveryBreaker(t *testing.T) {
+	registry := NewRegistry()
+
+	var breakers []*CircuitBreaker
+	for _, name := range []string{"database", "backend-a", "backend-b"} {
+		breakers = append(breakers, New(Settings{
+			Name:        name,
+			MaxRequests: 3,
+			Timeout:     30 * time.Second,
+			ReadyToTrip: ConsecutiveFailuresOver(2),
+			Registry:    registry,
+		}))
+	}
+
+	registry.UpdateThresholds(5, time.Minute, ConsecutiveFailuresOver(0))
+
+	for _, cb := range breakers {
+		if cb.maxRequests != 5 || cb.timeout != time.Minute {
+			t.Fatalf("%s yeni eşikleri almalı, alınan: %d / %s", cb.name, cb.maxRequests, cb.timeout)
+		}
+
+		cb.Execute(fail)
+		expectState(t, cb, StateOpen)
+	}
+}
@@ -0,0 +1,48 @@
+package circuitbreaker
+
+// outcomeWindow remembers whether each of the last len(failed) requests
+// failed, overwriting the oldest once full.
+type outcomeWindow struct {
+	failed   []bool
+	next     int
+	filled   int
+	failures int
+}
+
+func newOutcomeWindow(size uint32) *outcomeWindow {
+	return &outcomeWindow{failed: make([]bool, size)}
+}
+
+func (w *outcomeWindow) record(failed bool) {
+	if w.filled == len(w.failed) {
+		if w.failed[w.next] {
+			w.failures--
+		}
+	} else {
+		w.filled++
+	}
+
+	w.failed[w.next] = failed
+	if failed {
+		w.failures++
+	}
+	w.next = (w.next + 1) % len(w.failed)
+}
+
+// failureRate is only reported once the window is full, so a handful of early
+// failures cannot trip the breaker.
+func (w *outcomeWindow) failureRate() (float64, bool) {
+	if w.filled < len(w.failed) {
+		return 0, false
+	}
+	return float64(w.failures) / float64(w.filled), true
+}
+
+func (w *outcomeWindow) clear() {
+	for i := range w.failed {
+		w.failed[i] = false
+	}
+	w.next = 0
+	w.filled = 0
+	w.failures = 0
+}
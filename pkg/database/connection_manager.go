@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"payflow/internal/config"
@@ -13,16 +14,42 @@ import (
 	"payflow/pkg/logger"
 
 	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
 )
 
+// rywTimeout bounds the Redis round trip on the read path; when it is
+// exceeded the read goes to the master rather than risking a stale replica.
+const rywTimeout = 100 * time.Millisecond
+
+// Router hands out the master for writes and a read replica, or the master
+// when none is healthy, for reads. Repositories only send reads that tolerate
+// replication lag to GetReadDB; reads that feed a write or must see the
+// caller's own writes stay on the master.
+//
+// GetReadDBForUser adds read-your-writes on top of GetReadDB: for a short
+// window after MarkUserWrite the user's reads are served by the master, so a
+// user never sees a replica that has not caught up with their own change.
+// Other users may still observe that change late.
+type Router interface {
+	GetWriteDB() *sql.DB
+	GetReadDB() *sql.DB
+	GetReadDBForUser(userID int64) *sql.DB
+	MarkUserWrite(userID int64)
+	Dialect() Dialect
+}
+
 type ConnectionManager struct {
+	dialect        Dialect
 	masterDB       *sql.DB
 	readDBs        []*ReadReplica
 	logger         logger.Logger
 	circuitBreaker *circuitbreaker.CircuitBreaker
 	mutex          sync.RWMutex
 
-	roundRobinIndex int
+	roundRobinIndex uint64
+
+	redisClient    redis.UniversalClient
+	readYourWrites time.Duration
 }
 
 type ReadReplica struct {
@@ -32,20 +59,27 @@ type ReadReplica struct {
 	mutex     sync.RWMutex
 }
 
-func NewConnectionManager(cfg *config.Config, logger logger.Logger) (*ConnectionManager, error) {
+// NewConnectionManager connects to the master and the read replicas. The
+// replica health check runs until ctx is done.
+func NewConnectionManager(ctx context.Context, cfg *config.Config, logger logger.Logger) (*ConnectionManager, error) {
+	dialect, err := NewDialect(cfg.Database.Driver)
+	if err != nil {
+		return nil, err
+	}
+
 	cm := &ConnectionManager{
-		logger:          logger,
-		roundRobinIndex: 0,
+		dialect: dialect,
+		logger:  logger,
 	}
 
 	cm.circuitBreaker = circuitbreaker.New(circuitbreaker.Settings{
-		Name:        "database",
-		MaxRequests: 3,
-		Interval:    time.Minute,
-		Timeout:     30 * time.Second,
-		ReadyToTrip: func(counts circuitbreaker.Counts) bool {
-			return counts.ConsecutiveFailures > 3
-		},
+		Name:         "database",
+		MaxRequests:  uint32(cfg.CircuitBreaker.MaxRequests),
+		Interval:     time.Minute,
+		Timeout:      cfg.CircuitBreaker.Timeout,
+		ReadyToTrip:  circuitbreaker.ConsecutiveFailuresOver(cfg.CircuitBreaker.FailureThreshold),
+		IsSuccessful: circuitbreaker.IgnoreContextErrors,
+		Registry:     circuitbreaker.DefaultRegistry,
 		OnStateChange: func(name string, from circuitbreaker.State, to circuitbreaker.State) {
 			logger.InfoContext(context.Background(), "Circuit breaker state changed", map[string]interface{}{
 				"name": name,
@@ -59,20 +93,23 @@ func NewConnectionManager(cfg *config.Config, logger logger.Logger) (*Connection
 		return nil, fmt.Errorf("master veritabanı bağlantısı başarısız: %w", err)
 	}
 
-	if err := cm.connectReadReplicas(cfg.Database.ReadReplicas); err != nil {
-		logger.Error("Read replica bağlantıları başarısız", map[string]interface{}{"error": err.Error()})
+	if dialect.Name() == DriverSQLite {
+		if len(cfg.Database.ReadReplicas) > 0 {
+			logger.Warn("SQLite read replica desteklemiyor, replica ayarları yok sayıldı", nil)
+		}
+	} else if err := cm.connectReadReplicas(cfg.Database.ReadReplicas); err != nil {
+		logger.ErrorWithErr("Read replica bağlantıları başarısız", err, nil)
 	}
 
-	go cm.startHealthCheck()
+	go cm.startHealthCheck(ctx)
 
 	return cm, nil
 }
 
 func (cm *ConnectionManager) connectMaster(cfg config.DatabaseConfig) error {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
+	dsn := cm.dialect.DSN(cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
 
-	db, err := sql.Open("postgres", dsn)
+	db, err := sql.Open(cm.dialect.DriverName(), dsn)
 	if err != nil {
 		return err
 	}
@@ -98,10 +135,9 @@ func (cm *ConnectionManager) connectReadReplicas(replicas []config.ReplicaConfig
 	cm.readDBs = make([]*ReadReplica, 0, len(replicas))
 
 	for _, replicaCfg := range replicas {
-		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-			replicaCfg.Host, replicaCfg.Port, replicaCfg.User, replicaCfg.Password, replicaCfg.Name, replicaCfg.SSLMode)
+		dsn := cm.dialect.DSN(replicaCfg.Host, replicaCfg.Port, replicaCfg.User, replicaCfg.Password, replicaCfg.Name, replicaCfg.SSLMode)
 
-		db, err := sql.Open("postgres", dsn)
+		db, err := sql.Open(cm.dialect.DriverName(), dsn)
 		if err != nil {
 			cm.logger.Error("Read replica bağlantısı başarısız", map[string]interface{}{
 				"host":  replicaCfg.Host,
@@ -145,6 +181,10 @@ func (cm *ConnectionManager) connectReadReplicas(replicas []config.ReplicaConfig
 	return nil
 }
 
+func (cm *ConnectionManager) Dialect() Dialect {
+	return cm.dialect
+}
+
 func (cm *ConnectionManager) GetWriteDB() *sql.DB {
 	return cm.masterDB
 }
@@ -154,14 +194,84 @@ func (cm *ConnectionManager) GetReadDB() *sql.DB {
 		return replica.DB
 	}
 
-	cm.logger.Warn("Hiçbir sağlıklı read replica yok, master kullanılıyor", nil)
+	if len(cm.readDBs) > 0 {
+		cm.logger.Warn("Hiçbir sağlıklı read replica yok, master kullanılıyor", nil)
+	}
 	return cm.masterDB
 }
 
+// EnableReadYourWrites tracks per-user writes in Redis for window. A zero
+// window turns the feature off and GetReadDBForUser behaves like GetReadDB.
+func (cm *ConnectionManager) EnableReadYourWrites(client redis.UniversalClient, window time.Duration) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.redisClient = client
+	cm.readYourWrites = window
+}
+
+func (cm *ConnectionManager) GetReadDBForUser(userID int64) *sql.DB {
+	client, window := cm.readYourWritesSettings()
+	if client == nil || window <= 0 {
+		return cm.GetReadDB()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rywTimeout)
+	defer cancel()
+
+	exists, err := client.Exists(ctx, userWriteKey(userID)).Result()
+	if err != nil {
+		cm.logger.Warn("Read-your-writes kontrolü başarısız, master kullanılıyor", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		return cm.masterDB
+	}
+	if exists > 0 {
+		return cm.masterDB
+	}
+
+	return cm.GetReadDB()
+}
+
+// MarkUserWrite pins the user's reads to the master for the configured
+// window. It is best effort: a failed mark only widens the staleness the
+// user may see back to plain replica lag.
+func (cm *ConnectionManager) MarkUserWrite(userID int64) {
+	client, window := cm.readYourWritesSettings()
+	if client == nil || window <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rywTimeout)
+	defer cancel()
+
+	if err := client.Set(ctx, userWriteKey(userID), 1, window).Err(); err != nil {
+		cm.logger.Warn("Read-your-writes işareti yazılamadı", map[string]interface{}{"user_id": userID, "error": err.Error()})
+	}
+}
+
+// readYourWritesSettings returns a nil client when there are no replicas,
+// since every read already goes to the master then.
+func (cm *ConnectionManager) readYourWritesSettings() (redis.UniversalClient, time.Duration) {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	if len(cm.readDBs) == 0 {
+		return nil, 0
+	}
+	return cm.redisClient, cm.readYourWrites
+}
+
+func userWriteKey(userID int64) string {
+	return fmt.Sprintf("payflow:ryw:user:%d", userID)
+}
+
 func (cm *ConnectionManager) ExecuteWithCircuitBreaker(operation func() (interface{}, error)) (interface{}, error) {
 	return cm.circuitBreaker.Execute(operation)
 }
 
+func (cm *ConnectionManager) ExecuteWithCircuitBreakerContext(ctx context.Context, operation func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	return cm.circuitBreaker.ExecuteContext(ctx, operation)
+}
+
 func (cm *ConnectionManager) getHealthyReplica() *ReadReplica {
 	cm.mutex.RLock()
 	defer cm.mutex.RUnlock()
@@ -193,19 +303,19 @@ func (cm *ConnectionManager) selectByWeight(replicas []*ReadReplica) *ReadReplic
 
 	totalWeight := 0
 	for _, replica := range replicas {
-		totalWeight += replica.Config.Weight
+		totalWeight += replicaWeight(replica)
 	}
 
 	if totalWeight == 0 {
-		cm.roundRobinIndex = (cm.roundRobinIndex + 1) % len(replicas)
-		return replicas[cm.roundRobinIndex]
+		next := atomic.AddUint64(&cm.roundRobinIndex, 1)
+		return replicas[next%uint64(len(replicas))]
 	}
 
 	random := rand.Intn(totalWeight)
 	currentWeight := 0
 
 	for _, replica := range replicas {
-		currentWeight += replica.Config.Weight
+		currentWeight += replicaWeight(replica)
 		if random < currentWeight {
 			return replica
 		}
@@ -214,12 +324,24 @@ func (cm *ConnectionManager) selectByWeight(replicas []*ReadReplica) *ReadReplic
 	return replicas[0]
 }
 
-func (cm *ConnectionManager) startHealthCheck() {
+func replicaWeight(replica *ReadReplica) int {
+	if replica.Config.Weight < 0 {
+		return 0
+	}
+	return replica.Config.Weight
+}
+
+func (cm *ConnectionManager) startHealthCheck(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		cm.checkReplicaHealth()
+	for {
+		select {
+		case <-ticker.C:
+			cm.checkReplicaHealth()
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
@@ -251,7 +373,7 @@ func (cm *ConnectionManager) checkReplicaHealth() {
 func (cm *ConnectionManager) Close() error {
 	if cm.masterDB != nil {
 		if err := cm.masterDB.Close(); err != nil {
-			cm.logger.Error("Master DB kapatma hatası", map[string]interface{}{"error": err.Error()})
+			cm.logger.ErrorWithErr("Master DB kapatma hatası", err, nil)
 		}
 	}
 
@@ -267,6 +389,22 @@ func (cm *ConnectionManager) Close() error {
 	return nil
 }
 
+// PoolStats returns the database/sql pool statistics keyed by "master" for
+// the master and "host:port" for each read replica.
+func (cm *ConnectionManager) PoolStats() map[string]sql.DBStats {
+	stats := make(map[string]sql.DBStats, len(cm.readDBs)+1)
+
+	if cm.masterDB != nil {
+		stats["master"] = cm.masterDB.Stats()
+	}
+
+	for _, replica := range cm.readDBs {
+		stats[fmt.Sprintf("%s:%s", replica.Config.Host, replica.Config.Port)] = replica.DB.Stats()
+	}
+
+	return stats
+}
+
 func (cm *ConnectionManager) GetStats() map[string]interface{} {
 	stats := map[string]interface{}{
 		"circuit_breaker_state":  cm.circuitBreaker.State().String(),
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Dialect hides the differences between the supported databases. Queries are
+// written once in Postgres style ($N placeholders, RETURNING, ON CONFLICT);
+// both databases accept RETURNING and ON CONFLICT, and the SQLite driver
+// rewrites the placeholders, so a dialect only covers what cannot be shared.
+type Dialect interface {
+	Name() string
+	DriverName() string
+	DSN(host, port, user, password, name, sslMode string) string
+
+	// ForUpdate is appended to a SELECT that must lock the rows it reads.
+	ForUpdate() string
+	// AutoIncrementPrimaryKey is the column type of a generated integer id.
+	AutoIncrementPrimaryKey() string
+	// JSONType is the column type of a JSON document.
+	JSONType() string
+	// AddColumn and DropColumn build ALTER TABLE statements that are
+	// idempotent where the database allows it.
+	AddColumn(table, definition string) string
+	DropColumn(table, column string) string
+	// SHA256Hex wraps a text expression in its lowercase hex SHA-256 digest.
+	SHA256Hex(expr string) string
+	// UpdateAlias names the target of an UPDATE ... FROM under an alias.
+	UpdateAlias(table, alias string) string
+	// SupportsAlterConstraint reports whether ALTER TABLE can change a
+	// column's nullability or a table's primary key in place.
+	SupportsAlterConstraint() bool
+	// SupportsWritableCTE reports whether DELETE ... RETURNING may feed an
+	// INSERT inside a single WITH statement.
+	SupportsWritableCTE() bool
+	IsUniqueViolation(err error) bool
+}
+
+func NewDialect(driver string) (Dialect, error) {
+	switch driver {
+	case "", DriverPostgres:
+		return postgresDialect{}, nil
+	case DriverSQLite:
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("desteklenmeyen veritabanı sürücüsü: %s", driver)
+	}
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string       { return DriverPostgres }
+func (postgresDialect) DriverName() string { return "postgres" }
+
+func (postgresDialect) DSN(host, port, user, password, name, sslMode string) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		host, port, user, password, name, sslMode)
+}
+
+func (postgresDialect) ForUpdate() string               { return "FOR UPDATE" }
+func (postgresDialect) AutoIncrementPrimaryKey() string { return "SERIAL PRIMARY KEY" }
+func (postgresDialect) JSONType() string                { return "JSONB" }
+func (postgresDialect) SupportsWritableCTE() bool       { return true }
+func (postgresDialect) SupportsAlterConstraint() bool   { return true }
+
+func (postgresDialect) AddColumn(table, definition string) string {
+	return "ALTER TABLE " + table + " ADD COLUMN IF NOT EXISTS " + definition
+}
+
+func (postgresDialect) DropColumn(table, column string) string {
+	return "ALTER TABLE " + table + " DROP COLUMN IF EXISTS " + column
+}
+
+func (postgresDialect) SHA256Hex(expr string) string {
+	return "encode(sha256(convert_to(" + expr + ", 'UTF8')), 'hex')"
+}
+
+func (postgresDialect) UpdateAlias(table, alias string) string { return table + " " + alias }
+
+func (postgresDialect) IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// sqliteDialect targets tests and local development. SQLite serialises
+// writers on the whole database, so row locks are unnecessary. DB_NAME is
+// used as the database file path; an empty name gives a shared in-memory
+// database.
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string       { return DriverSQLite }
+func (sqliteDialect) DriverName() string { return sqliteDriverName }
+
+func (sqliteDialect) DSN(host, port, user, password, name, sslMode string) string {
+	if name == "" {
+		name = "file::memory:?cache=shared"
+	}
+	if strings.Contains(name, "?") {
+		return name + "&_foreign_keys=on&_busy_timeout=5000"
+	}
+	return name + "?_foreign_keys=on&_busy_timeout=5000"
+}
+
+func (sqliteDialect) ForUpdate() string               { return "" }
+func (sqliteDialect) AutoIncrementPrimaryKey() string { return "INTEGER PRIMARY KEY AUTOINCREMENT" }
+func (sqliteDialect) JSONType() string                { return "TEXT" }
+func (sqliteDialect) SupportsWritableCTE() bool       { return false }
+func (sqliteDialect) SupportsAlterConstraint() bool   { return false }
+
+// SQLite has no IF [NOT] EXISTS for columns; migrations are applied once, so
+// the plain forms are enough.
+func (sqliteDialect) AddColumn(table, definition string) string {
+	return "ALTER TABLE " + table + " ADD COLUMN " + definition
+}
+
+func (sqliteDialect) DropColumn(table, column string) string {
+	return "ALTER TABLE " + table + " DROP COLUMN " + column
+}
+
+// SHA256Hex uses the sha256_hex function the SQLite driver registers.
+func (sqliteDialect) SHA256Hex(expr string) string {
+	return "sha256_hex(" + expr + ")"
+}
+
+// UpdateAlias needs AS: SQLite only accepts a bare alias in SELECT.
+func (sqliteDialect) UpdateAlias(table, alias string) string { return table + " AS " + alias }
+
+func (sqliteDialect) IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
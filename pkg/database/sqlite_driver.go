@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const sqliteDriverName = "payflow_sqlite"
+
+func init() {
+	sql.Register(sqliteDriverName, sqliteRebindDriver{&sqlite3.SQLiteDriver{ConnectHook: registerSQLiteFunctions}})
+}
+
+// registerSQLiteFunctions adds the functions migrations need that SQLite
+// lacks.
+func registerSQLiteFunctions(conn *sqlite3.SQLiteConn) error {
+	return conn.RegisterFunc("sha256_hex", func(value string) string {
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:])
+	}, true)
+}
+
+// sqliteRebindDriver wraps go-sqlite3 so repositories can keep Postgres-style
+// $N placeholders. SQLite numbers $NAME parameters by first appearance rather
+// than by N, so they are rewritten to ?N, which SQLite binds by position.
+type sqliteRebindDriver struct {
+	*sqlite3.SQLiteDriver
+}
+
+func (d sqliteRebindDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteRebindConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+type sqliteRebindConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *sqliteRebindConn) Prepare(query string) (driver.Stmt, error) {
+	return c.SQLiteConn.Prepare(rebindPlaceholders(query))
+}
+
+func (c *sqliteRebindConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.SQLiteConn.PrepareContext(ctx, rebindPlaceholders(query))
+}
+
+func (c *sqliteRebindConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.SQLiteConn.ExecContext(ctx, rebindPlaceholders(query), args)
+}
+
+func (c *sqliteRebindConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.SQLiteConn.QueryContext(ctx, rebindPlaceholders(query), args)
+}
+
+// rebindPlaceholders turns $N into ?N outside string literals.
+func rebindPlaceholders(query string) string {
+	if !strings.Contains(query, "$") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query))
+
+	inLiteral := false
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '\'':
+			inLiteral = !inLiteral
+		case ch == '$' && !inLiteral && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			ch = '?'
+		}
+		b.WriteByte(ch)
+	}
+
+	return b.String()
+}
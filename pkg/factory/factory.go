@@ -3,7 +3,9 @@ package factory
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -13,49 +15,68 @@ import (
 	"payflow/internal/domain"
 	"payflow/internal/repository"
 	"payflow/internal/service"
+	"payflow/pkg/auth"
 	"payflow/pkg/cache"
+	"payflow/pkg/circuitbreaker"
 	"payflow/pkg/database"
 	"payflow/pkg/fallback"
+	"payflow/pkg/fx"
 	"payflow/pkg/loadbalancer"
 	"payflow/pkg/logger"
+	"payflow/pkg/metrics"
 )
 
 type Factory interface {
 	GetLogger() logger.Logger
+	GetLogSampler() *logger.Sampler
 	GetConfig() *config.Config
+	GetConfigStore() *config.Store
 	GetDB() *sql.DB
 	GetConnectionManager() *database.ConnectionManager
-	GetRedisClient() *redis.Client
+	GetRedisClient() redis.UniversalClient
 	GetCache() cache.Cache
 	GetCacheManager() cache.CacheStrategy
 	GetWarmUpManager() *cache.WarmUpManager
 	GetFallbackManager() *fallback.FallbackManager
 	GetLoadBalancer() *loadbalancer.LoadBalancer
+	GetTokenManager() *auth.TokenManager
 
 	GetUserRepository() domain.UserRepository
 	GetTransactionRepository() domain.TransactionRepository
 	GetBalanceRepository() domain.BalanceRepository
 	GetAuditLogRepository() domain.AuditLogRepository
 	GetEventStoreRepository() domain.EventStoreRepository
+	GetRecurringTransferRepository() domain.RecurringTransferRepository
 
 	GetUserService() domain.UserService
 	GetTransactionService() domain.TransactionService
 	GetBalanceService() domain.BalanceService
 	GetAuditLogService() domain.AuditLogService
 	GetEventStoreService() domain.EventStoreService
+	GetRecurringTransferService() domain.RecurringTransferService
+
+	// ReloadConfig re-reads the configuration and applies the settings that
+	// are safe to change at runtime; see config.Store.Apply.
+	ReloadConfig() error
+	Shutdown(ctx context.Context) error
 }
 
 type AppFactory struct {
 	config            *config.Config
+	configStore       *config.Store
+	cacheTTL          *cache.TTLSettings
 	logger            logger.Logger
+	sampledLogger     logger.Logger
+	logSampler        *logger.Sampler
 	db                *sql.DB
 	connectionManager *database.ConnectionManager
-	redisClient       *redis.Client
+	redisClient       redis.UniversalClient
 	cache             cache.Cache
 	cacheManager      cache.CacheStrategy
 	warmUpManager     *cache.WarmUpManager
 	fallbackManager   *fallback.FallbackManager
 	loadBalancer      *loadbalancer.LoadBalancer
+	tokenManager      *auth.TokenManager
 
 	userRepository        domain.UserRepository
 	transactionRepository domain.TransactionRepository
@@ -63,55 +84,113 @@ type AppFactory struct {
 	auditLogRepository    domain.AuditLogRepository
 	eventStoreRepository  domain.EventStoreRepository
 
+	recurringTransferRepository domain.RecurringTransferRepository
+
 	userService        domain.UserService
 	transactionService domain.TransactionService
 	balanceService     domain.BalanceService
 	auditLogService    domain.AuditLogService
 	eventStoreService  domain.EventStoreService
+
+	recurringTransferService domain.RecurringTransferService
+
+	// cancelBackground stops the health checks and cleanup loops started
+	// by NewFactory.
+	cancelBackground context.CancelFunc
+	// logFile is the log file when LOG_OUTPUT is file, nil otherwise.
+	logFile io.Closer
+
+	poolCollector *metrics.PoolCollector
 }
 
-func NewFactory() (Factory, error) {
+// NewFactory builds the application's dependencies. Background loops started
+// here run until ctx is done or Shutdown is called.
+func NewFactory(ctx context.Context) (_ Factory, err error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, err
 	}
 
-	log := logger.New(logger.LogLevel(cfg.LogLevel), nil)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelBackground := context.WithCancel(ctx)
+	defer func() {
+		if err != nil {
+			cancelBackground()
+		}
+	}()
 
-	connManager, err := database.NewConnectionManager(cfg, log)
+	logOutput, err := logger.OpenOutput(cfg.LogOutput, cfg.LogFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var logFile io.Closer
+	if closer, ok := logOutput.(io.Closer); ok && cfg.LogOutput == logger.OutputFile {
+		logFile = closer
+		defer func() {
+			if err != nil {
+				logFile.Close()
+			}
+		}()
+	}
+
+	log := logger.New(logger.LogLevel(cfg.LogLevel), logger.Format(cfg.LogFormat), logOutput)
+
+	logSampler := logger.NewSampler(uint32(cfg.LogSampleRate))
+	sampledLog := log.WithSampler(logSampler)
+
+	connManager, err := database.NewConnectionManager(ctx, cfg, log)
 	if err != nil {
 		return nil, fmt.Errorf("connection manager oluşturulamadı: %w", err)
 	}
 
 	db := connManager.GetWriteDB()
 
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:         fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port),
-		Password:     cfg.Redis.Password,
-		DB:           cfg.Redis.DB,
-		PoolSize:     cfg.Redis.PoolSize,
-		MinIdleConns: cfg.Redis.MinIdleConns,
-	})
+	redisClient, err := newRedisClient(cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx := context.Background()
 	if _, err := redisClient.Ping(ctx).Result(); err != nil {
 		return nil, fmt.Errorf("Redis bağlantısı kurulamadı: %w", err)
 	}
 
-	cacheInstance := cache.NewRedisCache(redisClient, log, "payflow")
-	cacheManager := cache.NewCacheManager(cacheInstance, log)
+	connManager.EnableReadYourWrites(redisClient, cfg.Database.ReadYourWritesWindow)
 
-	fallbackMgr := fallback.NewFallbackManager(log)
+	cacheTTL := cache.NewTTLSettings(cfg.Cache.L1TTL, cfg.Cache.TTLJitter)
+	cacheInstance := cache.NewRedisCache(redisClient, sampledLog, "payflow", cacheTTL)
+	if cfg.Cache.L1Enabled && cfg.Cache.L1TTL > 0 {
+		cacheInstance = cache.NewTieredCache(cacheInstance, cfg.Cache.L1Size, cacheTTL, sampledLog)
+	}
+	cacheManager := cache.NewCacheManager(cacheInstance, fallback.NewRetryQueue(ctx, 5, log), sampledLog)
+
+	fallbackMgr := fallback.NewFallbackManager(ctx, log)
+
+	if cfg.Auth.JWTSecret == "" {
+		log.Warn("JWT_SECRET tanımlı değil, rastgele anahtar kullanılacak; tokenlar yeniden başlatmada geçersiz olur", map[string]interface{}{})
+	}
+
+	tokenManager, err := auth.NewTokenManager(cfg.Auth.JWTSecret, cfg.Auth.TokenTTL)
+	if err != nil {
+		return nil, err
+	}
 
 	var loadBal *loadbalancer.LoadBalancer
 	if cfg.Server.LoadBalancer.Enabled {
 		loadBal = loadbalancer.NewLoadBalancer(cfg.Server.LoadBalancer, log)
-		loadBal.StartHealthCheck()
+		loadBal.StartHealthCheck(ctx)
 	}
 
 	factory := &AppFactory{
 		config:            cfg,
+		configStore:       config.NewStore(cfg),
+		cacheTTL:          cacheTTL,
 		logger:            log,
+		sampledLogger:     sampledLog,
+		logSampler:        logSampler,
 		db:                db,
 		connectionManager: connManager,
 		redisClient:       redisClient,
@@ -119,6 +198,9 @@ func NewFactory() (Factory, error) {
 		cacheManager:      cacheManager,
 		fallbackManager:   fallbackMgr,
 		loadBalancer:      loadBal,
+		tokenManager:      tokenManager,
+		cancelBackground:  cancelBackground,
+		logFile:           logFile,
 	}
 
 	factory.initRepositories()
@@ -126,21 +208,52 @@ func NewFactory() (Factory, error) {
 	factory.initCacheManagers()
 	factory.initFallbacks()
 
+	factory.poolCollector, err = metrics.RegisterPoolCollector(factory.transactionService, connManager)
+	if err != nil {
+		return nil, fmt.Errorf("pool metrikleri kaydedilemedi: %w", err)
+	}
+
 	return factory, nil
 }
 
+// newRedisClient builds a cluster client when REDIS_CLUSTER is set and a
+// single-node client otherwise. Cluster mode has no databases, so DB is ignored.
+func newRedisClient(cfg config.RedisConfig) (redis.UniversalClient, error) {
+	if !cfg.Cluster {
+		return redis.NewClient(&redis.Options{
+			Addr:         fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
+			Password:     cfg.Password,
+			DB:           cfg.DB,
+			PoolSize:     cfg.PoolSize,
+			MinIdleConns: cfg.MinIdleConns,
+		}), nil
+	}
+
+	if len(cfg.Nodes) == 0 {
+		return nil, fmt.Errorf("REDIS_CLUSTER etkin ancak REDIS_CLUSTER_NODES tanımlı değil")
+	}
+
+	return redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:        cfg.Nodes,
+		Password:     cfg.Password,
+		PoolSize:     cfg.PoolSize,
+		MinIdleConns: cfg.MinIdleConns,
+	}), nil
+}
+
 func (f *AppFactory) initRepositories() {
-	f.userRepository = repository.NewUserRepository(f.db, f.logger)
-	f.transactionRepository = repository.NewTransactionRepository(f.db, f.logger)
-	f.balanceRepository = repository.NewBalanceRepository(f.db, f.logger)
-	f.auditLogRepository = repository.NewAuditLogRepository(f.db, f.logger)
-	f.eventStoreRepository = repository.NewEventStoreRepository(f.db, f.logger)
+	f.userRepository = repository.NewUserRepository(f.connectionManager, f.logger)
+	f.transactionRepository = repository.NewTransactionRepository(f.connectionManager, f.logger, f.config.Database.QueryTimeout)
+	f.balanceRepository = repository.NewBalanceRepository(f.connectionManager, f.logger, f.config.Database.QueryTimeout)
+	f.auditLogRepository = repository.NewAuditLogRepository(f.connectionManager, f.logger)
+	f.eventStoreRepository = repository.NewEventStoreRepository(f.connectionManager, f.logger)
+	f.recurringTransferRepository = repository.NewRecurringTransferRepository(f.connectionManager, f.logger)
 }
 
 func (f *AppFactory) initServices() {
-	f.eventStoreService = service.NewEventStoreService(f.eventStoreRepository, f.logger)
+	f.eventStoreService = service.NewEventStoreService(f.eventStoreRepository, f.logger, f.config.EventStore.SnapshotInterval)
 
-	f.auditLogService = service.NewAuditLogService(f.auditLogRepository, f.logger)
+	f.auditLogService = service.NewAuditLogService(f.auditLogRepository, f.logger, f.config.AuditLog)
 
 	baseBalanceService := service.NewBalanceService(
 		f.balanceRepository,
@@ -148,19 +261,44 @@ func (f *AppFactory) initServices() {
 		f.eventStoreService,
 		f.logger,
 		f.redisClient,
+		f.config.Currency.Default,
 	)
 	f.balanceService = service.NewCachedBalanceService(baseBalanceService, f.cache, f.cacheManager, f.logger)
 
-	baseUserService := service.NewUserService(f.userRepository, f.balanceService, f.auditLogRepository, f.logger)
+	baseUserService := service.NewUserService(
+		f.userRepository,
+		f.balanceService,
+		f.auditLogRepository,
+		f.eventStoreService,
+		f.logger,
+		f.config.Security,
+		domain.NewRolePermissions(f.config.Auth.RolePermissions),
+		service.NewLogUserNotifier(f.logger),
+	)
 	f.userService = service.NewCachedUserService(baseUserService, f.cache, f.cacheManager, f.logger)
 
 	f.transactionService = service.NewTransactionService(
 		f.transactionRepository,
 		f.balanceRepository,
 		f.balanceService,
+		f.userRepository,
 		f.auditLogRepository,
 		f.eventStoreService,
 		f.logger,
+		f.sampledLogger,
+		f.config.Transaction,
+		f.config.Currency.Default,
+		fx.NewConverter(fx.NewStaticRateProvider(f.config.Currency.Rates)),
+	)
+
+	f.recurringTransferService = service.NewRecurringTransferService(
+		f.recurringTransferRepository,
+		f.transactionRepository,
+		f.transactionService,
+		f.auditLogRepository,
+		f.logger,
+		f.config.Transaction.SchedulerInterval,
+		f.config.Currency.Default,
 	)
 }
 
@@ -171,6 +309,10 @@ func (f *AppFactory) initCacheManagers() {
 		f.userService,
 		f.balanceService,
 		f.transactionService,
+		f.userRepository,
+		f.balanceRepository,
+		f.transactionRepository,
+		f.config.Currency.Default,
 	)
 }
 
@@ -206,12 +348,111 @@ func (f *AppFactory) initFallbacks() {
 	})
 }
 
+// Shutdown releases everything the factory created, in dependency order:
+// the schedulers stop and the worker pool drains first, then pending
+// write-behind writes are flushed, background loops and retry workers are
+// cancelled and the pool metrics unregistered, then the Redis client and the
+// database connections are closed. The log file is closed last so every step
+// above can still log. Every step runs even if an earlier one fails; the
+// errors are logged and returned joined.
+// It must be called after the HTTP server has stopped accepting requests.
+func (f *AppFactory) Shutdown(ctx context.Context) error {
+	f.logger.Info("Factory kapatılıyor...", map[string]interface{}{})
+
+	f.recurringTransferService.Stop()
+	f.auditLogService.Stop()
+	f.transactionService.Shutdown()
+
+	var errs []error
+
+	flushCtx, cancel := context.WithTimeout(ctx, f.config.Transaction.DrainTimeout)
+	defer cancel()
+
+	if err := f.cacheManager.Flush(flushCtx); err != nil {
+		errs = append(errs, fmt.Errorf("write-behind yazmaları boşaltılamadı: %w", err))
+	}
+
+	f.cancelBackground()
+	metrics.UnregisterPoolCollector(f.poolCollector)
+
+	if err := f.redisClient.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("Redis istemcisi kapatılamadı: %w", err))
+	}
+
+	if err := f.connectionManager.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("veritabanı bağlantıları kapatılamadı: %w", err))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		f.logger.ErrorWithErr("Factory kapatılırken hata oluştu", err, nil)
+	}
+
+	if f.logFile != nil {
+		if err := f.logFile.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("log dosyası kapatılamadı: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func (f *AppFactory) GetLogger() logger.Logger {
 	return f.logger
 }
 
+func (f *AppFactory) GetLogSampler() *logger.Sampler {
+	return f.logSampler
+}
+
+// GetConfig returns the current configuration snapshot.
 func (f *AppFactory) GetConfig() *config.Config {
-	return f.config
+	return f.configStore.Get()
+}
+
+func (f *AppFactory) GetConfigStore() *config.Store {
+	return f.configStore
+}
+
+// ReloadConfig applies the hot-reloadable settings (log level, rate limits,
+// circuit breaker thresholds, cache TTLs). Changes to any other setting are
+// logged and left for the next restart. An invalid configuration is rejected
+// as a whole and the current one stays in effect.
+func (f *AppFactory) ReloadConfig() error {
+	next, err := config.Reload()
+	if err != nil {
+		return err
+	}
+
+	if err := next.Validate(); err != nil {
+		return err
+	}
+
+	cfg, restartRequired := f.configStore.Apply(next)
+
+	f.logger.SetLevel(logger.LogLevel(cfg.LogLevel))
+	f.cacheTTL.SetL1TTL(cfg.Cache.L1TTL)
+	f.cacheTTL.SetJitter(cfg.Cache.TTLJitter)
+	// Thresholds change in place, so open breakers stay open; the database and
+	// load balancer backend breakers all take the new values.
+	circuitbreaker.DefaultRegistry.UpdateThresholds(
+		uint32(cfg.CircuitBreaker.MaxRequests),
+		cfg.CircuitBreaker.Timeout,
+		circuitbreaker.ConsecutiveFailuresOver(cfg.CircuitBreaker.FailureThreshold),
+	)
+
+	if len(restartRequired) > 0 {
+		f.logger.Warn("Bazı ayar değişiklikleri yeniden başlatma gerektiriyor, şimdilik uygulanmadı", map[string]interface{}{
+			"settings": restartRequired,
+		})
+	}
+
+	f.logger.Info("Yapılandırma yeniden yüklendi", map[string]interface{}{
+		"log_level":       cfg.LogLevel,
+		"rate_limit_rate": cfg.RateLimit.Rate,
+		"cache_l1_ttl":    cfg.Cache.L1TTL.String(),
+	})
+
+	return nil
 }
 
 func (f *AppFactory) GetDB() *sql.DB {
@@ -222,7 +463,7 @@ func (f *AppFactory) GetConnectionManager() *database.ConnectionManager {
 	return f.connectionManager
 }
 
-func (f *AppFactory) GetRedisClient() *redis.Client {
+func (f *AppFactory) GetRedisClient() redis.UniversalClient {
 	return f.redisClient
 }
 
@@ -246,6 +487,10 @@ func (f *AppFactory) GetLoadBalancer() *loadbalancer.LoadBalancer {
 	return f.loadBalancer
 }
 
+func (f *AppFactory) GetTokenManager() *auth.TokenManager {
+	return f.tokenManager
+}
+
 func (f *AppFactory) GetUserRepository() domain.UserRepository {
 	return f.userRepository
 }
@@ -285,3 +530,11 @@ func (f *AppFactory) GetAuditLogService() domain.AuditLogService {
 func (f *AppFactory) GetEventStoreService() domain.EventStoreService {
 	return f.eventStoreService
 }
+
+func (f *AppFactory) GetRecurringTransferRepository() domain.RecurringTransferRepository {
+	return f.recurringTransferRepository
+}
+
+func (f *AppFactory) GetRecurringTransferService() domain.RecurringTransferService {
+	return f.recurringTransferService
+}
@@ -0,0 +1,137 @@
+package factory
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveFakeRedis answers just enough of the Redis protocol for the factory to
+// connect: PING gets PONG, HELLO is refused so the client falls back to
+// RESP2, and everything else gets OK.
+func serveFakeRedis(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go handleFakeRedisConn(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func handleFakeRedisConn(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readRESPArray(reader)
+		if err != nil {
+			return
+		}
+
+		reply := "+OK\r\n"
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			reply = "+PONG\r\n"
+		case "HELLO":
+			reply = "-ERR unknown command 'HELLO'\r\n"
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readRESPArray(reader *bufio.Reader) ([]string, error) {
+	var count int
+	if _, err := fmt.Fscanf(reader, "*%d\r\n", &count); err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(reader, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	if count == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return args, nil
+}
+
+// waitForGoroutines waits until at most want goroutines are running and
+// reports the stacks of the rest if they do not finish in time.
+func waitForGoroutines(t *testing.T, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			buf = buf[:runtime.Stack(buf, true)]
+			t.Fatalf("kapatma sonrası %d goroutine çalışıyor, beklenen en fazla %d:\n%s", runtime.NumGoroutine(), want, buf)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestShutdownLeavesNoGoroutines(t *testing.T) {
+	host, port, err := net.SplitHostPort(serveFakeRedis(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_NAME", "file:factory_shutdown?mode=memory&cache=shared")
+	t.Setenv("REDIS_HOST", host)
+	t.Setenv("REDIS_PORT", port)
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("LB_ENABLED", "true")
+	t.Setenv("LB_ALGORITHM", "round_robin")
+	t.Setenv("LB_HEALTH_CHECK_INTERVAL", "1")
+
+	baseline := runtime.NumGoroutine()
+
+	// The parent context stays alive: Shutdown alone must stop everything.
+	appFactory, err := NewFactory(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if runtime.NumGoroutine() <= baseline {
+		t.Fatal("factory arka plan döngülerini başlatmalı")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := appFactory.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForGoroutines(t, baseline)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"payflow/pkg/circuitbreaker"
@@ -64,22 +65,35 @@ type RetryQueue struct {
 	items   chan *RetryItem
 	workers int
 	logger  logger.Logger
+
+	// pending counts items queued, running or waiting for a retry.
+	pending  int64
+	draining int32
 }
 
+// maxRetryBackoff caps the exponential delay between attempts.
+const maxRetryBackoff = time.Minute
+
 type RetryItem struct {
 	ID         string
 	Function   func() error
 	MaxRetries int
 	Interval   time.Duration
 	Attempt    int
+
+	// OnDone, if set, is called once an accepted item succeeds, fails
+	// permanently or is dropped on requeue; err is nil only on success.
+	OnDone func(err error)
 }
 
-func NewFallbackManager(logger logger.Logger) *FallbackManager {
+// NewFallbackManager creates a manager whose cache cleanup and retry workers
+// run until ctx is done.
+func NewFallbackManager(ctx context.Context, logger logger.Logger) *FallbackManager {
 	return &FallbackManager{
 		strategies: make(map[string]*FallbackConfig),
-		cache:      NewSimpleCache(),
+		cache:      NewSimpleCache(ctx),
 		logger:     logger,
-		retryQueue: NewRetryQueue(5, logger),
+		retryQueue: NewRetryQueue(ctx, 5, logger),
 	}
 }
 
@@ -245,7 +259,7 @@ func (fm *FallbackManager) GetStats() map[string]interface{} {
 
 	stats := map[string]interface{}{
 		"registered_fallbacks": len(fm.strategies),
-		"retry_queue_size":     len(fm.retryQueue.items),
+		"retry_queue_size":     fm.retryQueue.Pending(),
 	}
 
 	fallbackStats := make(map[string]interface{})
@@ -264,12 +278,14 @@ func (fm *FallbackManager) GetStats() map[string]interface{} {
 	return stats
 }
 
-func NewSimpleCache() *SimpleCache {
+// NewSimpleCache creates a cache that evicts expired items every minute until
+// ctx is done.
+func NewSimpleCache(ctx context.Context) *SimpleCache {
 	cache := &SimpleCache{
 		data: make(map[string]*cacheItem),
 	}
 
-	go cache.cleanup()
+	go cache.cleanup(ctx)
 
 	return cache
 }
@@ -308,23 +324,35 @@ func (c *SimpleCache) Delete(key string) {
 	delete(c.data, key)
 }
 
-func (c *SimpleCache) cleanup() {
+func (c *SimpleCache) cleanup(ctx context.Context) {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.mutex.Lock()
-		now := time.Now()
-		for key, item := range c.data {
-			if now.After(item.expiresAt) {
-				delete(c.data, key)
-			}
+	for {
+		select {
+		case <-ticker.C:
+			c.removeExpired()
+		case <-ctx.Done():
+			return
 		}
-		c.mutex.Unlock()
 	}
 }
 
-func NewRetryQueue(workers int, logger logger.Logger) *RetryQueue {
+func (c *SimpleCache) removeExpired() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for key, item := range c.data {
+		if now.After(item.expiresAt) {
+			delete(c.data, key)
+		}
+	}
+}
+
+// NewRetryQueue starts workers that run until ctx is done. Items still queued
+// then are abandoned, so callers that need them run Flush first.
+func NewRetryQueue(ctx context.Context, workers int, logger logger.Logger) *RetryQueue {
 	rq := &RetryQueue{
 		items:   make(chan *RetryItem, 1000),
 		workers: workers,
@@ -332,25 +360,94 @@ func NewRetryQueue(workers int, logger logger.Logger) *RetryQueue {
 	}
 
 	for i := 0; i < workers; i++ {
-		go rq.worker()
+		go rq.worker(ctx)
 	}
 
 	return rq
 }
 
-func (rq *RetryQueue) Add(item *RetryItem) {
+// Add queues item and reports whether it was accepted; a full queue drops it
+// and OnDone is not called, leaving the caller to handle it.
+func (rq *RetryQueue) Add(item *RetryItem) bool {
+	atomic.AddInt64(&rq.pending, 1)
+
+	if !rq.enqueue(item) {
+		atomic.AddInt64(&rq.pending, -1)
+		return false
+	}
+
+	return true
+}
+
+// Pending returns the number of items not yet finished.
+func (rq *RetryQueue) Pending() int {
+	return int(atomic.LoadInt64(&rq.pending))
+}
+
+// Flush retries pending items without backoff and waits until all of them
+// finish or ctx is done.
+func (rq *RetryQueue) Flush(ctx context.Context) error {
+	atomic.StoreInt32(&rq.draining, 1)
+	defer atomic.StoreInt32(&rq.draining, 0)
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for rq.Pending() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("retry kuyruğu boşaltılamadı, %d öğe bekliyor: %w", rq.Pending(), ctx.Err())
+		}
+	}
+
+	return nil
+}
+
+func (rq *RetryQueue) enqueue(item *RetryItem) bool {
 	select {
 	case rq.items <- item:
+		return true
 	default:
 		rq.logger.Error("Retry queue is full, dropping item", map[string]interface{}{
 			"item_id": item.ID,
 		})
+		return false
+	}
+}
+
+func (rq *RetryQueue) finish(item *RetryItem, err error) {
+	if item.OnDone != nil {
+		item.OnDone(err)
+	}
+
+	atomic.AddInt64(&rq.pending, -1)
+}
+
+func (rq *RetryQueue) backoff(attempt int, interval time.Duration) time.Duration {
+	if atomic.LoadInt32(&rq.draining) == 1 {
+		return 0
+	}
+
+	delay := interval
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
 	}
+
+	return delay
 }
 
-func (rq *RetryQueue) worker() {
-	for item := range rq.items {
-		rq.processRetryItem(item)
+func (rq *RetryQueue) worker(ctx context.Context) {
+	for {
+		select {
+		case item := <-rq.items:
+			rq.processRetryItem(item)
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
@@ -363,18 +460,23 @@ func (rq *RetryQueue) processRetryItem(item *RetryItem) {
 			"item_id": item.ID,
 			"attempt": item.Attempt,
 		})
+		rq.finish(item, nil)
 		return
 	}
 
 	if item.Attempt < item.MaxRetries {
+		delay := rq.backoff(item.Attempt, item.Interval)
 		go func() {
-			time.Sleep(item.Interval)
-			rq.Add(item)
+			time.Sleep(delay)
+			if !rq.enqueue(item) {
+				rq.finish(item, err)
+			}
 		}()
 
 		rq.logger.Error("Retry operation failed, scheduling retry", map[string]interface{}{
 			"item_id": item.ID,
 			"attempt": item.Attempt,
+			"delay":   delay,
 			"error":   err.Error(),
 		})
 	} else {
@@ -384,5 +486,6 @@ func (rq *RetryQueue) processRetryItem(item *RetryItem) {
 			"max_retries": item.MaxRetries,
 			"error":       err.Error(),
 		})
+		rq.finish(item, err)
 	}
 }
@@ -0,0 +1,82 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"payflow/internal/domain"
+)
+
+var ErrRateNotFound = errors.New("döviz kuru tanımlı değil")
+
+type RateProvider interface {
+	Rate(from, to string) (float64, error)
+}
+
+type CurrencyConverter interface {
+	Rate(from, to string) (float64, error)
+	Convert(amount domain.Money, from, to string) (domain.Money, error)
+}
+
+func PairKey(from, to string) string {
+	return strings.ToUpper(from) + "/" + strings.ToUpper(to)
+}
+
+type StaticRateProvider struct {
+	rates map[string]float64
+}
+
+func NewStaticRateProvider(rates map[string]float64) *StaticRateProvider {
+	normalized := make(map[string]float64, len(rates))
+	for pair, rate := range rates {
+		normalized[strings.ToUpper(pair)] = rate
+	}
+
+	return &StaticRateProvider{rates: normalized}
+}
+
+func (p *StaticRateProvider) Rate(from, to string) (float64, error) {
+	if strings.EqualFold(from, to) {
+		return 1, nil
+	}
+
+	if rate, ok := p.rates[PairKey(from, to)]; ok && rate > 0 {
+		return rate, nil
+	}
+
+	if rate, ok := p.rates[PairKey(to, from)]; ok && rate > 0 {
+		return 1 / rate, nil
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrRateNotFound, PairKey(from, to))
+}
+
+type Converter struct {
+	provider RateProvider
+}
+
+func NewConverter(provider RateProvider) CurrencyConverter {
+	return &Converter{provider: provider}
+}
+
+func (c *Converter) Rate(from, to string) (float64, error) {
+	return c.provider.Rate(from, to)
+}
+
+func (c *Converter) Convert(amount domain.Money, from, to string) (domain.Money, error) {
+	rate, err := c.provider.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return ApplyRate(amount, rate), nil
+}
+
+// ApplyRate converts amount with an already fetched rate, rounding to the
+// nearest minor unit. Callers that record the rate use it so the stored rate
+// and converted amount always agree.
+func ApplyRate(amount domain.Money, rate float64) domain.Money {
+	return domain.Money(math.Round(float64(amount) * rate))
+}
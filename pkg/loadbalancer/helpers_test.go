@@ -0,0 +1,48 @@
+package loadbalancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payflow/internal/config"
+	"payflow/pkg/logger"
+)
+
+func newTestLoadBalancer(t *testing.T, algorithm Algorithm) *LoadBalancer {
+	t.Helper()
+
+	return NewLoadBalancer(config.LoadBalancerConfig{
+		Algorithm:       string(algorithm),
+		HealthCheckPath: "/health",
+	}, logger.New(logger.ErrorLevel, logger.FormatJSON, io.Discard))
+}
+
+// addTestBackend starts a backend that answers every path, /health included,
+// with 200 and its own name in X-Backend. The backend is removed again when
+// the test ends so its breaker leaves the default registry.
+func addTestBackend(t *testing.T, lb *LoadBalancer, name string, weight int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Backend", name)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	if err := lb.AddBackend(server.URL, weight); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lb.RemoveBackend(server.URL) })
+
+	return server
+}
+
+// serve sends one GET through lb and returns the status and the backend
+// that answered, if any.
+func serve(lb *LoadBalancer) (int, string) {
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+	return rec.Code, rec.Header().Get("X-Backend")
+}
@@ -1,8 +1,11 @@
 package loadbalancer
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -13,6 +16,7 @@ import (
 	"payflow/internal/config"
 	"payflow/pkg/circuitbreaker"
 	"payflow/pkg/logger"
+	"payflow/pkg/metrics"
 )
 
 type LoadBalancer struct {
@@ -27,13 +31,29 @@ type LoadBalancer struct {
 	healthCheckPath     string
 
 	circuitBreakers map[string]*circuitbreaker.CircuitBreaker
+
+	stickyFallback Algorithm
+	affinity       *affinityMap
+
+	// maxRetries is how many other backends an idempotent request may be
+	// retried on after a failed attempt.
+	maxRetries int
 }
 
+var (
+	ErrBackendNotFound = errors.New("backend bulunamadı")
+	ErrBackendExists   = errors.New("backend zaten kayıtlı")
+)
+
 type Backend struct {
-	URL          *url.URL
-	Alive        bool
-	Weight       int
-	Connections  int64
+	URL         *url.URL
+	Alive       bool
+	Maintenance bool
+	Weight      int
+	Connections int64
+	// ResponseTime is an EWMA of successful proxy latency in nanoseconds;
+	// zero until the first sample. Access it atomically.
+	ResponseTime int64
 	ReverseProxy *httputil.ReverseProxy
 	mutex        sync.RWMutex
 }
@@ -44,7 +64,9 @@ const (
 	RoundRobin         Algorithm = "round_robin"
 	WeightedRoundRobin Algorithm = "weighted_round_robin"
 	LeastConnections   Algorithm = "least_connections"
+	LeastResponseTime  Algorithm = "least_response_time"
 	IPHash             Algorithm = "ip_hash"
+	StickySession      Algorithm = "sticky_session"
 )
 
 func NewLoadBalancer(cfg config.LoadBalancerConfig, logger logger.Logger) *LoadBalancer {
@@ -54,6 +76,20 @@ func NewLoadBalancer(cfg config.LoadBalancerConfig, logger logger.Logger) *LoadB
 		healthCheckInterval: time.Duration(cfg.HealthCheckInterval) * time.Second,
 		healthCheckPath:     cfg.HealthCheckPath,
 		circuitBreakers:     make(map[string]*circuitbreaker.CircuitBreaker),
+		stickyFallback:      Algorithm(cfg.StickyFallback),
+		maxRetries:          cfg.MaxRetries,
+	}
+
+	if lb.algorithm == StickySession {
+		if lb.stickyFallback == StickySession || lb.stickyFallback == "" {
+			lb.stickyFallback = RoundRobin
+		}
+
+		ttl := cfg.StickyTTL
+		if ttl <= 0 {
+			ttl = 30 * time.Minute
+		}
+		lb.affinity = newAffinityMap(ttl)
 	}
 
 	return lb
@@ -65,11 +101,21 @@ func (lb *LoadBalancer) AddBackend(urlStr string, weight int) error {
 		return err
 	}
 
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	if _, exists := lb.circuitBreakers[serverURL.Host]; exists {
+		return ErrBackendExists
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(serverURL)
+	proxy.ErrorHandler = proxyErrorHandler
+
 	backend := &Backend{
 		URL:          serverURL,
 		Alive:        true,
 		Weight:       weight,
-		ReverseProxy: httputil.NewSingleHostReverseProxy(serverURL),
+		ReverseProxy: proxy,
 	}
 
 	cb := circuitbreaker.New(circuitbreaker.Settings{
@@ -80,6 +126,8 @@ func (lb *LoadBalancer) AddBackend(urlStr string, weight int) error {
 		ReadyToTrip: func(counts circuitbreaker.Counts) bool {
 			return counts.ConsecutiveFailures > 2
 		},
+		IsSuccessful: circuitbreaker.IgnoreContextErrors,
+		Registry:     circuitbreaker.DefaultRegistry,
 		OnStateChange: func(name string, from circuitbreaker.State, to circuitbreaker.State) {
 			lb.logger.InfoContext(context.Background(), "Backend circuit breaker state changed", map[string]interface{}{
 				"backend": name,
@@ -89,10 +137,8 @@ func (lb *LoadBalancer) AddBackend(urlStr string, weight int) error {
 		},
 	})
 
-	lb.mutex.Lock()
 	lb.backends = append(lb.backends, backend)
 	lb.circuitBreakers[serverURL.Host] = cb
-	lb.mutex.Unlock()
 
 	lb.logger.InfoContext(context.Background(), "Backend added", map[string]interface{}{
 		"url":    urlStr,
@@ -102,61 +148,261 @@ func (lb *LoadBalancer) AddBackend(urlStr string, weight int) error {
 	return nil
 }
 
+// RemoveBackend takes the backend out of rotation, waits up to
+// removeDrainTimeout for its in-flight requests and then drops it together
+// with its circuit breaker. Requests still running after the timeout hold
+// their own reference and finish normally.
+func (lb *LoadBalancer) RemoveBackend(urlStr string) error {
+	serverURL, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+	host := serverURL.Host
+
+	if err := lb.SetMaintenance(host, true); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), removeDrainTimeout)
+	defer cancel()
+
+	if err := lb.WaitForDrain(ctx, host); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	lb.mutex.Lock()
+	cb, exists := lb.circuitBreakers[host]
+	if !exists {
+		lb.mutex.Unlock()
+		return ErrBackendNotFound
+	}
+
+	backends := make([]*Backend, 0, len(lb.backends))
+	for _, backend := range lb.backends {
+		if backend.URL.Host != host {
+			backends = append(backends, backend)
+		}
+	}
+	lb.backends = backends
+	delete(lb.circuitBreakers, host)
+	lb.mutex.Unlock()
+
+	circuitbreaker.DefaultRegistry.Unregister(cb)
+
+	lb.logger.InfoContext(context.Background(), "Backend removed", map[string]interface{}{
+		"url": urlStr,
+	})
+
+	return nil
+}
+
+const removeDrainTimeout = 30 * time.Second
+
+func (lb *LoadBalancer) breakerFor(host string) *circuitbreaker.CircuitBreaker {
+	lb.mutex.RLock()
+	defer lb.mutex.RUnlock()
+
+	return lb.circuitBreakers[host]
+}
+
 func (lb *LoadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	backend := lb.getNextBackend(r)
-	if backend == nil {
-		http.Error(w, "Hiçbir sağlıklı backend bulunamadı", http.StatusServiceUnavailable)
-		return
+	retries := 0
+	var body []byte
+	if isIdempotent(r.Method) && lb.maxRetries > 0 {
+		var replayable bool
+		if body, replayable = bufferBody(r); replayable {
+			retries = lb.maxRetries
+		}
 	}
 
+	tried := make(map[*Backend]bool)
+	for attempt := 0; ; attempt++ {
+		backend := lb.getNextBackend(w, r, tried)
+		if backend == nil {
+			if attempt == 0 {
+				http.Error(w, "Hiçbir sağlıklı backend bulunamadı", http.StatusServiceUnavailable)
+			} else {
+				http.Error(w, "Backend unavailable", http.StatusBadGateway)
+			}
+			return
+		}
+		tried[backend] = true
+
+		if body != nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		err := lb.forward(w, r, backend, attempt < retries)
+		if err == nil {
+			return
+		}
+
+		// Stop once the client is gone or its deadline has passed.
+		if r.Context().Err() != nil {
+			http.Error(w, "Backend unavailable", http.StatusBadGateway)
+			return
+		}
+
+		metrics.RecordLoadBalancerRetry(backend.URL.Host)
+		lb.logger.Warn("Backend isteği başarısız, başka backend ile yeniden deneniyor", map[string]interface{}{
+			"backend": backend.URL.Host,
+			"attempt": attempt + 1,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// forward proxies r to backend. With deferFailure set a failed attempt
+// leaves w untouched and returns its error so the caller can retry;
+// otherwise every outcome is written to w and nil is returned.
+func (lb *LoadBalancer) forward(w http.ResponseWriter, r *http.Request, backend *Backend, deferFailure bool) error {
 	atomic.AddInt64(&backend.Connections, 1)
 	defer atomic.AddInt64(&backend.Connections, -1)
 
-	cb := lb.circuitBreakers[backend.URL.Host]
+	cb := lb.breakerFor(backend.URL.Host)
+	if cb == nil {
+		if deferFailure {
+			return ErrBackendNotFound
+		}
+		http.Error(w, "Backend unavailable", http.StatusBadGateway)
+		return nil
+	}
+
+	attempt := &proxyAttempt{deferFailure: deferFailure}
+	ctx := context.WithValue(r.Context(), proxyAttemptKey{}, attempt)
 
-	_, err := cb.Execute(func() (interface{}, error) {
-		backend.ReverseProxy.ServeHTTP(w, r)
-		return nil, nil
+	start := time.Now()
+	_, err := cb.ExecuteContext(ctx, func(ctx context.Context) (interface{}, error) {
+		backend.ReverseProxy.ServeHTTP(w, r.WithContext(ctx))
+		return nil, attempt.err
 	})
+	if err == nil {
+		// Failures are left out: a backend refusing connections answers fast.
+		backend.observeResponseTime(time.Since(start))
+		return nil
+	}
 
-	if err != nil {
-		if err == circuitbreaker.ErrCircuitBreakerOpen {
-			backend.setAlive(false)
-			lb.logger.Error("Backend marked unhealthy due to circuit breaker", map[string]interface{}{
-				"backend": backend.URL.Host,
-			})
-		}
+	if err == circuitbreaker.ErrCircuitBreakerOpen {
+		backend.setAlive(false)
+		lb.logger.Error("Backend marked unhealthy due to circuit breaker", map[string]interface{}{
+			"backend": backend.URL.Host,
+		})
+	}
+
+	if deferFailure {
+		return err
+	}
+
+	if err != attempt.err {
 		http.Error(w, "Backend unavailable", http.StatusBadGateway)
 	}
+	return nil
+}
+
+type proxyAttemptKey struct{}
+
+type proxyAttempt struct {
+	err          error
+	deferFailure bool
 }
 
-func (lb *LoadBalancer) getNextBackend(r *http.Request) *Backend {
+// proxyErrorHandler hands the proxy error back to forward so the circuit
+// breaker sees backend failures instead of an always-successful call. The
+// proxy only calls it before anything was written, so a deferred failure
+// can still be retried elsewhere.
+func proxyErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	attempt, ok := r.Context().Value(proxyAttemptKey{}).(*proxyAttempt)
+	if ok {
+		attempt.err = err
+		if attempt.deferFailure {
+			return
+		}
+	}
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+// maxRetryBodySize caps how much of a request body is buffered for replay;
+// larger requests are proxied once.
+const maxRetryBodySize = 1 << 20
+
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// bufferBody reads r.Body so it can be replayed. When the body is too large
+// or unreadable, r.Body is restored to stream what was read followed by the
+// rest, and false is returned.
+func bufferBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r.Body, maxRetryBodySize+1))
+	if err != nil || len(buf) > maxRetryBodySize {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+		return nil, false
+	}
+
+	r.Body.Close()
+	return buf, true
+}
+
+// getNextBackend picks among the alive backends not in exclude.
+func (lb *LoadBalancer) getNextBackend(w http.ResponseWriter, r *http.Request, exclude map[*Backend]bool) *Backend {
 	lb.mutex.RLock()
 	defer lb.mutex.RUnlock()
 
 	aliveBackends := lb.getAliveBackends()
+	if len(exclude) > 0 {
+		candidates := aliveBackends[:0]
+		for _, backend := range aliveBackends {
+			if !exclude[backend] {
+				candidates = append(candidates, backend)
+			}
+		}
+		aliveBackends = candidates
+	}
+
 	if len(aliveBackends) == 0 {
 		return nil
 	}
 
 	switch lb.algorithm {
+	case StickySession:
+		return lb.stickySession(w, r, aliveBackends)
+	default:
+		return lb.selectBackend(lb.algorithm, aliveBackends, r)
+	}
+}
+
+func (lb *LoadBalancer) selectBackend(algorithm Algorithm, backends []*Backend, r *http.Request) *Backend {
+	switch algorithm {
 	case RoundRobin:
-		return lb.roundRobin(aliveBackends)
+		return lb.roundRobin(backends)
 	case WeightedRoundRobin:
-		return lb.weightedRoundRobin(aliveBackends)
+		return lb.weightedRoundRobin(backends)
 	case LeastConnections:
-		return lb.leastConnections(aliveBackends)
+		return lb.leastConnections(backends)
+	case LeastResponseTime:
+		return lb.leastResponseTime(backends)
 	case IPHash:
-		return lb.ipHash(aliveBackends, r)
+		return lb.ipHash(backends, r)
 	default:
-		return lb.roundRobin(aliveBackends)
+		return lb.roundRobin(backends)
 	}
 }
 
+// getAliveBackends returns the backends that may take traffic: a passing
+// health check alone does not re-admit a backend whose breaker is still open
+// or that is in maintenance.
+// Callers must hold lb.mutex.
 func (lb *LoadBalancer) getAliveBackends() []*Backend {
 	var aliveBackends []*Backend
 	for _, backend := range lb.backends {
-		if backend.isAlive() {
+		if backend.isAlive() && !backend.inMaintenance() && lb.circuitBreakers[backend.URL.Host].State() != circuitbreaker.StateOpen {
 			aliveBackends = append(aliveBackends, backend)
 		}
 	}
@@ -171,19 +417,22 @@ func (lb *LoadBalancer) roundRobin(backends []*Backend) *Backend {
 func (lb *LoadBalancer) weightedRoundRobin(backends []*Backend) *Backend {
 	totalWeight := 0
 	for _, backend := range backends {
-		totalWeight += backend.Weight
+		totalWeight += effectiveWeight(backend)
 	}
 
 	if totalWeight == 0 {
 		return lb.roundRobin(backends)
 	}
 
+	// backends is a snapshot taken under lb.mutex and lb.current is only
+	// reduced modulo its total, so concurrent additions or removals can
+	// shift the rotation but never index past the slice.
 	next := atomic.AddUint64(&lb.current, 1)
-	target := int(next) % totalWeight
+	target := int((next - 1) % uint64(totalWeight))
 
 	currentWeight := 0
 	for _, backend := range backends {
-		currentWeight += backend.Weight
+		currentWeight += effectiveWeight(backend)
 		if target < currentWeight {
 			return backend
 		}
@@ -192,6 +441,15 @@ func (lb *LoadBalancer) weightedRoundRobin(backends []*Backend) *Backend {
 	return backends[0]
 }
 
+// effectiveWeight treats non-positive weights as zero so they cannot push the
+// weighted total below the number of slots actually handed out.
+func effectiveWeight(backend *Backend) int {
+	if backend.Weight < 0 {
+		return 0
+	}
+	return backend.Weight
+}
+
 func (lb *LoadBalancer) leastConnections(backends []*Backend) *Backend {
 	var selected *Backend
 	minConnections := int64(^uint64(0) >> 1)
@@ -207,20 +465,44 @@ func (lb *LoadBalancer) leastConnections(backends []*Backend) *Backend {
 	return selected
 }
 
+// leastResponseTime picks the backend with the lowest latency EWMA. Backends
+// without a sample yet count as fastest so new ones get probed.
+func (lb *LoadBalancer) leastResponseTime(backends []*Backend) *Backend {
+	selected := backends[0]
+	fastest := atomic.LoadInt64(&selected.ResponseTime)
+
+	for _, backend := range backends[1:] {
+		if latency := atomic.LoadInt64(&backend.ResponseTime); latency < fastest {
+			fastest = latency
+			selected = backend
+		}
+	}
+
+	return selected
+}
+
 func (lb *LoadBalancer) ipHash(backends []*Backend, r *http.Request) *Backend {
 	hash := hashString(getClientIP(r))
 	return backends[hash%uint32(len(backends))]
 }
 
-func (lb *LoadBalancer) StartHealthCheck() {
+// StartHealthCheck checks the backends periodically until ctx is done.
+func (lb *LoadBalancer) StartHealthCheck(ctx context.Context) {
 	if lb.healthCheckInterval <= 0 {
 		return
 	}
 
 	ticker := time.NewTicker(lb.healthCheckInterval)
 	go func() {
-		for range ticker.C {
-			lb.checkBackendsHealth()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				lb.checkBackendsHealth()
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
@@ -231,7 +513,11 @@ func (lb *LoadBalancer) StartHealthCheck() {
 }
 
 func (lb *LoadBalancer) checkBackendsHealth() {
-	for _, backend := range lb.backends {
+	lb.mutex.RLock()
+	backends := append([]*Backend(nil), lb.backends...)
+	lb.mutex.RUnlock()
+
+	for _, backend := range backends {
 		go lb.checkBackendHealth(backend)
 	}
 }
@@ -269,6 +555,87 @@ func (lb *LoadBalancer) checkBackendHealth(backend *Backend) {
 	}
 }
 
+// SetMaintenance takes the backend at host out of rotation, or puts it back.
+// Requests already proxied to it run to completion; WaitForDrain reports
+// when none are left.
+func (lb *LoadBalancer) SetMaintenance(host string, on bool) error {
+	backend := lb.findBackend(host)
+	if backend == nil {
+		return ErrBackendNotFound
+	}
+
+	backend.mutex.Lock()
+	backend.Maintenance = on
+	backend.mutex.Unlock()
+
+	lb.logger.InfoContext(context.Background(), "Backend maintenance mode changed", map[string]interface{}{
+		"backend":     host,
+		"maintenance": on,
+		"connections": atomic.LoadInt64(&backend.Connections),
+	})
+
+	return nil
+}
+
+// WaitForDrain blocks until the backend at host has no in-flight
+// connections or ctx is done.
+func (lb *LoadBalancer) WaitForDrain(ctx context.Context, host string) error {
+	backend := lb.findBackend(host)
+	if backend == nil {
+		return ErrBackendNotFound
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for atomic.LoadInt64(&backend.Connections) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+const drainPollInterval = 100 * time.Millisecond
+
+func (lb *LoadBalancer) findBackend(host string) *Backend {
+	lb.mutex.RLock()
+	defer lb.mutex.RUnlock()
+
+	for _, backend := range lb.backends {
+		if backend.URL.Host == host {
+			return backend
+		}
+	}
+	return nil
+}
+
+// responseTimeDecay weighs each new latency sample at 1/responseTimeDecay.
+const responseTimeDecay = 5
+
+func (b *Backend) observeResponseTime(latency time.Duration) {
+	sample := int64(latency)
+	for {
+		current := atomic.LoadInt64(&b.ResponseTime)
+		next := sample
+		if current != 0 {
+			next = current + (sample-current)/responseTimeDecay
+		}
+		if atomic.CompareAndSwapInt64(&b.ResponseTime, current, next) {
+			return
+		}
+	}
+}
+
+func (b *Backend) inMaintenance() bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.Maintenance
+}
+
 func (b *Backend) isAlive() bool {
 	b.mutex.RLock()
 	defer b.mutex.RUnlock()
@@ -298,8 +665,10 @@ func (lb *LoadBalancer) GetStats() map[string]interface{} {
 		backendStats[i] = map[string]interface{}{
 			"url":                    backend.URL.String(),
 			"alive":                  backend.isAlive(),
+			"maintenance":            backend.inMaintenance(),
 			"weight":                 backend.Weight,
 			"connections":            atomic.LoadInt64(&backend.Connections),
+			"response_time_ms":       float64(atomic.LoadInt64(&backend.ResponseTime)) / float64(time.Millisecond),
 			"circuit_breaker":        cb.State().String(),
 			"circuit_breaker_counts": cb.Counts(),
 		}
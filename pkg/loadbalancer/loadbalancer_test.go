@@ -0,0 +1,124 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestOpenBreakerOverridesPassingHealthCheck(t *testing.T) {
+	lb := newTestLoadBalancer(t, RoundRobin)
+	server := addTestBackend(t, lb, "a", 1)
+
+	backend := lb.findBackend(server.Listener.Addr().String())
+	breaker := lb.breakerFor(backend.URL.Host)
+
+	if code, name := serve(lb); code != http.StatusOK || name != "a" {
+		t.Fatalf("sağlıklı backend istek almalı, alınan: %d %q", code, name)
+	}
+
+	// The health endpoint keeps answering 200 while the breaker is open, so
+	// the backend would flap in and out if the check alone decided.
+	breaker.Trip()
+	for i := 0; i < 3; i++ {
+		lb.checkBackendHealth(backend)
+		if !backend.isAlive() {
+			t.Fatal("geçen sağlık kontrolü backend'i canlı işaretlemeli")
+		}
+		if code, _ := serve(lb); code != http.StatusServiceUnavailable {
+			t.Fatalf("devre açıkken backend'e istek gitmemeli, alınan: %d", code)
+		}
+	}
+
+	breaker.Reset()
+	if code, name := serve(lb); code != http.StatusOK || name != "a" {
+		t.Fatalf("devre kapanınca backend geri dönmeli, alınan: %d %q", code, name)
+	}
+}
+
+func TestFailingHealthCheckOverridesClosedBreaker(t *testing.T) {
+	lb := newTestLoadBalancer(t, RoundRobin)
+	server := addTestBackend(t, lb, "a", 1)
+	backend := lb.findBackend(server.Listener.Addr().String())
+
+	server.Close()
+	lb.checkBackendHealth(backend)
+	if backend.isAlive() {
+		t.Fatal("başarısız sağlık kontrolü backend'i devre dışı bırakmalı")
+	}
+	if code, _ := serve(lb); code != http.StatusServiceUnavailable {
+		t.Fatalf("sağlıksız backend'e istek gitmemeli, alınan: %d", code)
+	}
+}
+
+func TestSelectionWhileBackendsChange(t *testing.T) {
+	for _, algorithm := range []Algorithm{RoundRobin, WeightedRoundRobin} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			lb := newTestLoadBalancer(t, algorithm)
+			addTestBackend(t, lb, "stable", 3)
+
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; ; i++ {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+
+					url := fmt.Sprintf("http://%s-%d.invalid", algorithm, i%4)
+					if err := lb.AddBackend(url, i%3); err != nil {
+						t.Error(err)
+						return
+					}
+					if err := lb.RemoveBackend(url); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for i := 0; i < 10000; i++ {
+				if backend := lb.getNextBackend(nil, r, nil); backend == nil {
+					t.Fatal("sabit backend varken seçim boş dönmemeli")
+				}
+			}
+			close(stop)
+			wg.Wait()
+
+			if got := len(lb.GetStats()["backends"].([]map[string]interface{})); got != 1 {
+				t.Fatalf("yalnızca sabit backend kalmalı, kalan: %d", got)
+			}
+		})
+	}
+}
+
+func TestWeightedRoundRobinFollowsWeights(t *testing.T) {
+	lb := newTestLoadBalancer(t, WeightedRoundRobin)
+	addTestBackend(t, lb, "heavy", 3)
+	light := addTestBackend(t, lb, "light", 1)
+
+	counts := make(map[string]int)
+	for i := 0; i < 8; i++ {
+		_, name := serve(lb)
+		counts[name]++
+	}
+	if counts["heavy"] != 6 || counts["light"] != 2 {
+		t.Fatalf("ağırlıklara göre 6/2 dağılım beklenirdi, alınan: %v", counts)
+	}
+
+	if err := lb.RemoveBackend(light.URL); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, name := serve(lb); name != "heavy" {
+			t.Fatalf("kaldırılan backend istek almamalı, alınan: %q", name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package loadbalancer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const stickyCookieName = "payflow_lb_session"
+
+type affinityEntry struct {
+	host      string
+	expiresAt time.Time
+}
+
+// affinityMap pins session ids to backend hosts. Entries expire ttl after
+// their last use and are pruned at most once per ttl.
+type affinityMap struct {
+	mutex     sync.Mutex
+	ttl       time.Duration
+	entries   map[string]affinityEntry
+	lastPrune time.Time
+}
+
+func newAffinityMap(ttl time.Duration) *affinityMap {
+	return &affinityMap{
+		ttl:       ttl,
+		entries:   make(map[string]affinityEntry),
+		lastPrune: time.Now(),
+	}
+}
+
+func (m *affinityMap) get(sessionID string) (string, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entry, ok := m.entries[sessionID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false
+	}
+	return entry.host, true
+}
+
+func (m *affinityMap) set(sessionID, host string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	m.entries[sessionID] = affinityEntry{host: host, expiresAt: now.Add(m.ttl)}
+
+	if now.Sub(m.lastPrune) >= m.ttl {
+		for id, entry := range m.entries {
+			if now.After(entry.expiresAt) {
+				delete(m.entries, id)
+			}
+		}
+		m.lastPrune = now
+	}
+}
+
+// stickySession keeps a client on the backend its cookie is pinned to while
+// that backend can take traffic. Otherwise, or for new clients, it picks one
+// with the fallback algorithm and (re)writes the cookie. Session ids the map
+// does not know are replaced rather than trusted.
+func (lb *LoadBalancer) stickySession(w http.ResponseWriter, r *http.Request, backends []*Backend) *Backend {
+	var sessionID string
+	if cookie, err := r.Cookie(stickyCookieName); err == nil {
+		if host, ok := lb.affinity.get(cookie.Value); ok {
+			sessionID = cookie.Value
+			for _, backend := range backends {
+				if backend.URL.Host == host {
+					lb.affinity.set(sessionID, host)
+					return backend
+				}
+			}
+		}
+	}
+
+	if sessionID == "" {
+		sessionID = newSessionID()
+	}
+
+	backend := lb.selectBackend(lb.stickyFallback, backends, r)
+	lb.affinity.set(sessionID, backend.URL.Host)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stickyCookieName,
+		Value:    sessionID,
+		Path:     "/",
+		MaxAge:   int(lb.affinity.ttl.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return backend
+}
+
+func newSessionID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -25,6 +26,21 @@ const (
 	PanicLevel LogLevel = "panic"
 )
 
+// Format selects how log lines are written. An empty Format keeps the
+// historical default: console output when APP_ENV=development, JSON otherwise.
+type Format string
+
+const (
+	FormatJSON    Format = "json"
+	FormatConsole Format = "console"
+)
+
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
 type Logger interface {
 	Debug(msg string, fields map[string]interface{})
 	Info(msg string, fields map[string]interface{})
@@ -40,23 +56,40 @@ type Logger interface {
 	ErrorContext(ctx context.Context, msg string, fields map[string]interface{})
 
 	WithFields(fields map[string]interface{}) Logger
+	// WithError attaches err under the standard error fields; see ErrorFields.
+	WithError(err error) Logger
+	ErrorWithErr(msg string, err error, fields map[string]interface{})
+	// WithSampler returns a logger whose debug and info entries pass through s.
+	WithSampler(s *Sampler) Logger
+	// SetLevel changes the minimum level at runtime. The level is process
+	// wide: it applies to every logger created by New and derived from one.
+	SetLevel(level LogLevel)
 }
 
 type ZerologLogger struct {
 	logger zerolog.Logger
+	// base is logger without request and trace ids, so rebinding a new
+	// context replaces them instead of writing the keys twice.
+	base   zerolog.Logger
 	fields map[string]interface{}
 }
 
-func New(level LogLevel, output io.Writer) Logger {
+func New(level LogLevel, format Format, output io.Writer) Logger {
 	if output == nil {
 		output = os.Stdout
 	}
 
 	zerolog.TimeFieldFormat = time.RFC3339
-	zerologLevel := getZerologLevel(level)
+
+	if format == "" {
+		format = FormatJSON
+		if strings.ToLower(os.Getenv("APP_ENV")) == "development" {
+			format = FormatConsole
+		}
+	}
 
 	var consoleWriter io.Writer
-	if strings.ToLower(os.Getenv("APP_ENV")) == "development" {
+	if format == FormatConsole {
 		consoleWriter = zerolog.ConsoleWriter{
 			Out:        output,
 			TimeFormat: time.RFC3339,
@@ -66,18 +99,41 @@ func New(level LogLevel, output io.Writer) Logger {
 		consoleWriter = output
 	}
 
+	// The logger itself lets everything through; filtering is done by the
+	// global level so that SetLevel can change it at runtime.
 	zl := zerolog.New(consoleWriter).
-		Level(zerologLevel).
+		Level(zerolog.TraceLevel).
 		With().
 		Timestamp().
 		Logger()
+	zerolog.SetGlobalLevel(getZerologLevel(level))
 
 	return &ZerologLogger{
 		logger: zl,
+		base:   zl,
 		fields: make(map[string]interface{}),
 	}
 }
 
+// OpenOutput returns the writer for a LOG_OUTPUT target. Files are opened
+// for appending; the caller closes them once nothing logs any more.
+func OpenOutput(target, path string) (io.Writer, error) {
+	switch target {
+	case "", OutputStdout:
+		return os.Stdout, nil
+	case OutputStderr:
+		return os.Stderr, nil
+	case OutputFile:
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("log dosyası açılamadı: %w", err)
+		}
+		return file, nil
+	default:
+		return nil, fmt.Errorf("geçersiz log çıktısı: %s", target)
+	}
+}
+
 func getZerologLevel(level LogLevel) zerolog.Level {
 	switch strings.ToLower(string(level)) {
 	case "debug":
@@ -100,6 +156,7 @@ func getZerologLevel(level LogLevel) zerolog.Level {
 func (l *ZerologLogger) WithFields(fields map[string]interface{}) Logger {
 	newLogger := &ZerologLogger{
 		logger: l.logger,
+		base:   l.base,
 		fields: make(map[string]interface{}, len(l.fields)+len(fields)),
 	}
 
@@ -114,26 +171,80 @@ func (l *ZerologLogger) WithFields(fields map[string]interface{}) Logger {
 	return newLogger
 }
 
+func (l *ZerologLogger) WithError(err error) Logger {
+	if err == nil {
+		return l
+	}
+	return l.WithFields(ErrorFields(err))
+}
+
+func (l *ZerologLogger) WithSampler(s *Sampler) Logger {
+	newLogger := &ZerologLogger{
+		logger: l.logger.Sample(s),
+		base:   l.base.Sample(s),
+		fields: make(map[string]interface{}, len(l.fields)),
+	}
+
+	for k, v := range l.fields {
+		newLogger.fields[k] = v
+	}
+
+	return newLogger
+}
+
+func (l *ZerologLogger) SetLevel(level LogLevel) {
+	zerolog.SetGlobalLevel(getZerologLevel(level))
+}
+
+func (l *ZerologLogger) ErrorWithErr(msg string, err error, fields map[string]interface{}) {
+	l.WithError(err).Error(msg, fields)
+}
+
+// ErrorFields puts err's message under "error" and, when err wraps other
+// errors, the message of each wrapped error under "error_chain", outermost
+// first, so the root cause can be queried on its own.
+func ErrorFields(err error) map[string]interface{} {
+	fields := map[string]interface{}{"error": err.Error()}
+
+	var chain []string
+	for wrapped := errors.Unwrap(err); wrapped != nil; wrapped = errors.Unwrap(wrapped) {
+		chain = append(chain, wrapped.Error())
+	}
+	if len(chain) > 0 {
+		fields["error_chain"] = chain
+	}
+
+	return fields
+}
+
+// WithContext binds the request id and the trace and span ids of the active
+// span onto the underlying zerolog logger, so they are written with every
+// later entry.
 func (l *ZerologLogger) WithContext(ctx context.Context) Logger {
 	newLogger := &ZerologLogger{
-		logger: l.logger,
-		fields: make(map[string]interface{}, len(l.fields)+2), // Trace ve Span ID için
+		logger: l.base,
+		base:   l.base,
+		fields: make(map[string]interface{}, len(l.fields)),
 	}
 
 	for k, v := range l.fields {
 		newLogger.fields[k] = v
 	}
 
-	traceID := tracing.GetTraceID(ctx)
-	if traceID != "" {
-		newLogger.fields["trace_id"] = traceID
+	bound := l.base.With()
+	if requestID := tracing.GetRequestID(ctx); requestID != "" {
+		bound = bound.Str("request_id", requestID)
+	}
+	if traceID := tracing.GetTraceID(ctx); traceID != "" {
+		bound = bound.Str("trace_id", traceID).Str("span_id", tracing.GetSpanID(ctx))
 	}
+	newLogger.logger = bound.Logger()
 
 	return newLogger
 }
 
 func (l *ZerologLogger) addSourceInfo(event *zerolog.Event) *zerolog.Event {
-	if l.logger.GetLevel() == zerolog.DebugLevel {
+	if zerolog.GlobalLevel() <= zerolog.DebugLevel {
 		_, file, line, ok := runtime.Caller(2)
 		if ok {
 			parts := strings.Split(file, "/")
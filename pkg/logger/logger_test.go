@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestInfoContextIncludesActiveSpan(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "operation")
+	defer span.End()
+
+	var buf bytes.Buffer
+	New(InfoLevel, FormatJSON, &buf).InfoContext(ctx, "işlem tamamlandı", nil)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log satırı JSON olmalı: %v (%s)", err, buf.String())
+	}
+
+	spanCtx := span.SpanContext()
+	if entry["trace_id"] != spanCtx.TraceID().String() {
+		t.Fatalf("beklenen trace_id %s, alınan: %v", spanCtx.TraceID(), entry["trace_id"])
+	}
+	if entry["span_id"] != spanCtx.SpanID().String() {
+		t.Fatalf("beklenen span_id %s, alınan: %v", spanCtx.SpanID(), entry["span_id"])
+	}
+}
+
+func TestInfoContextWithoutSpanOmitsTraceFields(t *testing.T) {
+	var buf bytes.Buffer
+	New(InfoLevel, FormatJSON, &buf).InfoContext(context.Background(), "işlem tamamlandı", nil)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log satırı JSON olmalı: %v (%s)", err, buf.String())
+	}
+	if _, ok := entry["trace_id"]; ok {
+		t.Fatalf("span yokken trace_id yazılmamalı: %s", buf.String())
+	}
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Sampler keeps one in every N debug and info entries of the loggers it is
+// attached to; warnings and errors are always written. It is shared, so a
+// rate change applies to every attached logger at once.
+type Sampler struct {
+	rate      atomic.Uint32
+	counter   atomic.Uint32
+	fullUntil atomic.Int64
+}
+
+// NewSampler returns a sampler keeping 1 in rate entries; a rate of 0 or 1
+// keeps everything.
+func NewSampler(rate uint32) *Sampler {
+	s := &Sampler{}
+	s.SetRate(rate)
+	return s
+}
+
+func (s *Sampler) Sample(level zerolog.Level) bool {
+	if level > zerolog.InfoLevel {
+		return true
+	}
+
+	rate := s.rate.Load()
+	if rate <= 1 || time.Now().UnixNano() < s.fullUntil.Load() {
+		return true
+	}
+
+	return s.counter.Add(1)%rate == 1
+}
+
+func (s *Sampler) Rate() uint32 {
+	return s.rate.Load()
+}
+
+func (s *Sampler) SetRate(rate uint32) {
+	if rate == 0 {
+		rate = 1
+	}
+	s.rate.Store(rate)
+}
+
+// EnableFullLogging turns sampling off for d; a non-positive d turns it back
+// on immediately.
+func (s *Sampler) EnableFullLogging(d time.Duration) {
+	if d <= 0 {
+		s.fullUntil.Store(0)
+		return
+	}
+	s.fullUntil.Store(time.Now().Add(d).UnixNano())
+}
+
+// FullLoggingUntil returns the zero time when sampling is in effect.
+func (s *Sampler) FullLoggingUntil() time.Time {
+	until := s.fullUntil.Load()
+	if until == 0 || time.Now().UnixNano() >= until {
+		return time.Time{}
+	}
+	return time.Unix(0, until)
+}
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"payflow/internal/domain"
+)
+
+type WorkerPoolStatsSource interface {
+	GetWorkerPoolStats() (domain.TransactionStats, error)
+}
+
+type DBPoolStatsSource interface {
+	PoolStats() map[string]sql.DBStats
+}
+
+var (
+	workerPoolQueueSizeDesc = prometheus.NewDesc(
+		"payflow_worker_pool_queue_size",
+		"Worker pool kuyruğundaki iş sayısı",
+		nil, nil,
+	)
+	workerPoolQueueCapacityDesc = prometheus.NewDesc(
+		"payflow_worker_pool_queue_capacity",
+		"Worker pool kuyruk kapasitesi",
+		nil, nil,
+	)
+	workerPoolActiveWorkersDesc = prometheus.NewDesc(
+		"payflow_worker_pool_active_workers",
+		"Aktif worker sayısı",
+		nil, nil,
+	)
+	workerPoolBusyWorkersDesc = prometheus.NewDesc(
+		"payflow_worker_pool_busy_workers",
+		"İşlem işleyen worker sayısı",
+		nil, nil,
+	)
+	workerPoolIdleWorkersDesc = prometheus.NewDesc(
+		"payflow_worker_pool_idle_workers",
+		"Boşta bekleyen worker sayısı",
+		nil, nil,
+	)
+	workerPoolJobsDesc = prometheus.NewDesc(
+		"payflow_worker_pool_jobs_total",
+		"Worker pool'a gönderilen işlerin sonuca göre sayısı (submitted, completed, failed, rejected)",
+		[]string{"outcome"}, nil,
+	)
+	dbPoolOpenDesc = prometheus.NewDesc(
+		"payflow_db_pool_open_connections",
+		"Veritabanı havuzundaki açık bağlantı sayısı",
+		[]string{"pool"}, nil,
+	)
+	dbPoolInUseDesc = prometheus.NewDesc(
+		"payflow_db_pool_in_use_connections",
+		"Veritabanı havuzunda kullanımdaki bağlantı sayısı",
+		[]string{"pool"}, nil,
+	)
+	dbPoolIdleDesc = prometheus.NewDesc(
+		"payflow_db_pool_idle_connections",
+		"Veritabanı havuzunda boştaki bağlantı sayısı",
+		[]string{"pool"}, nil,
+	)
+)
+
+// PoolCollector reads worker pool and database pool statistics at scrape
+// time, so the exported values are never older than the scrape itself.
+type PoolCollector struct {
+	workerPool WorkerPoolStatsSource
+	dbPools    DBPoolStatsSource
+}
+
+func NewPoolCollector(workerPool WorkerPoolStatsSource, dbPools DBPoolStatsSource) *PoolCollector {
+	return &PoolCollector{
+		workerPool: workerPool,
+		dbPools:    dbPools,
+	}
+}
+
+// RegisterPoolCollector registers a PoolCollector with the default registry
+// that /metrics serves.
+func RegisterPoolCollector(workerPool WorkerPoolStatsSource, dbPools DBPoolStatsSource) (*PoolCollector, error) {
+	collector := NewPoolCollector(workerPool, dbPools)
+	if err := prometheus.Register(collector); err != nil {
+		return nil, err
+	}
+	return collector, nil
+}
+
+// UnregisterPoolCollector removes a collector added by RegisterPoolCollector,
+// once the pools it reports on are closed.
+func UnregisterPoolCollector(collector *PoolCollector) {
+	prometheus.Unregister(collector)
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workerPoolQueueSizeDesc
+	ch <- workerPoolQueueCapacityDesc
+	ch <- workerPoolActiveWorkersDesc
+	ch <- workerPoolBusyWorkersDesc
+	ch <- workerPoolIdleWorkersDesc
+	ch <- workerPoolJobsDesc
+	ch <- dbPoolOpenDesc
+	ch <- dbPoolInUseDesc
+	ch <- dbPoolIdleDesc
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collectWorkerPool(ch)
+	c.collectDBPools(ch)
+}
+
+func (c *PoolCollector) collectWorkerPool(ch chan<- prometheus.Metric) {
+	if c.workerPool == nil {
+		return
+	}
+
+	stats, err := c.workerPool.GetWorkerPoolStats()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(workerPoolQueueSizeDesc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(workerPoolQueueSizeDesc, prometheus.GaugeValue, float64(stats.QueueLength))
+	ch <- prometheus.MustNewConstMetric(workerPoolQueueCapacityDesc, prometheus.GaugeValue, float64(stats.QueueCapacity))
+	ch <- prometheus.MustNewConstMetric(workerPoolActiveWorkersDesc, prometheus.GaugeValue, float64(stats.NumWorkers))
+	ch <- prometheus.MustNewConstMetric(workerPoolBusyWorkersDesc, prometheus.GaugeValue, float64(stats.BusyWorkers))
+	ch <- prometheus.MustNewConstMetric(workerPoolIdleWorkersDesc, prometheus.GaugeValue, float64(stats.IdleWorkers))
+
+	ch <- prometheus.MustNewConstMetric(workerPoolJobsDesc, prometheus.CounterValue, float64(stats.Submitted), "submitted")
+	ch <- prometheus.MustNewConstMetric(workerPoolJobsDesc, prometheus.CounterValue, float64(stats.Completed), "completed")
+	ch <- prometheus.MustNewConstMetric(workerPoolJobsDesc, prometheus.CounterValue, float64(stats.Failed), "failed")
+	ch <- prometheus.MustNewConstMetric(workerPoolJobsDesc, prometheus.CounterValue, float64(stats.Rejected), "rejected")
+}
+
+func (c *PoolCollector) collectDBPools(ch chan<- prometheus.Metric) {
+	if c.dbPools == nil {
+		return
+	}
+
+	for pool, stats := range c.dbPools.PoolStats() {
+		ch <- prometheus.MustNewConstMetric(dbPoolOpenDesc, prometheus.GaugeValue, float64(stats.OpenConnections), pool)
+		ch <- prometheus.MustNewConstMetric(dbPoolInUseDesc, prometheus.GaugeValue, float64(stats.InUse), pool)
+		ch <- prometheus.MustNewConstMetric(dbPoolIdleDesc, prometheus.GaugeValue, float64(stats.Idle), pool)
+	}
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -50,42 +51,108 @@ var (
 		[]string{"type", "status"},
 	)
 
-	ActiveUsers = promauto.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "payflow_active_users",
-			Help: "Aktif kullanıcı sayısı",
+	TransactionProcessingDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "payflow_transaction_processing_duration_seconds",
+			Help:    "Worker pool'da işlem işleme süresi (saniye)",
+			Buckets: []float64{.001, .002, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
 		},
+		[]string{"type"},
 	)
 
-	WorkerPoolQueueSize = promauto.NewGauge(
+	TransactionVolume = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payflow_transaction_volume_total",
+			Help: "İşlenen toplam işlem tutarı (işlem para biriminde)",
+		},
+		[]string{"type", "status", "currency"},
+	)
+
+	PendingTransactions = promauto.NewGauge(
 		prometheus.GaugeOpts{
-			Name: "payflow_worker_pool_queue_size",
-			Help: "Worker pool kuyruğundaki iş sayısı",
+			Name: "payflow_pending_transactions",
+			Help: "Worker pool'a alınmış ve henüz sonuçlanmamış işlem sayısı",
 		},
 	)
 
-	WorkerPoolActiveWorkers = promauto.NewGauge(
+	StuckTransactionsReaped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payflow_stuck_transactions_reaped_total",
+			Help: "Takılı kalan pending işlemlerden kurtarılan veya başarısız sayılanların sayısı",
+		},
+		[]string{"outcome"},
+	)
+
+	ActiveUsers = promauto.NewGauge(
 		prometheus.GaugeOpts{
-			Name: "payflow_worker_pool_active_workers",
-			Help: "Aktif worker sayısı",
+			Name: "payflow_active_users",
+			Help: "Aktif kullanıcı sayısı",
 		},
 	)
 
-	CacheHits = promauto.NewCounter(
+	CacheHits = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "payflow_cache_hits_total",
 			Help: "Önbellek isabet sayısı",
 		},
+		[]string{"tier"},
 	)
 
-	CacheMisses = promauto.NewCounter(
+	CacheMisses = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "payflow_cache_misses_total",
 			Help: "Önbellek isabet etmeme sayısı",
 		},
+		[]string{"tier"},
+	)
+
+	WriteBehindPending = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "payflow_write_behind_pending",
+			Help: "Kaynağa yazılmayı bekleyen write-behind işlemi sayısı",
+		},
+	)
+
+	CacheHitRatio = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "payflow_cache_hit_ratio",
+			Help: "Önbellek isabet oranı (0-1), uygulama başlangıcından beri",
+		},
+		[]string{"tier"},
+	)
+
+	CircuitBreakerState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "payflow_circuit_breaker_state",
+			Help: "Devre kesici durumu (0=closed, 1=open, 2=half-open)",
+		},
+		[]string{"name"},
+	)
+
+	LoadBalancerRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payflow_lb_retries_total",
+			Help: "Başarısız backend denemesi sonrası başka backend'e yeniden gönderilen istek sayısı",
+		},
+		[]string{"backend"},
+	)
+
+	CircuitBreakerTrips = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payflow_circuit_breaker_trips_total",
+			Help: "Devre kesicinin açık duruma geçme sayısı",
+		},
+		[]string{"name"},
 	)
 )
 
+// cacheLookups backs CacheHitRatio; Prometheus counters cannot be read back.
+var cacheLookups = struct {
+	sync.Mutex
+	hits   map[string]uint64
+	misses map[string]uint64
+}{hits: map[string]uint64{}, misses: map[string]uint64{}}
+
 func RecordHttpRequest(method, endpoint, status string, duration time.Duration) {
 	HttpRequestsTotal.WithLabelValues(method, endpoint, status).Inc()
 	HttpRequestDuration.WithLabelValues(method, endpoint).Observe(duration.Seconds())
@@ -100,15 +167,66 @@ func RecordTransaction(txType string, status string) {
 	TransactionProcessed.WithLabelValues(txType, status).Inc()
 }
 
-func UpdateWorkerPoolStats(queueSize, activeWorkers int) {
-	WorkerPoolQueueSize.Set(float64(queueSize))
-	WorkerPoolActiveWorkers.Set(float64(activeWorkers))
+// RecordTransactionVolume adds amount, in major units of currency, to the
+// volume of the given type and outcome. Amounts in different currencies are
+// kept apart by the currency label and must not be summed.
+func RecordTransactionVolume(txType, status, currency string, amount float64) {
+	TransactionVolume.WithLabelValues(txType, status, currency).Add(amount)
+}
+
+func IncPendingTransactions() {
+	PendingTransactions.Inc()
+}
+
+func DecPendingTransactions() {
+	PendingTransactions.Dec()
+}
+
+func RecordTransactionProcessingTime(txType string, duration time.Duration) {
+	TransactionProcessingDuration.WithLabelValues(txType).Observe(duration.Seconds())
+}
+
+func RecordReapedTransaction(outcome string) {
+	StuckTransactionsReaped.WithLabelValues(outcome).Inc()
+}
+
+func RecordCacheHit(tier string) {
+	CacheHits.WithLabelValues(tier).Inc()
+	recordCacheLookup(tier, true)
+}
+
+func RecordCacheMiss(tier string) {
+	CacheMisses.WithLabelValues(tier).Inc()
+	recordCacheLookup(tier, false)
+}
+
+func recordCacheLookup(tier string, hit bool) {
+	cacheLookups.Lock()
+	defer cacheLookups.Unlock()
+
+	if hit {
+		cacheLookups.hits[tier]++
+	} else {
+		cacheLookups.misses[tier]++
+	}
+
+	hits := cacheLookups.hits[tier]
+	CacheHitRatio.WithLabelValues(tier).Set(float64(hits) / float64(hits+cacheLookups.misses[tier]))
+}
+
+func SetCircuitBreakerState(name string, state int) {
+	CircuitBreakerState.WithLabelValues(name).Set(float64(state))
+}
+
+func RecordCircuitBreakerTrip(name string) {
+	CircuitBreakerTrips.WithLabelValues(name).Inc()
 }
 
-func RecordCacheHit() {
-	CacheHits.Inc()
+func RemoveCircuitBreaker(name string) {
+	CircuitBreakerState.DeleteLabelValues(name)
+	CircuitBreakerTrips.DeleteLabelValues(name)
 }
 
-func RecordCacheMiss() {
-	CacheMisses.Inc()
+func RecordLoadBalancerRetry(backend string) {
+	LoadBalancerRetries.WithLabelValues(backend).Inc()
 }
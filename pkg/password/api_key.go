@@ -0,0 +1,23 @@
+package password
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// HashApiKey returns the SHA-256 hex digest stored in place of an API key.
+// Keys are random and high-entropy, so an unsalted fast hash is sufficient.
+func HashApiKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyApiKey compares apiKey against a stored hash in constant time.
+func VerifyApiKey(storedHash, apiKey string) bool {
+	if storedHash == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(storedHash), []byte(HashApiKey(apiKey))) == 1
+}
@@ -0,0 +1,77 @@
+package password
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBcrypt = "bcrypt"
+	AlgorithmSHA256 = "sha256"
+
+	// DefaultCost is used when no valid bcrypt cost is configured.
+	DefaultCost = bcrypt.DefaultCost
+)
+
+// HashPassword returns the password hashed with bcrypt, prefixed with the
+// algorithm name ("bcrypt$...") so the stored format can change over time.
+func HashPassword(password string, cost int) (string, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = DefaultCost
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", fmt.Errorf("şifre hashlenemedi: %w", err)
+	}
+
+	return AlgorithmBcrypt + "$" + string(hash), nil
+}
+
+// VerifyPassword reports whether password matches the stored hash. Hashes
+// without an algorithm prefix are legacy unsalted SHA-256 hex digests.
+func VerifyPassword(stored, password string) bool {
+	algorithm, hash := split(stored)
+
+	switch algorithm {
+	case AlgorithmBcrypt:
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case AlgorithmSHA256:
+		legacy := fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(legacy)) == 1
+	default:
+		return false
+	}
+}
+
+// NeedsRehash reports whether the stored hash uses a legacy algorithm or a
+// lower bcrypt cost than configured.
+func NeedsRehash(stored string, cost int) bool {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = DefaultCost
+	}
+
+	algorithm, hash := split(stored)
+	if algorithm != AlgorithmBcrypt {
+		return true
+	}
+
+	current, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+
+	return current < cost
+}
+
+func split(stored string) (string, string) {
+	if algorithm, hash, ok := strings.Cut(stored, "$"); ok && algorithm != "" {
+		return algorithm, hash
+	}
+
+	return AlgorithmSHA256, stored
+}
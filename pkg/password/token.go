@@ -0,0 +1,23 @@
+package password
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateToken returns a random single-use token, hex encoded. Store only
+// HashToken(token); the plaintext is handed to the user once.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("token oluşturulamadı: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken hashes a token generated by GenerateToken for storage and lookup.
+func HashToken(token string) string {
+	return HashApiKey(token)
+}
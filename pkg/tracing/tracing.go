@@ -100,3 +100,24 @@ func GetTraceID(ctx context.Context) string {
 	}
 	return ""
 }
+
+func GetSpanID(ctx context.Context) string {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if spanCtx.IsValid() {
+		return spanCtx.SpanID().String()
+	}
+	return ""
+}
+
+type requestIDKey struct{}
+
+// WithRequestID stores the id of the originating HTTP request, so logs can be
+// correlated even when no tracing backend is available.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}